@@ -0,0 +1,165 @@
+// Package codec implements the conversion of cached values to bytes and back. This is required by cache
+// backends, which keep the values outside of heimdall's process, like redis. As a cached value is only
+// known as any to these backends, the codec for a type is registered together with a name, which is
+// stored along with the encoded value and used to select the codec when decoding it.
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var (
+	ErrUnsupportedType = errors.New("no codec registered for type")
+	ErrMalformedValue  = errors.New("malformed value")
+
+	// by intention. Used only during application bootstrap
+	// nolint
+	codecsByType = map[reflect.Type]*entry{}
+	// nolint
+	codecsByName = map[string]*entry{}
+	// nolint
+	codecsMu sync.RWMutex
+)
+
+const nameSeparator = 0
+
+// Codec converts values of type T to bytes and back.
+type Codec[T any] interface {
+	Encode(value T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// JSON is a Codec for types, which can be marshalled to and unmarshalled from JSON.
+type JSON[T any] struct{}
+
+func (JSON[T]) Encode(value T) ([]byte, error) { return json.Marshal(value) }
+
+func (JSON[T]) Decode(data []byte) (T, error) {
+	var value T
+
+	err := json.Unmarshal(data, &value)
+
+	return value, err
+}
+
+type entry struct {
+	name   string
+	typ    reflect.Type
+	encode func(value any) ([]byte, error)
+	decode func(data []byte) (any, error)
+}
+
+// Register registers the codec for values of type T under the given name. The name must be unique.
+// Registering the same name for the same type again is a no-op, so that packages caching the same
+// type can register it independently.
+func Register[T any](name string, codec Codec[T]) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+
+	if len(name) == 0 || bytes.IndexByte([]byte(name), nameSeparator) != -1 {
+		panic(fmt.Sprintf("invalid codec name '%s'", name))
+	}
+
+	if known, ok := codecsByName[name]; ok {
+		if known.typ == typ {
+			return
+		}
+
+		panic(fmt.Sprintf("codec name '%s' is already registered for %s", name, known.typ))
+	}
+
+	if known, ok := codecsByType[typ]; ok {
+		panic(fmt.Sprintf("codec for %s is already registered as '%s'", typ, known.name))
+	}
+
+	codecEntry := &entry{
+		name: name,
+		typ:  typ,
+		// nolint: forcetypeassert
+		// the entry is selected by the type of the value
+		encode: func(value any) ([]byte, error) { return codec.Encode(value.(T)) },
+		decode: func(data []byte) (any, error) { return codec.Decode(data) },
+	}
+
+	codecsByName[name] = codecEntry
+	codecsByType[typ] = codecEntry
+}
+
+// Marshal encodes the given value using the codec registered for its type.
+func Marshal(value any) ([]byte, error) {
+	codecsMu.RLock()
+	codecEntry, ok := codecsByType[reflect.TypeOf(value)]
+	codecsMu.RUnlock()
+
+	if !ok {
+		return nil, errorchain.NewWithMessagef(ErrUnsupportedType, "%T", value)
+	}
+
+	data, err := codecEntry.encode(value)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(ErrMalformedValue, "failed to encode %T", value).CausedBy(err)
+	}
+
+	result := make([]byte, 0, len(codecEntry.name)+1+len(data))
+	result = append(result, codecEntry.name...)
+	result = append(result, nameSeparator)
+
+	return append(result, data...), nil
+}
+
+// Unmarshal decodes the given data, which must have been created by Marshal.
+func Unmarshal(data []byte) (any, error) {
+	idx := bytes.IndexByte(data, nameSeparator)
+	if idx == -1 {
+		return nil, errorchain.NewWithMessage(ErrMalformedValue, "no codec name present")
+	}
+
+	name := string(data[:idx])
+
+	codecsMu.RLock()
+	codecEntry, ok := codecsByName[name]
+	codecsMu.RUnlock()
+
+	if !ok {
+		return nil, errorchain.NewWithMessagef(ErrUnsupportedType, "%s", name)
+	}
+
+	value, err := codecEntry.decode(data[idx+1:])
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(ErrMalformedValue, "failed to decode %s", name).CausedBy(err)
+	}
+
+	return value, nil
+}
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	Register[string]("string", stringCodec{})
+	Register[[]byte]("bytes", bytesCodec{})
+	Register[bool]("bool", JSON[bool]{})
+}
+
+type stringCodec struct{}
+
+func (stringCodec) Encode(value string) ([]byte, error) { return []byte(value), nil }
+func (stringCodec) Decode(data []byte) (string, error)  { return string(data), nil }
+
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(value []byte) ([]byte, error) { return value, nil }
+
+func (bytesCodec) Decode(data []byte) ([]byte, error) {
+	// the data is copied, as it may be reused by the backend
+	return append([]byte{}, data...), nil
+}
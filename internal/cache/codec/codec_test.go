@@ -0,0 +1,85 @@
+package codec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testValue struct {
+	Foo string `json:"foo"`
+	Bar int    `json:"bar"`
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	Register[*testValue]("codec.test_value", JSON[*testValue]{})
+	// registering the same type with the same name again is allowed
+	Register[*testValue]("codec.test_value", JSON[*testValue]{})
+
+	for _, tc := range []struct {
+		uc    string
+		value any
+	}{
+		{uc: "string", value: "foo"},
+		{uc: "bytes", value: []byte("foo")},
+		{uc: "bool", value: true},
+		{uc: "registered type", value: &testValue{Foo: "foo", Bar: 42}},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			data, err := Marshal(tc.value)
+			require.NoError(t, err)
+
+			value, err := Unmarshal(data)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, tc.value, value)
+		})
+	}
+}
+
+func TestMarshalUnsupportedType(t *testing.T) {
+	t.Parallel()
+
+	// WHEN
+	_, err := Marshal(42)
+
+	// THEN
+	require.Error(t, err)
+	assert.ErrorIs(t, err, ErrUnsupportedType)
+}
+
+func TestUnmarshalInvalidData(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc   string
+		data []byte
+		err  error
+	}{
+		{uc: "without codec name", data: []byte("foo"), err: ErrMalformedValue},
+		{uc: "with unknown codec name", data: []byte("foo\x00bar"), err: ErrUnsupportedType},
+		{uc: "with malformed payload", data: []byte("bool\x00bar"), err: ErrMalformedValue},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			_, err := Unmarshal(tc.data)
+
+			// THEN
+			require.Error(t, err)
+			assert.ErrorIs(t, err, tc.err)
+		})
+	}
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	t.Parallel()
+
+	assert.Panics(t, func() { Register[int]("string", JSON[int]{}) })
+	assert.Panics(t, func() { Register[string]("other_string", JSON[string]{}) })
+	assert.Panics(t, func() { Register[int]("", JSON[int]{}) })
+}
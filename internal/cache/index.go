@@ -0,0 +1,68 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+const (
+	tagSubject = "subject:"
+	tagToken   = "token:"
+	tagHandler = "handler:"
+	tagSession = "session:"
+)
+
+// Indexer is implemented by caches supporting secondary indexes. These allow deleting all entries
+// related to e.g. a subject at once.
+type Indexer interface {
+	// Index associates the entry stored under the given key with the given tags. The ttl is the
+	// one used to store the entry.
+	Index(key string, ttl time.Duration, tags ...string)
+	// Invalidate deletes all entries associated with the given tag and returns their number.
+	Invalidate(tag string) int
+}
+
+// tagLookup is implemented by indexing caches, which can return the tags an entry is associated with.
+type tagLookup interface {
+	Tags(key string) []string
+}
+
+// Index associates the entry stored under the given key with the given tags, if the given cache
+// supports secondary indexes. Otherwise, it does nothing.
+func Index(cch Cache, key string, ttl time.Duration, tags ...string) {
+	if indexer, ok := cch.(Indexer); ok && len(tags) != 0 {
+		indexer.Index(key, ttl, tags...)
+	}
+}
+
+// Invalidate deletes all entries associated with the given tag, if the given cache supports
+// secondary indexes and returns their number.
+func Invalidate(cch Cache, tag string) int {
+	if indexer, ok := cch.(Indexer); ok {
+		return indexer.Invalidate(tag)
+	}
+
+	return 0
+}
+
+// SubjectTag returns the tag for entries related to the subject with the given id.
+func SubjectTag(subjectID string) string { return tagSubject + subjectID }
+
+// TokenTag returns the tag for entries related to the given token, like an access token or an api key.
+func TokenTag(token string) string {
+	hash := sha256.Sum256([]byte(token))
+
+	return TokenHashTag(hex.EncodeToString(hash[:]))
+}
+
+// TokenHashTag returns the tag for entries related to the token with the given hex encoded SHA-256 hash.
+func TokenHashTag(hash string) string { return tagToken + strings.ToLower(hash) }
+
+// HandlerTag returns the tag for entries created by the handler (like an authenticator) with the given id.
+func HandlerTag(handlerID string) string { return tagHandler + handlerID }
+
+// SessionTag returns the tag for entries related to the login session with the given id, like the
+// session id (sid) of the OpenID Connect provider.
+func SessionTag(sessionID string) string { return tagSession + sessionID }
@@ -0,0 +1,69 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/mocks"
+)
+
+func TestTags(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "subject:foo", SubjectTag("foo"))
+	assert.Equal(t, "handler:foo", HandlerTag("foo"))
+	assert.Equal(t, "session:foo", SessionTag("foo"))
+	assert.Equal(t, "token:2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae", TokenTag("foo"))
+	assert.Equal(t, TokenTag("foo"),
+		TokenHashTag("2C26B46B68FFC68FF99B453C1D30413413422D706483BFA0F98A5E886266E7AE"))
+}
+
+func TestIndexAndInvalidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc  string
+		cch func() Cache
+	}{
+		{uc: "memory cache", cch: func() Cache { return memory.New() }},
+		{uc: "namespaced memory cache", cch: func() Cache { return Namespaced(memory.New(), "index_test") }},
+		{uc: "tiered cache", cch: func() Cache {
+			return &tieredCache{l1: memory.New(), l2: memory.New(), l1TTL: defaultL1TTL}
+		}},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cch := tc.cch()
+
+			cch.Set("foo", "bar", 1*time.Minute)
+			cch.Set("bar", "baz", 1*time.Minute)
+			Index(cch, "foo", 1*time.Minute, SubjectTag("foo"))
+
+			// WHEN
+			count := Invalidate(cch, SubjectTag("foo"))
+
+			// THEN
+			assert.Equal(t, 1, count)
+			assert.Nil(t, cch.Get("foo"))
+			assert.Equal(t, "baz", cch.Get("bar"))
+		})
+	}
+}
+
+func TestIndexAndInvalidateWithCacheWithoutIndexSupport(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cch := &mocks.MockCache{}
+
+	// WHEN
+	Index(cch, "foo", 1*time.Minute, SubjectTag("foo"))
+	count := Invalidate(cch, SubjectTag("foo"))
+
+	// THEN
+	assert.Equal(t, 0, count)
+	cch.AssertExpectations(t)
+}
@@ -0,0 +1,319 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"golang.org/x/sync/singleflight"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// ErrCachedFailure is returned by Load if a failure has been cached for the requested key.
+var ErrCachedFailure = errors.New("cached failure")
+
+// by intention. Used only during application bootstrap
+// nolint
+var (
+	// loads deduplicates concurrent loads of the same key
+	loads singleflight.Group
+	// refreshes holds the keys of the entries currently refreshed
+	refreshes sync.Map
+)
+
+// loadTimeout bounds the time a load may take. Loads are not cancelled together with the request
+// triggering them, as other requests may wait for their result, or, when refreshing ahead, the
+// triggering request does not wait for them at all.
+const loadTimeout = 10 * time.Second
+
+// Policy defines how entries managed by Load are refreshed and whether failures are cached.
+type Policy struct {
+	// RefreshAhead defines how long before its expiry an entry is refreshed. The request hitting the entry
+	// first within that time triggers the refresh in the background. All requests keep using the cached
+	// value until the refresh is done.
+	RefreshAhead time.Duration `mapstructure:"refresh_ahead"`
+	// StaleTTL defines how long an expired entry is kept to be used if refreshing it fails.
+	StaleTTL time.Duration `mapstructure:"stale_ttl"`
+	// NegativeTTL defines how long failures marked by Negative are cached.
+	NegativeTTL time.Duration `mapstructure:"negative_ttl"`
+}
+
+// Loaded is the result of a LoadFunc.
+type Loaded[T any] struct {
+	Value T
+	// TTL defines how long the value is cached. The value is not cached if not greater than 0.
+	TTL time.Duration
+	// Tags to index the entry with.
+	Tags []string
+}
+
+// LoadFunc loads the value for a key, e.g. by calling an endpoint. The given context holds the values of
+// the context passed to Load, but is not cancelled together with it. Instead, it times out after loadTimeout.
+type LoadFunc[T any] func(ctx context.Context) (Loaded[T], error)
+
+// PrepareFunc is called by Load while serving the request, whenever the value has to be loaded. It takes
+// everything required from the request and returns the LoadFunc performing the actual load. As the latter
+// may be executed after the request has been served, it must not refer to the request, like to a
+// heimdall.Context, or to the strings taken from it, which refer to memory reused for other requests.
+type PrepareFunc[T any] func() (LoadFunc[T], error)
+
+type negativeError struct {
+	err error
+}
+
+func (e *negativeError) Error() string { return e.err.Error() }
+func (e *negativeError) Unwrap() error { return e.err }
+
+// Negative marks the given error returned by a LoadFunc as a definitive failure, like a rejected token.
+// Such failures are cached according to the NegativeTTL of the Policy and never result in a stale value
+// to be used.
+func Negative(err error) error { return &negativeError{err: err} }
+
+// Load returns the value cached for the given key. If there is no such value, it is loaded using the
+// LoadFunc created by prepare. Concurrent loads of the same key are deduplicated, so that only one of the
+// callers executes the load, with all others waiting for its result. If a failure has been cached for the
+// key, an error wrapping ErrCachedFailure is returned.
+func Load[T any](ctx context.Context, cch Cache, key string, policy Policy, prepare PrepareFunc[T]) (T, error) {
+	value, err := lookup(ctx, cch, key, policy, prepare)
+
+	var negErr *negativeError
+	if errors.As(err, &negErr) {
+		return value, negErr.err
+	}
+
+	return value, err
+}
+
+func lookup[T any](ctx context.Context, cch Cache, key string, policy Policy, prepare PrepareFunc[T]) (T, error) {
+	var zero T
+
+	switch item := cch.Get(key).(type) {
+	case nil:
+		return loadAndStore(ctx, cch, key, policy, prepare)
+	case *Entry:
+		if len(item.Failure) != 0 {
+			return zero, errorchain.NewWithMessage(ErrCachedFailure, item.Failure)
+		}
+
+		value, ok := item.Value.(T)
+		if !ok {
+			break
+		}
+
+		now := time.Now()
+
+		switch {
+		case now.Before(item.ExpiresAt.Add(-policy.RefreshAhead)):
+			return value, nil
+		case now.Before(item.ExpiresAt):
+			refreshAhead(ctx, cch, key, policy, prepare)
+
+			return value, nil
+		default:
+			return refreshStale(ctx, cch, key, policy, prepare, value, item.ExpiresAt)
+		}
+	case T:
+		// stored without making use of Load, e.g. by a previous version. The expiry of such
+		// entries is not known, so these are used until removed by the cache.
+		return item, nil
+	}
+
+	zerolog.Ctx(ctx).Warn().Msg("Wrong object type from cache")
+	cch.Delete(key)
+
+	return loadAndStore(ctx, cch, key, policy, prepare)
+}
+
+// refreshAhead refreshes a not yet expired entry in the background.
+func refreshAhead[T any](ctx context.Context, cch Cache, key string, policy Policy, prepare PrepareFunc[T]) {
+	logger := zerolog.Ctx(ctx)
+
+	if _, inProgress := refreshes.LoadOrStore(key, struct{}{}); inProgress {
+		return
+	}
+
+	load, err := prepare()
+	if err != nil {
+		refreshes.Delete(key)
+		logger.Warn().Err(err).Msg("Failed to refresh cache entry. Using the cached value")
+
+		return
+	}
+
+	logger.Debug().Msg("Refreshing cache entry")
+
+	go func() {
+		defer refreshes.Delete(key)
+
+		_, err := loadAndStore(ctx, cch, key, policy, func() (LoadFunc[T], error) { return load, nil })
+
+		var negErr *negativeError
+
+		switch {
+		case err == nil:
+			return
+		case errors.As(err, &negErr):
+			if policy.NegativeTTL <= 0 {
+				// the cached value must not be used anymore. Otherwise, it has been replaced by the failure
+				cch.Delete(key)
+			}
+
+			logger.Debug().Err(err).Msg("Refreshing cache entry resulted in a definitive failure")
+		default:
+			logger.Warn().Err(err).Msg("Failed to refresh cache entry. Using the cached value")
+		}
+	}()
+}
+
+// refreshStale refreshes an expired entry. The stale value is used if that fails.
+func refreshStale[T any](
+	ctx context.Context, cch Cache, key string, policy Policy, prepare PrepareFunc[T], value T, expiresAt time.Time,
+) (T, error) {
+	logger := zerolog.Ctx(ctx)
+
+	if _, inProgress := refreshes.LoadOrStore(key, struct{}{}); inProgress {
+		return value, nil
+	}
+
+	defer refreshes.Delete(key)
+
+	logger.Debug().Msg("Refreshing expired cache entry")
+
+	refreshed, err := loadAndStore(ctx, cch, key, policy, prepare)
+	if err == nil {
+		return refreshed, nil
+	}
+
+	var negErr *negativeError
+
+	switch {
+	case errors.As(err, &negErr):
+		return refreshed, err
+	case time.Now().Before(expiresAt.Add(policy.StaleTTL)):
+		logger.Warn().Err(err).Msg("Failed to refresh expired cache entry. Using the stale value")
+
+		return value, nil
+	default:
+		return refreshed, err
+	}
+}
+
+func loadAndStore[T any](
+	ctx context.Context, cch Cache, key string, policy Policy, prepare PrepareFunc[T],
+) (T, error) {
+	var zero T
+
+	result, err, _ := loads.Do(key, func() (any, error) {
+		load, err := prepare()
+		if err != nil {
+			return nil, err
+		}
+
+		loadCtx, cancel := context.WithTimeout(detachedContext{parent: ctx}, loadTimeout)
+		defer cancel()
+
+		loaded, err := load(loadCtx)
+		if err != nil {
+			var negErr *negativeError
+			if errors.As(err, &negErr) && policy.NegativeTTL > 0 {
+				cch.Set(key, &Entry{
+					Failure:   err.Error(),
+					ExpiresAt: time.Now().Add(policy.NegativeTTL),
+				}, policy.NegativeTTL)
+			}
+
+			return nil, err
+		}
+
+		if loaded.TTL > 0 {
+			ttl := loaded.TTL + policy.StaleTTL
+
+			cch.Set(key, &Entry{Value: loaded.Value, ExpiresAt: time.Now().Add(loaded.TTL)}, ttl)
+			Index(cch, key, ttl, loaded.Tags...)
+		}
+
+		return loaded.Value, nil
+	})
+	if err != nil {
+		return zero, err
+	}
+
+	// nolint: forcetypeassert
+	// the result is created by load
+	return result.(T), nil
+}
+
+// detachedContext holds the values of its parent, like the logger or the tracing span, but is neither
+// cancelled with it, nor has its deadline.
+type detachedContext struct {
+	parent context.Context // nolint: containedctx
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+func (c detachedContext) Value(key any) any         { return c.parent.Value(key) }
+
+// Entry is stored by Load. Its expiry is tracked here as well, as the entry is kept in the cache
+// for the StaleTTL of the Policy after it expired.
+type Entry struct {
+	Value any
+	// Failure holds the message of a cached failure. Value is nil in that case.
+	Failure   string
+	ExpiresAt time.Time
+}
+
+type entryCodec struct{}
+
+type entryDTO struct {
+	Value     []byte    `json:"value,omitempty"`
+	Failure   string    `json:"failure,omitempty"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (entryCodec) Encode(value *Entry) ([]byte, error) {
+	dto := entryDTO{Failure: value.Failure, ExpiresAt: value.ExpiresAt}
+
+	if value.Value != nil {
+		data, err := codec.Marshal(value.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		dto.Value = data
+	}
+
+	return json.Marshal(dto)
+}
+
+func (entryCodec) Decode(data []byte) (*Entry, error) {
+	var dto entryDTO
+
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+
+	value := &Entry{Failure: dto.Failure, ExpiresAt: dto.ExpiresAt}
+
+	if len(dto.Value) != 0 {
+		decoded, err := codec.Unmarshal(dto.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		value.Value = decoded
+	}
+
+	return value, nil
+}
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*Entry]("loaded_entry", entryCodec{})
+}
@@ -0,0 +1,496 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+)
+
+var errTest = errors.New("test error")
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		policy Policy
+		// refreshed in the background
+		background bool
+		setup      func(t *testing.T, cch *memory.InMemoryCache, key string)
+		load       func() (Loaded[string], error)
+		assert     func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error)
+	}{
+		{
+			uc: "without cached value",
+			load: func() (Loaded[string], error) {
+				return Loaded[string]{Value: "foo", TTL: time.Minute, Tags: []string{SubjectTag("bar")}}, nil
+			},
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", value)
+				assert.Equal(t, 1, calls)
+
+				entry, ok := cch.Get(key).(*Entry)
+				require.True(t, ok)
+				assert.Equal(t, "foo", entry.Value)
+				assert.WithinDuration(t, time.Now().Add(time.Minute), entry.ExpiresAt, time.Second)
+
+				assert.Equal(t, 1, cch.Invalidate(SubjectTag("bar")))
+			},
+		},
+		{
+			uc: "without cached value and value not to be cached",
+			load: func() (Loaded[string], error) {
+				return Loaded[string]{Value: "foo"}, nil
+			},
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", value)
+				assert.Equal(t, 1, calls)
+				assert.Nil(t, cch.Get(key))
+			},
+		},
+		{
+			uc: "with cached value",
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(time.Minute)}, time.Minute)
+			},
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 0, calls)
+			},
+		},
+		{
+			uc: "with value not stored by Load",
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, "bar", time.Minute)
+			},
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 0, calls)
+			},
+		},
+		{
+			uc: "with cached value of wrong type",
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: 10, ExpiresAt: time.Now().Add(time.Minute)}, time.Minute)
+			},
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", value)
+				assert.Equal(t, 1, calls)
+
+				entry, ok := cch.Get(key).(*Entry)
+				require.True(t, ok)
+				assert.Equal(t, "foo", entry.Value)
+			},
+		},
+		{
+			uc:         "with cached value to be refreshed ahead",
+			policy:     Policy{RefreshAhead: 30 * time.Second},
+			background: true,
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(10 * time.Second)}, time.Minute)
+			},
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 1, calls)
+
+				entry, ok := cch.Get(key).(*Entry)
+				require.True(t, ok)
+				assert.Equal(t, "foo", entry.Value)
+			},
+		},
+		{
+			uc:     "with cached value being refreshed by another request",
+			policy: Policy{RefreshAhead: 30 * time.Second},
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(10 * time.Second)}, time.Minute)
+				refreshes.Store(key, struct{}{})
+				t.Cleanup(func() { refreshes.Delete(key) })
+			},
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 0, calls)
+			},
+		},
+		{
+			uc:         "with cached value failed to be refreshed ahead",
+			policy:     Policy{RefreshAhead: 30 * time.Second},
+			background: true,
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(10 * time.Second)}, time.Minute)
+			},
+			load: func() (Loaded[string], error) { return Loaded[string]{}, errTest },
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 1, calls)
+
+				entry, ok := cch.Get(key).(*Entry)
+				require.True(t, ok)
+				assert.Equal(t, "bar", entry.Value)
+			},
+		},
+		{
+			uc:         "with cached value failed to be refreshed ahead definitively",
+			policy:     Policy{RefreshAhead: 30 * time.Second},
+			background: true,
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(10 * time.Second)}, time.Minute)
+			},
+			load: func() (Loaded[string], error) { return Loaded[string]{}, Negative(errTest) },
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 1, calls)
+				assert.Nil(t, cch.Get(key))
+			},
+		},
+		{
+			uc:     "with stale value and failing refresh",
+			policy: Policy{StaleTTL: time.Minute},
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(-10 * time.Second)}, time.Minute)
+			},
+			load: func() (Loaded[string], error) { return Loaded[string]{}, errTest },
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", value)
+				assert.Equal(t, 1, calls)
+			},
+		},
+		{
+			uc:     "with stale value and refresh failing definitively",
+			policy: Policy{StaleTTL: time.Minute},
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(-10 * time.Second)}, time.Minute)
+			},
+			load: func() (Loaded[string], error) { return Loaded[string]{}, Negative(errTest) },
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, errTest, err)
+				assert.Empty(t, value)
+				assert.Equal(t, 1, calls)
+			},
+		},
+		{
+			uc:     "with too old stale value and failing refresh",
+			policy: Policy{StaleTTL: 5 * time.Second},
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(-10 * time.Second)}, time.Minute)
+			},
+			load: func() (Loaded[string], error) { return Loaded[string]{}, errTest },
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, value string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, errTest)
+				assert.Empty(t, value)
+				assert.Equal(t, 1, calls)
+			},
+		},
+		{
+			uc:     "with failure not to be cached",
+			policy: Policy{NegativeTTL: time.Minute},
+			load:   func() (Loaded[string], error) { return Loaded[string]{}, errTest },
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, _ string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, errTest, err)
+				assert.Equal(t, 1, calls)
+				assert.Nil(t, cch.Get(key))
+			},
+		},
+		{
+			uc:   "with failure to be cached, but negative caching disabled",
+			load: func() (Loaded[string], error) { return Loaded[string]{}, Negative(errTest) },
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, _ string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, errTest, err)
+				assert.Equal(t, 1, calls)
+				assert.Nil(t, cch.Get(key))
+			},
+		},
+		{
+			uc:     "with failure to be cached",
+			policy: Policy{NegativeTTL: time.Minute},
+			load:   func() (Loaded[string], error) { return Loaded[string]{}, Negative(errTest) },
+			assert: func(t *testing.T, cch *memory.InMemoryCache, key string, calls int, _ string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, errTest, err)
+				assert.Equal(t, 1, calls)
+
+				entry, ok := cch.Get(key).(*Entry)
+				require.True(t, ok)
+				assert.Nil(t, entry.Value)
+				assert.Equal(t, errTest.Error(), entry.Failure)
+			},
+		},
+		{
+			uc: "with cached failure",
+			setup: func(t *testing.T, cch *memory.InMemoryCache, key string) {
+				t.Helper()
+
+				cch.Set(key, &Entry{Failure: "foo", ExpiresAt: time.Now().Add(time.Minute)}, time.Minute)
+			},
+			assert: func(t *testing.T, _ *memory.InMemoryCache, _ string, calls int, _ string, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrCachedFailure)
+				assert.Contains(t, err.Error(), "foo")
+				assert.Equal(t, 0, calls)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			var calls int32
+
+			key := "TestLoad-" + tc.uc
+			cch := memory.New()
+
+			if tc.setup != nil {
+				tc.setup(t, cch, key)
+			}
+
+			load := tc.load
+			if load == nil {
+				load = func() (Loaded[string], error) { return Loaded[string]{Value: "foo", TTL: time.Minute}, nil }
+			}
+
+			// WHEN
+			value, err := Load(context.Background(), cch, key, tc.policy, func() (LoadFunc[string], error) {
+				return func(context.Context) (Loaded[string], error) {
+					atomic.AddInt32(&calls, 1)
+
+					return load()
+				}, nil
+			})
+
+			if tc.background {
+				require.Eventually(t, func() bool {
+					_, inProgress := refreshes.Load(key)
+
+					return !inProgress
+				}, time.Second, 10*time.Millisecond)
+			}
+
+			// THEN
+			tc.assert(t, cch, key, int(atomic.LoadInt32(&calls)), value, err)
+		})
+	}
+}
+
+func TestLoadDeduplicatesConcurrentLoads(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	const callers = 10
+
+	var (
+		calls int32
+		wg    sync.WaitGroup
+	)
+
+	cch := memory.New()
+	release := make(chan struct{})
+	values := make(chan string, callers)
+
+	prepare := func() (LoadFunc[string], error) {
+		return func(context.Context) (Loaded[string], error) {
+			atomic.AddInt32(&calls, 1)
+			<-release
+
+			return Loaded[string]{Value: "foo", TTL: time.Minute}, nil
+		}, nil
+	}
+
+	// WHEN
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			value, err := Load(context.Background(), cch, "TestLoadDeduplicatesConcurrentLoads", Policy{}, prepare)
+			assert.NoError(t, err)
+
+			values <- value
+		}()
+	}
+
+	// give all callers the chance to wait for the first one
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(values)
+
+	// THEN
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	for value := range values {
+		assert.Equal(t, "foo", value)
+	}
+}
+
+func TestLoadDetachesLoadsFromTheCallerContext(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	type ctxKey struct{}
+
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), ctxKey{}, "foo"))
+	cancel()
+
+	// WHEN
+	value, err := Load(ctx, memory.New(), "TestLoadDetachesLoadsFromTheCallerContext", Policy{},
+		func() (LoadFunc[string], error) {
+			return func(ctx context.Context) (Loaded[string], error) {
+				_, hasDeadline := ctx.Deadline()
+
+				assert.NoError(t, ctx.Err())
+				assert.True(t, hasDeadline)
+
+				return Loaded[string]{Value: ctx.Value(ctxKey{}).(string)}, nil // nolint: forcetypeassert
+			}, nil
+		})
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "foo", value)
+}
+
+func TestLoadRefreshesAheadInBackground(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	const key = "TestLoadRefreshesAheadInBackground"
+
+	var prepared bool
+
+	cch := memory.New()
+	release := make(chan struct{})
+
+	cch.Set(key, &Entry{Value: "bar", ExpiresAt: time.Now().Add(10 * time.Second)}, time.Minute)
+
+	// WHEN
+	value, err := Load(context.Background(), cch, key, Policy{RefreshAhead: 30 * time.Second},
+		func() (LoadFunc[string], error) {
+			prepared = true
+
+			return func(context.Context) (Loaded[string], error) {
+				<-release
+
+				return Loaded[string]{Value: "foo", TTL: time.Minute}, nil
+			}, nil
+		})
+
+	// THEN the cached value is used, while the refresh is still in progress
+	require.NoError(t, err)
+	assert.Equal(t, "bar", value)
+	assert.True(t, prepared)
+
+	entry, ok := cch.Get(key).(*Entry)
+	require.True(t, ok)
+	assert.Equal(t, "bar", entry.Value)
+
+	// WHEN
+	close(release)
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		entry, ok := cch.Get(key).(*Entry)
+
+		return ok && entry.Value == "foo"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestEntryCodec(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc    string
+		entry *Entry
+	}{
+		{uc: "with value", entry: &Entry{Value: []byte("foo"), ExpiresAt: time.Now().Round(0).UTC()}},
+		{uc: "with failure", entry: &Entry{Failure: "foo", ExpiresAt: time.Now().Round(0).UTC()}},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			data, err := codec.Marshal(tc.entry)
+			require.NoError(t, err)
+
+			decoded, err := codec.Unmarshal(data)
+			require.NoError(t, err)
+
+			// THEN
+			entry, ok := decoded.(*Entry)
+			require.True(t, ok)
+			assert.Equal(t, tc.entry.Value, entry.Value)
+			assert.Equal(t, tc.entry.Failure, entry.Failure)
+			assert.True(t, tc.entry.ExpiresAt.Equal(entry.ExpiresAt))
+		})
+	}
+}
@@ -1,32 +1,352 @@
 package memory
 
 import (
+	"container/heap"
+	"sync"
 	"time"
 
-	"github.com/jellydator/ttlcache/v3"
+	"github.com/dadrus/heimdall/internal/cache/codec"
+	"github.com/dadrus/heimdall/internal/cache/metrics"
 )
 
+const (
+	// DefaultNamespace is used for entries set without a namespace.
+	DefaultNamespace = "default"
+
+	cleanupInterval = 30 * time.Second
+	// estimated size of values, which size is not determined
+	defaultValueSize = 64
+)
+
+// EvictionPolicy defines which entry is evicted if the cache reached its capacity.
+type EvictionPolicy string
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU EvictionPolicy = "lru"
+	// LFU evicts the least frequently used entry. From entries used equally often, the least recently
+	// used one is evicted.
+	LFU EvictionPolicy = "lfu"
+)
+
+type Option func(c *InMemoryCache)
+
+// WithMaxEntries limits the number of entries held by the cache. 0 means unlimited.
+func WithMaxEntries(maxEntries int) Option {
+	return func(c *InMemoryCache) { c.maxEntries = maxEntries }
+}
+
+// WithMaxBytes limits the estimated size of the entries held by the cache. 0 means unlimited.
+func WithMaxBytes(maxBytes int) Option {
+	return func(c *InMemoryCache) { c.maxBytes = maxBytes }
+}
+
+func WithEvictionPolicy(policy EvictionPolicy) Option {
+	return func(c *InMemoryCache) { c.queue.policy = policy }
+}
+
+type entry struct {
+	key       string
+	namespace string
+	value     any
+	size      int
+	expiresAt time.Time
+	hits      uint64
+	lastUsed  uint64
+	index     int
+	tags      []string
+}
+
+func (e *entry) expired(now time.Time) bool {
+	return !e.expiresAt.IsZero() && !now.Before(e.expiresAt)
+}
+
+// evictionQueue is a min heap, which has the entry to evict next at its root.
+type evictionQueue struct {
+	policy  EvictionPolicy
+	entries []*entry
+}
+
+func (q *evictionQueue) Len() int { return len(q.entries) }
+
+func (q *evictionQueue) Less(i, j int) bool {
+	left, right := q.entries[i], q.entries[j]
+
+	if q.policy == LFU && left.hits != right.hits {
+		return left.hits < right.hits
+	}
+
+	return left.lastUsed < right.lastUsed
+}
+
+func (q *evictionQueue) Swap(i, j int) {
+	q.entries[i], q.entries[j] = q.entries[j], q.entries[i]
+	q.entries[i].index = i
+	q.entries[j].index = j
+}
+
+func (q *evictionQueue) Push(value any) {
+	// nolint: forcetypeassert
+	// only entries are pushed
+	item := value.(*entry)
+	item.index = len(q.entries)
+	q.entries = append(q.entries, item)
+}
+
+func (q *evictionQueue) Pop() any {
+	last := len(q.entries) - 1
+	item := q.entries[last]
+
+	q.entries[last] = nil
+	q.entries = q.entries[:last]
+
+	return item
+}
+
+// InMemoryCache keeps the entries in the memory of the process. If bounded, entries are evicted
+// according to the configured EvictionPolicy to free space for new ones. Expired entries are removed
+// on access and periodically, as long as the cache is started.
 type InMemoryCache struct {
-	c *ttlcache.Cache[string, any]
+	mu         sync.Mutex
+	entries    map[string]*entry
+	tags       map[string]map[string]struct{}
+	queue      evictionQueue
+	maxEntries int
+	maxBytes   int
+	size       int
+	clock      uint64
+	now        func() time.Time
+	stop       chan struct{}
+	stopOnce   sync.Once
 }
 
-func New() *InMemoryCache {
-	return &InMemoryCache{c: ttlcache.New[string, any]()}
+func New(opts ...Option) *InMemoryCache {
+	cch := &InMemoryCache{
+		entries: make(map[string]*entry),
+		tags:    make(map[string]map[string]struct{}),
+		queue:   evictionQueue{policy: LRU},
+		now:     time.Now,
+		stop:    make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(cch)
+	}
+
+	return cch
 }
 
-func (c *InMemoryCache) Start() { c.c.Start() }
+// Start removes expired entries periodically until Stop is called.
+func (c *InMemoryCache) Start() {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.removeExpired()
+		case <-c.stop:
+			return
+		}
+	}
+}
 
-func (c *InMemoryCache) Stop() { c.c.Stop() }
+func (c *InMemoryCache) Stop() { c.stopOnce.Do(func() { close(c.stop) }) }
 
 func (c *InMemoryCache) Get(key string) any {
-	item := c.c.Get(key)
-	if item != nil && !item.IsExpired() {
-		return item.Value()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.entries[key]
+	if !ok {
+		return nil
 	}
 
-	return nil
+	if item.expired(c.now()) {
+		c.remove(item)
+		metrics.RecordEviction(item.namespace, metrics.EvictionReasonExpired)
+
+		return nil
+	}
+
+	c.clock++
+	item.hits++
+	item.lastUsed = c.clock
+	heap.Fix(&c.queue, item.index)
+
+	return item.value
 }
 
-func (c *InMemoryCache) Set(key string, value any, ttl time.Duration) { c.c.Set(key, value, ttl) }
+func (c *InMemoryCache) Set(key string, value any, ttl time.Duration) {
+	c.SetNamespaced(DefaultNamespace, key, value, ttl)
+}
+
+// SetNamespaced sets the value like Set and accounts it for the given namespace in the exposed metrics.
+func (c *InMemoryCache) SetNamespaced(namespace, key string, value any, ttl time.Duration) {
+	size := len(key) + c.valueSize(value)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if known, ok := c.entries[key]; ok {
+		c.remove(known)
+	}
+
+	if c.maxBytes > 0 && size > c.maxBytes {
+		// would evict everything else and still not fit
+		return
+	}
 
-func (c *InMemoryCache) Delete(key string) { c.c.Delete(key) }
+	for len(c.entries) != 0 &&
+		((c.maxEntries > 0 && len(c.entries)+1 > c.maxEntries) || (c.maxBytes > 0 && c.size+size > c.maxBytes)) {
+		victim := c.queue.entries[0]
+
+		c.remove(victim)
+		metrics.RecordEviction(victim.namespace, metrics.EvictionReasonCapacity)
+	}
+
+	c.clock++
+
+	item := &entry{
+		key:       key,
+		namespace: namespace,
+		value:     value,
+		size:      size,
+		lastUsed:  c.clock,
+	}
+
+	if ttl > 0 {
+		item.expiresAt = c.now().Add(ttl)
+	}
+
+	c.entries[key] = item
+	c.size += size
+	heap.Push(&c.queue, item)
+	metrics.RecordAdded(namespace, size)
+}
+
+func (c *InMemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item, ok := c.entries[key]; ok {
+		c.remove(item)
+	}
+}
+
+// Index associates the entry stored under the given key with the given tags. The index is removed
+// together with the entry.
+func (c *InMemoryCache) Index(key string, _ time.Duration, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.entries[key]
+	if !ok {
+		return
+	}
+
+	for _, tag := range tags {
+		keys, ok := c.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.tags[tag] = keys
+		}
+
+		if _, known := keys[key]; !known {
+			keys[key] = struct{}{}
+			item.tags = append(item.tags, tag)
+		}
+	}
+}
+
+// Invalidate deletes all entries associated with the given tag and returns their number.
+func (c *InMemoryCache) Invalidate(tag string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	keys := c.tags[tag]
+	count := len(keys)
+
+	for key := range keys {
+		c.remove(c.entries[key])
+	}
+
+	return count
+}
+
+// Tags returns the tags the entry stored under the given key is associated with.
+func (c *InMemoryCache) Tags(key string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, ok := c.entries[key]
+	if !ok || len(item.tags) == 0 {
+		return nil
+	}
+
+	return append([]string(nil), item.tags...)
+}
+
+// Len returns the number of entries held by the cache, including the expired ones not removed yet.
+func (c *InMemoryCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+func (c *InMemoryCache) removeExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+
+	for _, item := range c.entries {
+		if item.expired(now) {
+			c.remove(item)
+			metrics.RecordEviction(item.namespace, metrics.EvictionReasonExpired)
+		}
+	}
+}
+
+// remove must be called while holding the lock.
+func (c *InMemoryCache) remove(item *entry) {
+	heap.Remove(&c.queue, item.index)
+	delete(c.entries, item.key)
+
+	c.size -= item.size
+	metrics.RecordRemoved(item.namespace, item.size)
+
+	for _, tag := range item.tags {
+		keys := c.tags[tag]
+
+		delete(keys, item.key)
+
+		if len(keys) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
+
+// valueSize estimates the size of the given value. Structured values are encoded for that only if the
+// cache is bounded by size, as encoding them on each write is costly. Otherwise, defaultValueSize is
+// assumed for these.
+func (c *InMemoryCache) valueSize(value any) int {
+	switch val := value.(type) {
+	case string:
+		return len(val)
+	case []byte:
+		return len(val)
+	default:
+		if c.maxBytes == 0 {
+			return defaultValueSize
+		}
+
+		// the encoded form is a good approximation of the size of structured values
+		if data, err := codec.Marshal(value); err == nil {
+			return len(data)
+		}
+
+		return defaultValueSize
+	}
+}
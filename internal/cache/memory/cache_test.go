@@ -4,7 +4,12 @@ import (
 	"testing"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+	"github.com/dadrus/heimdall/internal/cache/metrics"
 )
 
 func TestCacheUsage(t *testing.T) {
@@ -88,3 +93,271 @@ func TestCacheUsage(t *testing.T) {
 		})
 	}
 }
+
+func TestBoundedCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		opts           []Option
+		configureCache func(t *testing.T, cache *InMemoryCache)
+		assert         func(t *testing.T, cache *InMemoryCache)
+	}{
+		{
+			uc:   "least recently used entry is evicted if max entries is reached",
+			opts: []Option{WithMaxEntries(2)},
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1", 10*time.Minute)
+				cache.Set("bar", "2", 10*time.Minute)
+				cache.Get("foo")
+				cache.Set("baz", "3", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 2, cache.Len())
+				assert.Equal(t, "1", cache.Get("foo"))
+				assert.Nil(t, cache.Get("bar"))
+				assert.Equal(t, "3", cache.Get("baz"))
+			},
+		},
+		{
+			uc:   "least frequently used entry is evicted if max entries is reached",
+			opts: []Option{WithMaxEntries(2), WithEvictionPolicy(LFU)},
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1", 10*time.Minute)
+				cache.Set("bar", "2", 10*time.Minute)
+				cache.Get("foo")
+				cache.Get("foo")
+				cache.Get("bar")
+				cache.Set("baz", "3", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 2, cache.Len())
+				assert.Equal(t, "1", cache.Get("foo"))
+				assert.Nil(t, cache.Get("bar"))
+				assert.Equal(t, "3", cache.Get("baz"))
+			},
+		},
+		{
+			uc:   "entries are evicted if max bytes is reached",
+			opts: []Option{WithMaxBytes(19)},
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1234567", 10*time.Minute)
+				cache.Set("bar", "1234567", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 1, cache.Len())
+				assert.Nil(t, cache.Get("foo"))
+				assert.Equal(t, "1234567", cache.Get("bar"))
+			},
+		},
+		{
+			uc:   "entry larger than max bytes is not stored",
+			opts: []Option{WithMaxBytes(10)},
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1", 10*time.Minute)
+				cache.Set("bar", "12345678910", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 1, cache.Len())
+				assert.Equal(t, "1", cache.Get("foo"))
+				assert.Nil(t, cache.Get("bar"))
+			},
+		},
+		{
+			uc:   "replacing an entry does not evict other entries",
+			opts: []Option{WithMaxEntries(2)},
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1", 10*time.Minute)
+				cache.Set("bar", "2", 10*time.Minute)
+				cache.Set("foo", "3", 10*time.Minute)
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 2, cache.Len())
+				assert.Equal(t, "3", cache.Get("foo"))
+				assert.Equal(t, "2", cache.Get("bar"))
+			},
+		},
+		{
+			uc: "expired entries are removed by the cleanup",
+			configureCache: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				cache.Set("foo", "1", 1*time.Minute)
+				cache.Set("bar", "2", 0)
+
+				cache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+				cache.removeExpired()
+			},
+			assert: func(t *testing.T, cache *InMemoryCache) {
+				t.Helper()
+
+				assert.Equal(t, 1, cache.Len())
+				assert.Equal(t, "2", cache.Get("bar"))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cache := New(tc.opts...)
+
+			// WHEN
+			tc.configureCache(t, cache)
+
+			// THEN
+			tc.assert(t, cache)
+		})
+	}
+}
+
+type structuredValue struct {
+	Foo string `json:"foo"`
+}
+
+// nolint: gochecknoinits
+func init() {
+	codec.Register[*structuredValue]("memory.structured_value", codec.JSON[*structuredValue]{})
+}
+
+func TestCacheValueSize(t *testing.T) {
+	t.Parallel()
+
+	value := &structuredValue{Foo: "bar"}
+
+	encoded, err := codec.Marshal(value)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc   string
+		opts []Option
+		size int
+	}{
+		{
+			uc:   "structured value in unbounded cache",
+			size: len("foo") + defaultValueSize,
+		},
+		{
+			uc:   "structured value in cache bounded by entries",
+			opts: []Option{WithMaxEntries(10)},
+			size: len("foo") + defaultValueSize,
+		},
+		{
+			uc:   "structured value in cache bounded by size",
+			opts: []Option{WithMaxBytes(1024)},
+			size: len("foo") + len(encoded),
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cache := New(tc.opts...)
+
+			// WHEN
+			cache.Set("foo", value, 10*time.Minute)
+
+			// THEN
+			assert.Equal(t, tc.size, cache.size)
+		})
+	}
+}
+
+func TestCacheMetrics(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New(WithMaxEntries(1))
+
+	// WHEN
+	cache.SetNamespaced("memory_test", "foo", "bar", 10*time.Minute)
+	cache.SetNamespaced("memory_test", "bar", "baz", 10*time.Minute)
+
+	// THEN
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Entries.WithLabelValues("memory_test")))
+	assert.Equal(t, float64(6), testutil.ToFloat64(metrics.Size.WithLabelValues("memory_test")))
+	assert.Equal(t, float64(1), testutil.ToFloat64(
+		metrics.Evictions.WithLabelValues("memory_test", metrics.EvictionReasonCapacity)))
+
+	cache.Delete("bar")
+
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Entries.WithLabelValues("memory_test")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Size.WithLabelValues("memory_test")))
+}
+
+func TestCacheStartStop(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New()
+	done := make(chan struct{})
+
+	go func() {
+		cache.Start()
+		close(done)
+	}()
+
+	// WHEN
+	cache.Stop()
+	cache.Stop()
+
+	// THEN
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		t.Fatal("cache has not been stopped")
+	}
+}
+
+func TestCacheIndex(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cache := New()
+
+	cache.Set("foo", "1", 10*time.Minute)
+	cache.Set("bar", "2", 10*time.Minute)
+	cache.Set("baz", "3", 10*time.Minute)
+
+	cache.Index("foo", 10*time.Minute, "subject:a", "handler:x")
+	cache.Index("bar", 10*time.Minute, "subject:a")
+	cache.Index("baz", 10*time.Minute, "subject:b", "handler:x")
+	cache.Index("qux", 10*time.Minute, "subject:a")
+
+	assert.ElementsMatch(t, []string{"subject:a", "handler:x"}, cache.Tags("foo"))
+	assert.Empty(t, cache.Tags("qux"))
+
+	// WHEN
+	count := cache.Invalidate("subject:a")
+
+	// THEN
+	assert.Equal(t, 2, count)
+	assert.Nil(t, cache.Get("foo"))
+	assert.Nil(t, cache.Get("bar"))
+	assert.Equal(t, "3", cache.Get("baz"))
+
+	// the index entries of removed entries are removed as well
+	assert.NotContains(t, cache.tags, "subject:a")
+	assert.Empty(t, cache.Tags("foo"))
+	assert.Equal(t, map[string]struct{}{"baz": {}}, cache.tags["handler:x"])
+
+	cache.Delete("baz")
+	assert.Empty(t, cache.tags)
+	assert.Equal(t, 0, cache.Invalidate("handler:x"))
+}
@@ -0,0 +1,80 @@
+// Package metrics holds the Prometheus metrics exposed by the cache implementations. All metrics
+// are labeled with the namespace of the cache entries, which identifies the type of the component,
+// like an authenticator or a hydrator, the entries are cached for.
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	metricsNamespace = "heimdall"
+	metricsSubsystem = "cache"
+
+	labelNamespace = "namespace"
+	labelReason    = "reason"
+
+	// EvictionReasonCapacity is used if an entry has been evicted to free space for a new one.
+	EvictionReasonCapacity = "capacity"
+	// EvictionReasonExpired is used if an entry has been removed after it expired.
+	EvictionReasonExpired = "expired"
+)
+
+// by intention. The metrics are shared by all cache instances
+// nolint
+var (
+	Hits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "hits_total",
+		Help:      "Number of cache lookups, which found an entry.",
+	}, []string{labelNamespace})
+
+	Misses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "misses_total",
+		Help:      "Number of cache lookups, which did not find an entry.",
+	}, []string{labelNamespace})
+
+	Evictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "evictions_total",
+		Help:      "Number of entries removed from the in memory cache before being deleted explicitly.",
+	}, []string{labelNamespace, labelReason})
+
+	Entries = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "entries",
+		Help:      "Number of entries held by the in memory cache.",
+	}, []string{labelNamespace})
+
+	Size = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "size_bytes",
+		Help:      "Estimated size of the entries held by the in memory cache in bytes.",
+	}, []string{labelNamespace})
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	prometheus.MustRegister(Hits, Misses, Evictions, Entries, Size)
+}
+
+func RecordHit(namespace string) { Hits.WithLabelValues(namespace).Inc() }
+
+func RecordMiss(namespace string) { Misses.WithLabelValues(namespace).Inc() }
+
+func RecordEviction(namespace, reason string) { Evictions.WithLabelValues(namespace, reason).Inc() }
+
+func RecordAdded(namespace string, size int) {
+	Entries.WithLabelValues(namespace).Inc()
+	Size.WithLabelValues(namespace).Add(float64(size))
+}
+
+func RecordRemoved(namespace string, size int) {
+	Entries.WithLabelValues(namespace).Dec()
+	Size.WithLabelValues(namespace).Sub(float64(size))
+}
@@ -7,25 +7,89 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/redis"
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
+const cacheTypeRedis = "redis"
+
 // nolint
 var Module = fx.Options(
 	fx.Provide(newCache),
 	fx.Invoke(registerCacheEviction),
 )
 
-func newCache(conf config.Configuration, logger zerolog.Logger) Cache {
-	if len(conf.Cache.Type) == 0 {
+func newCache(conf config.Configuration, logger zerolog.Logger) (Cache, error) {
+	switch conf.Cache.Type {
+	case "":
 		logger.Info().Msg("Instantiating in memory cache")
 
-		return memory.New()
+		return newMemoryCache(conf.Cache.Memory)
+	case cacheTypeRedis:
+		if conf.Cache.Redis == nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"redis cache requires a redis configuration")
+		}
+
+		logger.Info().Msg("Instantiating redis cache")
+
+		cch, err := redis.New(*conf.Cache.Redis, logger)
+		if err != nil {
+			return nil, err
+		}
+
+		if conf.Cache.L1 == nil {
+			return cch, nil
+		}
+
+		logger.Info().Msg("Instantiating local in memory cache in front of the redis cache")
+
+		l1, err := newMemoryCache(&config.MemoryCacheConfig{
+			MaxEntries:     conf.Cache.L1.MaxEntries,
+			MaxBytes:       conf.Cache.L1.MaxBytes,
+			EvictionPolicy: conf.Cache.L1.EvictionPolicy,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		return &tieredCache{
+			l1:    l1,
+			l2:    cch,
+			l1TTL: x.IfThenElse(conf.Cache.L1.TTL > 0, conf.Cache.L1.TTL, defaultL1TTL),
+		}, nil
+	default:
+		logger.Info().Msg("Cache is disabled")
+
+		return noopCache{}, nil
+	}
+}
+
+func newMemoryCache(conf *config.MemoryCacheConfig) (*memory.InMemoryCache, error) {
+	if conf == nil {
+		return memory.New(), nil
 	}
 
-	logger.Info().Msg("Cache is disabled")
+	if conf.MaxEntries < 0 || conf.MaxBytes < 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"the size of the in memory cache must not be negative")
+	}
+
+	policy := memory.EvictionPolicy(x.IfThenElse(len(conf.EvictionPolicy) != 0,
+		conf.EvictionPolicy, string(memory.LRU)))
+	if policy != memory.LRU && policy != memory.LFU {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported eviction policy '%s'", conf.EvictionPolicy)
+	}
 
-	return noopCache{}
+	return memory.New(
+		memory.WithMaxEntries(conf.MaxEntries),
+		memory.WithMaxBytes(conf.MaxBytes),
+		memory.WithEvictionPolicy(policy),
+	), nil
 }
 
 func registerCacheEviction(lifecycle fx.Lifecycle, logger zerolog.Logger, cache Cache) {
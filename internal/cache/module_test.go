@@ -13,7 +13,9 @@ import (
 
 	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
+	"github.com/dadrus/heimdall/internal/cache/redis"
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/x"
 )
 
@@ -23,32 +25,145 @@ func TestNewCache(t *testing.T) {
 	for _, tc := range []struct {
 		uc     string
 		conf   config.Configuration
-		assert func(t *testing.T, cch Cache)
+		assert func(t *testing.T, err error, cch Cache)
 	}{
 		{
 			uc: "in memory cache",
-			assert: func(t *testing.T, cch Cache) {
+			assert: func(t *testing.T, err error, cch Cache) {
 				t.Helper()
 
+				require.NoError(t, err)
 				assert.IsType(t, &memory.InMemoryCache{}, cch)
 			},
 		},
 		{
 			uc:   "disabled cache",
 			conf: config.Configuration{Cache: config.CacheConfig{Type: "foo"}},
-			assert: func(t *testing.T, cch Cache) {
+			assert: func(t *testing.T, err error, cch Cache) {
 				t.Helper()
 
+				require.NoError(t, err)
 				assert.IsType(t, noopCache{}, cch)
 			},
 		},
+		{
+			uc: "bounded in memory cache",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Memory: &config.MemoryCacheConfig{MaxEntries: 10, MaxBytes: 1024, EvictionPolicy: "lfu"},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.IsType(t, &memory.InMemoryCache{}, cch)
+			},
+		},
+		{
+			uc: "in memory cache with unsupported eviction policy",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Memory: &config.MemoryCacheConfig{EvictionPolicy: "fifo"},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "eviction policy")
+				assert.Nil(t, cch)
+			},
+		},
+		{
+			uc: "in memory cache with negative size",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Memory: &config.MemoryCacheConfig{MaxEntries: -1},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Nil(t, cch)
+			},
+		},
+		{
+			uc:   "redis cache without redis configuration",
+			conf: config.Configuration{Cache: config.CacheConfig{Type: "redis"}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Nil(t, cch)
+			},
+		},
+		{
+			uc: "redis cache with invalid redis configuration",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Type:  "redis",
+				Redis: &config.RedisCacheConfig{},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Nil(t, cch)
+			},
+		},
+		{
+			uc: "redis cache",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Type:  "redis",
+				Redis: &config.RedisCacheConfig{Addrs: []string{"127.0.0.1:6379"}},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.IsType(t, &redis.Cache{}, cch)
+			},
+		},
+		{
+			uc: "redis cache with local in memory cache",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Type:  "redis",
+				Redis: &config.RedisCacheConfig{Addrs: []string{"127.0.0.1:6379"}},
+				L1:    &config.L1CacheConfig{MaxEntries: 100},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.IsType(t, &tieredCache{}, cch)
+
+				tiered := cch.(*tieredCache) // nolint: forcetypeassert
+				assert.IsType(t, &memory.InMemoryCache{}, tiered.l1)
+				assert.IsType(t, &redis.Cache{}, tiered.l2)
+				assert.Equal(t, defaultL1TTL, tiered.l1TTL)
+			},
+		},
+		{
+			uc: "redis cache with invalid local in memory cache configuration",
+			conf: config.Configuration{Cache: config.CacheConfig{
+				Type:  "redis",
+				Redis: &config.RedisCacheConfig{Addrs: []string{"127.0.0.1:6379"}},
+				L1:    &config.L1CacheConfig{EvictionPolicy: "foo"},
+			}},
+			assert: func(t *testing.T, err error, cch Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Nil(t, cch)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// WHEN
-			cch := newCache(tc.conf, log.Logger)
+			cch, err := newCache(tc.conf, log.Logger)
 
 			// THEN
-			tc.assert(t, cch)
+			tc.assert(t, err, cch)
 		})
 	}
 }
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/cache/metrics"
+)
+
+// Namespaces of the cache entries. These are used to expose the cache metrics per type of component
+// making use of the cache.
+const (
+	NamespaceAuthenticator = "authenticator"
+	NamespaceAuthorizer    = "authorizer"
+	NamespaceHydrator      = "hydrator"
+	NamespaceMutator       = "mutator"
+	NamespaceHTTPCache     = "httpcache"
+	NamespaceEndpoint      = "endpoint"
+	NamespaceLogin         = "login"
+)
+
+// namespacedSetter is implemented by caches, which account the entries per namespace.
+type namespacedSetter interface {
+	SetNamespaced(namespace, key string, value any, ttl time.Duration)
+}
+
+// namespacedGetter is implemented by caches, which store entries on reads, like the tiered cache does.
+type namespacedGetter interface {
+	GetNamespaced(namespace, key string) any
+}
+
+type namespacedCache struct {
+	c         Cache
+	namespace string
+}
+
+// Namespaced returns a Cache, which records hits and misses of the given cache for the given namespace.
+// The keys are not changed. So the same key must not be used in different namespaces.
+func Namespaced(cch Cache, namespace string) Cache {
+	if _, ok := cch.(noopCache); ok {
+		return cch
+	}
+
+	return &namespacedCache{c: cch, namespace: namespace}
+}
+
+// NamespacedCtx is a shorthand for Namespaced(Ctx(ctx), namespace).
+func NamespacedCtx(ctx context.Context, namespace string) Cache {
+	return Namespaced(Ctx(ctx), namespace)
+}
+
+func (c *namespacedCache) Get(key string) any {
+	var value any
+
+	if getter, ok := c.c.(namespacedGetter); ok {
+		value = getter.GetNamespaced(c.namespace, key)
+	} else {
+		value = c.c.Get(key)
+	}
+
+	if value == nil {
+		metrics.RecordMiss(c.namespace)
+	} else {
+		metrics.RecordHit(c.namespace)
+	}
+
+	return value
+}
+
+func (c *namespacedCache) Set(key string, value any, ttl time.Duration) {
+	if setter, ok := c.c.(namespacedSetter); ok {
+		setter.SetNamespaced(c.namespace, key, value, ttl)
+	} else {
+		c.c.Set(key, value, ttl)
+	}
+}
+
+func (c *namespacedCache) Delete(key string) { c.c.Delete(key) }
+
+func (c *namespacedCache) Index(key string, ttl time.Duration, tags ...string) {
+	Index(c.c, key, ttl, tags...)
+}
+
+func (c *namespacedCache) Invalidate(tag string) int { return Invalidate(c.c, tag) }
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/metrics"
+	"github.com/dadrus/heimdall/internal/cache/mocks"
+)
+
+func TestNamespacedCache(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	cch := NamespacedCtx(WithContext(context.Background(), memory.New()), "namespace_test")
+
+	// WHEN
+	cch.Set("foo", "bar", 1*time.Minute)
+
+	value1 := cch.Get("foo")
+	value2 := cch.Get("bar")
+
+	cch.Delete("foo")
+
+	value3 := cch.Get("foo")
+
+	// THEN
+	assert.Equal(t, "bar", value1)
+	assert.Nil(t, value2)
+	assert.Nil(t, value3)
+
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Hits.WithLabelValues("namespace_test")))
+	assert.Equal(t, float64(2), testutil.ToFloat64(metrics.Misses.WithLabelValues("namespace_test")))
+	assert.Equal(t, float64(0), testutil.ToFloat64(metrics.Entries.WithLabelValues("namespace_test")))
+}
+
+func TestNamespacedCacheWithCacheNotAwareOfNamespaces(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	mcch := &mocks.MockCache{}
+	mcch.On("Set", "foo", "bar", 1*time.Minute)
+	mcch.On("Get", "foo").Return("bar")
+
+	cch := Namespaced(mcch, "namespace_test_mock")
+
+	// WHEN
+	cch.Set("foo", "bar", 1*time.Minute)
+	value := cch.Get("foo")
+
+	// THEN
+	assert.Equal(t, "bar", value)
+	assert.Equal(t, float64(1), testutil.ToFloat64(metrics.Hits.WithLabelValues("namespace_test_mock")))
+	mcch.AssertExpectations(t)
+}
+
+func TestNamespacedNoopCache(t *testing.T) {
+	t.Parallel()
+
+	// WHEN
+	cch := NamespacedCtx(context.Background(), "namespace_test_noop")
+
+	// THEN
+	assert.Equal(t, noopCache{}, cch)
+}
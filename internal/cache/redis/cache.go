@@ -0,0 +1,295 @@
+package redis
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultKeyPrefix        = "heimdall:"
+	defaultOperationTimeout = 3 * time.Second
+
+	tagKeyPrefix     = "tag:"
+	keyTagsKeyPrefix = "tags:"
+)
+
+// indexScript adds a key to the set of keys associated with a tag. The set must live at least as long
+// as the entries referenced by it. ARGV[2] is the ttl of the added entry in milliseconds, with 0
+// meaning the entry does not expire.
+//
+// by intention. The script is loaded once
+// nolint: gochecknoglobals
+var indexScript = redis.NewScript(`
+redis.call('SADD', KEYS[1], ARGV[1])
+local ttl = tonumber(ARGV[2])
+if ttl == 0 then
+  redis.call('PERSIST', KEYS[1])
+  return 1
+end
+local current = redis.call('PTTL', KEYS[1])
+-- a set without ttl and more than one member references entries, which do not expire
+if current == -1 and redis.call('SCARD', KEYS[1]) > 1 then
+  return 1
+end
+if current < ttl then
+  redis.call('PEXPIRE', KEYS[1], ttl)
+end
+return 1
+`)
+
+// Cache stores the values in a Redis compatible server. This way the cached values are shared between
+// all heimdall instances using the same server. Values are converted using the codecs registered in
+// the codec package. Values of other types are not cached.
+type Cache struct {
+	c       redis.UniversalClient
+	prefix  string
+	timeout time.Duration
+	logger  zerolog.Logger
+}
+
+func New(conf config.RedisCacheConfig, logger zerolog.Logger) (*Cache, error) {
+	if len(conf.Addrs) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "no redis addresses configured")
+	}
+
+	tlsConf, err := tlsConfig(conf.TLS)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
+		Addrs:        conf.Addrs,
+		MasterName:   conf.MasterName,
+		Username:     conf.Username,
+		Password:     conf.Password,
+		DB:           conf.DB,
+		PoolSize:     conf.PoolSize,
+		MinIdleConns: conf.MinIdleConnections,
+		DialTimeout:  conf.DialTimeout,
+		ReadTimeout:  conf.ReadTimeout,
+		WriteTimeout: conf.WriteTimeout,
+		TLSConfig:    tlsConf,
+	})
+
+	return &Cache{
+		c:      client,
+		prefix: x.IfThenElse(len(conf.KeyPrefix) != 0, conf.KeyPrefix, defaultKeyPrefix),
+		timeout: x.IfThenElse(conf.ReadTimeout > 0 || conf.WriteTimeout > 0,
+			x.IfThenElse(conf.ReadTimeout > conf.WriteTimeout, conf.ReadTimeout, conf.WriteTimeout),
+			defaultOperationTimeout),
+		logger: logger,
+	}, nil
+}
+
+func tlsConfig(conf *config.RedisTLSConfig) (*tls.Config, error) {
+	if conf == nil {
+		return nil, nil
+	}
+
+	tlsConf := &tls.Config{
+		MinVersion: x.IfThenElse(conf.MinVersion != 0, uint16(conf.MinVersion), tls.VersionTLS12),
+	}
+
+	if len(conf.TrustStore) != 0 {
+		trustStore, err := truststore.NewTrustStoreFromPEMFile(conf.TrustStore)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"failed to load redis trust store").CausedBy(err)
+		}
+
+		tlsConf.RootCAs = x509.NewCertPool()
+
+		for _, cert := range trustStore {
+			tlsConf.RootCAs.AddCert(cert)
+		}
+	}
+
+	if len(conf.Key) != 0 || len(conf.Cert) != 0 {
+		cert, err := tls.LoadX509KeyPair(conf.Cert, conf.Key)
+		if err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"failed to load redis client key and certificate").CausedBy(err)
+		}
+
+		tlsConf.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConf, nil
+}
+
+func (c *Cache) Get(key string) any {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	data, err := c.c.Get(ctx, c.prefix+key).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			c.logger.Warn().Err(err).Msg("Failed to retrieve value from redis cache")
+		}
+
+		return nil
+	}
+
+	value, err := codec.Unmarshal(data)
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to decode value from redis cache")
+
+		return nil
+	}
+
+	return value
+}
+
+func (c *Cache) Set(key string, value any, ttl time.Duration) {
+	data, err := codec.Marshal(value)
+	if err != nil {
+		c.logger.Debug().Err(err).Msg("Value cannot be stored in redis cache")
+
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	// a ttl of 0 means, the value does not expire. Negative values would keep the ttl of an existing entry
+	if err = c.c.Set(ctx, c.prefix+key, data, x.IfThenElse(ttl > 0, ttl, 0)).Err(); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to store value in redis cache")
+	}
+}
+
+func (c *Cache) Delete(key string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	// the keys are deleted one by one, as these may reside on different nodes of a cluster
+	pipe := c.c.Pipeline()
+	pipe.Del(ctx, c.prefix+key)
+	pipe.Del(ctx, c.prefix+keyTagsKeyPrefix+key)
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to delete value from redis cache")
+	}
+}
+
+// Index associates the entry stored under the given key with the given tags. Each tag is represented
+// by a set holding the keys of the associated entries. In addition, the tags of each entry are kept in
+// a set living as long as the entry, so that these can be taken over when the entry is copied.
+func (c *Cache) Index(key string, ttl time.Duration, tags ...string) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	for _, tag := range tags {
+		if err := indexScript.Run(ctx, c.c, []string{c.prefix + tagKeyPrefix + tag},
+			key, x.IfThenElse(ttl > 0, ttl.Milliseconds(), 0)).Err(); err != nil {
+			c.logger.Warn().Err(err).Str("_tag", tag).Msg("Failed to index value in redis cache")
+		}
+	}
+
+	if len(tags) == 0 {
+		return
+	}
+
+	keyTagsKey := c.prefix + keyTagsKeyPrefix + key
+	members := make([]any, len(tags))
+
+	for idx, tag := range tags {
+		members[idx] = tag
+	}
+
+	pipe := c.c.TxPipeline()
+	pipe.SAdd(ctx, keyTagsKey, members...)
+
+	if ttl > 0 {
+		pipe.PExpire(ctx, keyTagsKey, ttl)
+	} else {
+		pipe.Persist(ctx, keyTagsKey)
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to store tags of value in redis cache")
+	}
+}
+
+// Tags returns the tags the entry stored under the given key is associated with.
+func (c *Cache) Tags(key string) []string {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	tags, err := c.c.SMembers(ctx, c.prefix+keyTagsKeyPrefix+key).Result()
+	if err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to retrieve tags of value from redis cache")
+
+		return nil
+	}
+
+	return tags
+}
+
+// Invalidate deletes all entries associated with the given tag and returns their number. The keys
+// are deleted one by one, as these may reside on different nodes of a cluster.
+func (c *Cache) Invalidate(tag string) int {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	tagKey := c.prefix + tagKeyPrefix + tag
+
+	keys, err := c.c.SMembers(ctx, tagKey).Result()
+	if err != nil {
+		c.logger.Warn().Err(err).Str("_tag", tag).Msg("Failed to retrieve indexed keys from redis cache")
+
+		return 0
+	}
+
+	pipe := c.c.Pipeline()
+	cmds := make([]*redis.IntCmd, len(keys))
+
+	for idx, key := range keys {
+		cmds[idx] = pipe.Del(ctx, c.prefix+key)
+		pipe.Del(ctx, c.prefix+keyTagsKeyPrefix+key)
+	}
+
+	pipe.Del(ctx, tagKey)
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		c.logger.Warn().Err(err).Str("_tag", tag).Msg("Failed to invalidate values in redis cache")
+	}
+
+	var count int
+
+	for _, cmd := range cmds {
+		count += int(cmd.Val())
+	}
+
+	return count
+}
+
+// Start verifies the connection to the server. Failures are only logged, as heimdall can operate
+// without a cache.
+func (c *Cache) Start() {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	if err := c.c.Ping(ctx).Err(); err != nil {
+		c.logger.Warn().Err(err).Msg("Redis cache is not reachable")
+	}
+}
+
+// Stop closes the connections to the server.
+func (c *Cache) Stop() {
+	if err := c.c.Close(); err != nil {
+		c.logger.Warn().Err(err).Msg("Failed to close redis connections")
+	}
+}
@@ -0,0 +1,293 @@
+package redis
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   config.RedisCacheConfig
+		assert func(t *testing.T, err error, cch *Cache)
+	}{
+		{
+			uc: "without addresses",
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no redis addresses")
+			},
+		},
+		{
+			uc: "with not existing trust store",
+			conf: config.RedisCacheConfig{
+				Addrs: []string{"127.0.0.1:6379"},
+				TLS:   &config.RedisTLSConfig{TrustStore: "/does/not/exist.pem"},
+			},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "trust store")
+			},
+		},
+		{
+			uc: "with not existing client key",
+			conf: config.RedisCacheConfig{
+				Addrs: []string{"127.0.0.1:6379"},
+				TLS:   &config.RedisTLSConfig{Key: "/does/not/exist.pem", Cert: "/does/not/exist.pem"},
+			},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "client key")
+			},
+		},
+		{
+			uc:   "with defaults",
+			conf: config.RedisCacheConfig{Addrs: []string{"127.0.0.1:6379"}},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, defaultKeyPrefix, cch.prefix)
+				assert.Equal(t, defaultOperationTimeout, cch.timeout)
+			},
+		},
+		{
+			uc: "with configured prefix and timeouts",
+			conf: config.RedisCacheConfig{
+				Addrs:        []string{"127.0.0.1:6379"},
+				KeyPrefix:    "foo:",
+				ReadTimeout:  2 * time.Second,
+				WriteTimeout: 5 * time.Second,
+			},
+			assert: func(t *testing.T, err error, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo:", cch.prefix)
+				assert.Equal(t, 5*time.Second, cch.timeout)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			cch, err := New(tc.conf, log.Logger)
+
+			// THEN
+			tc.assert(t, err, cch)
+		})
+	}
+}
+
+func TestCacheUsage(t *testing.T) {
+	t.Parallel()
+
+	srv := miniredis.RunT(t)
+
+	cch, err := New(config.RedisCacheConfig{Addrs: []string{srv.Addr()}, KeyPrefix: "test:"}, log.Logger)
+	require.NoError(t, err)
+
+	cch.Start()
+	defer cch.Stop()
+
+	for _, tc := range []struct {
+		uc             string
+		key            string
+		configureCache func(t *testing.T, cch *Cache)
+		assert         func(t *testing.T, data any)
+	}{
+		{
+			uc:  "can retrieve not expired value",
+			key: "foo",
+			configureCache: func(t *testing.T, cch *Cache) {
+				t.Helper()
+
+				cch.Set("foo", "bar", 10*time.Minute)
+
+				assert.True(t, srv.Exists("test:foo"))
+				assert.Equal(t, 10*time.Minute, srv.TTL("test:foo"))
+			},
+			assert: func(t *testing.T, data any) {
+				t.Helper()
+
+				assert.Equal(t, "bar", data)
+			},
+		},
+		{
+			uc:  "cannot retrieve expired value",
+			key: "bar",
+			configureCache: func(t *testing.T, cch *Cache) {
+				t.Helper()
+
+				cch.Set("bar", []byte("baz"), 1*time.Second)
+
+				srv.FastForward(2 * time.Second)
+			},
+			assert: func(t *testing.T, data any) {
+				t.Helper()
+
+				assert.Nil(t, data)
+			},
+		},
+		{
+			uc:  "cannot retrieve deleted value",
+			key: "baz",
+			configureCache: func(t *testing.T, cch *Cache) {
+				t.Helper()
+
+				cch.Set("baz", true, 1*time.Minute)
+				cch.Delete("baz")
+			},
+			assert: func(t *testing.T, data any) {
+				t.Helper()
+
+				assert.Nil(t, data)
+			},
+		},
+		{
+			uc:  "value of unsupported type is not stored",
+			key: "qux",
+			configureCache: func(t *testing.T, cch *Cache) {
+				t.Helper()
+
+				cch.Set("qux", 42, 1*time.Minute)
+
+				assert.False(t, srv.Exists("test:qux"))
+			},
+			assert: func(t *testing.T, data any) {
+				t.Helper()
+
+				assert.Nil(t, data)
+			},
+		},
+		{
+			uc:  "malformed value is ignored",
+			key: "zab",
+			configureCache: func(t *testing.T, cch *Cache) {
+				t.Helper()
+
+				require.NoError(t, srv.Set("test:zab", "foo"))
+			},
+			assert: func(t *testing.T, data any) {
+				t.Helper()
+
+				assert.Nil(t, data)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			tc.configureCache(t, cch)
+
+			// WHEN
+			data := cch.Get(tc.key)
+
+			// THEN
+			tc.assert(t, data)
+		})
+	}
+}
+
+func TestCacheWithUnreachableServer(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := New(config.RedisCacheConfig{
+		Addrs:       []string{srv.Addr()},
+		DialTimeout: 100 * time.Millisecond,
+	}, log.Logger)
+	require.NoError(t, err)
+
+	defer cch.Stop()
+
+	srv.Close()
+
+	// WHEN
+	cch.Start()
+	cch.Set("foo", "bar", 1*time.Minute)
+	data := cch.Get("foo")
+
+	// THEN
+	assert.Nil(t, data)
+}
+
+func TestCacheIndex(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := miniredis.RunT(t)
+
+	cch, err := New(config.RedisCacheConfig{Addrs: []string{srv.Addr()}}, log.Logger)
+	require.NoError(t, err)
+
+	defer cch.Stop()
+
+	cch.Set("foo", "1", 1*time.Minute)
+	cch.Set("bar", "2", 10*time.Minute)
+	cch.Set("baz", "3", 0)
+	cch.Set("zab", "4", 1*time.Minute)
+
+	// WHEN
+	cch.Index("foo", 1*time.Minute, "subject:a")
+	cch.Index("bar", 10*time.Minute, "subject:a")
+	cch.Index("zab", 1*time.Minute, "subject:b", "handler:x")
+
+	// THEN
+	tagKey := defaultKeyPrefix + tagKeyPrefix + "subject:a"
+	members, err := srv.SMembers(tagKey)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"foo", "bar"}, members)
+	// the index lives as long as the longest living entry
+	assert.Equal(t, 10*time.Minute, srv.TTL(tagKey))
+
+	// the tags of an entry live as long as the entry
+	assert.ElementsMatch(t, []string{"subject:b", "handler:x"}, cch.Tags("zab"))
+	assert.Equal(t, 1*time.Minute, srv.TTL(defaultKeyPrefix+keyTagsKeyPrefix+"zab"))
+	assert.Empty(t, cch.Tags("unknown"))
+
+	// WHEN
+	cch.Index("baz", 0, "subject:a")
+
+	// THEN
+	// entries, which do not expire are referenced by an index, which does not expire
+	assert.Equal(t, time.Duration(0), srv.TTL(tagKey))
+
+	// WHEN
+	count := cch.Invalidate("subject:a")
+
+	// THEN
+	assert.Equal(t, 3, count)
+	assert.Nil(t, cch.Get("foo"))
+	assert.Nil(t, cch.Get("bar"))
+	assert.Nil(t, cch.Get("baz"))
+	assert.Equal(t, "4", cch.Get("zab"))
+	assert.False(t, srv.Exists(tagKey))
+	assert.False(t, srv.Exists(defaultKeyPrefix+keyTagsKeyPrefix+"foo"))
+	assert.Equal(t, 0, cch.Invalidate("subject:c"))
+
+	// WHEN
+	cch.Delete("zab")
+
+	// THEN
+	assert.False(t, srv.Exists(defaultKeyPrefix+keyTagsKeyPrefix+"zab"))
+}
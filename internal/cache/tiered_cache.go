@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+const defaultL1TTL = 10 * time.Second
+
+// tieredCache uses a local cache (l1) in front of a shared one (l2). Entries are kept in l1 for
+// at most l1TTL, which bounds the time changes to l2 done by other heimdall instances remain unnoticed.
+type tieredCache struct {
+	l1    Cache
+	l2    Cache
+	l1TTL time.Duration
+}
+
+func (c *tieredCache) Get(key string) any { return c.GetNamespaced("", key) }
+
+// GetNamespaced gets the value like Get. A value taken from l2 is stored in l1 for the given namespace.
+// The tags of the entry are taken over, so that Invalidate removes it from l1 as well.
+func (c *tieredCache) GetNamespaced(namespace, key string) any {
+	if value := c.l1.Get(key); value != nil {
+		return value
+	}
+
+	value := c.l2.Get(key)
+	if value != nil {
+		// the remaining ttl of the entry in l2 is not known
+		c.setLocal(namespace, key, value, c.l1TTL)
+
+		if lookup, ok := c.l2.(tagLookup); ok {
+			Index(c.l1, key, c.l1TTL, lookup.Tags(key)...)
+		}
+	}
+
+	return value
+}
+
+func (c *tieredCache) Set(key string, value any, ttl time.Duration) {
+	c.l2.Set(key, value, ttl)
+	c.l1.Set(key, value, c.localTTL(ttl))
+}
+
+func (c *tieredCache) SetNamespaced(namespace, key string, value any, ttl time.Duration) {
+	c.l2.Set(key, value, ttl)
+	c.setLocal(namespace, key, value, c.localTTL(ttl))
+}
+
+func (c *tieredCache) Delete(key string) {
+	c.l2.Delete(key)
+	c.l1.Delete(key)
+}
+
+func (c *tieredCache) Index(key string, ttl time.Duration, tags ...string) {
+	Index(c.l2, key, ttl, tags...)
+	Index(c.l1, key, c.localTTL(ttl), tags...)
+}
+
+// Invalidate deletes the entries from both tiers. The local caches of other heimdall instances
+// are not affected. There the entries expire after l1TTL.
+func (c *tieredCache) Invalidate(tag string) int {
+	count := Invalidate(c.l2, tag)
+	local := Invalidate(c.l1, tag)
+
+	return x.IfThenElse(count > local, count, local)
+}
+
+// Start starts both tiers. Like the Start method of all Evictor implementations, it may block.
+func (c *tieredCache) Start() {
+	if evictor, ok := c.l2.(Evictor); ok {
+		go evictor.Start()
+	}
+
+	if evictor, ok := c.l1.(Evictor); ok {
+		evictor.Start()
+	}
+}
+
+func (c *tieredCache) Stop() {
+	if evictor, ok := c.l1.(Evictor); ok {
+		evictor.Stop()
+	}
+
+	if evictor, ok := c.l2.(Evictor); ok {
+		evictor.Stop()
+	}
+}
+
+func (c *tieredCache) setLocal(namespace, key string, value any, ttl time.Duration) {
+	if setter, ok := c.l1.(namespacedSetter); ok && len(namespace) != 0 {
+		setter.SetNamespaced(namespace, key, value, ttl)
+	} else {
+		c.l1.Set(key, value, ttl)
+	}
+}
+
+func (c *tieredCache) localTTL(ttl time.Duration) time.Duration {
+	return x.IfThenElse(ttl > 0 && ttl < c.l1TTL, ttl, c.l1TTL)
+}
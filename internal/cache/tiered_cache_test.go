@@ -0,0 +1,161 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/metrics"
+)
+
+func TestTieredCache(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		configureCache func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache)
+		assert         func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache)
+	}{
+		{
+			uc: "set stores the value in both tiers",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set("foo", "bar", 10*time.Minute)
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "bar", l1.Get("foo"))
+				assert.Equal(t, "bar", l2.Get("foo"))
+				assert.Equal(t, "bar", cch.Get("foo"))
+			},
+		},
+		{
+			uc: "value from l2 is stored in l1",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				l2.Set("foo", "bar", 10*time.Minute)
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, l1.Get("foo"))
+				assert.Equal(t, "bar", cch.Get("foo"))
+				assert.Equal(t, "bar", l1.Get("foo"))
+			},
+		},
+		{
+			uc: "value from l2 is stored in l1 for the namespace it is read for",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				l2.Set("foo", "bar", 10*time.Minute)
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "bar", Namespaced(cch, "tiered_promotion_test").Get("foo"))
+				assert.Equal(t, "bar", l1.Get("foo"))
+				assert.Equal(t, float64(1),
+					testutil.ToFloat64(metrics.Entries.WithLabelValues("tiered_promotion_test")))
+			},
+		},
+		{
+			uc: "value from l2 is stored in l1 together with its tags",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				l2.Set("foo", "bar", 10*time.Minute)
+				l2.Index("foo", 10*time.Minute, SubjectTag("alice"), HandlerTag("auth"))
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Equal(t, "bar", Namespaced(cch, "tiered_test").Get("foo"))
+				assert.ElementsMatch(t, []string{SubjectTag("alice"), HandlerTag("auth")}, l1.Tags("foo"))
+
+				assert.Equal(t, 1, Invalidate(cch, SubjectTag("alice")))
+				assert.Nil(t, l1.Get("foo"))
+				assert.Nil(t, l2.Get("foo"))
+				assert.Nil(t, cch.Get("foo"))
+			},
+		},
+		{
+			uc: "value is kept in l1 for at most the configured ttl",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				cch.l1TTL = 1 * time.Millisecond
+				cch.SetNamespaced("tiered_test", "foo", "bar", 10*time.Minute)
+
+				time.Sleep(10 * time.Millisecond)
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, l1.Get("foo"))
+				assert.Equal(t, "bar", l2.Get("foo"))
+			},
+		},
+		{
+			uc: "delete removes the value from both tiers",
+			configureCache: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				cch.Set("foo", "bar", 10*time.Minute)
+				cch.Delete("foo")
+			},
+			assert: func(t *testing.T, l1, l2 *memory.InMemoryCache, cch *tieredCache) {
+				t.Helper()
+
+				assert.Nil(t, l1.Get("foo"))
+				assert.Nil(t, l2.Get("foo"))
+				assert.Nil(t, cch.Get("foo"))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			l1 := memory.New()
+			l2 := memory.New()
+			cch := &tieredCache{l1: l1, l2: l2, l1TTL: defaultL1TTL}
+
+			// WHEN
+			tc.configureCache(t, l1, l2, cch)
+
+			// THEN
+			tc.assert(t, l1, l2, cch)
+		})
+	}
+}
+
+func TestTieredCacheStartStop(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	l1 := memory.New()
+	l2 := memory.New()
+	cch := &tieredCache{l1: l1, l2: l2, l1TTL: defaultL1TTL}
+	done := make(chan struct{})
+
+	go func() {
+		cch.Start()
+		close(done)
+	}()
+
+	// WHEN
+	cch.Stop()
+
+	// THEN
+	select {
+	case <-done:
+	case <-time.After(1 * time.Second):
+		require.Fail(t, "cache has not been stopped")
+	}
+}
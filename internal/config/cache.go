@@ -1,5 +1,65 @@
 package config
 
+import "time"
+
 type CacheConfig struct {
-	Type string `koanf:"type"`
+	Type   string             `koanf:"type"`
+	Memory *MemoryCacheConfig `koanf:"memory,omitempty"`
+	Redis  *RedisCacheConfig  `koanf:"redis,omitempty"`
+	// L1 enables a local in memory cache in front of the shared cache, if set. Used with redis only.
+	L1 *L1CacheConfig `koanf:"l1,omitempty"`
+	// Invalidation controls the availability of the cache invalidation endpoint of the management service.
+	Invalidation CacheInvalidationConfig `koanf:"invalidation"`
+}
+
+// CacheInvalidationConfig enables the DELETE /cache endpoint of the management service. It is disabled
+// by default, as the management service does not authenticate its clients.
+type CacheInvalidationConfig struct {
+	Enabled bool `koanf:"enabled"`
+}
+
+// MemoryCacheConfig bounds the size of the in memory cache. Zero values mean unlimited.
+type MemoryCacheConfig struct {
+	MaxEntries     int    `koanf:"max_entries"`
+	MaxBytes       int    `koanf:"max_bytes"`
+	EvictionPolicy string `koanf:"eviction_policy"`
+}
+
+// L1CacheConfig configures the local in memory cache used in front of a shared cache. TTL limits the
+// time an entry is kept locally and by that the time a change in the shared cache, e.g. a deleted
+// entry, remains unnoticed by the given instance.
+type L1CacheConfig struct {
+	MaxEntries     int           `koanf:"max_entries"`
+	MaxBytes       int           `koanf:"max_bytes"`
+	EvictionPolicy string        `koanf:"eviction_policy"`
+	TTL            time.Duration `koanf:"ttl,string"`
+}
+
+// RedisCacheConfig configures the connection to a Redis compatible server, like Valkey, used as a cache
+// shared by all heimdall instances.
+type RedisCacheConfig struct {
+	// Addrs holds the addresses of the servers. If more than one address is configured, a cluster
+	// client is used, unless MasterName is set, in which case the addresses are these of the sentinels.
+	Addrs              []string        `koanf:"addrs"`
+	MasterName         string          `koanf:"master_name"`
+	Username           string          `koanf:"username"`
+	Password           string          `koanf:"password"`
+	DB                 int             `koanf:"db"`
+	KeyPrefix          string          `koanf:"key_prefix"`
+	PoolSize           int             `koanf:"pool_size"`
+	MinIdleConnections int             `koanf:"min_idle_connections"`
+	DialTimeout        time.Duration   `koanf:"dial_timeout,string"`
+	ReadTimeout        time.Duration   `koanf:"read_timeout,string"`
+	WriteTimeout       time.Duration   `koanf:"write_timeout,string"`
+	TLS                *RedisTLSConfig `koanf:"tls,omitempty"`
+}
+
+// RedisTLSConfig enables TLS for the connections to the Redis server.
+type RedisTLSConfig struct {
+	// TrustStore is the path to a PEM file with the trust anchors. Defaults to the system trust store.
+	TrustStore string `koanf:"trust_store"`
+	// Key and Cert are paths to PEM files with the key and the certificate used for client authentication.
+	Key        string        `koanf:"key"`
+	Cert       string        `koanf:"cert"`
+	MinVersion TLSMinVersion `koanf:"min_version"`
 }
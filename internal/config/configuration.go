@@ -18,6 +18,7 @@ type Configuration struct {
 	Cache    CacheConfig    `koanf:"cache"`
 	Pipeline PipelineConfig `koanf:"pipeline"`
 	Rules    RulesConfig    `koanf:"rules,omitempty"`
+	Login    *LoginConfig   `koanf:"login,omitempty"`
 }
 
 func NewConfiguration(envPrefix EnvVarPrefix, configFile ConfigurationPath) (Configuration, error) {
@@ -31,6 +32,7 @@ func NewConfiguration(envPrefix EnvVarPrefix, configFile ConfigurationPath) (Con
 		parser.WithDecodeHookFunc(logFormatDecodeHookFunc),
 		parser.WithDecodeHookFunc(decodeTLSCipherSuiteHookFunc),
 		parser.WithDecodeHookFunc(decodeTLSMinVersionHookFunc),
+		parser.WithDecodeHookFunc(decodeTLSClientAuthModeHookFunc),
 		parser.WithEnvPrefix(string(envPrefix)),
 		parser.WithDefaultConfigFilename("heimdall.yaml"),
 		parser.WithConfigFile(string(configFile)),
@@ -11,6 +11,8 @@ const (
 	defaultWriteTimeout = time.Second * 10
 	defaultIdleTimeout  = time.Second * 120
 
+	defaultKeyRotationOverlap = time.Minute * 10
+
 	defaultProxyServicePort      = 4455
 	defaultDecisionServicePort   = 4456
 	defaultManagementServicePort = 4457
@@ -60,7 +62,8 @@ var defaultConfig = Configuration{
 		},
 	},
 	Signer: SignerConfig{
-		Name: "heimdall",
+		Name:            "heimdall",
+		RotationOverlap: defaultKeyRotationOverlap,
 	},
 	Pipeline: PipelineConfig{
 		Authenticators: []PipelineObject{},
@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+type LoginSessionConfig struct {
+	Store        string        `koanf:"store"`
+	CookieName   string        `koanf:"cookie_name"`
+	CookieDomain string        `koanf:"cookie_domain"`
+	Secret       string        `koanf:"secret"`
+	MaxAge       time.Duration `koanf:"max_age,string"`
+}
+
+type LoginConfig struct {
+	Issuer                string             `koanf:"issuer"`
+	ClientID              string             `koanf:"client_id"`
+	ClientSecret          string             `koanf:"client_secret"`
+	Scopes                []string           `koanf:"scopes"`
+	RedirectURL           string             `koanf:"redirect_url"`
+	PostLogoutRedirectURL string             `koanf:"post_logout_redirect_url"`
+	LoginPath             string             `koanf:"login_path"`
+	LogoutPath            string             `koanf:"logout_path"`
+	BackChannelLogoutPath string             `koanf:"backchannel_logout_path"`
+	Session               LoginSessionConfig `koanf:"session"`
+}
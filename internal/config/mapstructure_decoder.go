@@ -109,3 +109,23 @@ func decodeTLSMinVersionHookFunc(from reflect.Type, to reflect.Type, data any) (
 		return data, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "TLS version %s is unsupported", data)
 	}
 }
+
+func decodeTLSClientAuthModeHookFunc(from reflect.Type, to reflect.Type, data any) (any, error) {
+	if from.Kind() != reflect.String || to != reflect.TypeOf(TLSClientAuthMode(0)) {
+		return data, nil
+	}
+
+	switch data {
+	case "request":
+		return TLSClientAuthMode(tls.RequestClientCert), nil
+	case "require":
+		return TLSClientAuthMode(tls.RequireAnyClientCert), nil
+	case "verify_if_given":
+		return TLSClientAuthMode(tls.VerifyClientCertIfGiven), nil
+	case "verify":
+		return TLSClientAuthMode(tls.RequireAndVerifyClientCert), nil
+	default:
+		return data, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"TLS client auth mode %s is unsupported", data)
+	}
+}
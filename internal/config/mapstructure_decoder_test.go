@@ -345,3 +345,88 @@ func TestDecodeTLSMinVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestDecodeTLSClientAuthMode(t *testing.T) {
+	t.Parallel()
+
+	type Type struct {
+		Mode TLSClientAuthMode `mapstructure:"mode"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, mode TLSClientAuthMode)
+	}{
+		{
+			uc:     "unsupported mode",
+			config: []byte(`mode: foo`),
+			assert: func(t *testing.T, err error, mode TLSClientAuthMode) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "unsupported")
+			},
+		},
+		{
+			uc:     "request mode",
+			config: []byte(`mode: request`),
+			assert: func(t *testing.T, err error, mode TLSClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, TLSClientAuthMode(tls.RequestClientCert), mode)
+			},
+		},
+		{
+			uc:     "require mode",
+			config: []byte(`mode: require`),
+			assert: func(t *testing.T, err error, mode TLSClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, TLSClientAuthMode(tls.RequireAnyClientCert), mode)
+			},
+		},
+		{
+			uc:     "verify_if_given mode",
+			config: []byte(`mode: verify_if_given`),
+			assert: func(t *testing.T, err error, mode TLSClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, TLSClientAuthMode(tls.VerifyClientCertIfGiven), mode)
+			},
+		},
+		{
+			uc:     "verify mode",
+			config: []byte(`mode: verify`),
+			assert: func(t *testing.T, err error, mode TLSClientAuthMode) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, TLSClientAuthMode(tls.RequireAndVerifyClientCert), mode)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			var typ Type
+
+			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				DecodeHook: decodeTLSClientAuthModeHookFunc,
+				Result:     &typ,
+			})
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			err = dec.Decode(conf)
+
+			// THEN
+			tc.assert(t, err, typ.Mode)
+		})
+	}
+}
@@ -3,22 +3,36 @@ package config
 type PipelineObjectType string
 
 const (
-	POTNoop                PipelineObjectType = "noop"
-	POTBasicAuth           PipelineObjectType = "basic_auth"
-	POTAnonymous           PipelineObjectType = "anonymous"
-	POTUnauthorized        PipelineObjectType = "unauthorized"
-	POTOAuth2Introspection PipelineObjectType = "oauth2_introspection"
-	POTJwt                 PipelineObjectType = "jwt"
-	POTAllow               PipelineObjectType = "allow"
-	POTDeny                PipelineObjectType = "deny"
-	POTLocal               PipelineObjectType = "local"
-	POTRemote              PipelineObjectType = "remote"
-	POTDefault             PipelineObjectType = "default"
-	POTGeneric             PipelineObjectType = "generic"
-	POTHeader              PipelineObjectType = "header"
-	POTCookie              PipelineObjectType = "cookie"
-	POTRedirect            PipelineObjectType = "redirect"
-	POTWWWAuthenticate     PipelineObjectType = "www_authenticate"
+	POTNoop                 PipelineObjectType = "noop"
+	POTBasicAuth            PipelineObjectType = "basic_auth"
+	POTAnonymous            PipelineObjectType = "anonymous"
+	POTUnauthorized         PipelineObjectType = "unauthorized"
+	POTOAuth2Introspection  PipelineObjectType = "oauth2_introspection"
+	POTJwt                  PipelineObjectType = "jwt"
+	POTX509                 PipelineObjectType = "x509"
+	POTSessionCookie        PipelineObjectType = "session_cookie"
+	POTOIDCSession          PipelineObjectType = "oidc_session"
+	POTAPIKey               PipelineObjectType = "api_key"
+	POTLDAP                 PipelineObjectType = "ldap"
+	POTAllow                PipelineObjectType = "allow"
+	POTDeny                 PipelineObjectType = "deny"
+	POTLocal                PipelineObjectType = "local"
+	POTRemote               PipelineObjectType = "remote"
+	POTOPA                  PipelineObjectType = "opa"
+	POTCEL                  PipelineObjectType = "cel"
+	POTReBAC                PipelineObjectType = "rebac"
+	POTAnyOf                PipelineObjectType = "any_of"
+	POTAllOf                PipelineObjectType = "all_of"
+	POTNot                  PipelineObjectType = "not"
+	POTDefault              PipelineObjectType = "default"
+	POTGeneric              PipelineObjectType = "generic"
+	POTFile                 PipelineObjectType = "file"
+	POTHeader               PipelineObjectType = "header"
+	POTCookie               PipelineObjectType = "cookie"
+	POTTokenExchange        PipelineObjectType = "token_exchange"
+	POTHTTPMessageSignature PipelineObjectType = "http_message_signature"
+	POTRedirect             PipelineObjectType = "redirect"
+	POTWWWAuthenticate      PipelineObjectType = "www_authenticate"
 )
 
 func (p PipelineObjectType) String() string { return string(p) }
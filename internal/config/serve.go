@@ -48,11 +48,25 @@ func (v TLSMinVersion) OrDefault() uint16 {
 	return uint16(v)
 }
 
+type TLSClientAuthMode tls.ClientAuthType
+
+type ClientAuth struct {
+	Mode       TLSClientAuthMode `koanf:"mode"`
+	TrustStore string            `koanf:"trust_store"`
+}
+
+func (c ClientAuth) RequiresVerification() bool {
+	mode := tls.ClientAuthType(c.Mode)
+
+	return mode == tls.VerifyClientCertIfGiven || mode == tls.RequireAndVerifyClientCert
+}
+
 type TLS struct {
 	Key          string          `koanf:"key"`
 	Cert         string          `koanf:"cert"`
 	CipherSuites TLSCipherSuites `koanf:"cipher_suites"`
 	MinVersion   TLSMinVersion   `koanf:"min_version"`
+	ClientAuth   *ClientAuth     `koanf:"client_auth,omitempty"`
 }
 
 type ServiceConfig struct {
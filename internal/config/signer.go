@@ -1,8 +1,30 @@
 package config
 
+import "time"
+
 type SignerConfig struct {
-	Name     string `koanf:"name"`
-	KeyStore string `koanf:"key_store"`
-	Password string `koanf:"password"`
-	KeyID    string `koanf:"key_id"`
+	Name            string              `koanf:"name"`
+	KeyStore        string              `koanf:"key_store"`
+	Password        string              `koanf:"password"`
+	KeyID           string              `koanf:"key_id"`
+	Algorithm       string              `koanf:"algorithm"`
+	Watch           bool                `koanf:"watch"`
+	RotationOverlap time.Duration       `koanf:"rotation_overlap,string"`
+	PKCS11          *PKCS11Config       `koanf:"pkcs11,omitempty"`
+	Remote          *RemoteSignerConfig `koanf:"remote,omitempty"`
+}
+
+// PKCS11Config configures the access to a token holding the signing keys via a PKCS#11 module.
+type PKCS11Config struct {
+	ModulePath  string `koanf:"module_path"`
+	TokenLabel  string `koanf:"token_label"`
+	TokenSerial string `koanf:"token_serial"`
+	Pin         string `koanf:"pin"`
+}
+
+// RemoteSignerConfig configures a remote signing service, which keeps the signing keys.
+type RemoteSignerConfig struct {
+	URL     string            `koanf:"url"`
+	Headers map[string]string `koanf:"headers"`
+	Timeout time.Duration     `koanf:"timeout,string"`
 }
@@ -0,0 +1,9 @@
+package endpoint
+
+import "github.com/dadrus/heimdall/internal/cache/codec"
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*tokenEndpointResponse]("endpoint.token_endpoint_response", codec.JSON[*tokenEndpointResponse]{})
+}
@@ -32,7 +32,7 @@ func (c *ClientCredentialsStrategy) Apply(ctx context.Context, req *http.Request
 
 	key := c.calculateCacheKey()
 
-	cch := cache.Ctx(ctx)
+	cch := cache.NamespacedCtx(ctx, cache.NamespaceEndpoint)
 	if item := cch.Get(key); item != nil {
 		logger.Debug().Msg("Reusing access token from cache")
 
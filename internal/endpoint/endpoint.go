@@ -11,6 +11,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"time"
 
 	"github.com/rs/zerolog"
@@ -177,10 +178,19 @@ func (e Endpoint) Hash() string {
 		hash.Write(giveUpAfterBytes)
 	}
 
+	// map iteration order is random, so the header names are sorted
+	// to get the same hash for the same configuration
+	headerNames := make([]string, 0, len(e.Headers))
+	for k := range e.Headers {
+		headerNames = append(headerNames, k)
+	}
+
+	sort.Strings(headerNames)
+
 	buf := bytes.NewBufferString("")
-	for k, v := range e.Headers {
+	for _, k := range headerNames {
 		buf.Write([]byte(k))
-		buf.Write([]byte(v))
+		buf.Write([]byte(e.Headers[k]))
 	}
 
 	hash.Write(buf.Bytes())
@@ -454,4 +454,10 @@ func TestEndpointHash(t *testing.T) {
 	assert.NotEqual(t, hash2, hash3)
 	assert.NotEqual(t, hash2, hash4)
 	assert.NotEqual(t, hash3, hash4)
+
+	// the hash does not depend on the iteration order of headers
+	e5 := Endpoint{URL: "foo.bar", Headers: map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, e5.Hash(), e5.Hash())
+	}
 }
@@ -17,8 +17,7 @@ func DecodeAuthenticationStrategyHookFunc() mapstructure.DecodeHookFunc {
 			return data, nil
 		}
 
-		dect := reflect.ValueOf(&as).Elem().Type()
-		if !dect.AssignableTo(to) {
+		if to != reflect.ValueOf(&as).Elem().Type() {
 			return data, nil
 		}
 
@@ -0,0 +1,15 @@
+package login
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/dadrus/heimdall/internal/login"
+)
+
+func New(rp *login.RelyingParty) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(login.WithContext(c.UserContext(), rp))
+
+		return c.Next()
+	}
+}
@@ -6,18 +6,21 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/dadrus/heimdall/internal/config"
+	loginmiddleware "github.com/dadrus/heimdall/internal/fiber/middleware/login"
 	fiberxforwarded "github.com/dadrus/heimdall/internal/fiber/middleware/xfmphu"
 	"github.com/dadrus/heimdall/internal/handler/requestcontext"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/login"
 	"github.com/dadrus/heimdall/internal/rules"
 	"github.com/dadrus/heimdall/internal/signer"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 type Handler struct {
-	r rules.Repository
-	s heimdall.JWTSigner
+	r  rules.Repository
+	s  heimdall.JWTSigner
+	rp *login.RelyingParty
 }
 
 type handlerParams struct {
@@ -26,6 +29,7 @@ type handlerParams struct {
 	App             *fiber.App `name:"decision"`
 	RulesRepository rules.Repository
 	KeyStore        keystore.KeyStore
+	RelyingParty    *login.RelyingParty
 	Config          config.Configuration
 	Logger          zerolog.Logger
 }
@@ -37,8 +41,9 @@ func newHandler(params handlerParams) (*Handler, error) {
 	}
 
 	handler := &Handler{
-		r: params.RulesRepository,
-		s: jwtSigner,
+		r:  params.RulesRepository,
+		s:  jwtSigner,
+		rp: params.RelyingParty,
 	}
 
 	router := params.App.Group("/")
@@ -51,6 +56,11 @@ func newHandler(params handlerParams) (*Handler, error) {
 func (h *Handler) registerRoutes(router fiber.Router, logger zerolog.Logger) {
 	logger.Debug().Msg("Registering decision service routes")
 
+	if h.rp != nil {
+		// to enable verification of sessions created by the login flow
+		router.Use(loginmiddleware.New(h.rp))
+	}
+
 	router.All("/*", fiberxforwarded.New(), h.decisions)
 }
 
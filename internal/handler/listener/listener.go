@@ -2,12 +2,14 @@ package listener
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"net"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
@@ -46,5 +48,38 @@ func newTLSListener(conf config.ServiceConfig, listener net.Listener) (net.Liste
 		cfg.CipherSuites = conf.TLS.CipherSuites.OrDefault()
 	}
 
+	if conf.TLS.ClientAuth != nil {
+		if err = configureClientAuth(cfg, conf.TLS.ClientAuth); err != nil {
+			return nil, err
+		}
+	}
+
 	return tls.NewListener(listener, cfg), nil
 }
+
+func configureClientAuth(cfg *tls.Config, conf *config.ClientAuth) error {
+	cfg.ClientAuth = tls.ClientAuthType(conf.Mode)
+
+	if len(conf.TrustStore) == 0 {
+		if conf.RequiresVerification() {
+			return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"verification of client certificates requires a trust store")
+		}
+
+		return nil
+	}
+
+	trustStore, err := truststore.NewTrustStoreFromPEMFile(conf.TrustStore)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed loading client auth trust store").
+			CausedBy(err)
+	}
+
+	cfg.ClientCAs = x509.NewCertPool()
+
+	for _, cert := range trustStore {
+		cfg.ClientCAs.AddCert(cert)
+	}
+
+	return nil
+}
@@ -137,6 +137,84 @@ func TestCreateNewListener(t *testing.T) {
 				assert.Contains(t, ln.Addr().String(), port)
 			},
 		},
+		{
+			uc:      "creation of listener with TLS client auth fails due to missing trust store",
+			network: "tcp",
+			serviceConf: config.ServiceConfig{
+				TLS: &config.TLS{
+					Key:        keyFile.Name(),
+					Cert:       certFile.Name(),
+					ClientAuth: &config.ClientAuth{Mode: config.TLSClientAuthMode(tls.RequireAndVerifyClientCert)},
+				},
+			},
+			assert: func(t *testing.T, err error, ln net.Listener, port string) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires a trust store")
+			},
+		},
+		{
+			uc:      "creation of listener with TLS client auth fails due to not existing trust store",
+			network: "tcp",
+			serviceConf: config.ServiceConfig{
+				TLS: &config.TLS{
+					Key:  keyFile.Name(),
+					Cert: certFile.Name(),
+					ClientAuth: &config.ClientAuth{
+						Mode:       config.TLSClientAuthMode(tls.RequireAndVerifyClientCert),
+						TrustStore: "/no/such/file",
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, ln net.Listener, port string) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "trust store")
+			},
+		},
+		{
+			uc:      "listener with TLS client auth without verification",
+			network: "tcp",
+			serviceConf: config.ServiceConfig{
+				TLS: &config.TLS{
+					Key:        keyFile.Name(),
+					Cert:       certFile.Name(),
+					ClientAuth: &config.ClientAuth{Mode: config.TLSClientAuthMode(tls.RequestClientCert)},
+				},
+			},
+			assert: func(t *testing.T, err error, ln net.Listener, port string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ln)
+				assert.Contains(t, ln.Addr().String(), port)
+			},
+		},
+		{
+			uc:      "listener with TLS client auth with verification",
+			network: "tcp",
+			serviceConf: config.ServiceConfig{
+				TLS: &config.TLS{
+					Key:  keyFile.Name(),
+					Cert: certFile.Name(),
+					ClientAuth: &config.ClientAuth{
+						Mode:       config.TLSClientAuthMode(tls.RequireAndVerifyClientCert),
+						TrustStore: certFile.Name(),
+					},
+				},
+			},
+			assert: func(t *testing.T, err error, ln net.Listener, port string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ln)
+				assert.Contains(t, ln.Addr().String(), port)
+			},
+		},
 	} {
 		t.Run(tc.uc, func(t *testing.T) {
 			// GIVEN
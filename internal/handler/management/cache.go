@@ -0,0 +1,53 @@
+package management
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// invalidateCache implements an endpoint deleting the cache entries related to the subjects, tokens,
+// handlers and login sessions given in the query.
+func invalidateCache(cch cache.Cache) fiber.Handler {
+	type response struct {
+		Invalidated int `json:"invalidated"`
+	}
+
+	return func(c *fiber.Ctx) error {
+		var tags []string
+
+		for param, tag := range map[string]func(string) string{
+			"subject_id": cache.SubjectTag,
+			"token_hash": cache.TokenHashTag,
+			"handler_id": cache.HandlerTag,
+			"session_id": cache.SessionTag,
+		} {
+			for _, value := range c.Context().QueryArgs().PeekMulti(param) {
+				if len(value) != 0 {
+					tags = append(tags, tag(string(value)))
+				}
+			}
+		}
+
+		if len(tags) == 0 {
+			return errorchain.NewWithMessage(heimdall.ErrArgument,
+				"at least one of subject_id, token_hash, handler_id or session_id is required")
+		}
+
+		var count int
+
+		for _, tag := range tags {
+			count += cache.Invalidate(cch, tag)
+		}
+
+		zerolog.Ctx(c.UserContext()).Info().
+			Strs("_tags", tags).
+			Int("_invalidated", count).
+			Msg("Cache entries invalidated")
+
+		return c.JSON(response{Invalidated: count})
+	}
+}
@@ -0,0 +1,159 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/keystore"
+)
+
+func TestInvalidateCacheRequest(t *testing.T) {
+	t.Parallel()
+
+	const rsa2048 = 2048
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, rsa2048)
+	require.NoError(t, err)
+
+	ks, err := keystore.NewKeyStoreFromKey(privateKey)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc       string
+		disabled bool
+		query    string
+		assert   func(t *testing.T, resp *http.Response, cch cache.Cache)
+	}{
+		{
+			uc:       "with invalidation disabled",
+			disabled: true,
+			query:    "?subject_id=alice",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				// the endpoint is not registered
+				assert.NotEqual(t, http.StatusOK, resp.StatusCode)
+				assert.Equal(t, 3, cch.(*memory.InMemoryCache).Len())
+			},
+		},
+		{
+			uc: "without any query parameters",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, 3, cch.(*memory.InMemoryCache).Len())
+			},
+		},
+		{
+			uc:    "with unknown query parameters only",
+			query: "?foo=bar",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+				assert.Equal(t, 3, cch.(*memory.InMemoryCache).Len())
+			},
+		},
+		{
+			uc:    "by subject",
+			query: "?subject_id=alice",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				rawResp, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, `{ "invalidated": 2 }`, string(rawResp))
+				assert.Nil(t, cch.Get("foo"))
+				assert.Nil(t, cch.Get("bar"))
+				assert.NotNil(t, cch.Get("baz"))
+			},
+		},
+		{
+			uc:    "by token hash and handler",
+			query: "?token_hash=" + cache.TokenTag("baz")[len("token:"):] + "&handler_id=bar",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				rawResp, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, `{ "invalidated": 2 }`, string(rawResp))
+				assert.NotNil(t, cch.Get("foo"))
+				assert.Nil(t, cch.Get("bar"))
+				assert.Nil(t, cch.Get("baz"))
+			},
+		},
+		{
+			uc:    "by session",
+			query: "?session_id=unknown",
+			assert: func(t *testing.T, resp *http.Response, cch cache.Cache) {
+				t.Helper()
+
+				require.Equal(t, http.StatusOK, resp.StatusCode)
+
+				rawResp, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+
+				assert.JSONEq(t, `{ "invalidated": 0 }`, string(rawResp))
+				assert.Equal(t, 3, cch.(*memory.InMemoryCache).Len())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			cch := memory.New()
+
+			cch.Set("foo", "1", 1*time.Minute)
+			cch.Set("bar", "2", 1*time.Minute)
+			cch.Set("baz", "3", 1*time.Minute)
+			cch.Index("foo", 1*time.Minute, cache.SubjectTag("alice"))
+			cch.Index("bar", 1*time.Minute, cache.SubjectTag("alice"), cache.HandlerTag("bar"))
+			cch.Index("baz", 1*time.Minute, cache.SubjectTag("bob"), cache.TokenTag("baz"))
+
+			conf := config.Configuration{
+				Serve: config.ServeConfig{Management: config.ServiceConfig{}},
+				Cache: config.CacheConfig{Invalidation: config.CacheInvalidationConfig{Enabled: !tc.disabled}},
+			}
+
+			app := newFiberApp(conf, log.Logger)
+			_, err := newHandler(handlerParams{
+				App:      app,
+				Config:   conf,
+				Logger:   log.Logger,
+				KeyStore: ks,
+				Cache:    cch,
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			resp, err := app.Test(
+				httptest.NewRequest(http.MethodDelete, "http://heimdall.test.local/cache"+tc.query, nil),
+				-1)
+
+			// THEN
+			require.NoError(t, err)
+
+			defer resp.Body.Close()
+
+			tc.assert(t, resp, cch)
+		})
+	}
+}
@@ -3,4 +3,5 @@ package management
 const (
 	EndpointHealth = "/.well-known/health"
 	EndpointJWKS   = "/.well-known/jwks"
+	EndpointCache  = "/cache"
 )
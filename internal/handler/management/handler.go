@@ -6,6 +6,8 @@ import (
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/keystore"
 )
 
@@ -15,21 +17,31 @@ type handlerParams struct {
 	fx.In
 
 	App      *fiber.App `name:"management"`
+	Config   config.Configuration
 	KeyStore keystore.KeyStore
+	Cache    cache.Cache `optional:"true"`
 	Logger   zerolog.Logger
 }
 
 func newHandler(params handlerParams) (*Handler, error) {
 	handler := &Handler{}
 
-	handler.registerRoutes(params.App.Group("/"), params.Logger, params.KeyStore)
+	handler.registerRoutes(params.App.Group("/"), params.Logger, params.Config, params.KeyStore, params.Cache)
 
 	return handler, nil
 }
 
-func (h *Handler) registerRoutes(router fiber.Router, logger zerolog.Logger, ks keystore.KeyStore) {
+func (h *Handler) registerRoutes(
+	router fiber.Router, logger zerolog.Logger, conf config.Configuration, ks keystore.KeyStore, cch cache.Cache,
+) {
 	logger.Debug().Msg("Registering Management service routes")
 
 	router.Get(EndpointHealth, health)
 	router.Get(EndpointJWKS, etag.New(), jwks(ks))
+
+	// the management service does not authenticate its clients. So the endpoint allowing anyone able to
+	// reach it to invalidate cache entries is only available if explicitly enabled.
+	if conf.Cache.Invalidation.Enabled {
+		router.Delete(EndpointCache, invalidateCache(cch))
+	}
 }
@@ -8,19 +8,22 @@ import (
 	"go.uber.org/fx"
 
 	"github.com/dadrus/heimdall/internal/config"
+	loginmiddleware "github.com/dadrus/heimdall/internal/fiber/middleware/login"
 	fiberxforwarded "github.com/dadrus/heimdall/internal/fiber/middleware/xfmphu"
 	"github.com/dadrus/heimdall/internal/handler/requestcontext"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/login"
 	"github.com/dadrus/heimdall/internal/rules"
 	"github.com/dadrus/heimdall/internal/signer"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 type Handler struct {
-	r rules.Repository
-	s heimdall.JWTSigner
-	t time.Duration
+	r  rules.Repository
+	s  heimdall.JWTSigner
+	t  time.Duration
+	rp *login.RelyingParty
 }
 
 type handlerParams struct {
@@ -29,6 +32,7 @@ type handlerParams struct {
 	App             *fiber.App `name:"proxy"`
 	RulesRepository rules.Repository
 	KeyStore        keystore.KeyStore
+	RelyingParty    *login.RelyingParty
 	Config          config.Configuration
 	Logger          zerolog.Logger
 }
@@ -40,9 +44,10 @@ func newHandler(params handlerParams) (*Handler, error) {
 	}
 
 	handler := &Handler{
-		r: params.RulesRepository,
-		s: jwtSigner,
-		t: params.Config.Serve.Proxy.Timeout.Read,
+		r:  params.RulesRepository,
+		s:  jwtSigner,
+		t:  params.Config.Serve.Proxy.Timeout.Read,
+		rp: params.RelyingParty,
 	}
 
 	router := params.App.Group("/")
@@ -55,6 +60,23 @@ func newHandler(params handlerParams) (*Handler, error) {
 func (h *Handler) registerRoutes(router fiber.Router, logger zerolog.Logger) {
 	logger.Debug().Msg("Registering Proxy service routes")
 
+	if h.rp != nil {
+		logger.Debug().Msg("Registering login routes")
+
+		router.Use(loginmiddleware.New(h.rp))
+		router.Get(h.rp.LoginPath(), h.login)
+		router.Get(h.rp.CallbackPath(), h.loginCallback)
+		router.Post(h.rp.LogoutPath(), h.logout)
+		router.All(h.rp.LogoutPath(), func(c *fiber.Ctx) error {
+			return errorchain.NewWithMessagef(heimdall.ErrMethodNotAllowed,
+				"%s method is not allowed for the logout endpoint", c.Method())
+		})
+
+		if len(h.rp.BackChannelLogoutPath()) != 0 {
+			router.Post(h.rp.BackChannelLogoutPath(), h.backChannelLogout)
+		}
+	}
+
 	router.All("/*", fiberxforwarded.New(), h.proxy)
 }
 
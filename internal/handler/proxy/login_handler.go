@@ -0,0 +1,153 @@
+package proxy
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/valyala/fasthttp"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/login"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+func (h *Handler) login(c *fiber.Ctx) error {
+	logger := zerolog.Ctx(c.UserContext())
+	logger.Debug().Msg("Login endpoint called")
+
+	returnTo := c.Query("return_to")
+	if !isAllowedReturnTo(returnTo, c.Hostname()) {
+		return errorchain.NewWithMessagef(heimdall.ErrArgument, "return_to '%s' is not allowed", returnTo)
+	}
+
+	authURL, state, err := h.rp.StartLogin(c.UserContext(), returnTo)
+	if err != nil {
+		return err
+	}
+
+	setCookie(c, h.rp.LoginStateCookie(), state)
+
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+func (h *Handler) loginCallback(c *fiber.Ctx) error {
+	logger := zerolog.Ctx(c.UserContext())
+	logger.Debug().Msg("Login callback endpoint called")
+
+	stateCookie := h.rp.LoginStateCookie()
+	state := c.Cookies(stateCookie.Name)
+
+	clearCookie(c, stateCookie)
+
+	if errorCode := c.Query("error"); len(errorCode) != 0 {
+		return errorchain.NewWithMessagef(heimdall.ErrAuthentication,
+			"login failed: %s %s", errorCode, c.Query("error_description"))
+	}
+
+	session, returnTo, err := h.rp.FinishLogin(c.UserContext(), state, c.Query("state"), c.Query("code"))
+	if err != nil {
+		return err
+	}
+
+	setCookie(c, h.rp.SessionCookie(), session)
+
+	return c.Redirect(returnTo, fiber.StatusFound)
+}
+
+func (h *Handler) logout(c *fiber.Ctx) error {
+	logger := zerolog.Ctx(c.UserContext())
+	logger.Debug().Msg("Logout endpoint called")
+
+	if !isSameOriginRequest(c) {
+		return errorchain.NewWithMessage(heimdall.ErrAuthorization, "cross-site logout requests are not allowed")
+	}
+
+	sessionCookie := h.rp.SessionCookie()
+	redirectTo := h.rp.Logout(c.UserContext(), c.Cookies(sessionCookie.Name))
+
+	clearCookie(c, sessionCookie)
+
+	return c.Redirect(redirectTo, fiber.StatusFound)
+}
+
+// backChannelLogout implements the back-channel logout endpoint, which is called by the provider
+// to terminate the sessions of a user.
+func (h *Handler) backChannelLogout(c *fiber.Ctx) error {
+	logger := zerolog.Ctx(c.UserContext())
+	logger.Debug().Msg("Back-channel logout endpoint called")
+
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	if err := h.rp.BackChannelLogout(c.UserContext(), c.FormValue("logout_token")); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusOK)
+}
+
+// isAllowedReturnTo prevents usage of the login endpoint as an open redirector. Only relative
+// references and URLs pointing to the host the login endpoint has been called for are allowed.
+func isAllowedReturnTo(returnTo, host string) bool {
+	if len(returnTo) == 0 {
+		return true
+	}
+
+	returnToURL, err := url.Parse(returnTo)
+	if err != nil {
+		return false
+	}
+
+	if !returnToURL.IsAbs() {
+		return len(returnToURL.Host) == 0 && strings.HasPrefix(returnTo, "/") &&
+			!strings.HasPrefix(returnTo, "//") && !strings.HasPrefix(returnTo, "/\\")
+	}
+
+	return (returnToURL.Scheme == "https" || returnToURL.Scheme == "http") && returnToURL.Host == host
+}
+
+// isSameOriginRequest prevents cross-site request forgery. Browsers send the Sec-Fetch-Site header, or
+// at least the Origin header with POST requests, so requests without both are rejected as well.
+func isSameOriginRequest(c *fiber.Ctx) bool {
+	if fetchSite := c.Get("Sec-Fetch-Site"); len(fetchSite) != 0 {
+		return fetchSite == "same-origin"
+	}
+
+	origin := c.Get(fiber.HeaderOrigin)
+	if len(origin) == 0 || origin == "null" {
+		return false
+	}
+
+	originURL, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+
+	return (originURL.Scheme == "https" || originURL.Scheme == "http") && originURL.Host == c.Hostname()
+}
+
+func setCookie(c *fiber.Ctx, settings login.CookieSettings, value string) {
+	c.Cookie(&fiber.Cookie{
+		Name:     settings.Name,
+		Value:    value,
+		Path:     settings.Path,
+		Domain:   settings.Domain,
+		MaxAge:   int(settings.MaxAge.Seconds()),
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func clearCookie(c *fiber.Ctx, settings login.CookieSettings) {
+	c.Cookie(&fiber.Cookie{
+		Name:     settings.Name,
+		Path:     settings.Path,
+		Domain:   settings.Domain,
+		Expires:  fasthttp.CookieExpireDelete,
+		Secure:   true,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
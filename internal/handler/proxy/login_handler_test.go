@@ -0,0 +1,348 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/cache/mocks"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/login"
+	mocks2 "github.com/dadrus/heimdall/internal/rules/mocks"
+)
+
+func TestIsAllowedReturnTo(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		returnTo string
+		allowed  bool
+	}{
+		{returnTo: "", allowed: true},
+		{returnTo: "/foo?bar=baz", allowed: true},
+		{returnTo: "https://heimdall.local/foo", allowed: true},
+		{returnTo: "http://heimdall.local/foo", allowed: true},
+		{returnTo: "foo", allowed: false},
+		{returnTo: "//evil.local/foo", allowed: false},
+		{returnTo: "/\\evil.local/foo", allowed: false},
+		{returnTo: "https://evil.local/foo", allowed: false},
+		{returnTo: "javascript://heimdall.local/%0aalert(1)", allowed: false},
+	} {
+		t.Run("case="+tc.returnTo, func(t *testing.T) {
+			assert.Equal(t, tc.allowed, isAllowedReturnTo(tc.returnTo, "heimdall.local"))
+		})
+	}
+}
+
+func TestHandleLoginEndpointRequests(t *testing.T) {
+	t.Parallel()
+
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ks, err := keystore.NewKeyStoreFromKey(privateKey)
+	require.NoError(t, err)
+
+	var idpURL string
+
+	idp := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{
+"issuer": "` + idpURL + `",
+"authorization_endpoint": "` + idpURL + `/authorize",
+"token_endpoint": "` + idpURL + `/token",
+"jwks_uri": "` + idpURL + `/jwks",
+"end_session_endpoint": "` + idpURL + `/logout"
+}`))
+		assert.NoError(t, err)
+	}))
+	defer idp.Close()
+
+	idpURL = idp.URL
+
+	rp, err := login.NewRelyingParty(config.LoginConfig{
+		Issuer:                idpURL,
+		ClientID:              "heimdall",
+		RedirectURL:           "https://heimdall.local/_heimdall/callback",
+		BackChannelLogoutPath: "/_heimdall/backchannel_logout",
+		Session: config.LoginSessionConfig{
+			Store:      "cache",
+			CookieName: "session",
+			Secret:     "0123456789abcdefghijklmnopqrstuvwxyz",
+		},
+	}, memory.New())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc             string
+		createRequest  func(t *testing.T) *http.Request
+		assertResponse func(t *testing.T, err error, response *http.Response)
+	}{
+		{
+			uc: "login with not allowed return_to",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet,
+					"https://heimdall.local/_heimdall/login?return_to="+url.QueryEscape("https://evil.local"), nil)
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+			},
+		},
+		{
+			uc: "login redirects to the provider",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet,
+					"https://heimdall.local/_heimdall/login?return_to="+url.QueryEscape("/foo"), nil)
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusFound, response.StatusCode)
+				assert.True(t, strings.HasPrefix(response.Header.Get("Location"), idpURL+"/authorize?"))
+
+				cookies := response.Cookies()
+				require.Len(t, cookies, 1)
+				assert.Equal(t, "session_login", cookies[0].Name)
+				assert.Equal(t, "/_heimdall/callback", cookies[0].Path)
+				assert.NotEmpty(t, cookies[0].Value)
+				assert.True(t, cookies[0].HttpOnly)
+				assert.True(t, cookies[0].Secure)
+			},
+		},
+		{
+			uc: "callback with error from the provider",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet,
+					"https://heimdall.local/_heimdall/callback?error=access_denied", nil)
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+			},
+		},
+		{
+			uc: "callback without login state",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				return httptest.NewRequest(http.MethodGet,
+					"https://heimdall.local/_heimdall/callback?code=foo&state=bar", nil)
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+			},
+		},
+		{
+			uc: "logout redirects to the provider and removes the session cookie",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/logout", nil)
+				req.Header.Set("Origin", "https://heimdall.local")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusFound, response.StatusCode)
+				assert.True(t, strings.HasPrefix(response.Header.Get("Location"), idpURL+"/logout?"))
+
+				cookies := response.Cookies()
+				require.Len(t, cookies, 1)
+				assert.Equal(t, "session", cookies[0].Name)
+				assert.Empty(t, cookies[0].Value)
+				assert.True(t, cookies[0].Expires.Before(time.Now()))
+			},
+		},
+		{
+			uc: "logout from the same origin according to fetch metadata",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/logout", nil)
+				req.Header.Set("Sec-Fetch-Site", "same-origin")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusFound, response.StatusCode)
+				assert.True(t, strings.HasPrefix(response.Header.Get("Location"), idpURL+"/logout?"))
+			},
+		},
+		{
+			uc: "logout using GET method",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodGet, "https://heimdall.local/_heimdall/logout", nil)
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusMethodNotAllowed, response.StatusCode)
+				assert.Empty(t, response.Cookies())
+			},
+		},
+		{
+			uc: "logout without origin information",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/logout", nil)
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, response.StatusCode)
+				assert.Empty(t, response.Cookies())
+			},
+		},
+		{
+			uc: "logout from another origin",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/logout", nil)
+				req.Header.Set("Origin", "https://evil.local")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, response.StatusCode)
+				assert.Empty(t, response.Cookies())
+			},
+		},
+		{
+			uc: "cross-site logout according to fetch metadata",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/logout", nil)
+				req.Header.Set("Sec-Fetch-Site", "cross-site")
+				req.Header.Set("Origin", "https://heimdall.local")
+				req.AddCookie(&http.Cookie{Name: "session", Value: "foo"})
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusForbidden, response.StatusCode)
+				assert.Empty(t, response.Cookies())
+			},
+		},
+		{
+			uc: "back-channel logout without logout token",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/backchannel_logout",
+					strings.NewReader(url.Values{"foo": []string{"bar"}}.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+				assert.Equal(t, "no-store", response.Header.Get("Cache-Control"))
+			},
+		},
+		{
+			uc: "back-channel logout with invalid logout token",
+			createRequest: func(t *testing.T) *http.Request {
+				t.Helper()
+
+				req := httptest.NewRequest(http.MethodPost, "https://heimdall.local/_heimdall/backchannel_logout",
+					strings.NewReader(url.Values{"logout_token": []string{"foo.bar.baz"}}.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+				return req
+			},
+			assertResponse: func(t *testing.T, err error, response *http.Response) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf := config.Configuration{}
+			repo := &mocks2.MockRepository{}
+
+			app := newFiberApp(conf, &mocks.MockCache{}, log.Logger)
+			defer app.Shutdown() // nolint: errcheck
+
+			_, err := newHandler(handlerParams{
+				App:             app,
+				RulesRepository: repo,
+				KeyStore:        ks,
+				RelyingParty:    rp,
+				Config:          conf,
+				Logger:          log.Logger,
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			resp, err := app.Test(tc.createRequest(t), -1)
+
+			// THEN
+			if err == nil {
+				defer resp.Body.Close()
+			}
+
+			tc.assertResponse(t, err, resp)
+			repo.AssertExpectations(t)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package requestcontext
 
 import (
 	"context"
+	"crypto/tls"
 	"net/http"
 	"net/url"
 	"time"
@@ -49,6 +50,10 @@ func (s *RequestContext) AddHeaderForUpstream(name, value string)  { s.upstreamH
 func (s *RequestContext) AddCookieForUpstream(name, value string)  { s.upstreamCookies[name] = value }
 func (s *RequestContext) Signer() heimdall.JWTSigner               { return s.jwtSigner }
 func (s *RequestContext) RequestURL() *url.URL                     { return s.reqURL }
+func (s *RequestContext) RequestTLSConnectionState() *tls.ConnectionState {
+	return s.c.Context().TLSConnectionState()
+}
+
 func (s *RequestContext) RequestClientIPs() []string {
 	ips := s.c.IPs()
 
@@ -2,6 +2,7 @@ package heimdall
 
 import (
 	"context"
+	"crypto/tls"
 	"net/url"
 )
 
@@ -15,6 +16,7 @@ type Context interface { // nolint: interfacebloat
 	RequestBody() []byte
 	RequestURL() *url.URL
 	RequestClientIPs() []string
+	RequestTLSConnectionState() *tls.ConnectionState
 
 	AddHeaderForUpstream(name, value string)
 	AddCookieForUpstream(name, value string)
@@ -2,7 +2,34 @@ package heimdall
 
 import "time"
 
+// JWTOptions control the creation of a JWT beyond its claims.
+type JWTOptions struct {
+	// Headers are added to the JOSE header. The alg and kid headers are always set by the signer.
+	Headers map[string]any
+	// IncludeCertChain adds the certificate chain of the signing key as x5c header.
+	IncludeCertChain bool
+	// OmitClaims lists the standard claims (iss, sub, iat, nbf, jti), the signer should not set.
+	OmitClaims []string
+	// OverrideClaims lets the given claims take precedence over the standard claims set by the signer.
+	// The exp claim is always set from the ttl.
+	OverrideClaims bool
+}
+
+// DataSignature is a signature over arbitrary data together with the information about the used key.
+type DataSignature struct {
+	// KeyID is the id of the key, the signature has been created with.
+	KeyID string
+	// Algorithm is the JOSE signature algorithm of the key.
+	Algorithm string
+	// Value holds the raw signature. ECDSA signatures are encoded as R || S.
+	Value []byte
+}
+
 type JWTSigner interface {
-	Sign(sub string, ttl time.Duration, claims map[string]any) (string, error)
+	Sign(sub string, ttl time.Duration, claims map[string]any, opts JWTOptions) (string, error)
+	// SignData signs the data returned by the given function with the key having the given id. If keyID
+	// is empty, the current signing key is used. The function receives the id and the JOSE algorithm of
+	// the used key, so that these can become part of the signed data.
+	SignData(keyID string, data func(keyID, algorithm string) []byte) (DataSignature, error)
 	Hash() string
 }
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"crypto/tls"
 	"net/url"
 
 	"github.com/stretchr/testify/mock"
@@ -57,3 +58,7 @@ func (m *MockContext) Signer() heimdall.JWTSigner {
 func (m *MockContext) RequestURL() *url.URL { return convertTo[*url.URL](m.Called().Get(0)) }
 
 func (m *MockContext) RequestClientIPs() []string { return convertTo[[]string](m.Called().Get(0)) }
+
+func (m *MockContext) RequestTLSConnectionState() *tls.ConnectionState {
+	return convertTo[*tls.ConnectionState](m.Called().Get(0))
+}
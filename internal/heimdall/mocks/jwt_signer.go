@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/mock"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 type MockJWTSigner struct {
@@ -12,8 +14,18 @@ type MockJWTSigner struct {
 
 func (m *MockJWTSigner) Hash() string { return m.Called().String(0) }
 
-func (m *MockJWTSigner) Sign(subjectID string, ttl time.Duration, claims map[string]any) (string, error) {
-	args := m.Called(subjectID, ttl, claims)
+func (m *MockJWTSigner) Sign(
+	subjectID string, ttl time.Duration, claims map[string]any, opts heimdall.JWTOptions,
+) (string, error) {
+	args := m.Called(subjectID, ttl, claims, opts)
 
 	return args.String(0), args.Error(1)
 }
+
+func (m *MockJWTSigner) SignData(
+	keyID string, data func(keyID, algorithm string) []byte,
+) (heimdall.DataSignature, error) {
+	args := m.Called(keyID, data)
+
+	return convertTo[heimdall.DataSignature](args.Get(0)), args.Error(1)
+}
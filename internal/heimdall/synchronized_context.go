@@ -0,0 +1,130 @@
+package heimdall
+
+import (
+	"context"
+	"crypto/tls"
+	"net/url"
+	"sync"
+)
+
+// synchronizedContext serializes the access to the wrapped Context. Implementations parse the
+// request data lazily and are therefore not safe for concurrent use.
+type synchronizedContext struct {
+	ctx Context
+	mut sync.Mutex
+}
+
+// NewSynchronizedContext returns a Context, which can be used by multiple goroutines concurrently,
+// like by pipeline handlers executed in parallel.
+func NewSynchronizedContext(ctx Context) Context {
+	if _, ok := ctx.(*synchronizedContext); ok {
+		return ctx
+	}
+
+	return &synchronizedContext{ctx: ctx}
+}
+
+func (c *synchronizedContext) RequestMethod() string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestMethod()
+}
+
+func (c *synchronizedContext) RequestHeaders() map[string]string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestHeaders()
+}
+
+func (c *synchronizedContext) RequestHeader(key string) string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestHeader(key)
+}
+
+func (c *synchronizedContext) RequestCookie(key string) string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestCookie(key)
+}
+
+func (c *synchronizedContext) RequestQueryParameter(key string) string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestQueryParameter(key)
+}
+
+func (c *synchronizedContext) RequestFormParameter(key string) string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestFormParameter(key)
+}
+
+func (c *synchronizedContext) RequestBody() []byte {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestBody()
+}
+
+func (c *synchronizedContext) RequestURL() *url.URL {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestURL()
+}
+
+func (c *synchronizedContext) RequestClientIPs() []string {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestClientIPs()
+}
+
+func (c *synchronizedContext) RequestTLSConnectionState() *tls.ConnectionState {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.RequestTLSConnectionState()
+}
+
+func (c *synchronizedContext) AddHeaderForUpstream(name, value string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.ctx.AddHeaderForUpstream(name, value)
+}
+
+func (c *synchronizedContext) AddCookieForUpstream(name, value string) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.ctx.AddCookieForUpstream(name, value)
+}
+
+func (c *synchronizedContext) AppContext() context.Context {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.AppContext()
+}
+
+func (c *synchronizedContext) SetPipelineError(err error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	c.ctx.SetPipelineError(err)
+}
+
+func (c *synchronizedContext) Signer() JWTSigner {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	return c.ctx.Signer()
+}
@@ -42,7 +42,7 @@ func (rt *RoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
 }
 
 func (rt *RoundTripper) cachedResponse(req *http.Request) (*http.Response, error) {
-	cch := cache.Ctx(req.Context())
+	cch := cache.NamespacedCtx(req.Context(), cache.NamespaceHTTPCache)
 
 	cachedValue := cch.Get(cacheKey(req))
 	if cachedValue == nil {
@@ -70,7 +70,7 @@ func (rt *RoundTripper) cacheResponse(req *http.Request, resp *http.Response) {
 		return
 	}
 
-	cch := cache.Ctx(req.Context())
+	cch := cache.NamespacedCtx(req.Context(), cache.NamespaceHTTPCache)
 	cch.Set(cacheKey(req), respDump, time.Until(expires))
 }
 
@@ -3,9 +3,11 @@ package keystore
 import (
 	"crypto"
 	"crypto/x509"
-	"fmt"
 
 	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
 const (
@@ -16,6 +18,8 @@ const (
 	ecdsa256 = 256
 	ecdsa384 = 384
 	ecdsa512 = 521
+
+	ed25519Size = 256
 )
 
 type Entry struct {
@@ -24,6 +28,8 @@ type Entry struct {
 	KeySize    int
 	PrivateKey crypto.Signer
 	CertChain  []*x509.Certificate
+
+	algorithm jose.SignatureAlgorithm
 }
 
 func (e *Entry) JWK() jose.JSONWebKey {
@@ -36,39 +42,88 @@ func (e *Entry) JWK() jose.JSONWebKey {
 	}
 }
 
+// JOSEAlgorithm returns the signature algorithm to be used with the key. Unless explicitly configured,
+// it is derived from the key type and size. An empty value is returned for unsupported keys.
 func (e *Entry) JOSEAlgorithm() jose.SignatureAlgorithm {
-	switch e.Alg {
+	if len(e.algorithm) != 0 {
+		return e.algorithm
+	}
+
+	alg, _ := defaultAlgorithm(e.Alg, e.KeySize)
+
+	return alg
+}
+
+// WithAlgorithm returns a copy of the entry, which uses the given signature algorithm. An error is
+// returned if the algorithm cannot be used with the key of the entry.
+func (e *Entry) WithAlgorithm(alg string) (*Entry, error) {
+	sigAlg := jose.SignatureAlgorithm(alg)
+	if err := checkAlgorithm(e.Alg, e.KeySize, sigAlg); err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"algorithm %s cannot be used with key %s", alg, e.KeyID).CausedBy(err)
+	}
+
+	entry := *e
+	entry.algorithm = sigAlg
+
+	return &entry, nil
+}
+
+func defaultAlgorithm(keyAlg string, keySize int) (jose.SignatureAlgorithm, error) {
+	switch keyAlg {
 	case AlgRSA:
-		return getRSAAlgorithm(e.KeySize)
+		return getRSAAlgorithm(keySize)
 	case AlgECDSA:
-		return getECDSAAlgorithm(e.KeySize)
+		return getECDSAAlgorithm(keySize)
+	case AlgEdDSA:
+		return jose.EdDSA, nil
 	default:
-		panic(fmt.Sprintf("Unsupported algorithm: %s", e.Alg))
+		return "", errorchain.NewWithMessagef(heimdall.ErrInternal, "unsupported key algorithm: %s", keyAlg)
 	}
 }
 
-func getECDSAAlgorithm(keySize int) jose.SignatureAlgorithm {
+func checkAlgorithm(keyAlg string, keySize int, alg jose.SignatureAlgorithm) error {
+	expected, err := defaultAlgorithm(keyAlg, keySize)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case alg == expected:
+		return nil
+	case keyAlg == AlgRSA:
+		switch alg { // nolint: exhaustive
+		case jose.RS256, jose.RS384, jose.RS512, jose.PS256, jose.PS384, jose.PS512:
+			return nil
+		}
+	}
+
+	return errorchain.NewWithMessagef(heimdall.ErrInternal,
+		"%s algorithm is not supported for %s %d key", alg, keyAlg, keySize)
+}
+
+func getECDSAAlgorithm(keySize int) (jose.SignatureAlgorithm, error) {
 	switch keySize {
 	case ecdsa256:
-		return jose.ES256
+		return jose.ES256, nil
 	case ecdsa384:
-		return jose.ES384
+		return jose.ES384, nil
 	case ecdsa512:
-		return jose.ES512
+		return jose.ES512, nil
 	default:
-		panic(fmt.Sprintf("unsupported ECDSA key size: %d", keySize))
+		return "", errorchain.NewWithMessagef(heimdall.ErrInternal, "unsupported ECDSA key size: %d", keySize)
 	}
 }
 
-func getRSAAlgorithm(keySize int) jose.SignatureAlgorithm {
+func getRSAAlgorithm(keySize int) (jose.SignatureAlgorithm, error) {
 	switch keySize {
 	case rsa2048:
-		return jose.PS256
+		return jose.PS256, nil
 	case rsa3072:
-		return jose.PS384
+		return jose.PS384, nil
 	case rsa4096:
-		return jose.PS512
+		return jose.PS512, nil
 	default:
-		panic(fmt.Sprintf("unsupported RSA key size: %d", keySize))
+		return "", errorchain.NewWithMessagef(heimdall.ErrInternal, "unsupported RSA key size: %d", keySize)
 	}
 }
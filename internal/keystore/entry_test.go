@@ -2,6 +2,7 @@ package keystore
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -10,6 +11,8 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
 )
 
 func TestEntryToJWK(t *testing.T) {
@@ -33,6 +36,9 @@ func TestEntryToJWK(t *testing.T) {
 	ecdsaPrivKey3, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
 	require.NoError(t, err)
 
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc     string
 		entry  *Entry
@@ -134,6 +140,28 @@ func TestEntryToJWK(t *testing.T) {
 				assert.Empty(t, jwk.CertificateThumbprintSHA256)
 			},
 		},
+		{
+			uc:    "ed25519 key",
+			entry: &Entry{KeyID: "oof", Alg: AlgEdDSA, PrivateKey: ed25519PrivKey, KeySize: ed25519Size},
+			assert: func(t *testing.T, entry *Entry, jwk jose.JSONWebKey) {
+				t.Helper()
+
+				assert.Equal(t, entry.KeyID, jwk.KeyID)
+				assert.Equal(t, entry.PrivateKey.Public(), jwk.Key)
+				assert.Equal(t, "sig", jwk.Use)
+				assert.Equal(t, string(jose.EdDSA), jwk.Algorithm)
+			},
+		},
+		{
+			uc:    "rsa key with unsupported size",
+			entry: &Entry{KeyID: "rab", Alg: AlgRSA, PrivateKey: rsaPrivKey1, KeySize: 1024},
+			assert: func(t *testing.T, entry *Entry, jwk jose.JSONWebKey) {
+				t.Helper()
+
+				assert.Equal(t, entry.KeyID, jwk.KeyID)
+				assert.Empty(t, jwk.Algorithm)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -146,3 +174,56 @@ func TestEntryToJWK(t *testing.T) {
 		})
 	}
 }
+
+func TestEntryWithAlgorithm(t *testing.T) {
+	t.Parallel()
+
+	rsaPrivKey, err := rsa.GenerateKey(rand.Reader, rsa2048)
+	require.NoError(t, err)
+
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	rsaEntry := &Entry{KeyID: "rsa", Alg: AlgRSA, PrivateKey: rsaPrivKey, KeySize: rsa2048}
+	ecdsaEntry := &Entry{KeyID: "ecdsa", Alg: AlgECDSA, PrivateKey: ecdsaPrivKey, KeySize: ecdsa384}
+	ed25519Entry := &Entry{KeyID: "ed25519", Alg: AlgEdDSA, PrivateKey: ed25519PrivKey, KeySize: ed25519Size}
+
+	for _, tc := range []struct {
+		uc        string
+		entry     *Entry
+		algorithm string
+		expErr    bool
+	}{
+		{uc: "RS256 for rsa key", entry: rsaEntry, algorithm: "RS256"},
+		{uc: "PS512 for rsa key", entry: rsaEntry, algorithm: "PS512"},
+		{uc: "ES256 for rsa key", entry: rsaEntry, algorithm: "ES256", expErr: true},
+		{uc: "ES384 for ecdsa P384 key", entry: ecdsaEntry, algorithm: "ES384"},
+		{uc: "ES256 for ecdsa P384 key", entry: ecdsaEntry, algorithm: "ES256", expErr: true},
+		{uc: "EdDSA for ed25519 key", entry: ed25519Entry, algorithm: "EdDSA"},
+		{uc: "RS256 for ed25519 key", entry: ed25519Entry, algorithm: "RS256", expErr: true},
+		{uc: "unknown algorithm", entry: rsaEntry, algorithm: "foo", expErr: true},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			entry, err := tc.entry.WithAlgorithm(tc.algorithm)
+
+			// THEN
+			if tc.expErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), tc.entry.KeyID)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotSame(t, tc.entry, entry)
+			assert.Equal(t, tc.entry.KeyID, entry.KeyID)
+			assert.Equal(t, jose.SignatureAlgorithm(tc.algorithm), entry.JOSEAlgorithm())
+			assert.Equal(t, tc.algorithm, entry.JWK().Algorithm)
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package keystore
+
+import (
+	"crypto"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// KeyRing is a KeyStore, which additionally keeps track of the key to be used for signing purposes.
+//
+// If created from a watched PEM file, the key store is reloaded on changes. A changed signing key
+// is published as the next key first and is used for signing only after the rotation overlap has
+// passed. This way verifiers can fetch it in advance. The replaced key is then still published as
+// the previous key for the same duration, so that objects signed with it can still be verified.
+type KeyRing interface {
+	KeyStore
+
+	// SigningKey returns the key to be used for signing purposes.
+	SigningKey() *Entry
+}
+
+type keyRing struct {
+	keyID     string
+	algorithm string
+	overlap   time.Duration
+	now       func() time.Time
+	logger    zerolog.Logger
+
+	mut           sync.Mutex
+	ks            KeyStore
+	current       *Entry
+	next          *Entry
+	nextFrom      time.Time
+	previous      *Entry
+	previousUntil time.Time
+
+	watcher *fsnotify.Watcher
+}
+
+// NewKeyRing creates a key ring with a fixed signing key. If keyID is empty, the first key from the
+// key store is used for signing. If algorithm is not empty, it overrides the signature algorithm of
+// the signing key.
+func NewKeyRing(ks KeyStore, keyID, algorithm string) (KeyRing, error) {
+	return newKeyRing(ks, keyID, algorithm, 0, zerolog.Nop())
+}
+
+// NewKeyRingFromPEMFile creates a key ring backed by the given PEM file, which is reloaded on changes.
+// If keyID is empty, the first key from the PEM file is used for signing. Otherwise, the key with the
+// given id must be present in each version of the PEM file. As its key material must not change either,
+// the signing key is not rotated in that case. Reloads only publish the other keys from the PEM file.
+// The returned key ring implements io.Closer, which stops watching the PEM file.
+func NewKeyRingFromPEMFile(
+	pemFilePath, password, keyID, algorithm string, overlap time.Duration, logger zerolog.Logger,
+) (KeyRing, error) {
+	ks, err := NewKeyStoreFromPEMFile(pemFilePath, password)
+	if err != nil {
+		return nil, err
+	}
+
+	ring, err := newKeyRing(ks, keyID, algorithm, overlap, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to instantiate file watcher").
+			CausedBy(err)
+	}
+
+	// the directory is watched, as editors and secret updates replace the file instead of writing to it
+	if err = watcher.Add(filepath.Dir(pemFilePath)); err != nil {
+		watcher.Close()
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to watch %s", pemFilePath).
+			CausedBy(err)
+	}
+
+	ring.watcher = watcher
+
+	go ring.watch(pemFilePath, password)
+
+	return ring, nil
+}
+
+func newKeyRing(
+	ks KeyStore, keyID, algorithm string, overlap time.Duration, logger zerolog.Logger,
+) (*keyRing, error) {
+	entry, err := selectSigningKey(ks, keyID, algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &keyRing{
+		keyID:     keyID,
+		algorithm: algorithm,
+		overlap:   overlap,
+		now:       time.Now,
+		logger:    logger,
+		ks:        ks,
+		current:   entry,
+	}, nil
+}
+
+func selectSigningKey(ks KeyStore, keyID, algorithm string) (*Entry, error) {
+	var entry *Entry
+
+	if len(keyID) != 0 {
+		var err error
+
+		if entry, err = ks.GetKey(keyID); err != nil {
+			return nil, err
+		}
+	} else {
+		entries := ks.Entries()
+		if len(entries) == 0 {
+			return nil, errorchain.NewWithMessage(ErrNoSuchKey, "key store does not contain any keys")
+		}
+
+		entry = entries[0]
+	}
+
+	if len(algorithm) == 0 {
+		return entry, nil
+	}
+
+	// the signing key takes precedence over the same key from the key store when published
+	return entry.WithAlgorithm(algorithm)
+}
+
+func (r *keyRing) SigningKey() *Entry {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.advance()
+
+	return r.current
+}
+
+// Entries returns the next, the current and the previous signing key followed by all other keys
+// from the key store.
+func (r *keyRing) Entries() []*Entry {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.advance()
+
+	return r.entries()
+}
+
+func (r *keyRing) GetKey(id string) (*Entry, error) {
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.advance()
+
+	for _, entry := range r.entries() {
+		if entry.KeyID == id {
+			return entry, nil
+		}
+	}
+
+	return nil, errorchain.NewWithMessagef(ErrNoSuchKey, "%s", id)
+}
+
+func (r *keyRing) entries() []*Entry {
+	storeEntries := r.ks.Entries()
+	entries := make([]*Entry, 0, len(storeEntries)+3)   // nolint: gomnd
+	known := make(map[string]bool, len(storeEntries)+3) // nolint: gomnd
+
+	for _, entry := range append([]*Entry{r.next, r.current, r.previous}, storeEntries...) {
+		if entry == nil || known[entry.KeyID] {
+			continue
+		}
+
+		known[entry.KeyID] = true
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// advance performs the time based transitions of a key rotation. Must be called with the lock held.
+func (r *keyRing) advance() {
+	now := r.now()
+
+	if r.next != nil && !now.Before(r.nextFrom) {
+		r.logger.Info().
+			Str("_key_id", r.next.KeyID).
+			Str("_previous_key_id", r.current.KeyID).
+			Msg("Switched signing key")
+
+		r.previous, r.previousUntil = r.current, r.nextFrom.Add(r.overlap)
+		r.current, r.next = r.next, nil
+	}
+
+	if r.previous != nil && !now.Before(r.previousUntil) {
+		r.previous = nil
+	}
+}
+
+func (r *keyRing) update(ks KeyStore) error {
+	entry, err := selectSigningKey(ks, r.keyID, r.algorithm)
+	if err != nil {
+		return err
+	}
+
+	r.mut.Lock()
+	defer r.mut.Unlock()
+
+	r.advance()
+
+	// verifiers identify keys by their id. Different key material published under the id of a key
+	// held by the ring would break the verification of objects signed with the former one.
+	for _, held := range []*Entry{r.next, r.current, r.previous} {
+		if held != nil && held.KeyID == entry.KeyID && !samePublicKey(held, entry) {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"key material of key %s changed without changing its key id", entry.KeyID)
+		}
+	}
+
+	r.ks = ks
+
+	switch {
+	case entry.KeyID == r.current.KeyID:
+		// a pending rotation is cancelled if the current key is configured again
+		r.current, r.next = entry, nil
+	case r.next != nil && entry.KeyID == r.next.KeyID:
+		r.next = entry
+	default:
+		r.next, r.nextFrom = entry, r.now().Add(r.overlap)
+
+		r.logger.Info().
+			Str("_key_id", entry.KeyID).
+			Time("_active_from", r.nextFrom).
+			Msg("Signing key rotation scheduled")
+	}
+
+	r.advance()
+
+	return nil
+}
+
+func samePublicKey(first, second *Entry) bool {
+	key, ok := first.PrivateKey.Public().(interface{ Equal(x crypto.PublicKey) bool })
+
+	return ok && key.Equal(second.PrivateKey.Public())
+}
+
+func (r *keyRing) Close() error {
+	if r.watcher == nil {
+		return nil
+	}
+
+	return r.watcher.Close()
+}
+
+func (r *keyRing) watch(pemFilePath, password string) {
+	for {
+		select {
+		case evt, ok := <-r.watcher.Events:
+			if !ok {
+				return
+			}
+
+			if evt.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) == 0 || !isKeyStoreChange(evt, pemFilePath) {
+				continue
+			}
+
+			ks, err := NewKeyStoreFromPEMFile(pemFilePath, password)
+			if err == nil {
+				err = r.update(ks)
+			}
+
+			if err != nil {
+				// the previously loaded keys stay active
+				r.logger.Error().Err(err).Msg("Failed to reload key store")
+
+				continue
+			}
+
+			r.logger.Info().Msg("Key store reloaded")
+		case err, ok := <-r.watcher.Errors:
+			if !ok {
+				return
+			}
+
+			r.logger.Warn().Err(err).Msg("Key store watcher error")
+		}
+	}
+}
+
+func isKeyStoreChange(evt fsnotify.Event, pemFilePath string) bool {
+	name := filepath.Clean(evt.Name)
+	if name == filepath.Clean(pemFilePath) {
+		return true
+	}
+
+	// kubernetes updates mounted secrets by atomically replacing the ..data symlink,
+	// the files in the mounted directory are symlinks to
+	return filepath.Base(name) == "..data" && filepath.Dir(name) == filepath.Dir(filepath.Clean(pemFilePath))
+}
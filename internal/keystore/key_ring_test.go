@@ -0,0 +1,381 @@
+package keystore
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func createPEMKeys(t *testing.T, keyIDs ...string) []byte {
+	t.Helper()
+
+	var buf []byte
+
+	for _, keyID := range keyIDs {
+		privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+
+		raw, err := x509.MarshalPKCS8PrivateKey(privKey)
+		require.NoError(t, err)
+
+		buf = append(buf, pem.EncodeToMemory(&pem.Block{
+			Type:    pemBlockTypePrivateKey,
+			Headers: map[string]string{"X-Key-ID": keyID},
+			Bytes:   raw,
+		})...)
+	}
+
+	return buf
+}
+
+func createKeyStoreWith(t *testing.T, keyIDs ...string) KeyStore {
+	t.Helper()
+
+	ks, err := NewKeyStoreFromPEMBytes(createPEMKeys(t, keyIDs...), "")
+	require.NoError(t, err)
+
+	return ks
+}
+
+func createKeyStoreFrom(t *testing.T, pemKeys ...[]byte) KeyStore {
+	t.Helper()
+
+	ks, err := NewKeyStoreFromPEMBytes(bytes.Join(pemKeys, nil), "")
+	require.NoError(t, err)
+
+	return ks
+}
+
+func keyIDs(entries []*Entry) []string {
+	ids := make([]string, len(entries))
+	for idx, entry := range entries {
+		ids[idx] = entry.KeyID
+	}
+
+	return ids
+}
+
+func TestNewKeyRing(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		ks        KeyStore
+		keyID     string
+		algorithm string
+		assert    func(t *testing.T, kr KeyRing, err error)
+	}{
+		{
+			uc: "without key id",
+			ks: createKeyStoreWith(t, "foo", "bar", "baz"),
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "foo", kr.SigningKey().KeyID)
+				assert.Equal(t, []string{"foo", "bar", "baz"}, keyIDs(kr.Entries()))
+			},
+		},
+		{
+			uc:    "with key id",
+			ks:    createKeyStoreWith(t, "foo", "bar", "baz"),
+			keyID: "bar",
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", kr.SigningKey().KeyID)
+				assert.Equal(t, []string{"bar", "foo", "baz"}, keyIDs(kr.Entries()))
+
+				entry, err := kr.GetKey("baz")
+				require.NoError(t, err)
+				assert.Equal(t, "baz", entry.KeyID)
+
+				_, err = kr.GetKey("zab")
+				require.ErrorIs(t, err, ErrNoSuchKey)
+			},
+		},
+		{
+			uc:    "with not existing key id",
+			ks:    createKeyStoreWith(t, "foo"),
+			keyID: "bar",
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrNoSuchKey)
+			},
+		},
+		{
+			uc:        "with algorithm",
+			ks:        createKeyStoreWith(t, "foo", "bar"),
+			keyID:     "bar",
+			algorithm: "ES256",
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", kr.SigningKey().KeyID)
+				assert.Equal(t, jose.ES256, kr.SigningKey().JOSEAlgorithm())
+
+				entry, err := kr.GetKey("bar")
+				require.NoError(t, err)
+				assert.Same(t, kr.SigningKey(), entry)
+			},
+		},
+		{
+			uc:        "with algorithm not matching the key",
+			ks:        createKeyStoreWith(t, "foo"),
+			algorithm: "RS256",
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "RS256")
+			},
+		},
+		{
+			uc: "without keys",
+			ks: &keyStore{},
+			assert: func(t *testing.T, kr KeyRing, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrNoSuchKey)
+				assert.Contains(t, err.Error(), "does not contain any keys")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			kr, err := NewKeyRing(tc.ks, tc.keyID, tc.algorithm)
+
+			// THEN
+			tc.assert(t, kr, err)
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	now := time.Now()
+	overlap := 10 * time.Minute
+	foo := createPEMKeys(t, "foo")
+	bar := createPEMKeys(t, "bar")
+
+	kr, err := newKeyRing(createKeyStoreFrom(t, foo), "", "", overlap, log.Logger)
+	require.NoError(t, err)
+
+	kr.now = func() time.Time { return now }
+
+	// WHEN a new key is added in front of the current one
+	require.NoError(t, kr.update(createKeyStoreFrom(t, bar, foo)))
+
+	// THEN it is published as next key, but not used for signing yet
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+
+	// WHEN the overlap has passed
+	now = now.Add(overlap)
+
+	// THEN the new key is used for signing and the old one is still published
+	assert.Equal(t, "bar", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+
+	// WHEN the old key is removed from the key store
+	require.NoError(t, kr.update(createKeyStoreFrom(t, bar)))
+
+	// THEN it is still published as previous key
+	assert.Equal(t, "bar", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+
+	_, err = kr.GetKey("foo")
+	require.NoError(t, err)
+
+	// WHEN the overlap has passed again
+	now = now.Add(overlap)
+
+	// THEN the previous key is not published anymore
+	assert.Equal(t, "bar", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"bar"}, keyIDs(kr.Entries()))
+
+	_, err = kr.GetKey("foo")
+	require.ErrorIs(t, err, ErrNoSuchKey)
+}
+
+func TestKeyRingRotationCancellation(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	now := time.Now()
+	foo := createPEMKeys(t, "foo")
+	bar := createPEMKeys(t, "bar")
+
+	kr, err := newKeyRing(createKeyStoreFrom(t, foo), "", "", time.Minute, log.Logger)
+	require.NoError(t, err)
+
+	kr.now = func() time.Time { return now }
+
+	require.NoError(t, kr.update(createKeyStoreFrom(t, bar, foo)))
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+
+	// WHEN
+	require.NoError(t, kr.update(createKeyStoreFrom(t, foo, bar)))
+	now = now.Add(time.Minute)
+
+	// THEN
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"foo", "bar"}, keyIDs(kr.Entries()))
+}
+
+func TestKeyRingUpdateWithMissingKeyID(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	kr, err := newKeyRing(createKeyStoreWith(t, "foo", "bar"), "foo", "", 0, log.Logger)
+	require.NoError(t, err)
+
+	// WHEN
+	err = kr.update(createKeyStoreWith(t, "bar", "baz"))
+
+	// THEN
+	require.ErrorIs(t, err, ErrNoSuchKey)
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"foo", "bar"}, keyIDs(kr.Entries()))
+}
+
+func TestKeyRingUpdateWithChangedKeyMaterial(t *testing.T) {
+	t.Parallel()
+
+	foo := createPEMKeys(t, "foo")
+	bar := createPEMKeys(t, "bar")
+
+	for _, tc := range []struct {
+		uc      string
+		stores  [][][]byte
+		changed [][]byte
+	}{
+		{
+			uc:      "current key changed",
+			changed: [][]byte{createPEMKeys(t, "foo"), bar},
+		},
+		{
+			uc:      "next key changed",
+			stores:  [][][]byte{{bar, foo}},
+			changed: [][]byte{createPEMKeys(t, "bar"), foo},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			kr, err := newKeyRing(createKeyStoreFrom(t, foo, bar), "", "", time.Minute, log.Logger)
+			require.NoError(t, err)
+
+			for _, pemKeys := range tc.stores {
+				require.NoError(t, kr.update(createKeyStoreFrom(t, pemKeys...)))
+			}
+
+			entries := kr.Entries()
+
+			// WHEN
+			err = kr.update(createKeyStoreFrom(t, tc.changed...))
+
+			// THEN
+			require.Error(t, err)
+			require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			assert.Contains(t, err.Error(), "changed without changing its key id")
+			assert.Equal(t, "foo", kr.SigningKey().KeyID)
+			assert.Equal(t, entries, kr.Entries())
+		})
+	}
+}
+
+func TestKeyRingFromWatchedPEMFile(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	pemFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(pemFile, createPEMKeys(t, "foo"), 0o600))
+
+	kr, err := NewKeyRingFromPEMFile(pemFile, "", "", "", 0, log.Logger)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+
+	// WHEN
+	require.NoError(t, os.WriteFile(pemFile, []byte("foo"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN the broken key store is ignored
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+
+	// WHEN
+	require.NoError(t, os.WriteFile(pemFile, createPEMKeys(t, "bar", "foo"), 0o600))
+
+	// THEN
+	assert.Eventually(t, func() bool { return kr.SigningKey().KeyID == "bar" },
+		2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+}
+
+func TestKeyRingFromWatchedPEMFileInKubernetesSecretMount(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN a directory structured the way kubernetes mounts secrets
+	dir := t.TempDir()
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..v1", "keys.pem"), createPEMKeys(t, "foo"), 0o600))
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "keys.pem"), filepath.Join(dir, "keys.pem")))
+
+	kr, err := NewKeyRingFromPEMFile(filepath.Join(dir, "keys.pem"), "", "", "", 0, log.Logger)
+	require.NoError(t, err)
+
+	defer kr.(io.Closer).Close()
+
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+
+	// WHEN the secret is updated by replacing the ..data symlink
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v2"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "..v2", "keys.pem"), createPEMKeys(t, "bar", "foo"), 0o600))
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	// THEN
+	assert.Eventually(t, func() bool { return kr.SigningKey().KeyID == "bar" },
+		2*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"bar", "foo"}, keyIDs(kr.Entries()))
+}
+
+func TestKeyRingFromWatchedPEMFileIsNotReloadedAfterClose(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	pemFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(pemFile, createPEMKeys(t, "foo"), 0o600))
+
+	kr, err := NewKeyRingFromPEMFile(pemFile, "", "", "", 0, log.Logger)
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, kr.(io.Closer).Close())
+	require.NoError(t, os.WriteFile(pemFile, createPEMKeys(t, "bar", "foo"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN
+	assert.Equal(t, "foo", kr.SigningKey().KeyID)
+	assert.Equal(t, []string{"foo"}, keyIDs(kr.Entries()))
+}
@@ -3,6 +3,7 @@ package keystore
 import (
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/hex"
@@ -11,7 +12,7 @@ import (
 	"os"
 
 	"github.com/youmark/pkcs8"
-	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
@@ -25,8 +26,12 @@ const (
 	pemBlockTypeRSAPrivateKey       = "RSA PRIVATE KEY"
 	pemBlockTypeCertificate         = "CERTIFICATE"
 
+	pemHeaderKeyID        = "X-Key-ID"
+	pemHeaderKeyAlgorithm = "X-Key-Algorithm"
+
 	AlgRSA   = "RSA"
 	AlgECDSA = "ECDSA"
+	AlgEdDSA = "EdDSA"
 )
 
 var ErrNoSuchKey = errors.New("no such key")
@@ -36,10 +41,14 @@ type KeyStore interface {
 	Entries() []*Entry
 }
 
-type keyStore map[string]*Entry
+// keyStore keeps the entries in the order these were defined in.
+type keyStore struct {
+	entries []*Entry
+	index   map[string]*Entry
+}
 
-func (ks keyStore) GetKey(id string) (*Entry, error) {
-	entry, ok := ks[id]
+func (ks *keyStore) GetKey(id string) (*Entry, error) {
+	entry, ok := ks.index[id]
 	if !ok {
 		return nil, errorchain.NewWithMessagef(ErrNoSuchKey, "%s", id)
 	}
@@ -47,8 +56,8 @@ func (ks keyStore) GetKey(id string) (*Entry, error) {
 	return entry, nil
 }
 
-func (ks keyStore) Entries() []*Entry {
-	return maps.Values(ks)
+func (ks *keyStore) Entries() []*Entry {
+	return slices.Clone(ks.entries)
 }
 
 func NewKeyStoreFromKey(privateKey crypto.Signer) (KeyStore, error) {
@@ -57,7 +66,12 @@ func NewKeyStoreFromKey(privateKey crypto.Signer) (KeyStore, error) {
 		return nil, err
 	}
 
-	return verifyAndBuildKeyStore([]*Entry{entry}, nil)
+	ks, err := verifyAndBuildKeyStore([]*Entry{entry}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return ks, nil
 }
 
 func NewKeyStoreFromPEMFile(pemFilePath, password string) (KeyStore, error) {
@@ -80,10 +94,15 @@ func NewKeyStoreFromPEMFile(pemFilePath, password string) (KeyStore, error) {
 }
 
 func NewKeyStoreFromPEMBytes(pemBytes []byte, password string) (KeyStore, error) {
-	return createKeyStore(readPEMContents(pemBytes), password)
+	ks, err := createKeyStore(readPEMContents(pemBytes), password)
+	if err != nil {
+		return nil, err
+	}
+
+	return ks, nil
 }
 
-func createKeyStore(blocks []*pem.Block, password string) (keyStore, error) {
+func createKeyStore(blocks []*pem.Block, password string) (*keyStore, error) {
 	var (
 		entries []*Entry
 		certs   []*x509.Certificate
@@ -124,11 +143,17 @@ func createKeyStore(blocks []*pem.Block, password string) (keyStore, error) {
 		if cert != nil {
 			certs = append(certs, cert)
 		} else {
-			entry, err := createEntry(key, block.Headers["X-Key-ID"])
+			entry, err := createEntry(key, block.Headers[pemHeaderKeyID])
 			if err != nil {
 				return nil, err
 			}
 
+			if alg := block.Headers[pemHeaderKeyAlgorithm]; len(alg) != 0 {
+				if entry, err = entry.WithAlgorithm(alg); err != nil {
+					return nil, err
+				}
+			}
+
 			entries = append(entries, entry)
 		}
 	}
@@ -136,8 +161,8 @@ func createKeyStore(blocks []*pem.Block, password string) (keyStore, error) {
 	return verifyAndBuildKeyStore(entries, certs)
 }
 
-func verifyAndBuildKeyStore(entries []*Entry, certs []*x509.Certificate) (keyStore, error) {
-	ks := make(keyStore)
+func verifyAndBuildKeyStore(entries []*Entry, certs []*x509.Certificate) (*keyStore, error) {
+	ks := &keyStore{index: make(map[string]*Entry, len(entries))}
 
 	for _, entry := range entries {
 		chain := FindChain(entry.PrivateKey.Public(), certs)
@@ -170,13 +195,14 @@ func verifyAndBuildKeyStore(entries []*Entry, certs []*x509.Certificate) (keySto
 			entry.KeyID = hex.EncodeToString(keyID)
 		}
 
-		if _, ok := ks[entry.KeyID]; ok {
+		if _, ok := ks.index[entry.KeyID]; ok {
 			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
 				"duplicate entry for key_id=%s found", entry.KeyID)
 		}
 
 		entry.CertChain = chain
-		ks[entry.KeyID] = entry
+		ks.index[entry.KeyID] = entry
+		ks.entries = append(ks.entries, entry)
 	}
 
 	return ks, nil
@@ -192,6 +218,11 @@ func readPEMContents(data []byte) []*pem.Block {
 
 	for {
 		block, next = pem.Decode(next)
+		if block == nil {
+			// no further pem data, e.g. trailing garbage or a file, which is currently being written
+			break
+		}
+
 		blocks = append(blocks, block)
 
 		if len(next) == 0 {
@@ -202,27 +233,46 @@ func readPEMContents(data []byte) []*pem.Block {
 	return blocks
 }
 
+// createEntry creates an entry for the given key. Next to the private keys from the crypto package,
+// keys implementing crypto.Signer, like these residing in an HSM, are supported. The type of such
+// keys is determined by their public key.
 func createEntry(key any, keyID string) (*Entry, error) {
+	const bitsInByte = 8
+
 	var (
-		sigKey    crypto.Signer
 		algorithm string
 		size      int
 	)
 
-	switch typedKey := key.(type) {
-	case *rsa.PrivateKey:
-		const bitsInByte = 8
+	if typedKey, ok := key.(*ed25519.PrivateKey); ok {
+		key = *typedKey
+	}
+
+	sigKey, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"unsupported key type; only rsa, ecdsa and ed25519 keys are supported")
+	}
 
+	switch pubKey := sigKey.Public().(type) {
+	case *rsa.PublicKey:
 		algorithm = AlgRSA
-		sigKey = typedKey
-		size = typedKey.Size() * bitsInByte
-	case *ecdsa.PrivateKey:
+		size = pubKey.Size() * bitsInByte
+	case *ecdsa.PublicKey:
 		algorithm = AlgECDSA
-		sigKey = typedKey
-		size = typedKey.Params().BitSize
+		size = pubKey.Params().BitSize
+	case ed25519.PublicKey:
+		algorithm = AlgEdDSA
+		size = ed25519Size
 	default:
 		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
-			"unsupported key type; only rsa and ecdsa keys are supported")
+			"unsupported key type; only rsa, ecdsa and ed25519 keys are supported")
+	}
+
+	// unsupported key sizes are rejected here and not on first use of the key
+	sigAlg, err := defaultAlgorithm(algorithm, size)
+	if err != nil {
+		return nil, err
 	}
 
 	return &Entry{
@@ -230,5 +280,6 @@ func createEntry(key any, keyID string) (*Entry, error) {
 		Alg:        algorithm,
 		KeySize:    size,
 		PrivateKey: sigKey,
+		algorithm:  sigAlg,
 	}, nil
 }
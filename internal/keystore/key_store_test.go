@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/keystore"
@@ -441,6 +443,109 @@ xijD/4gPFRBfs2GsfVZzSL9kH7HH0chB9w==
 				assert.Equal(t, "bar", entry1.KeyID)
 			},
 		},
+		{
+			uc: "pem contains an ed25519 key",
+			pemContents: func(t *testing.T) []byte {
+				t.Helper()
+
+				_, privKey, err := ed25519.GenerateKey(rand.Reader)
+				require.NoError(t, err)
+
+				pemBytes, err := testsupport.BuildPEM(
+					testsupport.WithEd25519PrivateKey(privKey, testsupport.WithPEMHeader("X-Key-ID", "foo")),
+				)
+				require.NoError(t, err)
+
+				return pemBytes
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ks)
+
+				assert.Len(t, ks.Entries(), 1)
+
+				entry := ks.Entries()[0]
+				assert.Equal(t, "foo", entry.KeyID)
+				assert.Equal(t, keystore.AlgEdDSA, entry.Alg)
+				assert.Equal(t, 256, entry.KeySize)
+				assert.Equal(t, jose.EdDSA, entry.JOSEAlgorithm())
+			},
+		},
+		{
+			uc: "pem contains a key with X-Key-Algorithm specified",
+			pemContents: func(t *testing.T) []byte {
+				t.Helper()
+
+				privKey, err := rsa.GenerateKey(rand.Reader, 2048)
+				require.NoError(t, err)
+
+				pemBytes, err := testsupport.BuildPEM(
+					testsupport.WithRSAPrivateKey(privKey,
+						testsupport.WithPEMHeader("X-Key-ID", "foo"),
+						testsupport.WithPEMHeader("X-Key-Algorithm", "RS256")),
+				)
+				require.NoError(t, err)
+
+				return pemBytes
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, ks)
+
+				entry, err := ks.GetKey("foo")
+				require.NoError(t, err)
+				assert.Equal(t, jose.RS256, entry.JOSEAlgorithm())
+				assert.Equal(t, string(jose.RS256), entry.JWK().Algorithm)
+			},
+		},
+		{
+			uc: "pem contains a key with X-Key-Algorithm not matching the key",
+			pemContents: func(t *testing.T) []byte {
+				t.Helper()
+
+				privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+				require.NoError(t, err)
+
+				pemBytes, err := testsupport.BuildPEM(
+					testsupport.WithECDSAPrivateKey(privKey, testsupport.WithPEMHeader("X-Key-Algorithm", "ES256")),
+				)
+				require.NoError(t, err)
+
+				return pemBytes
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "ES256")
+			},
+		},
+		{
+			uc: "pem contains an ecdsa key with unsupported curve",
+			pemContents: func(t *testing.T) []byte {
+				t.Helper()
+
+				privKey, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+				require.NoError(t, err)
+
+				pemBytes, err := testsupport.BuildPEM(testsupport.WithECDSAPrivateKey(privKey))
+				require.NoError(t, err)
+
+				return pemBytes
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "unsupported ECDSA key size")
+			},
+		},
 		{
 			uc: "pem contains key with cert without SubjectKeyID and without X-Key-ID specified",
 			pemContents: func(t *testing.T) []byte {
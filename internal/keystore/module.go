@@ -1,9 +1,11 @@
 package keystore
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
+	"io"
 
 	"github.com/rs/zerolog"
 	"go.uber.org/fx"
@@ -15,15 +17,42 @@ import (
 
 var Module = fx.Options( //nolint:gochecknoglobals
 	fx.Provide(NewKeyStore),
+	fx.Invoke(registerKeyStoreCloser),
 )
 
+func registerKeyStoreCloser(lifecycle fx.Lifecycle, logger zerolog.Logger, ks KeyStore) {
+	closer, ok := ks.(io.Closer)
+	if !ok {
+		return
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			logger.Debug().Msg("Releasing key store resources")
+
+			return closer.Close()
+		},
+	})
+}
+
+// NewKeyStore creates the key store used for signing purposes. The returned key store is a KeyRing.
 func NewKeyStore(conf config.Configuration, logger zerolog.Logger) (KeyStore, error) {
 	var (
 		ks  KeyStore
+		kr  KeyRing
 		err error
 	)
 
-	if len(conf.Signer.KeyStore) == 0 {
+	if err = checkKeySources(conf.Signer); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case conf.Signer.PKCS11 != nil:
+		ks, err = NewKeyStoreFromPKCS11(*conf.Signer.PKCS11)
+	case conf.Signer.Remote != nil:
+		ks, err = NewKeyStoreFromRemoteSigner(*conf.Signer.Remote)
+	case len(conf.Signer.KeyStore) == 0:
 		logger.Warn().
 			Msg("Key store is not configured. NEVER DO IT IN PRODUCTION!!!! Generating an ECDSA P-384 key pair.")
 
@@ -36,7 +65,10 @@ func NewKeyStore(conf config.Configuration, logger zerolog.Logger) (KeyStore, er
 		}
 
 		ks, err = NewKeyStoreFromKey(privateKey)
-	} else {
+	case conf.Signer.Watch:
+		kr, err = NewKeyRingFromPEMFile(conf.Signer.KeyStore, conf.Signer.Password, conf.Signer.KeyID,
+			conf.Signer.Algorithm, conf.Signer.RotationOverlap, logger)
+	default:
 		ks, err = NewKeyStoreFromPEMFile(conf.Signer.KeyStore, conf.Signer.Password)
 	}
 
@@ -44,15 +76,44 @@ func NewKeyStore(conf config.Configuration, logger zerolog.Logger) (KeyStore, er
 		return nil, err
 	}
 
+	if kr == nil {
+		if kr, err = NewKeyRing(ks, conf.Signer.KeyID, conf.Signer.Algorithm); err != nil {
+			return nil, err
+		}
+	}
+
 	logger.Info().Msg("Key store contains following entries")
 
-	for _, entry := range ks.Entries() {
+	for _, entry := range kr.Entries() {
 		logger.Info().
 			Str("_key_id", entry.KeyID).
 			Str("_algorithm", entry.Alg).
 			Int("_size", entry.KeySize).
+			Str("_signature_algorithm", string(entry.JOSEAlgorithm())).
 			Msg("Entry info")
 	}
 
-	return ks, nil
+	return kr, nil
+}
+
+func checkKeySources(conf config.SignerConfig) error {
+	sources := 0
+
+	for _, configured := range []bool{len(conf.KeyStore) != 0, conf.PKCS11 != nil, conf.Remote != nil} {
+		if configured {
+			sources++
+		}
+	}
+
+	if sources > 1 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"only one of key_store, pkcs11 and remote can be configured for the signer")
+	}
+
+	if conf.Watch && len(conf.KeyStore) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"watch can only be used together with key_store")
+	}
+
+	return nil
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/keystore"
 )
 
@@ -88,6 +89,47 @@ func TestNewKeyStore(t *testing.T) {
 				assert.Equal(t, "RSA", rsaKeyEntry.Alg)
 			},
 		},
+		{
+			uc: "multiple key sources configured",
+			conf: config.Configuration{
+				Signer: config.SignerConfig{
+					KeyStore: file.Name(),
+					Remote:   &config.RemoteSignerConfig{URL: "http://foo.bar"},
+				},
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "only one of")
+			},
+		},
+		{
+			uc: "watch configured without key store",
+			conf: config.Configuration{
+				Signer: config.SignerConfig{
+					Watch:  true,
+					PKCS11: &config.PKCS11Config{ModulePath: "/foo/bar.so"},
+				},
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "watch")
+			},
+		},
+		{
+			uc: "pkcs11 configured without module path",
+			conf: config.Configuration{
+				Signer: config.SignerConfig{PKCS11: &config.PKCS11Config{}},
+			},
+			assert: func(t *testing.T, ks keystore.KeyStore, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// WHEN
@@ -0,0 +1,87 @@
+//go:build cgo
+
+package keystore
+
+import (
+	"crypto"
+	"encoding/hex"
+
+	"github.com/ThalesIgnite/crypto11"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// NewKeyStoreFromPKCS11 creates a key store from the key pairs available in the configured PKCS#11
+// token. The private keys never leave the token. The label of a key is used as its key id. If a key
+// does not have a label, the hex representation of its CKA_ID is used instead.
+func NewKeyStoreFromPKCS11(conf config.PKCS11Config) (KeyStore, error) {
+	if len(conf.ModulePath) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "pkcs11 requires module_path to be set")
+	}
+
+	// the context is kept open as long as the process lives, as the keys are used for signing
+	ctx, err := crypto11.Configure(&crypto11.Config{
+		Path:        conf.ModulePath,
+		TokenLabel:  conf.TokenLabel,
+		TokenSerial: conf.TokenSerial,
+		Pin:         conf.Pin,
+	})
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to access the pkcs11 token").CausedBy(err)
+	}
+
+	signers, err := ctx.FindAllKeyPairs()
+	if err != nil {
+		ctx.Close()
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to retrieve key pairs from the pkcs11 token").CausedBy(err)
+	}
+
+	entries := make([]*Entry, len(signers))
+
+	for idx, signer := range signers {
+		keyID, err := pkcs11KeyID(ctx, signer)
+		if err != nil {
+			ctx.Close()
+
+			return nil, err
+		}
+
+		if entries[idx], err = createEntry(signer, keyID); err != nil {
+			ctx.Close()
+
+			return nil, err
+		}
+	}
+
+	ks, err := verifyAndBuildKeyStore(entries, nil)
+	if err != nil {
+		ctx.Close()
+
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+func pkcs11KeyID(ctx *crypto11.Context, key crypto.Signer) (string, error) {
+	attrs, err := ctx.GetAttributes(key, []crypto11.AttributeType{crypto11.CkaLabel, crypto11.CkaId})
+	if err != nil {
+		return "", errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to retrieve key attributes from the pkcs11 token").CausedBy(err)
+	}
+
+	if label := attrs[crypto11.CkaLabel]; label != nil && len(label.Value) != 0 {
+		return string(label.Value), nil
+	}
+
+	if id := attrs[crypto11.CkaId]; id != nil {
+		return hex.EncodeToString(id.Value), nil
+	}
+
+	return "", nil
+}
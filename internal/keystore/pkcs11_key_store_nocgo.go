@@ -0,0 +1,15 @@
+//go:build !cgo
+
+package keystore
+
+import (
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// NewKeyStoreFromPKCS11 is not supported, as PKCS#11 modules can only be loaded with cgo enabled.
+func NewKeyStoreFromPKCS11(_ config.PKCS11Config) (KeyStore, error) {
+	return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+		"pkcs11 is not supported by this build of heimdall as it has been built without cgo")
+}
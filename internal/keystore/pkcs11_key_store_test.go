@@ -0,0 +1,140 @@
+//go:build cgo
+
+package keystore_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/ThalesIgnite/crypto11"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+)
+
+// setupSoftHSM initializes a SoftHSM token and returns the path to the SoftHSM PKCS#11 module.
+// The test is skipped if SoftHSM is not installed.
+func setupSoftHSM(t *testing.T) string {
+	t.Helper()
+
+	var modulePath string
+
+	for _, candidate := range []string{
+		"/usr/lib/softhsm/libsofthsm2.so",
+		"/usr/lib/x86_64-linux-gnu/softhsm/libsofthsm2.so",
+		"/usr/local/lib/softhsm/libsofthsm2.so",
+	} {
+		if _, err := os.Stat(candidate); err == nil {
+			modulePath = candidate
+
+			break
+		}
+	}
+
+	if _, err := exec.LookPath("softhsm2-util"); err != nil || len(modulePath) == 0 {
+		t.Skip("SoftHSM is not installed")
+	}
+
+	tokenDir := t.TempDir()
+	confFile := filepath.Join(tokenDir, "softhsm2.conf")
+	require.NoError(t, os.WriteFile(confFile, []byte("directories.tokendir = "+tokenDir+"\n"), 0o600))
+	t.Setenv("SOFTHSM2_CONF", confFile)
+
+	out, err := exec.Command("softhsm2-util", "--init-token", "--free",
+		"--label", "heimdall", "--pin", "1234", "--so-pin", "5678").CombinedOutput()
+	require.NoError(t, err, string(out))
+
+	return modulePath
+}
+
+func TestCreateKeyStoreFromPKCS11(t *testing.T) {
+	// GIVEN
+	modulePath := setupSoftHSM(t)
+	conf := config.PKCS11Config{ModulePath: modulePath, TokenLabel: "heimdall", Pin: "1234"}
+
+	ctx, err := crypto11.Configure(&crypto11.Config{Path: modulePath, TokenLabel: "heimdall", Pin: "1234"})
+	require.NoError(t, err)
+
+	_, err = ctx.GenerateECDSAKeyPairWithLabel([]byte{1}, []byte("foo"), elliptic.P256())
+	require.NoError(t, err)
+
+	_, err = ctx.GenerateRSAKeyPair([]byte{2}, 2048)
+	require.NoError(t, err)
+
+	require.NoError(t, ctx.Close())
+
+	// WHEN
+	ks, err := keystore.NewKeyStoreFromPKCS11(conf)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Len(t, ks.Entries(), 2)
+
+	ecEntry, err := ks.GetKey("foo")
+	require.NoError(t, err)
+	assert.Equal(t, keystore.AlgECDSA, ecEntry.Alg)
+	assert.Equal(t, jose.ES256, ecEntry.JOSEAlgorithm())
+
+	digest := sha256.Sum256([]byte("foobar"))
+	sig, err := ecEntry.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+
+	pubKey, ok := ecEntry.PrivateKey.Public().(*ecdsa.PublicKey)
+	require.True(t, ok)
+	assert.True(t, ecdsa.VerifyASN1(pubKey, digest[:], sig))
+
+	// key without label is identified by the hex representation of its CKA_ID
+	rsaEntry, err := ks.GetKey("02")
+	require.NoError(t, err)
+	assert.Equal(t, keystore.AlgRSA, rsaEntry.Alg)
+	assert.Equal(t, 2048, rsaEntry.KeySize)
+}
+
+func TestCreateKeyStoreFromPKCS11WithErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   config.PKCS11Config
+		assert func(t *testing.T, err error)
+	}{
+		{
+			uc: "without module path",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "module_path")
+			},
+		},
+		{
+			uc:   "with not existing module",
+			conf: config.PKCS11Config{ModulePath: "/does/not/exist.so", TokenLabel: "heimdall"},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to access")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			_, err := keystore.NewKeyStoreFromPKCS11(tc.conf)
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package keystore
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const defaultRemoteSignerTimeout = 5 * time.Second
+
+type remoteSignRequest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    []byte `json:"digest"`
+}
+
+type remoteSignResponse struct {
+	Signature []byte `json:"signature"`
+}
+
+// NewKeyStoreFromRemoteSigner creates a key store from the public keys published by a remote signing
+// service. The keys are expected to be available as JWKS at <url>/keys. Signatures are created by
+// sending the digest to <url>/keys/<key id>/sign, so the private keys never leave the service.
+func NewKeyStoreFromRemoteSigner(conf config.RemoteSignerConfig) (KeyStore, error) {
+	if len(conf.URL) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "remote signer requires url to be set")
+	}
+
+	timeout := conf.Timeout
+	if timeout == 0 {
+		timeout = defaultRemoteSignerTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	keysEP := endpoint.Endpoint{
+		URL:     strings.TrimSuffix(conf.URL, "/") + "/keys",
+		Method:  http.MethodGet,
+		Headers: conf.Headers,
+	}
+
+	rawJWKS, err := keysEP.SendRequest(ctx, nil, nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to retrieve keys from the remote signer").CausedBy(err)
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err = json.Unmarshal(rawJWKS, &jwks); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"failed to decode keys retrieved from the remote signer").CausedBy(err)
+	}
+
+	entries := make([]*Entry, len(jwks.Keys))
+
+	for idx, jwk := range jwks.Keys {
+		if !jwk.IsPublic() || len(jwk.KeyID) == 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"remote signer published an invalid key at position %d; a public key with key id is required", idx)
+		}
+
+		signer := &remoteSigner{
+			ep: endpoint.Endpoint{
+				URL:     fmt.Sprintf("%s/%s/sign", keysEP.URL, url.PathEscape(jwk.KeyID)),
+				Method:  http.MethodPost,
+				Headers: withContentType(conf.Headers),
+			},
+			timeout: timeout,
+			pubKey:  jwk.Key,
+		}
+
+		entry, err := createEntry(signer, jwk.KeyID)
+		if err != nil {
+			return nil, err
+		}
+
+		// the algorithm published by the service takes precedence over the one derived from the key
+		if len(jwk.Algorithm) != 0 {
+			if entry, err = entry.WithAlgorithm(jwk.Algorithm); err != nil {
+				return nil, err
+			}
+		}
+
+		entries[idx] = entry
+	}
+
+	ks, err := verifyAndBuildKeyStore(entries, jwksCertificates(jwks))
+	if err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+func withContentType(headers map[string]string) map[string]string {
+	result := make(map[string]string, len(headers)+1)
+	result["Content-Type"] = "application/json"
+
+	for k, v := range headers {
+		result[k] = v
+	}
+
+	return result
+}
+
+func jwksCertificates(jwks jose.JSONWebKeySet) []*x509.Certificate {
+	var certs []*x509.Certificate
+
+	for _, jwk := range jwks.Keys {
+		certs = append(certs, jwk.Certificates...)
+	}
+
+	return certs
+}
+
+// remoteSigner implements crypto.Signer by delegating the signature creation to the remote signing
+// service. As required by crypto.Signer, ECDSA signatures are expected in ASN.1 DER format.
+type remoteSigner struct {
+	ep      endpoint.Endpoint
+	timeout time.Duration
+	pubKey  crypto.PublicKey
+}
+
+func (s *remoteSigner) Public() crypto.PublicKey { return s.pubKey }
+
+func (s *remoteSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	alg, err := s.algorithm(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(remoteSignRequest{Algorithm: alg, Digest: digest})
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to encode sign request").CausedBy(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	rawResp, err := s.ep.SendRequest(ctx, bytes.NewReader(body), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp remoteSignResponse
+	if err = json.Unmarshal(rawResp, &resp); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication,
+			"failed to decode response from the remote signer").CausedBy(err)
+	}
+
+	if len(resp.Signature) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication,
+			"remote signer did not return a signature")
+	}
+
+	return resp.Signature, nil
+}
+
+// algorithm determines the JOSE algorithm from the key type and the signer options, as only these
+// are known to a crypto.Signer.
+func (s *remoteSigner) algorithm(opts crypto.SignerOpts) (string, error) {
+	hashBits := map[crypto.Hash]string{crypto.SHA256: "256", crypto.SHA384: "384", crypto.SHA512: "512"}
+
+	switch s.pubKey.(type) {
+	case ed25519.PublicKey:
+		return string(jose.EdDSA), nil
+	case *ecdsa.PublicKey:
+		if bits, ok := hashBits[opts.HashFunc()]; ok {
+			return "ES" + bits, nil
+		}
+	case *rsa.PublicKey:
+		bits, ok := hashBits[opts.HashFunc()]
+		if _, pss := opts.(*rsa.PSSOptions); ok && pss {
+			return "PS" + bits, nil
+		} else if ok {
+			return "RS" + bits, nil
+		}
+	}
+
+	return "", errorchain.NewWithMessagef(heimdall.ErrInternal,
+		"unsupported signature options for remote signer: %v", opts.HashFunc())
+}
@@ -0,0 +1,228 @@
+package keystore_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+)
+
+type signRequest struct {
+	Algorithm string `json:"algorithm"`
+	Digest    []byte `json:"digest"`
+}
+
+func newRemoteSigner(t *testing.T, keys map[string]crypto.Signer, jwks jose.JSONWebKeySet) *httptest.Server {
+	t.Helper()
+
+	return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer foo" {
+			rw.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		if req.Method == http.MethodGet && req.URL.Path == "/keys" {
+			rw.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(rw).Encode(jwks))
+
+			return
+		}
+
+		keyID := strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/keys/"), "/sign")
+		key, ok := keys[keyID]
+		if req.Method != http.MethodPost || !ok {
+			rw.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		var sigReq signRequest
+		require.NoError(t, json.NewDecoder(req.Body).Decode(&sigReq))
+
+		var opts crypto.SignerOpts
+
+		switch sigReq.Algorithm {
+		case "ES256", "RS256":
+			opts = crypto.SHA256
+		case "PS256":
+			opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+		case "EdDSA":
+			opts = crypto.Hash(0)
+		default:
+			rw.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		sig, err := key.Sign(rand.Reader, sigReq.Digest, opts)
+		require.NoError(t, err)
+
+		rw.Header().Set("Content-Type", "application/json")
+		require.NoError(t, json.NewEncoder(rw).Encode(map[string]any{"signature": sig}))
+	}))
+}
+
+func TestCreateKeyStoreFromRemoteSigner(t *testing.T) {
+	t.Parallel()
+
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaPrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	keys := map[string]crypto.Signer{"ec": ecdsaPrivKey, "rsa": rsaPrivKey, "ed": ed25519PrivKey}
+	srv := newRemoteSigner(t, keys, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{KeyID: "ec", Key: ecdsaPrivKey.Public(), Use: "sig"},
+		{KeyID: "rsa", Key: rsaPrivKey.Public(), Algorithm: "RS256", Use: "sig"},
+		{KeyID: "ed", Key: ed25519PrivKey.Public(), Use: "sig"},
+	}})
+	defer srv.Close()
+
+	// WHEN
+	ks, err := keystore.NewKeyStoreFromRemoteSigner(config.RemoteSignerConfig{
+		URL:     srv.URL,
+		Headers: map[string]string{"Authorization": "Bearer foo"},
+	})
+
+	// THEN
+	require.NoError(t, err)
+
+	entries := ks.Entries()
+	require.Len(t, entries, 3)
+
+	digest := sha256.Sum256([]byte("foobar"))
+
+	ecEntry, err := ks.GetKey("ec")
+	require.NoError(t, err)
+	assert.Equal(t, keystore.AlgECDSA, ecEntry.Alg)
+	assert.Equal(t, jose.ES256, ecEntry.JOSEAlgorithm())
+
+	sig, err := ecEntry.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	assert.True(t, ecdsa.VerifyASN1(&ecdsaPrivKey.PublicKey, digest[:], sig))
+
+	rsaEntry, err := ks.GetKey("rsa")
+	require.NoError(t, err)
+	assert.Equal(t, keystore.AlgRSA, rsaEntry.Alg)
+	assert.Equal(t, 2048, rsaEntry.KeySize)
+	assert.Equal(t, jose.RS256, rsaEntry.JOSEAlgorithm())
+
+	sig, err = rsaEntry.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.NoError(t, rsa.VerifyPKCS1v15(&rsaPrivKey.PublicKey, crypto.SHA256, digest[:], sig))
+
+	pssOpts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+	sig, err = rsaEntry.PrivateKey.Sign(rand.Reader, digest[:], pssOpts)
+	require.NoError(t, err)
+	require.NoError(t, rsa.VerifyPSS(&rsaPrivKey.PublicKey, crypto.SHA256, digest[:], sig, pssOpts))
+
+	edEntry, err := ks.GetKey("ed")
+	require.NoError(t, err)
+	assert.Equal(t, jose.EdDSA, edEntry.JOSEAlgorithm())
+
+	sig, err = edEntry.PrivateKey.Sign(rand.Reader, []byte("foobar"), crypto.Hash(0))
+	require.NoError(t, err)
+	assert.True(t, ed25519.Verify(ed25519PrivKey.Public().(ed25519.PublicKey), []byte("foobar"), sig))
+
+	_, err = ecEntry.PrivateKey.Sign(rand.Reader, digest[:], crypto.SHA1)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrInternal)
+}
+
+func TestCreateKeyStoreFromRemoteSignerWithErrors(t *testing.T) {
+	t.Parallel()
+
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		jwks   jose.JSONWebKeySet
+		conf   func(srvURL string) config.RemoteSignerConfig
+		assert func(t *testing.T, err error)
+	}{
+		{
+			uc:   "without url",
+			conf: func(_ string) config.RemoteSignerConfig { return config.RemoteSignerConfig{} },
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires url")
+			},
+		},
+		{
+			uc: "with failing keys retrieval",
+			conf: func(srvURL string) config.RemoteSignerConfig {
+				return config.RemoteSignerConfig{URL: srvURL}
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to retrieve keys")
+			},
+		},
+		{
+			uc:   "with key without key id",
+			jwks: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: ecdsaPrivKey.Public()}}},
+			conf: func(srvURL string) config.RemoteSignerConfig {
+				return config.RemoteSignerConfig{URL: srvURL, Headers: map[string]string{"Authorization": "Bearer foo"}}
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid key")
+			},
+		},
+		{
+			uc: "with algorithm not matching the key",
+			jwks: jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+				{KeyID: "foo", Key: ecdsaPrivKey.Public(), Algorithm: "RS256"},
+			}},
+			conf: func(srvURL string) config.RemoteSignerConfig {
+				return config.RemoteSignerConfig{URL: srvURL, Headers: map[string]string{"Authorization": "Bearer foo"}}
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "RS256")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			srv := newRemoteSigner(t, nil, tc.jwks)
+			defer srv.Close()
+
+			// WHEN
+			_, err := keystore.NewKeyStoreFromRemoteSigner(tc.conf(srv.URL))
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package ldap
+
+import (
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	AttributeDN     = "dn"
+	AttributeGroups = "groups"
+)
+
+// AttributeMapping maps names of subject attributes to LDAP attributes.
+type AttributeMapping map[string]string
+
+func (m AttributeMapping) Validate() error {
+	for name, attr := range m {
+		if name == AttributeDN || name == AttributeGroups {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"attribute name '%s' is reserved", name)
+		}
+
+		if len(attr) == 0 {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"no ldap attribute configured for '%s'", name)
+		}
+	}
+
+	return nil
+}
+
+// LDAPAttributes returns the names of the LDAP attributes to request.
+func (m AttributeMapping) LDAPAttributes() []string {
+	attributes := make([]string, 0, len(m))
+
+	for _, attr := range m {
+		attributes = append(attributes, attr)
+	}
+
+	return attributes
+}
+
+// Apply maps the attributes of the entry. Attributes with a single value are mapped to a string,
+// attributes with multiple values to a list of strings. Missing attributes are omitted.
+func (m AttributeMapping) Apply(entry *goldap.Entry) map[string]any {
+	result := make(map[string]any, len(m)+1)
+	result[AttributeDN] = entry.DN
+
+	for name, attr := range m {
+		values := entry.GetEqualFoldAttributeValues(attr)
+
+		switch len(values) {
+		case 0:
+		case 1:
+			result[name] = values[0]
+		default:
+			result[name] = values
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,40 @@
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+func TestAttributeMappingValidate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc      string
+		mapping AttributeMapping
+		errMsg  string
+	}{
+		{uc: "without mapping"},
+		{uc: "with valid mapping", mapping: AttributeMapping{"email": "mail", "name": "cn"}},
+		{uc: "with reserved dn name", mapping: AttributeMapping{"dn": "distinguishedName"}, errMsg: "'dn' is reserved"},
+		{uc: "with reserved groups name", mapping: AttributeMapping{"groups": "memberOf"}, errMsg: "'groups' is reserved"},
+		{uc: "with empty ldap attribute", mapping: AttributeMapping{"email": ""}, errMsg: "no ldap attribute"},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			err := tc.mapping.Validate()
+
+			// THEN
+			if len(tc.errMsg) != 0 {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), tc.errMsg)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
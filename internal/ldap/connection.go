@@ -0,0 +1,88 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultPoolSize = 5
+	defaultTimeout  = 10 * time.Second
+)
+
+// Connection configures how to connect to an LDAP server.
+type Connection struct {
+	URL          string                `mapstructure:"url"`
+	StartTLS     bool                  `mapstructure:"start_tls"`
+	TrustStore   truststore.TrustStore `mapstructure:"trust_store"`
+	BindDN       string                `mapstructure:"bind_dn"`
+	BindPassword string                `mapstructure:"bind_password"`
+	PoolSize     int                   `mapstructure:"pool_size"`
+	Timeout      time.Duration         `mapstructure:"timeout"`
+}
+
+func (c Connection) tlsConfig() (*tls.Config, error) {
+	if len(c.URL) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "no ldap url configured")
+	}
+
+	ldapURL, err := url.Parse(c.URL)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to parse ldap url").
+			CausedBy(err)
+	}
+
+	if ldapURL.Scheme != "ldap" && ldapURL.Scheme != "ldaps" {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported ldap url scheme '%s'. Use ldap or ldaps", ldapURL.Scheme)
+	}
+
+	if len(ldapURL.Hostname()) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "ldap url does not contain a host")
+	}
+
+	if ldapURL.Scheme == "ldaps" && c.StartTLS {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"start_tls cannot be used together with ldaps")
+	}
+
+	if len(c.BindDN) != 0 && len(c.BindPassword) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"bind_dn is configured without bind_password")
+	}
+
+	if ldapURL.Scheme == "ldap" && !c.StartTLS {
+		return nil, nil
+	}
+
+	var rootCAs *x509.CertPool
+
+	if len(c.TrustStore) != 0 {
+		rootCAs = x509.NewCertPool()
+
+		for _, cert := range c.TrustStore {
+			rootCAs.AddCert(cert)
+		}
+	}
+
+	return &tls.Config{
+		RootCAs:    rootCAs,
+		ServerName: ldapURL.Hostname(),
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+func (c Connection) poolSize() int {
+	return x.IfThenElse(c.PoolSize > 0, c.PoolSize, defaultPoolSize)
+}
+
+func (c Connection) timeout() time.Duration {
+	return x.IfThenElse(c.Timeout > 0, c.Timeout, defaultTimeout)
+}
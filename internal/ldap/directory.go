@@ -0,0 +1,206 @@
+package ldap
+
+import (
+	"errors"
+	"strings"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var (
+	ErrEntryNotFound  = errors.New("no ldap entry found")
+	ErrAmbiguousEntry = errors.New("ambiguous ldap entry")
+)
+
+const (
+	defaultGroupFilter        = "(|(member={dn})(uniqueMember={dn}))"
+	defaultGroupNameAttribute = "cn"
+	maxGroupNestingDepth      = 10
+)
+
+// Search defines the base and the scope of a search.
+type Search struct {
+	BaseDN string `mapstructure:"base_dn"`
+	Scope  string `mapstructure:"scope"`
+}
+
+func (s Search) Validate() error {
+	if len(s.BaseDN) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "no base_dn configured for ldap search")
+	}
+
+	_, err := parseScope(s.Scope)
+
+	return err
+}
+
+// GroupSearch defines how the groups an entry is member of are looked up. The {dn} placeholder
+// in the filter is replaced by the (escaped) DN of the member.
+type GroupSearch struct {
+	Search        `mapstructure:",squash"`
+	Filter        string `mapstructure:"filter"`
+	NameAttribute string `mapstructure:"name_attribute"`
+	Nested        bool   `mapstructure:"nested"`
+}
+
+// Directory provides access to an LDAP server via a pool of connections.
+type Directory struct {
+	p *pool
+}
+
+func NewDirectory(conf Connection) (*Directory, error) {
+	p, err := newPool(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Directory{p: p}, nil
+}
+
+// FindEntry searches for exactly one entry matching the given filter.
+func (d *Directory) FindEntry(search Search, filter string, attributes []string) (*goldap.Entry, error) {
+	entries, err := d.search(search, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(entries) {
+	case 0:
+		return nil, errorchain.New(ErrEntryNotFound)
+	case 1:
+		return entries[0], nil
+	default:
+		return nil, errorchain.NewWithMessagef(ErrAmbiguousEntry, "%d entries match the filter", len(entries))
+	}
+}
+
+// Authenticate verifies the given credentials by binding as dn. The connection is bound with
+// the service account again afterwards.
+func (d *Directory) Authenticate(dn, password string) error {
+	// an empty password would result in an unauthenticated bind, which always succeeds
+	if len(dn) == 0 || len(password) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "empty credentials")
+	}
+
+	conn, err := d.p.acquire()
+	if err != nil {
+		return err
+	}
+
+	if err = conn.Bind(dn, password); err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultInvalidCredentials) {
+			err = errorchain.NewWithMessage(heimdall.ErrAuthentication, "invalid credentials").CausedBy(err)
+		} else {
+			err = errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to bind to ldap server").
+				CausedBy(err)
+		}
+	}
+
+	if rebindErr := d.p.bind(conn); rebindErr != nil {
+		conn.Close()
+
+		return x.IfThenElse(err != nil, err, rebindErr)
+	}
+
+	d.p.release(conn)
+
+	return err
+}
+
+// Groups returns the names of the groups the entry identified by dn is member of. If nested
+// group resolution is enabled, the groups of these groups are looked up as well.
+func (d *Directory) Groups(search GroupSearch, dn string) ([]string, error) {
+	filter := x.IfThenElse(len(search.Filter) != 0, search.Filter, defaultGroupFilter)
+	nameAttribute := x.IfThenElse(len(search.NameAttribute) != 0, search.NameAttribute, defaultGroupNameAttribute)
+
+	names := []string{}
+	visited := map[string]bool{strings.ToLower(dn): true}
+	members := []string{dn}
+
+	for depth := 0; len(members) != 0 && depth < maxGroupNestingDepth; depth++ {
+		var groups []string
+
+		for _, member := range members {
+			entries, err := d.search(search.Search,
+				ReplacePlaceholder(filter, "dn", member), []string{nameAttribute})
+			if err != nil {
+				return nil, err
+			}
+
+			for _, entry := range entries {
+				key := strings.ToLower(entry.DN)
+				if visited[key] {
+					continue
+				}
+
+				visited[key] = true
+				groups = append(groups, entry.DN)
+
+				if name := entry.GetEqualFoldAttributeValue(nameAttribute); len(name) != 0 {
+					names = append(names, name)
+				}
+			}
+		}
+
+		if !search.Nested {
+			break
+		}
+
+		members = groups
+	}
+
+	return names, nil
+}
+
+func (d *Directory) search(search Search, filter string, attributes []string) ([]*goldap.Entry, error) {
+	scope, err := parseScope(search.Scope)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := d.p.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	defer d.p.release(conn)
+
+	result, err := conn.Search(goldap.NewSearchRequest(
+		search.BaseDN, scope, goldap.NeverDerefAliases, 0, 0, false, filter, attributes, nil))
+	if err != nil {
+		if goldap.IsErrorWithCode(err, goldap.LDAPResultNoSuchObject) {
+			return nil, nil
+		}
+
+		if goldap.IsErrorWithCode(err, goldap.ErrorNetwork) {
+			conn.Close()
+		}
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "ldap search failed").CausedBy(err)
+	}
+
+	return result.Entries, nil
+}
+
+// ReplacePlaceholder replaces all occurrences of {name} in filter by the escaped value.
+func ReplacePlaceholder(filter, name, value string) string {
+	return strings.ReplaceAll(filter, "{"+name+"}", goldap.EscapeFilter(value))
+}
+
+func parseScope(scope string) (int, error) {
+	switch scope {
+	case "", "sub":
+		return goldap.ScopeWholeSubtree, nil
+	case "one":
+		return goldap.ScopeSingleLevel, nil
+	case "base":
+		return goldap.ScopeBaseObject, nil
+	default:
+		return 0, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported ldap search scope '%s'. Use base, one or sub", scope)
+	}
+}
@@ -0,0 +1,330 @@
+package ldap
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/truststore"
+)
+
+func testEntries() []testsupport.LDAPEntry {
+	return []testsupport.LDAPEntry{
+		{DN: "dc=example,dc=org"},
+		{DN: "ou=users,dc=example,dc=org"},
+		{DN: "ou=groups,dc=example,dc=org"},
+		{
+			DN:       "cn=service,dc=example,dc=org",
+			Password: "service-secret",
+		},
+		{
+			DN:       "uid=alice,ou=users,dc=example,dc=org",
+			Password: "alice-secret",
+			Attributes: map[string][]string{
+				"uid":  {"alice"},
+				"mail": {"alice@example.org", "a@example.org"},
+				"cn":   {"Alice"},
+			},
+		},
+		{
+			DN: "uid=bob,ou=users,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"uid": {"bob"},
+				"cn":  {"Alice"},
+			},
+		},
+		{
+			DN: "cn=devs,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":     {"devs"},
+				"member": {"uid=alice,ou=users,dc=example,dc=org"},
+			},
+		},
+		{
+			DN: "cn=staff,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":           {"staff"},
+				"uniqueMember": {"cn=devs,ou=groups,dc=example,dc=org", "cn=all,ou=groups,dc=example,dc=org"},
+			},
+		},
+		{
+			DN: "cn=all,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":     {"all"},
+				"member": {"cn=staff,ou=groups,dc=example,dc=org"},
+			},
+		},
+	}
+}
+
+func newServerTLSConfig(t *testing.T) (*tls.Config, truststore.TrustStore) {
+	t.Helper()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour)
+	require.NoError(t, err)
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	cert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "ldap server"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&privKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature),
+		testsupport.WithIPAddresses(net.ParseIP("127.0.0.1")))
+	require.NoError(t, err)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{cert.Raw}, PrivateKey: privKey}},
+		MinVersion:   tls.VersionTLS12,
+	}, truststore.TrustStore{rootCA.Certificate}
+}
+
+func TestNewDirectory(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   Connection
+		errMsg string
+	}{
+		{uc: "without url", conf: Connection{}, errMsg: "no ldap url"},
+		{uc: "with unsupported scheme", conf: Connection{URL: "http://foo"}, errMsg: "unsupported ldap url scheme"},
+		{uc: "without host", conf: Connection{URL: "ldap://"}, errMsg: "does not contain a host"},
+		{uc: "with ldaps and start_tls", conf: Connection{URL: "ldaps://foo", StartTLS: true}, errMsg: "start_tls"},
+		{uc: "with bind_dn only", conf: Connection{URL: "ldap://foo", BindDN: "cn=foo"}, errMsg: "bind_password"},
+		{uc: "with valid ldap config", conf: Connection{URL: "ldap://foo:389"}},
+		{uc: "with valid ldaps config", conf: Connection{URL: "ldaps://foo:636", BindDN: "cn=foo", BindPassword: "bar"}},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			dir, err := NewDirectory(tc.conf)
+
+			// THEN
+			if len(tc.errMsg) != 0 {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), tc.errMsg)
+			} else {
+				require.NoError(t, err)
+				assert.NotNil(t, dir)
+			}
+		})
+	}
+}
+
+func TestDirectoryFindEntry(t *testing.T) {
+	t.Parallel()
+
+	srv := testsupport.NewLDAPServer(t, nil, false, testEntries()...)
+	srv.RequireBind = true
+
+	dir, err := NewDirectory(Connection{
+		URL:          srv.URL,
+		BindDN:       "cn=service,dc=example,dc=org",
+		BindPassword: "service-secret",
+		PoolSize:     1,
+	})
+	require.NoError(t, err)
+
+	mapping := AttributeMapping{"email": "mail", "name": "cn"}
+	users := Search{BaseDN: "ou=users,dc=example,dc=org", Scope: "one"}
+
+	// entry found
+	entry, err := dir.FindEntry(users, "(uid=alice)", mapping.LDAPAttributes())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{
+		"dn":    "uid=alice,ou=users,dc=example,dc=org",
+		"email": []string{"alice@example.org", "a@example.org"},
+		"name":  "Alice",
+	}, mapping.Apply(entry))
+
+	// no entry found
+	_, err = dir.FindEntry(users, "(uid=carol)", nil)
+	require.ErrorIs(t, err, ErrEntryNotFound)
+
+	// unknown base dn
+	_, err = dir.FindEntry(Search{BaseDN: "ou=foo,dc=example,dc=org"}, "(uid=alice)", nil)
+	require.ErrorIs(t, err, ErrEntryNotFound)
+
+	// several entries found
+	_, err = dir.FindEntry(users, "(cn=Alice)", nil)
+	require.ErrorIs(t, err, ErrAmbiguousEntry)
+
+	// the connection is reused
+	assert.Equal(t, 1, srv.Connections())
+	assert.Equal(t, []string{"cn=service,dc=example,dc=org"}, srv.Binds())
+}
+
+func TestDirectoryFindEntryWithBadServiceCredentials(t *testing.T) {
+	t.Parallel()
+
+	srv := testsupport.NewLDAPServer(t, nil, false, testEntries()...)
+
+	dir, err := NewDirectory(Connection{
+		URL:          srv.URL,
+		BindDN:       "cn=service,dc=example,dc=org",
+		BindPassword: "wrong",
+	})
+	require.NoError(t, err)
+
+	_, err = dir.FindEntry(Search{BaseDN: "dc=example,dc=org"}, "(uid=alice)", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrCommunication)
+	assert.Contains(t, err.Error(), "failed to bind")
+}
+
+func TestDirectoryFindEntryWithUnreachableServer(t *testing.T) {
+	t.Parallel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	dir, err := NewDirectory(Connection{URL: "ldap://" + addr, Timeout: time.Second})
+	require.NoError(t, err)
+
+	_, err = dir.FindEntry(Search{BaseDN: "dc=example,dc=org"}, "(uid=alice)", nil)
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrCommunication)
+	assert.Contains(t, err.Error(), "failed to connect")
+}
+
+func TestDirectoryAuthenticate(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		bindDN   string
+		bindPass string
+	}{
+		{uc: "with service account", bindDN: "cn=service,dc=example,dc=org", bindPass: "service-secret"},
+		{uc: "with anonymous access"},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			srv := testsupport.NewLDAPServer(t, nil, false, testEntries()...)
+			srv.RequireBind = len(tc.bindDN) != 0
+
+			dir, err := NewDirectory(Connection{URL: srv.URL, BindDN: tc.bindDN, BindPassword: tc.bindPass})
+			require.NoError(t, err)
+
+			// WHEN & THEN
+			require.NoError(t, dir.Authenticate("uid=alice,ou=users,dc=example,dc=org", "alice-secret"))
+
+			err = dir.Authenticate("uid=alice,ou=users,dc=example,dc=org", "wrong")
+			require.ErrorIs(t, err, heimdall.ErrAuthentication)
+
+			err = dir.Authenticate("uid=alice,ou=users,dc=example,dc=org", "")
+			require.ErrorIs(t, err, heimdall.ErrAuthentication)
+
+			err = dir.Authenticate("uid=bob,ou=users,dc=example,dc=org", "bob-secret")
+			require.ErrorIs(t, err, heimdall.ErrAuthentication)
+
+			// the service binding has been restored on the reused connection
+			_, err = dir.FindEntry(Search{BaseDN: "dc=example,dc=org"}, "(uid=alice)", nil)
+			require.NoError(t, err)
+
+			assert.Equal(t, 1, srv.Connections())
+		})
+	}
+}
+
+func TestDirectoryGroups(t *testing.T) {
+	t.Parallel()
+
+	srv := testsupport.NewLDAPServer(t, nil, false, testEntries()...)
+
+	dir, err := NewDirectory(Connection{URL: srv.URL})
+	require.NoError(t, err)
+
+	search := GroupSearch{Search: Search{BaseDN: "ou=groups,dc=example,dc=org"}}
+
+	// direct groups only
+	groups, err := dir.Groups(search, "uid=alice,ou=users,dc=example,dc=org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devs"}, groups)
+
+	// nested groups with a cycle between staff and all
+	search.Nested = true
+
+	groups, err = dir.Groups(search, "uid=alice,ou=users,dc=example,dc=org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"devs", "staff", "all"}, groups)
+
+	// custom filter and name attribute
+	groups, err = dir.Groups(GroupSearch{
+		Search:        Search{BaseDN: "ou=groups,dc=example,dc=org", Scope: "one"},
+		Filter:        "(member={dn})",
+		NameAttribute: "CN",
+	}, "cn=staff,ou=groups,dc=example,dc=org")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"all"}, groups)
+
+	// no groups
+	groups, err = dir.Groups(search, "uid=bob,ou=users,dc=example,dc=org")
+	require.NoError(t, err)
+	assert.Empty(t, groups)
+}
+
+func TestDirectoryWithTLS(t *testing.T) {
+	t.Parallel()
+
+	tlsConf, trustStore := newServerTLSConfig(t)
+
+	for _, tc := range []struct {
+		uc         string
+		startTLS   bool
+		trustStore truststore.TrustStore
+		err        bool
+	}{
+		{uc: "ldaps with trust store", trustStore: trustStore},
+		{uc: "ldaps without trust store", err: true},
+		{uc: "start tls with trust store", startTLS: true, trustStore: trustStore},
+		{uc: "start tls without trust store", startTLS: true, err: true},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			srv := testsupport.NewLDAPServer(t, tlsConf, tc.startTLS, testEntries()...)
+
+			dir, err := NewDirectory(Connection{
+				URL:        srv.URL,
+				StartTLS:   tc.startTLS,
+				TrustStore: tc.trustStore,
+				Timeout:    time.Second,
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			_, err = dir.FindEntry(Search{BaseDN: "dc=example,dc=org"}, "(uid=alice)", nil)
+
+			// THEN
+			if tc.err {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestReplacePlaceholder(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, `(&(uid=foo\2a\29)(mail=foo\2a\29))`,
+		ReplacePlaceholder("(&(uid={username})(mail={username}))", "username", "foo*)"))
+}
@@ -0,0 +1,105 @@
+package ldap
+
+import (
+	"crypto/tls"
+	"net"
+
+	goldap "github.com/go-ldap/ldap/v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// pool keeps up to the configured amount of idle connections, which are bound with the service
+// account (if configured). Connections are used exclusively while acquired, as the bind state is
+// a property of the connection.
+type pool struct {
+	conf   Connection
+	tlsCfg *tls.Config
+	idle   chan *goldap.Conn
+}
+
+func newPool(conf Connection) (*pool, error) {
+	tlsCfg, err := conf.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	return &pool{conf: conf, tlsCfg: tlsCfg, idle: make(chan *goldap.Conn, conf.poolSize())}, nil
+}
+
+func (p *pool) acquire() (*goldap.Conn, error) {
+	for {
+		select {
+		case conn := <-p.idle:
+			if !conn.IsClosing() {
+				return conn, nil
+			}
+		default:
+			return p.dial()
+		}
+	}
+}
+
+func (p *pool) release(conn *goldap.Conn) {
+	if conn.IsClosing() {
+		return
+	}
+
+	select {
+	case p.idle <- conn:
+	default:
+		conn.Close()
+	}
+}
+
+func (p *pool) dial() (*goldap.Conn, error) {
+	conn, err := goldap.DialURL(p.conf.URL,
+		goldap.DialWithTLSConfig(p.tlsCfg),
+		goldap.DialWithDialer(&net.Dialer{Timeout: p.conf.timeout()}))
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to connect to ldap server").
+			CausedBy(err)
+	}
+
+	if p.conf.StartTLS {
+		if err = conn.StartTLS(p.tlsCfg); err != nil {
+			conn.Close()
+
+			return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to start tls").
+				CausedBy(err)
+		}
+	}
+
+	conn.SetTimeout(p.conf.timeout())
+
+	// fresh connections are anonymous
+	if len(p.conf.BindDN) == 0 {
+		return conn, nil
+	}
+
+	if err = p.bind(conn); err != nil {
+		conn.Close()
+
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func (p *pool) bind(conn *goldap.Conn) error {
+	var err error
+
+	if len(p.conf.BindDN) != 0 {
+		err = conn.Bind(p.conf.BindDN, p.conf.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to bind to ldap server").
+			CausedBy(err)
+	}
+
+	return nil
+}
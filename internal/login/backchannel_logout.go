@@ -0,0 +1,81 @@
+package login
+
+import (
+	"context"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	backChannelLogoutEvent = "http://schemas.openid.net/event/backchannel-logout"
+	// logout tokens are accepted only if issued within that time and not twice within it
+	logoutTokenReplayWindow = 5 * time.Minute
+)
+
+// BackChannelLogout terminates the sessions referenced by the given logout token according to
+// https://openid.net/specs/openid-connect-backchannel-1_0.html. If the token references a session
+// of the provider (sid claim), only the heimdall sessions created for it are terminated. Otherwise,
+// all sessions of the subject (sub claim) are terminated and all other cache entries related to that
+// subject are invalidated as well.
+func (rp *RelyingParty) BackChannelLogout(ctx context.Context, rawToken string) error {
+	if len(rawToken) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "no logout token present")
+	}
+
+	claims, err := rp.verifyProviderToken(ctx, rawToken, "logout token", heimdall.ErrArgument)
+	if err != nil {
+		return err
+	}
+
+	iat, ok := claims["iat"].(float64)
+	if !ok {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token does not contain an iat")
+	}
+
+	// older tokens could be replayed, as used tokens are remembered only for the replay window
+	if time.Unix(int64(iat), 0).Before(time.Now().Add(-logoutTokenReplayWindow)) {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token has been issued too long ago")
+	}
+
+	events, _ := claims["events"].(map[string]any)
+	if _, ok := events[backChannelLogoutEvent]; !ok {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token does not contain the logout event")
+	}
+
+	if _, ok := claims["nonce"]; ok {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token must not contain a nonce")
+	}
+
+	sub, _ := claims["sub"].(string)
+	sid, _ := claims["sid"].(string)
+
+	if len(sub) == 0 && len(sid) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token contains neither sub nor sid")
+	}
+
+	jti, _ := claims["jti"].(string)
+	if len(jti) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token does not contain a jti")
+	}
+
+	cch := cache.Namespaced(rp.cch, cache.NamespaceLogin)
+	replayKey := "login-logout-token-" + jti
+
+	if cch.Get(replayKey) != nil {
+		return errorchain.NewWithMessage(heimdall.ErrArgument, "logout token has already been used")
+	}
+
+	// tokens issued in the future within the leeway stay acceptable for slightly longer than the window
+	cch.Set(replayKey, true, logoutTokenReplayWindow+idTokenValidationLeeway)
+
+	if len(sid) != 0 {
+		cache.Invalidate(cch, cache.SessionTag(sid))
+	} else {
+		cache.Invalidate(cch, cache.SubjectTag(sub))
+	}
+
+	return nil
+}
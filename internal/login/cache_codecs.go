@@ -0,0 +1,9 @@
+package login
+
+import cachecodec "github.com/dadrus/heimdall/internal/cache/codec"
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	cachecodec.Register[*Session]("login.session", cachecodec.JSON[*Session]{})
+}
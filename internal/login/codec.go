@@ -0,0 +1,56 @@
+package login
+
+import (
+	"crypto/sha256"
+
+	"github.com/goccy/go-json"
+	"gopkg.in/square/go-jose.v2"
+)
+
+// codec protects the state kept in cookies by encrypting it with a key
+// derived from the configured secret.
+type codec struct {
+	key []byte
+}
+
+func newCodec(secret string) codec {
+	key := sha256.Sum256([]byte(secret))
+
+	return codec{key: key[:]}
+}
+
+func (c codec) encode(value any) (string, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+
+	encrypter, err := jose.NewEncrypter(
+		jose.A256GCM,
+		jose.Recipient{Algorithm: jose.DIRECT, Key: c.key},
+		&jose.EncrypterOptions{Compression: jose.DEFLATE})
+	if err != nil {
+		return "", err
+	}
+
+	obj, err := encrypter.Encrypt(raw)
+	if err != nil {
+		return "", err
+	}
+
+	return obj.CompactSerialize()
+}
+
+func (c codec) decode(value string, target any) error {
+	obj, err := jose.ParseEncrypted(value)
+	if err != nil {
+		return err
+	}
+
+	raw, err := obj.Decrypt(c.key)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, target)
+}
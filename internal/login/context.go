@@ -0,0 +1,20 @@
+package login
+
+import "context"
+
+type ctxKey struct{}
+
+// WithContext returns a copy of ctx with the relying party associated.
+func WithContext(ctx context.Context, rp *RelyingParty) context.Context {
+	return context.WithValue(ctx, ctxKey{}, rp)
+}
+
+// Ctx returns the RelyingParty associated with the ctx. If no relying party is
+// associated, nil is returned.
+func Ctx(ctx context.Context) *RelyingParty {
+	if rp, ok := ctx.Value(ctxKey{}).(*RelyingParty); ok {
+		return rp
+	}
+
+	return nil
+}
@@ -0,0 +1,26 @@
+package login
+
+import (
+	"github.com/rs/zerolog"
+	"go.uber.org/fx"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+)
+
+// nolint
+var Module = fx.Options(
+	fx.Provide(newRelyingPartyFromConfig),
+)
+
+func newRelyingPartyFromConfig(conf config.Configuration, cch cache.Cache, logger zerolog.Logger) (*RelyingParty, error) {
+	if conf.Login == nil {
+		logger.Info().Msg("Login is not configured")
+
+		return nil, nil // nolint: nilnil
+	}
+
+	logger.Info().Str("_issuer", conf.Login.Issuer).Msg("Configuring OpenID Connect login")
+
+	return NewRelyingParty(*conf.Login, cch)
+}
@@ -0,0 +1,276 @@
+package login
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"golang.org/x/exp/slices"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const idTokenValidationLeeway = 10 * time.Second
+
+// nolint: gochecknoglobals
+var allowedIDTokenAlgorithms = []string{
+	string(jose.ES256), string(jose.ES384), string(jose.ES512),
+	string(jose.PS256), string(jose.PS384), string(jose.PS512),
+	string(jose.RS256), string(jose.RS384), string(jose.RS512),
+}
+
+type providerMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	EndSessionEndpoint    string `json:"end_session_endpoint"`
+}
+
+type tokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+	IDToken      string `json:"id_token"`
+}
+
+func (r *tokenResponse) expiry(now time.Time) time.Time {
+	if r.ExpiresIn == 0 {
+		return time.Time{}
+	}
+
+	return now.Add(time.Duration(r.ExpiresIn) * time.Second)
+}
+
+func (rp *RelyingParty) providerMetadata(ctx context.Context) (*providerMetadata, error) {
+	rp.mut.Lock()
+	defer rp.mut.Unlock()
+
+	if rp.metadata != nil {
+		return rp.metadata, nil
+	}
+
+	ept := endpoint.Endpoint{
+		URL:     strings.TrimSuffix(rp.issuer, "/") + "/.well-known/openid-configuration",
+		Method:  http.MethodGet,
+		Headers: map[string]string{"Accept": "application/json"},
+	}
+
+	rawData, err := ept.SendRequest(ctx, nil, nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to fetch provider metadata").
+			CausedBy(err)
+	}
+
+	var metadata providerMetadata
+	if err = json.Unmarshal(rawData, &metadata); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal provider metadata").
+			CausedBy(err)
+	}
+
+	if metadata.Issuer != rp.issuer {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"issuer '%s' from provider metadata does not match configured issuer", metadata.Issuer)
+	}
+
+	rp.metadata = &metadata
+
+	return rp.metadata, nil
+}
+
+func (rp *RelyingParty) signingKey(ctx context.Context, metadata *providerMetadata, keyID string) (any, error) {
+	rp.mut.Lock()
+	defer rp.mut.Unlock()
+
+	if key := findKey(rp.keys, keyID); key != nil {
+		return key.Key, nil
+	}
+
+	// the provider might have rotated its keys
+	ept := endpoint.Endpoint{
+		URL:     metadata.JWKSURI,
+		Method:  http.MethodGet,
+		Headers: map[string]string{"Accept": "application/json"},
+	}
+
+	rawData, err := ept.SendRequest(ctx, nil, nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "failed to fetch provider keys").
+			CausedBy(err)
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err = json.Unmarshal(rawData, &jwks); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal provider keys").
+			CausedBy(err)
+	}
+
+	rp.keys = &jwks
+
+	if key := findKey(rp.keys, keyID); key != nil {
+		return key.Key, nil
+	}
+
+	return nil, errorchain.NewWithMessagef(heimdall.ErrAuthentication,
+		"no key found for the keyID='%s' referenced in the id token", keyID)
+}
+
+func findKey(jwks *jose.JSONWebKeySet, keyID string) *jose.JSONWebKey {
+	if jwks == nil {
+		return nil
+	}
+
+	if len(keyID) != 0 {
+		if keys := jwks.Key(keyID); len(keys) != 0 {
+			return &keys[0]
+		}
+
+		return nil
+	}
+
+	// without a key id, the key can only be identified unambiguously if there is just one
+	if len(jwks.Keys) == 1 {
+		return &jwks.Keys[0]
+	}
+
+	return nil
+}
+
+func (rp *RelyingParty) exchangeCode(ctx context.Context, code, verifier string) (*tokenResponse, error) {
+	return rp.requestTokens(ctx, url.Values{
+		"grant_type":    []string{"authorization_code"},
+		"code":          []string{code},
+		"redirect_uri":  []string{rp.redirectURL.String()},
+		"code_verifier": []string{verifier},
+	})
+}
+
+func (rp *RelyingParty) refreshTokens(ctx context.Context, refreshToken string) (*tokenResponse, error) {
+	return rp.requestTokens(ctx, url.Values{
+		"grant_type":    []string{"refresh_token"},
+		"refresh_token": []string{refreshToken},
+	})
+}
+
+func (rp *RelyingParty) requestTokens(ctx context.Context, data url.Values) (*tokenResponse, error) {
+	metadata, err := rp.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ept := endpoint.Endpoint{
+		URL:    metadata.TokenEndpoint,
+		Method: http.MethodPost,
+		Headers: map[string]string{
+			"Content-Type": "application/x-www-form-urlencoded",
+			"Accept":       "application/json",
+		},
+	}
+
+	if len(rp.clientSecret) != 0 {
+		ept.AuthStrategy = &endpoint.BasicAuthStrategy{
+			User:     url.QueryEscape(rp.clientID),
+			Password: url.QueryEscape(rp.clientSecret),
+		}
+	} else {
+		data.Set("client_id", rp.clientID)
+	}
+
+	rawData, err := ept.SendRequest(ctx, strings.NewReader(data.Encode()), nil)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "token request failed").
+			CausedBy(err)
+	}
+
+	var resp tokenResponse
+	if err = json.Unmarshal(rawData, &resp); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal token response").
+			CausedBy(err)
+	}
+
+	return &resp, nil
+}
+
+func (rp *RelyingParty) verifyIDToken(ctx context.Context, rawToken, nonce string) (map[string]any, error) {
+	claims, err := rp.verifyProviderToken(ctx, rawToken, "id token", heimdall.ErrAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	if tokenNonce, _ := claims["nonce"].(string); len(nonce) != 0 && tokenNonce != nonce {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// verifyProviderToken verifies the signature, the issuer, the audience and the time based claims
+// of a JWT issued by the provider, like an id token, and returns its claims. An invalid token is
+// reported using the given error type.
+func (rp *RelyingParty) verifyProviderToken(
+	ctx context.Context, rawToken, kind string, errType error,
+) (map[string]any, error) {
+	metadata, err := rp.providerMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.ParseSigned(rawToken)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(errType, "failed to parse %s", kind).
+			CausedBy(err)
+	}
+
+	header := token.Headers[0]
+	if !slices.Contains(allowedIDTokenAlgorithms, header.Algorithm) {
+		return nil, errorchain.NewWithMessagef(errType,
+			"%s algorithm is not allowed", header.Algorithm)
+	}
+
+	key, err := rp.signingKey(ctx, metadata, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		claims    map[string]any
+		stdClaims jwt.Claims
+	)
+
+	if err = token.Claims(key, &claims, &stdClaims); err != nil {
+		return nil, errorchain.NewWithMessagef(errType, "failed to verify %s", kind).
+			CausedBy(err)
+	}
+
+	if err = stdClaims.ValidateWithLeeway(jwt.Expected{
+		Issuer:   metadata.Issuer,
+		Audience: jwt.Audience{rp.clientID},
+		Time:     time.Now(),
+	}, idTokenValidationLeeway); err != nil {
+		return nil, errorchain.NewWithMessagef(errType, "%s is invalid", kind).
+			CausedBy(err)
+	}
+
+	return claims, nil
+}
+
+func joinScopes(scopes []string) string { return strings.Join(scopes, " ") }
+
+func randomString(length int) (string, error) {
+	buf := make([]byte, length)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,376 @@
+package login
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slices"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	defaultSessionCookieName = "heimdall_session"
+	defaultLoginPath         = "/_heimdall/login"
+	defaultLogoutPath        = "/_heimdall/logout"
+	defaultSessionMaxAge     = 24 * time.Hour
+
+	loginStateMaxAge  = 10 * time.Minute
+	minSecretLength   = 32
+	randomValueLength = 32
+)
+
+type loginState struct {
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ReturnTo  string `json:"return_to"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// CookieSettings describes the attributes to be used for the cookies set by the login flow.
+type CookieSettings struct {
+	Name   string
+	Path   string
+	Domain string
+	MaxAge time.Duration
+}
+
+// RelyingParty implements the OpenID Connect authorization code flow with PKCE and manages the
+// sessions of the users logged in that way.
+type RelyingParty struct {
+	issuer                string
+	clientID              string
+	clientSecret          string
+	scopes                []string
+	redirectURL           *url.URL
+	postLogoutRedirectURL string
+	loginPath             string
+	logoutPath            string
+	backChannelLogoutPath string
+	cookieName            string
+	cookieDomain          string
+	maxAge                time.Duration
+	c                     codec
+	store                 sessionStore
+	cch                   cache.Cache
+
+	mut      sync.Mutex
+	metadata *providerMetadata
+	keys     *jose.JSONWebKeySet
+}
+
+func NewRelyingParty(conf config.LoginConfig, cch cache.Cache) (*RelyingParty, error) {
+	if len(conf.Issuer) == 0 || len(conf.ClientID) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"login requires issuer and client_id to be set")
+	}
+
+	redirectURL, err := url.Parse(conf.RedirectURL)
+	if err != nil || !redirectURL.IsAbs() {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"login requires redirect_url to be set to an absolute URL")
+	}
+
+	if len(conf.Session.Secret) < minSecretLength {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"login requires session secret to be at least %d characters long", minSecretLength)
+	}
+
+	cdc := newCodec(conf.Session.Secret)
+
+	var store sessionStore
+
+	switch conf.Session.Store {
+	case "", sessionStoreCookie:
+		store = cookieSessionStore{c: cdc}
+	case sessionStoreCache:
+		store = cacheSessionStore{cch: cache.Namespaced(cch, cache.NamespaceLogin)}
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported login session store '%s'", conf.Session.Store)
+	}
+
+	if len(conf.BackChannelLogoutPath) != 0 && conf.Session.Store != sessionStoreCache {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"back-channel logout requires the cache session store")
+	}
+
+	scopes := conf.Scopes
+	if !slices.Contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	return &RelyingParty{
+		issuer:                conf.Issuer,
+		clientID:              conf.ClientID,
+		clientSecret:          conf.ClientSecret,
+		scopes:                scopes,
+		redirectURL:           redirectURL,
+		postLogoutRedirectURL: conf.PostLogoutRedirectURL,
+		loginPath:             x.IfThenElse(len(conf.LoginPath) != 0, conf.LoginPath, defaultLoginPath),
+		logoutPath:            x.IfThenElse(len(conf.LogoutPath) != 0, conf.LogoutPath, defaultLogoutPath),
+		backChannelLogoutPath: conf.BackChannelLogoutPath,
+		cookieName: x.IfThenElse(len(conf.Session.CookieName) != 0,
+			conf.Session.CookieName, defaultSessionCookieName),
+		cookieDomain: conf.Session.CookieDomain,
+		maxAge:       x.IfThenElse(conf.Session.MaxAge != 0, conf.Session.MaxAge, defaultSessionMaxAge),
+		c:            cdc,
+		store:        store,
+		cch:          cch,
+	}, nil
+}
+
+func (rp *RelyingParty) LoginPath() string { return rp.loginPath }
+
+func (rp *RelyingParty) LogoutPath() string { return rp.logoutPath }
+
+// BackChannelLogoutPath returns the path of the back-channel logout endpoint. An empty string is
+// returned, if back-channel logout is not enabled.
+func (rp *RelyingParty) BackChannelLogoutPath() string { return rp.backChannelLogoutPath }
+
+func (rp *RelyingParty) CallbackPath() string { return rp.redirectURL.Path }
+
+func (rp *RelyingParty) SessionCookie() CookieSettings {
+	return CookieSettings{Name: rp.cookieName, Path: "/", Domain: rp.cookieDomain, MaxAge: rp.maxAge}
+}
+
+func (rp *RelyingParty) LoginStateCookie() CookieSettings {
+	return CookieSettings{
+		Name:   rp.cookieName + "_login",
+		Path:   rp.CallbackPath(),
+		Domain: rp.cookieDomain,
+		MaxAge: loginStateMaxAge,
+	}
+}
+
+// StartLogin creates the URL to redirect the user agent to for authentication purposes, as well as
+// the value of the login state cookie, which is required to finish the login.
+func (rp *RelyingParty) StartLogin(ctx context.Context, returnTo string) (string, string, error) {
+	metadata, err := rp.providerMetadata(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := newLoginState(returnTo)
+	if err != nil {
+		return "", "", err
+	}
+
+	stateValue, err := rp.c.encode(state)
+	if err != nil {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to encode login state").
+			CausedBy(err)
+	}
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrInternal, "invalid authorization endpoint").
+			CausedBy(err)
+	}
+
+	challenge := sha256.Sum256([]byte(state.Verifier))
+
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", rp.clientID)
+	query.Set("redirect_uri", rp.redirectURL.String())
+	query.Set("scope", joinScopes(rp.scopes))
+	query.Set("state", state.State)
+	query.Set("nonce", state.Nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	return authURL.String(), stateValue, nil
+}
+
+// FinishLogin verifies the response from the authorization endpoint, exchanges the received code
+// and creates a new session. It returns the value of the session cookie, as well as the URL to
+// redirect the user agent to.
+func (rp *RelyingParty) FinishLogin(ctx context.Context, stateValue, state, code string) (string, string, error) {
+	var ls loginState
+
+	if len(stateValue) == 0 {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrArgument, "no login state present")
+	}
+
+	if err := rp.c.decode(stateValue, &ls); err != nil {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrArgument, "failed to decode login state").
+			CausedBy(err)
+	}
+
+	if time.Now().Unix() > ls.ExpiresAt {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrArgument, "login state expired")
+	}
+
+	if len(state) == 0 || state != ls.State {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrArgument, "state mismatch")
+	}
+
+	if len(code) == 0 {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrArgument, "no authorization code present")
+	}
+
+	tokens, err := rp.exchangeCode(ctx, code, ls.Verifier)
+	if err != nil {
+		return "", "", err
+	}
+
+	claims, err := rp.verifyIDToken(ctx, tokens.IDToken, ls.Nonce)
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	sess := &Session{
+		Claims:       claims,
+		IDToken:      tokens.IDToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: tokens.RefreshToken,
+		TokenExpiry:  tokens.expiry(now),
+		ExpiresAt:    now.Add(rp.maxAge),
+	}
+
+	value, err := rp.store.save(ctx, "", sess)
+	if err != nil {
+		return "", "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to store session").CausedBy(err)
+	}
+
+	return value, x.IfThenElse(len(ls.ReturnTo) != 0, ls.ReturnTo, "/"), nil
+}
+
+// Session returns the session referenced by the given session cookie value. If the tokens of the
+// session expired and the session can be updated in place, the tokens are refreshed.
+func (rp *RelyingParty) Session(ctx context.Context, value string) (*Session, error) {
+	sess, err := rp.store.load(ctx, value)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to load session").
+			CausedBy(err)
+	}
+
+	now := time.Now()
+	if sess.expired(now) {
+		rp.store.delete(ctx, value)
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "session expired")
+	}
+
+	if !sess.tokenExpired(now) || len(sess.RefreshToken) == 0 || !rp.store.supportsUpdate() {
+		return sess, nil
+	}
+
+	refreshed, err := rp.refresh(ctx, sess)
+	if err != nil {
+		rp.store.delete(ctx, value)
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrAuthentication, "failed to refresh session").
+			CausedBy(err)
+	}
+
+	if _, err = rp.store.save(ctx, value, refreshed); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to store session").CausedBy(err)
+	}
+
+	return refreshed, nil
+}
+
+// Logout terminates the session referenced by the given session cookie value (if any) and returns
+// the URL to redirect the user agent to. If the provider supports RP-initiated logout, this is its
+// end session endpoint.
+func (rp *RelyingParty) Logout(ctx context.Context, value string) string {
+	var idToken string
+
+	if len(value) != 0 {
+		if sess, err := rp.store.load(ctx, value); err == nil {
+			idToken = sess.IDToken
+		}
+
+		rp.store.delete(ctx, value)
+	}
+
+	metadata, err := rp.providerMetadata(ctx)
+	if err != nil || len(metadata.EndSessionEndpoint) == 0 {
+		return x.IfThenElse(len(rp.postLogoutRedirectURL) != 0, rp.postLogoutRedirectURL, "/")
+	}
+
+	logoutURL, err := url.Parse(metadata.EndSessionEndpoint)
+	if err != nil {
+		return x.IfThenElse(len(rp.postLogoutRedirectURL) != 0, rp.postLogoutRedirectURL, "/")
+	}
+
+	query := logoutURL.Query()
+	query.Set("client_id", rp.clientID)
+
+	if len(idToken) != 0 {
+		query.Set("id_token_hint", idToken)
+	}
+
+	if len(rp.postLogoutRedirectURL) != 0 {
+		query.Set("post_logout_redirect_uri", rp.postLogoutRedirectURL)
+	}
+
+	logoutURL.RawQuery = query.Encode()
+
+	return logoutURL.String()
+}
+
+func (rp *RelyingParty) refresh(ctx context.Context, sess *Session) (*Session, error) {
+	tokens, err := rp.refreshTokens(ctx, sess.RefreshToken)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshed := &Session{
+		Claims:       sess.Claims,
+		IDToken:      sess.IDToken,
+		AccessToken:  tokens.AccessToken,
+		RefreshToken: x.IfThenElse(len(tokens.RefreshToken) != 0, tokens.RefreshToken, sess.RefreshToken),
+		TokenExpiry:  tokens.expiry(time.Now()),
+		ExpiresAt:    sess.ExpiresAt,
+	}
+
+	// the provider may issue a new id token, which does not contain a nonce
+	if len(tokens.IDToken) != 0 {
+		claims, err := rp.verifyIDToken(ctx, tokens.IDToken, "")
+		if err != nil {
+			return nil, err
+		}
+
+		refreshed.Claims = claims
+		refreshed.IDToken = tokens.IDToken
+	}
+
+	return refreshed, nil
+}
+
+func newLoginState(returnTo string) (*loginState, error) {
+	var (
+		values [3]string
+		err    error
+	)
+
+	for idx := range values {
+		if values[idx], err = randomString(randomValueLength); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to generate login state").
+				CausedBy(err)
+		}
+	}
+
+	return &loginState{
+		State:     values[0],
+		Nonce:     values[1],
+		Verifier:  values[2],
+		ReturnTo:  returnTo,
+		ExpiresAt: time.Now().Add(loginStateMaxAge).Unix(),
+	}, nil
+}
@@ -0,0 +1,843 @@
+package login
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+const (
+	testClientID     = "heimdall"
+	testClientSecret = "secret"
+	testSecret       = "0123456789abcdefghijklmnopqrstuvwxyz"
+)
+
+type authRequest struct {
+	nonce     string
+	challenge string
+}
+
+type testProvider struct {
+	t   *testing.T
+	srv *httptest.Server
+	key *ecdsa.PrivateKey
+
+	mut      sync.Mutex
+	codes    map[string]authRequest
+	refreshs int
+
+	idTokenNonce func(nonce string) string
+	expiresIn    int64
+}
+
+func newTestProvider(t *testing.T) *testProvider {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	prov := &testProvider{
+		t:            t,
+		key:          key,
+		codes:        make(map[string]authRequest),
+		idTokenNonce: func(nonce string) string { return nonce },
+		expiresIn:    300,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", prov.discovery)
+	mux.HandleFunc("/jwks", prov.jwks)
+	mux.HandleFunc("/token", prov.token)
+
+	prov.srv = httptest.NewServer(mux)
+	t.Cleanup(prov.srv.Close)
+
+	return prov
+}
+
+func (p *testProvider) writeJSON(w http.ResponseWriter, value any) {
+	raw, err := json.Marshal(value)
+	require.NoError(p.t, err)
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(raw)
+	require.NoError(p.t, err)
+}
+
+func (p *testProvider) discovery(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, map[string]string{
+		"issuer":                 p.srv.URL,
+		"authorization_endpoint": p.srv.URL + "/authorize",
+		"token_endpoint":         p.srv.URL + "/token",
+		"jwks_uri":               p.srv.URL + "/jwks",
+		"end_session_endpoint":   p.srv.URL + "/logout",
+	})
+}
+
+func (p *testProvider) jwks(w http.ResponseWriter, _ *http.Request) {
+	p.writeJSON(w, jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+		{KeyID: "key1", Algorithm: string(jose.ES256), Key: &p.key.PublicKey, Use: "sig"},
+	}})
+}
+
+func (p *testProvider) token(w http.ResponseWriter, r *http.Request) {
+	user, password, ok := r.BasicAuth()
+	if !ok || user != testClientID || password != testClientSecret {
+		w.WriteHeader(http.StatusUnauthorized)
+
+		return
+	}
+
+	require.NoError(p.t, r.ParseForm())
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		req, ok := p.codes[r.PostForm.Get("code")]
+		delete(p.codes, r.PostForm.Get("code"))
+
+		challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != req.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		p.writeJSON(w, map[string]any{
+			"access_token":  "access-token-0",
+			"token_type":    "Bearer",
+			"expires_in":    p.expiresIn,
+			"refresh_token": "refresh-token-0",
+			"id_token":      p.idToken(p.idTokenNonce(req.nonce)),
+		})
+	case "refresh_token":
+		if r.PostForm.Get("refresh_token") != "refresh-token-0" {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		p.refreshs++
+		p.writeJSON(w, map[string]any{
+			"access_token": "access-token-1",
+			"token_type":   "Bearer",
+			"expires_in":   300,
+		})
+	default:
+		w.WriteHeader(http.StatusBadRequest)
+	}
+}
+
+func (p *testProvider) idToken(nonce string) string {
+	now := time.Now()
+	claims := map[string]any{
+		"iss":   p.srv.URL,
+		"sub":   "foo",
+		"sid":   "bar",
+		"aud":   testClientID,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Minute).Unix(),
+		"email": "foo@bar.baz",
+	}
+
+	if len(nonce) != 0 {
+		claims["nonce"] = nonce
+	}
+
+	return p.sign(claims)
+}
+
+func (p *testProvider) logoutToken(modify func(claims map[string]any)) string {
+	now := time.Now()
+	claims := map[string]any{
+		"iss":    p.srv.URL,
+		"sub":    "foo",
+		"aud":    testClientID,
+		"iat":    now.Unix(),
+		"exp":    now.Add(time.Minute).Unix(),
+		"jti":    "logout-" + strconv.FormatInt(now.UnixNano(), 10),
+		"events": map[string]any{backChannelLogoutEvent: map[string]any{}},
+	}
+
+	if modify != nil {
+		modify(claims)
+	}
+
+	return p.sign(claims)
+}
+
+func (p *testProvider) sign(claims map[string]any) string {
+	signer, err := jose.NewSigner(
+		jose.SigningKey{Algorithm: jose.ES256, Key: p.key},
+		(&jose.SignerOptions{}).WithType("JWT").WithHeader("kid", "key1"))
+	require.NoError(p.t, err)
+
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	require.NoError(p.t, err)
+
+	return token
+}
+
+// authorize simulates the user authentication at the provider and returns the issued code.
+func (p *testProvider) authorize(authURL string) (string, string) {
+	parsed, err := url.Parse(authURL)
+	require.NoError(p.t, err)
+
+	query := parsed.Query()
+	code := "code-" + query.Get("state")
+
+	p.mut.Lock()
+	p.codes[code] = authRequest{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	p.mut.Unlock()
+
+	return code, query.Get("state")
+}
+
+func (p *testProvider) loginConfig(store string) config.LoginConfig {
+	return config.LoginConfig{
+		Issuer:                p.srv.URL,
+		ClientID:              testClientID,
+		ClientSecret:          testClientSecret,
+		Scopes:                []string{"profile"},
+		RedirectURL:           "https://heimdall.local/_heimdall/callback",
+		PostLogoutRedirectURL: "https://heimdall.local/",
+		Session: config.LoginSessionConfig{
+			Store:  store,
+			Secret: testSecret,
+		},
+	}
+}
+
+func TestNewRelyingParty(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		conf   config.LoginConfig
+		assert func(t *testing.T, err error, rp *RelyingParty)
+	}{
+		{
+			uc:   "without issuer",
+			conf: config.LoginConfig{ClientID: "foo"},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "issuer and client_id")
+			},
+		},
+		{
+			uc:   "with relative redirect url",
+			conf: config.LoginConfig{Issuer: "https://idp.local", ClientID: "foo", RedirectURL: "/callback"},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "redirect_url")
+			},
+		},
+		{
+			uc: "with too short secret",
+			conf: config.LoginConfig{
+				Issuer:      "https://idp.local",
+				ClientID:    "foo",
+				RedirectURL: "https://heimdall.local/callback",
+				Session:     config.LoginSessionConfig{Secret: "foo"},
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "secret")
+			},
+		},
+		{
+			uc: "with unsupported session store",
+			conf: config.LoginConfig{
+				Issuer:      "https://idp.local",
+				ClientID:    "foo",
+				RedirectURL: "https://heimdall.local/callback",
+				Session:     config.LoginSessionConfig{Secret: testSecret, Store: "foo"},
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported")
+			},
+		},
+		{
+			uc: "with back-channel logout and cookie session store",
+			conf: config.LoginConfig{
+				Issuer:                "https://idp.local",
+				ClientID:              "foo",
+				RedirectURL:           "https://heimdall.local/callback",
+				BackChannelLogoutPath: "/backchannel_logout",
+				Session:               config.LoginSessionConfig{Secret: testSecret},
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "back-channel logout")
+			},
+		},
+		{
+			uc: "with minimal configuration",
+			conf: config.LoginConfig{
+				Issuer:      "https://idp.local",
+				ClientID:    "foo",
+				RedirectURL: "https://heimdall.local/callback",
+				Session:     config.LoginSessionConfig{Secret: testSecret},
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"openid"}, rp.scopes)
+				assert.Equal(t, defaultLoginPath, rp.LoginPath())
+				assert.Equal(t, defaultLogoutPath, rp.LogoutPath())
+				assert.Empty(t, rp.BackChannelLogoutPath())
+				assert.Equal(t, "/callback", rp.CallbackPath())
+				assert.Equal(t, CookieSettings{
+					Name:   defaultSessionCookieName,
+					Path:   "/",
+					MaxAge: defaultSessionMaxAge,
+				}, rp.SessionCookie())
+				assert.Equal(t, defaultSessionCookieName+"_login", rp.LoginStateCookie().Name)
+				assert.Equal(t, "/callback", rp.LoginStateCookie().Path)
+				assert.IsType(t, cookieSessionStore{}, rp.store)
+			},
+		},
+		{
+			uc: "with full configuration",
+			conf: config.LoginConfig{
+				Issuer:                "https://idp.local",
+				ClientID:              "foo",
+				ClientSecret:          "bar",
+				Scopes:                []string{"openid", "email"},
+				RedirectURL:           "https://heimdall.local/callback",
+				LoginPath:             "/login",
+				LogoutPath:            "/logout",
+				BackChannelLogoutPath: "/backchannel_logout",
+				Session: config.LoginSessionConfig{
+					Store:        "cache",
+					CookieName:   "session",
+					CookieDomain: "heimdall.local",
+					Secret:       testSecret,
+					MaxAge:       time.Hour,
+				},
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []string{"openid", "email"}, rp.scopes)
+				assert.Equal(t, "/login", rp.LoginPath())
+				assert.Equal(t, "/logout", rp.LogoutPath())
+				assert.Equal(t, "/backchannel_logout", rp.BackChannelLogoutPath())
+				assert.Equal(t, CookieSettings{
+					Name:   "session",
+					Path:   "/",
+					Domain: "heimdall.local",
+					MaxAge: time.Hour,
+				}, rp.SessionCookie())
+				assert.IsType(t, cacheSessionStore{}, rp.store)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			rp, err := NewRelyingParty(tc.conf, memory.New())
+
+			// THEN
+			tc.assert(t, err, rp)
+		})
+	}
+}
+
+func TestRelyingPartyLoginFlow(t *testing.T) {
+	t.Parallel()
+
+	for _, store := range []string{sessionStoreCookie, sessionStoreCache} {
+		store := store
+
+		t.Run("store="+store, func(t *testing.T) {
+			t.Parallel()
+
+			// GIVEN
+			ctx := context.Background()
+			prov := newTestProvider(t)
+
+			rp, err := NewRelyingParty(prov.loginConfig(store), memory.New())
+			require.NoError(t, err)
+
+			// WHEN
+			authURL, stateValue, err := rp.StartLogin(ctx, "/foo")
+
+			// THEN
+			require.NoError(t, err)
+			require.NotEmpty(t, stateValue)
+			assert.True(t, strings.HasPrefix(authURL, prov.srv.URL+"/authorize?"))
+
+			parsed, err := url.Parse(authURL)
+			require.NoError(t, err)
+
+			query := parsed.Query()
+			assert.Equal(t, "code", query.Get("response_type"))
+			assert.Equal(t, testClientID, query.Get("client_id"))
+			assert.Equal(t, "https://heimdall.local/_heimdall/callback", query.Get("redirect_uri"))
+			assert.Equal(t, "openid profile", query.Get("scope"))
+			assert.Equal(t, "S256", query.Get("code_challenge_method"))
+			assert.NotEmpty(t, query.Get("nonce"))
+			assert.NotEmpty(t, query.Get("code_challenge"))
+
+			// WHEN
+			code, state := prov.authorize(authURL)
+			sessionValue, returnTo, err := rp.FinishLogin(ctx, stateValue, state, code)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, "/foo", returnTo)
+			require.NotEmpty(t, sessionValue)
+
+			// WHEN
+			sess, err := rp.Session(ctx, sessionValue)
+
+			// THEN
+			require.NoError(t, err)
+			assert.Equal(t, "foo", sess.Claims["sub"])
+			assert.Equal(t, "foo@bar.baz", sess.Claims["email"])
+			assert.Equal(t, "access-token-0", sess.AccessToken)
+			assert.Equal(t, "refresh-token-0", sess.RefreshToken)
+
+			// WHEN
+			logoutURL := rp.Logout(ctx, sessionValue)
+
+			// THEN
+			parsed, err = url.Parse(logoutURL)
+			require.NoError(t, err)
+			assert.Equal(t, prov.srv.URL+"/logout", parsed.Scheme+"://"+parsed.Host+parsed.Path)
+			assert.Equal(t, sess.IDToken, parsed.Query().Get("id_token_hint"))
+			assert.Equal(t, "https://heimdall.local/", parsed.Query().Get("post_logout_redirect_uri"))
+
+			if store == sessionStoreCache {
+				_, err = rp.Session(ctx, sessionValue)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+			}
+		})
+	}
+}
+
+func TestRelyingPartyRefreshesExpiredTokens(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := context.Background()
+	prov := newTestProvider(t)
+	prov.expiresIn = -1
+
+	rp, err := NewRelyingParty(prov.loginConfig(sessionStoreCache), memory.New())
+	require.NoError(t, err)
+
+	authURL, stateValue, err := rp.StartLogin(ctx, "")
+	require.NoError(t, err)
+
+	code, state := prov.authorize(authURL)
+	sessionValue, returnTo, err := rp.FinishLogin(ctx, stateValue, state, code)
+	require.NoError(t, err)
+	assert.Equal(t, "/", returnTo)
+
+	// WHEN
+	sess, err := rp.Session(ctx, sessionValue)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 1, prov.refreshs)
+	assert.Equal(t, "access-token-1", sess.AccessToken)
+	assert.Equal(t, "refresh-token-0", sess.RefreshToken)
+	assert.Equal(t, "foo", sess.Claims["sub"])
+
+	// WHEN
+	sess, err = rp.Session(ctx, sessionValue)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, 1, prov.refreshs)
+	assert.Equal(t, "access-token-1", sess.AccessToken)
+}
+
+func TestRelyingPartyFinishLoginErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		configure func(t *testing.T, prov *testProvider)
+		modify    func(stateValue, state, code string) (string, string, string)
+		assert    func(t *testing.T, err error)
+	}{
+		{
+			uc: "without login state",
+			modify: func(_, state, code string) (string, string, string) {
+				return "", state, code
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no login state")
+			},
+		},
+		{
+			uc: "with tampered login state",
+			modify: func(stateValue, state, code string) (string, string, string) {
+				return stateValue + "foo", state, code
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc: "with state mismatch",
+			modify: func(stateValue, _, code string) (string, string, string) {
+				return stateValue, "foo", code
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "state mismatch")
+			},
+		},
+		{
+			uc: "with unknown code",
+			modify: func(stateValue, state, _ string) (string, string, string) {
+				return stateValue, state, "foo"
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+			},
+		},
+		{
+			uc: "with nonce mismatch",
+			configure: func(t *testing.T, prov *testProvider) {
+				t.Helper()
+
+				prov.idTokenNonce = func(string) string { return "foo" }
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "nonce mismatch")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := context.Background()
+			prov := newTestProvider(t)
+
+			if tc.configure != nil {
+				tc.configure(t, prov)
+			}
+
+			modify := tc.modify
+			if modify == nil {
+				modify = func(stateValue, state, code string) (string, string, string) {
+					return stateValue, state, code
+				}
+			}
+
+			rp, err := NewRelyingParty(prov.loginConfig(sessionStoreCookie), memory.New())
+			require.NoError(t, err)
+
+			authURL, stateValue, err := rp.StartLogin(ctx, "/foo")
+			require.NoError(t, err)
+
+			code, state := prov.authorize(authURL)
+
+			stateValue, state, code = modify(stateValue, state, code)
+
+			// WHEN
+			_, _, err = rp.FinishLogin(ctx, stateValue, state, code)
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
+
+func TestRelyingPartyBackChannelLogout(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		token  func(prov *testProvider) string
+		assert func(t *testing.T, err error, rp *RelyingParty, sessionValue string, cch *memory.InMemoryCache)
+	}{
+		{
+			uc:    "without logout token",
+			token: func(*testProvider) string { return "" },
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no logout token")
+			},
+		},
+		{
+			uc:    "with malformed logout token",
+			token: func(*testProvider) string { return "foo.bar.baz" },
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to parse logout token")
+			},
+		},
+		{
+			uc: "with logout token issued for another client",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { claims["aud"] = "foo" })
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "logout token is invalid")
+			},
+		},
+		{
+			uc: "without iat claim",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { delete(claims, "iat") })
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "iat")
+			},
+		},
+		{
+			uc: "with iat older than the replay window",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) {
+					claims["iat"] = time.Now().Add(-logoutTokenReplayWindow - time.Minute).Unix()
+				})
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "issued too long ago")
+			},
+		},
+		{
+			uc: "without logout event",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) {
+					claims["events"] = map[string]any{"http://foo.bar/event": map[string]any{}}
+				})
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "logout event")
+			},
+		},
+		{
+			uc: "with nonce claim",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { claims["nonce"] = "foo" })
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "nonce")
+			},
+		},
+		{
+			uc: "without sub and sid claims",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { delete(claims, "sub") })
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "neither sub nor sid")
+			},
+		},
+		{
+			uc: "without jti claim",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { delete(claims, "jti") })
+			},
+			assert: func(t *testing.T, err error, _ *RelyingParty, _ string, _ *memory.InMemoryCache) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "jti")
+			},
+		},
+		{
+			uc: "with logout token referencing another provider session",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { claims["sid"] = "baz" })
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty, sessionValue string, cch *memory.InMemoryCache) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, err = rp.Session(context.Background(), sessionValue)
+				require.NoError(t, err)
+				assert.NotNil(t, cch.Get("foo"))
+			},
+		},
+		{
+			uc: "with logout token referencing the provider session",
+			token: func(prov *testProvider) string {
+				return prov.logoutToken(func(claims map[string]any) { claims["sid"] = "bar" })
+			},
+			assert: func(t *testing.T, err error, rp *RelyingParty, sessionValue string, cch *memory.InMemoryCache) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, err = rp.Session(context.Background(), sessionValue)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+
+				// other entries related to the subject are kept
+				assert.NotNil(t, cch.Get("foo"))
+			},
+		},
+		{
+			uc:    "with logout token referencing the subject",
+			token: func(prov *testProvider) string { return prov.logoutToken(nil) },
+			assert: func(t *testing.T, err error, rp *RelyingParty, sessionValue string, cch *memory.InMemoryCache) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				_, err = rp.Session(context.Background(), sessionValue)
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Nil(t, cch.Get("foo"))
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := context.Background()
+			prov := newTestProvider(t)
+			cch := memory.New()
+
+			conf := prov.loginConfig(sessionStoreCache)
+			conf.BackChannelLogoutPath = "/backchannel_logout"
+
+			rp, err := NewRelyingParty(conf, cch)
+			require.NoError(t, err)
+
+			authURL, stateValue, err := rp.StartLogin(ctx, "/foo")
+			require.NoError(t, err)
+
+			code, state := prov.authorize(authURL)
+			sessionValue, _, err := rp.FinishLogin(ctx, stateValue, state, code)
+			require.NoError(t, err)
+
+			cch.Set("foo", "bar", time.Minute)
+			cch.Index("foo", time.Minute, cache.SubjectTag("foo"))
+
+			// WHEN
+			err = rp.BackChannelLogout(ctx, tc.token(prov))
+
+			// THEN
+			tc.assert(t, err, rp, sessionValue, cch)
+		})
+	}
+}
+
+func TestRelyingPartyBackChannelLogoutRejectsReplayedTokens(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := context.Background()
+	prov := newTestProvider(t)
+
+	conf := prov.loginConfig(sessionStoreCache)
+	conf.BackChannelLogoutPath = "/backchannel_logout"
+
+	rp, err := NewRelyingParty(conf, memory.New())
+	require.NoError(t, err)
+
+	token := prov.logoutToken(nil)
+
+	// WHEN
+	err = rp.BackChannelLogout(ctx, token)
+
+	// THEN
+	require.NoError(t, err)
+
+	// WHEN
+	err = rp.BackChannelLogout(ctx, token)
+
+	// THEN
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrArgument)
+	assert.Contains(t, err.Error(), "already been used")
+}
@@ -0,0 +1,20 @@
+package login
+
+import "time"
+
+// Session represents the state of a user, who logged in using the OpenID Connect
+// authorization code flow.
+type Session struct {
+	Claims       map[string]any `json:"claims"`
+	IDToken      string         `json:"id_token"`
+	AccessToken  string         `json:"access_token"`
+	RefreshToken string         `json:"refresh_token,omitempty"`
+	TokenExpiry  time.Time      `json:"token_expiry,omitempty"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+}
+
+func (s *Session) expired(now time.Time) bool { return now.After(s.ExpiresAt) }
+
+func (s *Session) tokenExpired(now time.Time) bool {
+	return !s.TokenExpiry.IsZero() && now.After(s.TokenExpiry)
+}
@@ -0,0 +1,112 @@
+package login
+
+import (
+	"context"
+	"time"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	sessionStoreCookie = "cookie"
+	sessionStoreCache  = "cache"
+
+	sessionIDLength = 32
+)
+
+type sessionStore interface {
+	// save stores the given session and returns the value to be set in the session cookie.
+	// If value is not empty, it references an already existing session, which should be
+	// updated.
+	save(ctx context.Context, value string, sess *Session) (string, error)
+	load(ctx context.Context, value string) (*Session, error)
+	delete(ctx context.Context, value string)
+	// supportsUpdate returns whether a session can be updated without changing the value
+	// of the session cookie.
+	supportsUpdate() bool
+}
+
+type cookieSessionStore struct {
+	c codec
+}
+
+func (s cookieSessionStore) save(_ context.Context, _ string, sess *Session) (string, error) {
+	return s.c.encode(sess)
+}
+
+func (s cookieSessionStore) load(_ context.Context, value string) (*Session, error) {
+	var sess Session
+
+	if err := s.c.decode(value, &sess); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "failed to decode session").CausedBy(err)
+	}
+
+	return &sess, nil
+}
+
+func (s cookieSessionStore) delete(_ context.Context, _ string) {}
+
+func (s cookieSessionStore) supportsUpdate() bool { return false }
+
+type cacheSessionStore struct {
+	cch cache.Cache
+}
+
+func (s cacheSessionStore) save(_ context.Context, value string, sess *Session) (string, error) {
+	if len(value) == 0 {
+		id, err := randomString(sessionIDLength)
+		if err != nil {
+			return "", errorchain.
+				NewWithMessage(heimdall.ErrInternal, "failed to generate session id").
+				CausedBy(err)
+		}
+
+		value = id
+	}
+
+	ttl := time.Until(sess.ExpiresAt)
+	key := s.cacheKey(value)
+
+	s.cch.Set(key, sess, ttl)
+
+	// allows terminating the session by a back-channel logout, or by the management api
+	var tags []string
+
+	if sub, ok := sess.Claims["sub"].(string); ok && len(sub) != 0 {
+		tags = append(tags, cache.SubjectTag(sub))
+	}
+
+	if sid, ok := sess.Claims["sid"].(string); ok && len(sid) != 0 {
+		tags = append(tags, cache.SessionTag(sid))
+	}
+
+	cache.Index(s.cch, key, ttl, tags...)
+
+	return value, nil
+}
+
+func (s cacheSessionStore) load(_ context.Context, value string) (*Session, error) {
+	item := s.cch.Get(s.cacheKey(value))
+	if item == nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "session not found")
+	}
+
+	sess, ok := item.(*Session)
+	if !ok {
+		s.cch.Delete(s.cacheKey(value))
+
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "wrong object type from cache")
+	}
+
+	return sess, nil
+}
+
+func (s cacheSessionStore) delete(_ context.Context, value string) {
+	s.cch.Delete(s.cacheKey(value))
+}
+
+func (s cacheSessionStore) supportsUpdate() bool { return true }
+
+func (s cacheSessionStore) cacheKey(value string) string { return "login-session-" + value }
@@ -9,6 +9,7 @@ import (
 	"github.com/dadrus/heimdall/internal/handler/prometheus"
 	"github.com/dadrus/heimdall/internal/keystore"
 	"github.com/dadrus/heimdall/internal/logging"
+	"github.com/dadrus/heimdall/internal/login"
 	"github.com/dadrus/heimdall/internal/pipeline"
 	"github.com/dadrus/heimdall/internal/rules"
 	"github.com/dadrus/heimdall/internal/tracing"
@@ -21,6 +22,7 @@ var Module = fx.Options(
 	tracing.Module,
 	cache.Module,
 	keystore.Module,
+	login.Module,
 	pipeline.Module,
 	rules.Module,
 	management.Module,
@@ -0,0 +1,393 @@
+package authenticators
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"text/template"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authenticator, error) {
+			if typ != config.POTAPIKey {
+				return false, nil, nil
+			}
+
+			auth, err := newAPIKeyAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type apiKeyAuthenticator struct {
+	id                   string
+	ads                  extractors.AuthDataExtractStrategy
+	sf                   SubjectFactory
+	file                 *apiKeyFile
+	e                    *endpoint.Endpoint
+	ttl                  time.Duration
+	cachePolicy          cache.Policy
+	allowFallbackOnError bool
+}
+
+func newAPIKeyAuthenticator(id string, rawConfig map[string]any) (*apiKeyAuthenticator, error) {
+	type KeyStore struct {
+		File     string             `mapstructure:"file"`
+		Endpoint *endpoint.Endpoint `mapstructure:"endpoint"`
+	}
+
+	type Config struct {
+		KeySource            extractors.CompositeExtractStrategy `mapstructure:"key_source"`
+		KeyStore             KeyStore                            `mapstructure:"key_store"`
+		SubjectInfo          SubjectInfo                         `mapstructure:"subject"`
+		CacheTTL             *time.Duration                      `mapstructure:"cache_ttl"`
+		CachePolicy          cache.Policy                        `mapstructure:"cache_policy"`
+		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var (
+		conf Config
+		file *apiKeyFile
+		err  error
+	)
+
+	if err = decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode api_key authenticator config").
+			CausedBy(err)
+	}
+
+	switch {
+	case len(conf.KeyStore.File) != 0 && conf.KeyStore.Endpoint != nil:
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"api_key authenticator key_store can either reference a file or an endpoint, but not both")
+	case len(conf.KeyStore.File) != 0:
+		if file, err = newAPIKeyFile(conf.KeyStore.File); err != nil {
+			return nil, err
+		}
+	case conf.KeyStore.Endpoint != nil:
+		if err = conf.KeyStore.Endpoint.Validate(); err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrConfiguration, "failed to validate key_store endpoint configuration").
+				CausedBy(err)
+		}
+
+		if len(conf.KeyStore.Endpoint.Method) == 0 {
+			conf.KeyStore.Endpoint.Method = http.MethodGet
+		}
+	default:
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"api_key authenticator requires a key_store referencing either a file or an endpoint")
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "owner"
+	}
+
+	return &apiKeyAuthenticator{
+		id: id,
+		ads: x.IfThenElse[extractors.AuthDataExtractStrategy](conf.KeySource != nil,
+			conf.KeySource,
+			extractors.HeaderValueExtractStrategy{Name: "X-API-Key"}),
+		sf:   &conf.SubjectInfo,
+		file: file,
+		e:    conf.KeyStore.Endpoint,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return 0 }),
+		cachePolicy:          conf.CachePolicy,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authenticating using api_key authenticator")
+
+	authData, err := a.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no api key present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	digest := sha256.Sum256([]byte(authData.Value()))
+	keyHash := hex.EncodeToString(digest[:])
+
+	key, err := a.lookupKey(ctx, keyHash)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = a.verifyKey(key); err != nil {
+		return nil, err
+	}
+
+	rawData, err := json.Marshal(map[string]any{
+		"owner":      key.Owner,
+		"scopes":     key.Scopes,
+		"expires_at": key.ExpiresAt,
+		"attributes": key.Attributes,
+	})
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal api key information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawData)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from api key").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *apiKeyAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows ttl, cache policy and fallback to be redefined on the rule level
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		CacheTTL             *time.Duration `mapstructure:"cache_ttl"`
+		CachePolicy          *cache.Policy  `mapstructure:"cache_policy"`
+		AllowFallbackOnError *bool          `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode api_key authenticator config").
+			CausedBy(err)
+	}
+
+	return &apiKeyAuthenticator{
+		id:   a.id,
+		ads:  a.ads,
+		sf:   a.sf,
+		file: a.file,
+		e:    a.e,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return a.ttl }),
+		cachePolicy: x.IfThenElseExec(conf.CachePolicy != nil,
+			func() cache.Policy { return *conf.CachePolicy },
+			func() cache.Policy { return a.cachePolicy }),
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *apiKeyAuthenticator) HandlerID() string {
+	return a.id
+}
+
+func (a *apiKeyAuthenticator) verifyKey(key *apiKey) error {
+	if key == nil {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "unknown api key").
+			WithErrorContext(a)
+	}
+
+	if key.Revoked {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "api key has been revoked").
+			WithErrorContext(a)
+	}
+
+	if key.expired(time.Now()) {
+		return errorchain.NewWithMessage(heimdall.ErrAuthentication, "api key has expired").
+			WithErrorContext(a)
+	}
+
+	return nil
+}
+
+func (a *apiKeyAuthenticator) lookupKey(ctx heimdall.Context, keyHash string) (*apiKey, error) {
+	if a.file != nil {
+		key, err := a.file.lookup(keyHash)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrInternal, "failed to load api keys").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return key, nil
+	}
+
+	if a.ttl <= 0 {
+		load, err := a.prepareLoad(ctx, keyHash)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := load(ctx.AppContext())
+
+		return loaded.Value, err
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceAuthenticator)
+
+	key, err := cache.Load(ctx.AppContext(), cch, a.calculateCacheKey(keyHash), a.cachePolicy,
+		func() (cache.LoadFunc[*apiKey], error) { return a.prepareLoad(ctx, keyHash) })
+	if errors.Is(err, cache.ErrCachedFailure) {
+		return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+	}
+
+	return key, err
+}
+
+// prepareLoad creates the request to the key store. The returned function does not refer to the request
+// to heimdall, so that it can be executed after the latter has been served.
+func (a *apiKeyAuthenticator) prepareLoad(ctx heimdall.Context, keyHash string) (cache.LoadFunc[*apiKey], error) {
+	req, err := a.e.CreateRequest(ctx.AppContext(), nil,
+		endpoint.RenderFunc(func(value string) (string, error) {
+			tpl, err := template.New("api_key").Parse(value)
+			if err != nil {
+				return "", err
+			}
+
+			var buf bytes.Buffer
+
+			err = tpl.Execute(&buf, map[string]string{"KeyHash": keyHash})
+
+			return buf.String(), err
+		}))
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return func(loadCtx context.Context) (cache.Loaded[*apiKey], error) {
+		return a.loadKey(req.WithContext(loadCtx), keyHash)
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) loadKey(req *http.Request, keyHash string) (cache.Loaded[*apiKey], error) {
+	key, err := a.fetchKey(req, keyHash)
+	if err != nil {
+		return cache.Loaded[*apiKey]{}, err
+	}
+
+	cacheTTL := a.getCacheTTL(key)
+
+	return cache.Loaded[*apiKey]{
+		Value: key,
+		TTL:   cacheTTL,
+		Tags: x.IfThenElseExec(cacheTTL > 0,
+			func() []string { return []string{cache.HandlerTag(a.id), cache.TokenHashTag(keyHash)} },
+			func() []string { return nil }),
+	}, nil
+}
+
+func (a *apiKeyAuthenticator) fetchKey(req *http.Request, keyHash string) (*apiKey, error) {
+	resp, err := a.e.CreateClient(req.URL.Hostname()).Do(req)
+	if err != nil {
+		var clientErr *url.Error
+		if errors.As(err, &clientErr) && clientErr.Timeout() {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunicationTimeout, "request to the api key store timed out").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "request to the api key store failed").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	defer resp.Body.Close()
+
+	return a.readResponse(resp, keyHash)
+}
+
+func (a *apiKeyAuthenticator) readResponse(resp *http.Response, keyHash string) (*apiKey, error) {
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, cache.Negative(errorchain.NewWithMessage(heimdall.ErrAuthentication, "unknown api key").
+			WithErrorContext(a))
+	}
+
+	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"unexpected response code: %v", resp.StatusCode).WithErrorContext(a)
+	}
+
+	rawData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to read response").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	var key apiKey
+	if err = json.Unmarshal(rawData, &key); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to unmarshal api key information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	key.Hash = keyHash
+
+	return &key, nil
+}
+
+func (a *apiKeyAuthenticator) getCacheTTL(key *apiKey) time.Duration {
+	if a.ttl <= 0 {
+		return 0
+	}
+
+	// unknown keys are cached only if configured by the negative_ttl of the cache policy. Otherwise,
+	// newly created keys would be rejected until the cache entry expires. The ttl of known keys does
+	// not exceed the lifetime of the key itself.
+	if key.ExpiresAt != nil {
+		expiresIn := time.Until(*key.ExpiresAt)
+
+		return x.IfThenElse(a.ttl < expiresIn, a.ttl, x.IfThenElse(expiresIn > 0, expiresIn, 0))
+	}
+
+	return a.ttl
+}
+
+func (a *apiKeyAuthenticator) calculateCacheKey(keyHash string) string {
+	digest := sha256.New()
+	digest.Write([]byte(a.e.Hash()))
+	digest.Write([]byte(keyHash))
+
+	return hex.EncodeToString(digest.Sum(nil))
+}
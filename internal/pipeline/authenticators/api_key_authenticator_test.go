@@ -0,0 +1,551 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func apiKeyHash(key string) string {
+	digest := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(digest[:])
+}
+
+func writeAPIKeyFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestCreateAPIKeyAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	keyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	writeAPIKeyFile(t, keyFile, `
+- hash: `+apiKeyHash("foo")+`
+  owner: foo
+`)
+
+	invalidKeyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	writeAPIKeyFile(t, invalidKeyFile, `
+- hash: foo
+  owner: foo
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *apiKeyAuthenticator)
+	}{
+		{
+			uc: "without key store",
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires a key_store")
+			},
+		},
+		{
+			uc: "with file and endpoint based key store",
+			config: []byte(`
+key_store:
+  file: ` + keyFile + `
+  endpoint:
+    url: http://keys.local/{{ .KeyHash }}
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not both")
+			},
+		},
+		{
+			uc:     "with not existing key file",
+			config: []byte(`key_store: { file: /does/not/exist.yaml }`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to access api key file")
+			},
+		},
+		{
+			uc:     "with key file containing invalid hash",
+			config: []byte(`key_store: { file: ` + invalidKeyFile + ` }`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "valid hex encoded SHA-256 hash")
+			},
+		},
+		{
+			uc:     "with invalid endpoint configuration",
+			config: []byte(`key_store: { endpoint: { method: GET } }`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to validate key_store endpoint")
+			},
+		},
+		{
+			uc:     "with unsupported attributes",
+			config: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "with file based key store and defaults",
+			id:     "auth1",
+			config: []byte(`key_store: { file: ` + keyFile + ` }`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Equal(t, extractors.HeaderValueExtractStrategy{Name: "X-API-Key"}, auth.ads)
+				assert.Equal(t, &SubjectInfo{IDFrom: "owner"}, auth.sf)
+				assert.NotNil(t, auth.file)
+				assert.Nil(t, auth.e)
+				assert.Zero(t, auth.ttl)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with endpoint based key store and full configuration",
+			id: "auth2",
+			config: []byte(`
+key_source:
+  - query_parameter: api_key
+key_store:
+  endpoint:
+    url: http://keys.local/{{ .KeyHash }}
+subject:
+  id: attributes.id
+cache_ttl: 5m
+cache_policy:
+  refresh_ahead: 1m
+  negative_ttl: 30s
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					&extractors.QueryParameterExtractStrategy{Name: "api_key"},
+				}, auth.ads)
+				assert.Equal(t, &SubjectInfo{IDFrom: "attributes.id"}, auth.sf)
+				assert.Nil(t, auth.file)
+				require.NotNil(t, auth.e)
+				assert.Equal(t, "http://keys.local/{{ .KeyHash }}", auth.e.URL)
+				assert.Equal(t, http.MethodGet, auth.e.Method)
+				assert.Equal(t, 5*time.Minute, auth.ttl)
+				assert.Equal(t, cache.Policy{RefreshAhead: time.Minute, NegativeTTL: 30 * time.Second}, auth.cachePolicy)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newAPIKeyAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateAPIKeyAuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "cache ttl, cache policy and fallback on error are redefined",
+			config: []byte(`{ cache_ttl: 1m, cache_policy: { stale_ttl: 10m }, allow_fallback_on_error: true }`),
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.ads, configured.ads)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.Equal(t, prototype.e, configured.e)
+				assert.Equal(t, 5*time.Minute, prototype.ttl)
+				assert.Equal(t, time.Minute, configured.ttl)
+				assert.Equal(t, cache.Policy{}, prototype.cachePolicy)
+				assert.Equal(t, cache.Policy{StaleTTL: 10 * time.Minute}, configured.cachePolicy)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "not overridable attribute is used",
+			config: []byte(`key_store: { file: /foo.yaml }`),
+			assert: func(t *testing.T, err error, prototype *apiKeyAuthenticator, configured *apiKeyAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig([]byte(`
+key_store:
+  endpoint:
+    url: http://keys.local/{{ .KeyHash }}
+cache_ttl: 5m
+`))
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newAPIKeyAuthenticator("auth1", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				akAuth *apiKeyAuthenticator
+				ok     bool
+			)
+
+			if err == nil {
+				akAuth, ok = auth.(*apiKeyAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, akAuth)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorExecuteWithFileBasedKeyStore(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	keyFile := filepath.Join(t.TempDir(), "keys.yaml")
+	writeAPIKeyFile(t, keyFile, `
+- hash: `+apiKeyHash("valid")+`
+  owner: foo
+  scopes: [ read, write ]
+  attributes:
+    team: bar
+- hash: `+apiKeyHash("revoked")+`
+  owner: foo
+  revoked: true
+- hash: `+apiKeyHash("expired")+`
+  owner: foo
+  expires_at: 2020-01-01T00:00:00Z
+`)
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`key_store: { file: ` + keyFile + ` }`))
+	require.NoError(t, err)
+
+	auth, err := newAPIKeyAuthenticator("auth1", conf)
+	require.NoError(t, err)
+
+	execute := func(t *testing.T, key string) (*subject.Subject, error) {
+		t.Helper()
+
+		ctx := &mocks.MockContext{}
+		ctx.On("AppContext").Return(context.Background())
+		ctx.On("RequestHeader", "X-API-Key").Return(key)
+
+		return auth.Execute(ctx)
+	}
+
+	// key not present
+	sub, err := execute(t, "")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+	assert.Contains(t, err.Error(), "no api key present")
+	assert.Nil(t, sub)
+
+	// unknown key
+	_, err = execute(t, "unknown")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+	assert.Contains(t, err.Error(), "unknown api key")
+
+	var identifier HandlerIdentifier
+	require.True(t, errors.As(err, &identifier))
+	assert.Equal(t, "auth1", identifier.HandlerID())
+
+	// revoked key
+	_, err = execute(t, "revoked")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+	assert.Contains(t, err.Error(), "revoked")
+
+	// expired key
+	_, err = execute(t, "expired")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+	assert.Contains(t, err.Error(), "expired")
+
+	// valid key
+	sub, err = execute(t, "valid")
+	require.NoError(t, err)
+	require.NotNil(t, sub)
+	assert.Equal(t, "foo", sub.ID)
+	assert.Equal(t, []any{"read", "write"}, sub.Attributes["scopes"])
+	assert.Equal(t, map[string]any{"team": "bar"}, sub.Attributes["attributes"])
+
+	// rotation: the valid key is revoked and a new one is added without restarting
+	writeAPIKeyFile(t, keyFile, `
+- hash: `+apiKeyHash("valid")+`
+  owner: foo
+  revoked: true
+- hash: `+apiKeyHash("rotated")+`
+  owner: foo
+`)
+
+	_, err = execute(t, "valid")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+	assert.Contains(t, err.Error(), "revoked")
+
+	sub, err = execute(t, "rotated")
+	require.NoError(t, err)
+	assert.Equal(t, "foo", sub.ID)
+
+	// a broken file rejects all keys
+	writeAPIKeyFile(t, keyFile, `foo: [`)
+
+	_, err = execute(t, "rotated")
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrInternal)
+	assert.Contains(t, err.Error(), "failed to load api keys")
+}
+
+func TestAPIKeyAuthenticatorExecuteWithEndpointBasedKeyStore(t *testing.T) {
+	t.Parallel()
+
+	var (
+		requestedPath string
+		storeCalls    int
+		responseCode  int
+		responseBody  string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeCalls++
+		requestedPath = r.URL.Path
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseCode)
+
+		_, err := w.Write([]byte(responseBody))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc           string
+		key          string
+		responseCode int
+		responseBody string
+		assert       func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:           "unknown key",
+			key:          "unknown",
+			responseCode: http.StatusNotFound,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "unknown api key")
+				assert.Equal(t, "/keys/"+apiKeyHash("unknown"), requestedPath)
+			},
+		},
+		{
+			uc:           "unexpected response code",
+			key:          "foo",
+			responseCode: http.StatusInternalServerError,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "unexpected response code")
+			},
+		},
+		{
+			uc:           "revoked key",
+			key:          "revoked",
+			responseCode: http.StatusOK,
+			responseBody: `{"owner": "foo", "revoked": true}`,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "revoked")
+			},
+		},
+		{
+			uc:           "valid key",
+			key:          "valid",
+			responseCode: http.StatusOK,
+			responseBody: `{"owner": "foo", "scopes": ["read"], "expires_at": "2100-01-01T00:00:00Z"}`,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+				assert.Equal(t, []any{"read"}, sub.Attributes["scopes"])
+				assert.Equal(t, "2100-01-01T00:00:00Z", sub.Attributes["expires_at"])
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			storeCalls = 0
+			responseCode = tc.responseCode
+			responseBody = tc.responseBody
+
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+key_store:
+  endpoint:
+    url: ` + srv.URL + `/keys/{{ .KeyHash }}
+cache_ttl: 1m
+`))
+			require.NoError(t, err)
+
+			auth, err := newAPIKeyAuthenticator("auth1", conf)
+			require.NoError(t, err)
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+			ctx.On("RequestHeader", "X-API-Key").Return(tc.key)
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+
+			// known keys are taken from the cache on subsequent requests
+			calls := storeCalls
+			sub2, err2 := auth.Execute(ctx)
+
+			if tc.responseCode == http.StatusOK {
+				assert.Equal(t, calls, storeCalls)
+			} else {
+				assert.Equal(t, calls+1, storeCalls)
+			}
+
+			tc.assert(t, err2, sub2)
+		})
+	}
+}
+
+func TestAPIKeyAuthenticatorExecuteWithEndpointBasedKeyStoreCachesUnknownKeysIfConfigured(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	var storeCalls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		storeCalls++
+
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+key_store:
+  endpoint:
+    url: ` + srv.URL + `/keys/{{ .KeyHash }}
+cache_ttl: 1m
+cache_policy:
+  negative_ttl: 1m
+`))
+	require.NoError(t, err)
+
+	auth, err := newAPIKeyAuthenticator("auth1", conf)
+	require.NoError(t, err)
+
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+	ctx.On("RequestHeader", "X-API-Key").Return("unknown")
+
+	// WHEN
+	_, err1 := auth.Execute(ctx)
+	_, err2 := auth.Execute(ctx)
+
+	// THEN
+	require.Error(t, err1)
+	assert.ErrorIs(t, err1, heimdall.ErrAuthentication)
+	assert.Contains(t, err1.Error(), "unknown api key")
+
+	require.Error(t, err2)
+	assert.ErrorIs(t, err2, heimdall.ErrAuthentication)
+	assert.ErrorIs(t, err2, cache.ErrCachedFailure)
+
+	assert.Equal(t, 1, storeCalls)
+}
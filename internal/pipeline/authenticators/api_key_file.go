@@ -0,0 +1,128 @@
+package authenticators
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type apiKey struct {
+	Hash       string         `json:"hash"       yaml:"hash"`
+	Owner      string         `json:"owner"      yaml:"owner"`
+	Scopes     []string       `json:"scopes"     yaml:"scopes"`
+	ExpiresAt  *time.Time     `json:"expires_at" yaml:"expires_at"`
+	Revoked    bool           `json:"revoked"    yaml:"revoked"`
+	Attributes map[string]any `json:"attributes" yaml:"attributes"`
+}
+
+func (k *apiKey) expired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// apiKeyFile holds the keys defined in a file. The file is checked for modifications on each lookup and
+// reloaded if it has been changed, which allows keys to be added, rotated and revoked without a restart.
+type apiKeyFile struct {
+	path string
+
+	mut     sync.RWMutex
+	modTime time.Time
+	size    int64
+	keys    map[string]*apiKey
+	err     error
+}
+
+func newAPIKeyFile(path string) (*apiKeyFile, error) {
+	akf := &apiKeyFile{path: path}
+
+	fileInfo, err := os.Stat(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to access api key file").
+			CausedBy(err)
+	}
+
+	akf.reload(fileInfo)
+	if akf.err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to load api key file").
+			CausedBy(akf.err)
+	}
+
+	return akf, nil
+}
+
+func (f *apiKeyFile) lookup(keyHash string) (*apiKey, error) {
+	fileInfo, err := os.Stat(f.path)
+	if err != nil {
+		return nil, err
+	}
+
+	f.mut.RLock()
+	modified := !fileInfo.ModTime().Equal(f.modTime) || fileInfo.Size() != f.size
+	f.mut.RUnlock()
+
+	if modified {
+		f.reload(fileInfo)
+	}
+
+	f.mut.RLock()
+	defer f.mut.RUnlock()
+
+	if f.err != nil {
+		// a broken file results in all keys being rejected. Otherwise, revoked keys could still be used.
+		return nil, f.err
+	}
+
+	return f.keys[keyHash], nil
+}
+
+func (f *apiKeyFile) reload(fileInfo os.FileInfo) {
+	f.mut.Lock()
+	defer f.mut.Unlock()
+
+	f.modTime = fileInfo.ModTime()
+	f.size = fileInfo.Size()
+	f.keys, f.err = loadAPIKeys(f.path)
+}
+
+func loadAPIKeys(path string) (map[string]*apiKey, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*apiKey
+	if err = yaml.Unmarshal(raw, &entries); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*apiKey, len(entries))
+
+	for idx, entry := range entries {
+		if entry == nil {
+			continue
+		}
+
+		entry.Hash = strings.ToLower(entry.Hash)
+
+		if decoded, err := hex.DecodeString(entry.Hash); err != nil || len(decoded) != sha256.Size {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"entry %d does not contain a valid hex encoded SHA-256 hash", idx)
+		}
+
+		if _, present := keys[entry.Hash]; present {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"entry %d is a duplicate", idx)
+		}
+
+		keys[entry.Hash] = entry
+	}
+
+	return keys, nil
+}
@@ -12,8 +12,8 @@ import (
 func TestCreateAuthenticatorPrototype(t *testing.T) {
 	t.Parallel()
 
-	// there are seven authenticators implemented, which should have been registered
-	require.Len(t, authenticatorTypeFactories, 7)
+	// there are twelve authenticators implemented, which should have been registered
+	require.Len(t, authenticatorTypeFactories, 12)
 
 	for _, tc := range []struct {
 		uc     string
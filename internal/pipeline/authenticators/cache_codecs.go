@@ -0,0 +1,14 @@
+package authenticators
+
+import (
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*apiKey]("authenticators.api_key", codec.JSON[*apiKey]{})
+	codec.Register[*jose.JSONWebKey]("jwk", codec.JSON[*jose.JSONWebKey]{})
+}
@@ -1,6 +1,7 @@
 package authenticators
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -19,6 +20,7 @@ import (
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
 // by intention. Used only during application bootstrap
@@ -42,6 +44,7 @@ type genericAuthenticator struct {
 	sf                   SubjectFactory
 	ads                  extractors.AuthDataExtractStrategy
 	ttl                  time.Duration
+	cachePolicy          cache.Policy
 	sessionLifespanConf  *SessionLifespanConfig
 	allowFallbackOnError bool
 }
@@ -53,6 +56,7 @@ func newGenericAuthenticator(id string, rawConfig map[string]any) (*genericAuthe
 		SubjectInfo           SubjectInfo                         `mapstructure:"subject"`
 		SessionLifespanConfig *SessionLifespanConfig              `mapstructure:"session_lifespan"`
 		CacheTTL              *time.Duration                      `mapstructure:"cache_ttl"`
+		CachePolicy           cache.Policy                        `mapstructure:"cache_policy"`
 		AllowFallbackOnError  bool                                `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -89,6 +93,7 @@ func newGenericAuthenticator(id string, rawConfig map[string]any) (*genericAuthe
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return 0 }),
+		cachePolicy:          conf.CachePolicy,
 		allowFallbackOnError: conf.AllowFallbackOnError,
 		sessionLifespanConf:  conf.SessionLifespanConfig,
 	}, nil
@@ -123,13 +128,14 @@ func (a *genericAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject,
 }
 
 func (a *genericAuthenticator) WithConfig(config map[string]any) (Authenticator, error) {
-	// this authenticator allows ttl to be redefined on the rule level
+	// this authenticator allows ttl and cache policy to be redefined on the rule level
 	if len(config) == 0 {
 		return a, nil
 	}
 
 	type Config struct {
 		CacheTTL             *time.Duration `mapstructure:"cache_ttl"`
+		CachePolicy          *cache.Policy  `mapstructure:"cache_policy"`
 		AllowFallbackOnError *bool          `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -148,6 +154,9 @@ func (a *genericAuthenticator) WithConfig(config map[string]any) (Authenticator,
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return a.ttl }),
+		cachePolicy: x.IfThenElseExec(conf.CachePolicy != nil,
+			func() cache.Policy { return *conf.CachePolicy },
+			func() cache.Policy { return a.cachePolicy }),
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
@@ -166,71 +175,91 @@ func (a *genericAuthenticator) HandlerID() string {
 func (a *genericAuthenticator) getSubjectInformation(ctx heimdall.Context,
 	authData extractors.AuthData,
 ) ([]byte, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-	cch := cache.Ctx(ctx.AppContext())
-
-	var (
-		cacheKey       string
-		cacheEntry     any
-		cachedResponse []byte
-		ok             bool
-		session        *SessionLifespan
-	)
-
-	if a.ttl > 0 {
-		cacheKey = a.calculateCacheKey(authData.Value())
-		cacheEntry = cch.Get(cacheKey)
+	if a.ttl <= 0 {
+		load, err := a.prepareLoad(ctx, authData)
+		if err != nil {
+			return nil, err
+		}
+
+		loaded, err := load(ctx.AppContext())
+
+		return loaded.Value, err
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceAuthenticator)
+
+	payload, err := cache.Load(ctx.AppContext(), cch, a.calculateCacheKey(authData.Value()), a.cachePolicy,
+		func() (cache.LoadFunc[[]byte], error) { return a.prepareLoad(ctx, authData) })
+	if errors.Is(err, cache.ErrCachedFailure) {
+		return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+	}
+
+	return payload, err
+}
+
+// prepareLoad creates the request to the endpoint. The returned function does not refer to the request
+// to heimdall, so that it can be executed after the latter has been served.
+func (a *genericAuthenticator) prepareLoad(ctx heimdall.Context,
+	authData extractors.AuthData,
+) (cache.LoadFunc[[]byte], error) {
+	req, err := a.e.CreateRequest(ctx.AppContext(), nil, nil)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			WithErrorContext(a).
+			CausedBy(err)
 	}
 
-	if cacheEntry != nil {
-		if cachedResponse, ok = cacheEntry.([]byte); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing subject information from cache")
+	authData.ApplyTo(req)
 
-			return cachedResponse, nil
+	// the values taken from the request to heimdall refer to memory reused for other requests
+	for _, values := range req.Header {
+		for idx, value := range values {
+			values[idx] = stringx.Clone(value)
 		}
 	}
 
-	payload, err := a.fetchSubjectInformation(ctx, authData)
+	token := stringx.Clone(authData.Value())
+
+	return func(loadCtx context.Context) (cache.Loaded[[]byte], error) {
+		return a.loadSubjectInformation(req.WithContext(loadCtx), token)
+	}, nil
+}
+
+func (a *genericAuthenticator) loadSubjectInformation(req *http.Request, token string) (cache.Loaded[[]byte], error) {
+	var session *SessionLifespan
+
+	payload, err := a.fetchSubjectInformation(req)
 	if err != nil {
-		return nil, err
+		return cache.Loaded[[]byte]{}, err
 	}
 
 	if a.sessionLifespanConf != nil {
 		session, err = a.sessionLifespanConf.CreateSessionLifespan(payload)
 		if err != nil {
-			return nil, errorchain.New(heimdall.ErrInternal).WithErrorContext(a).CausedBy(err)
+			return cache.Loaded[[]byte]{}, errorchain.New(heimdall.ErrInternal).WithErrorContext(a).CausedBy(err)
 		}
 
 		if session != nil {
 			if err = session.Assert(); err != nil {
-				return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+				return cache.Loaded[[]byte]{}, cache.Negative(
+					errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err))
 			}
 		}
 	}
 
-	if cacheTTL := a.getCacheTTL(session); cacheTTL > 0 {
-		cch.Set(cacheKey, payload, cacheTTL)
-	}
+	cacheTTL := a.getCacheTTL(session)
 
-	return payload, nil
+	return cache.Loaded[[]byte]{
+		Value: payload,
+		TTL:   cacheTTL,
+		Tags: x.IfThenElseExec(cacheTTL > 0,
+			func() []string { return cacheTags(a.id, token, a.sf, payload) },
+			func() []string { return nil }),
+	}, nil
 }
 
-func (a *genericAuthenticator) fetchSubjectInformation(ctx heimdall.Context,
-	authData extractors.AuthData,
-) ([]byte, error) {
-	req, err := a.e.CreateRequest(ctx.AppContext(), nil, nil)
-	if err != nil {
-		return nil, errorchain.
-			NewWithMessage(heimdall.ErrInternal, "failed creating request").
-			WithErrorContext(a).
-			CausedBy(err)
-	}
-
-	authData.ApplyTo(req)
-
+func (a *genericAuthenticator) fetchSubjectInformation(req *http.Request) ([]byte, error) {
 	resp, err := a.e.CreateClient(req.URL.Hostname()).Do(req)
 	if err != nil {
 		var clientErr *url.Error
@@ -255,6 +284,12 @@ func (a *genericAuthenticator) fetchSubjectInformation(ctx heimdall.Context,
 }
 
 func (a *genericAuthenticator) readResponse(resp *http.Response) ([]byte, error) {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, cache.Negative(errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "endpoint rejected the authentication data").
+			WithErrorContext(a))
+	}
+
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
 		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
 			"unexpected response code: %v", resp.StatusCode).WithErrorContext(a)
@@ -6,13 +6,16 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
 	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
 	"github.com/dadrus/heimdall/internal/cache/mocks"
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
@@ -134,7 +137,11 @@ authentication_data_source:
   - cookie: foo-cookie
 subject:
   id: some_template
-cache_ttl: 5s`),
+cache_ttl: 5s
+cache_policy:
+  refresh_ahead: 1s
+  stale_ttl: 1m
+  negative_ttl: 10s`),
 			assertError: func(t *testing.T, err error, auth *genericAuthenticator) {
 				t.Helper()
 
@@ -149,6 +156,11 @@ cache_ttl: 5s`),
 				assert.Contains(t, ces, &extractors.CookieValueExtractStrategy{Name: "foo-cookie"})
 				assert.Equal(t, &SubjectInfo{IDFrom: "some_template"}, auth.sf)
 				assert.Equal(t, 5*time.Second, auth.ttl)
+				assert.Equal(t, cache.Policy{
+					RefreshAhead: 1 * time.Second,
+					StaleTTL:     1 * time.Minute,
+					NegativeTTL:  10 * time.Second,
+				}, auth.cachePolicy)
 				assert.False(t, auth.IsFallbackOnErrorAllowed())
 				assert.Nil(t, auth.sessionLifespanConf)
 				assert.Equal(t, "auth1", auth.HandlerID())
@@ -369,7 +381,9 @@ subject:
   id: some_template
 cache_ttl: 5s`),
 			config: []byte(`
-cache_ttl: 15s`),
+cache_ttl: 15s
+cache_policy:
+  negative_ttl: 10s`),
 			assert: func(t *testing.T, err error, prototype *genericAuthenticator,
 				configured *genericAuthenticator,
 			) {
@@ -383,6 +397,8 @@ cache_ttl: 15s`),
 				assert.NotEqual(t, prototype.ttl, configured.ttl)
 				assert.Equal(t, 15*time.Second, configured.ttl)
 				assert.Equal(t, 5*time.Second, prototype.ttl)
+				assert.Equal(t, cache.Policy{}, prototype.cachePolicy)
+				assert.Equal(t, cache.Policy{NegativeTTL: 10 * time.Second}, configured.cachePolicy)
 				assert.Equal(t, prototype.IsFallbackOnErrorAllowed(), configured.IsFallbackOnErrorAllowed())
 				assert.Equal(t, prototype.sessionLifespanConf, configured.sessionLifespanConf)
 				assert.Equal(t, "auth2", configured.HandlerID())
@@ -824,7 +840,7 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
 				cch.On("Get", cacheKey).Return(time.Duration(10))
 				cch.On("Delete", cacheKey)
-				cch.On("Set", cacheKey, []byte(`{ "user_id": "barbar" }`), auth.ttl)
+				cch.On("Set", cacheKey, cachedValue([]byte(`{ "user_id": "barbar" }`)), auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -877,7 +893,10 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 				cacheKey := auth.calculateCacheKey("session_token")
 
 				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
-				cch.On("Get", cacheKey).Return([]byte(`{ "user_id": "barbar" }`))
+				cch.On("Get", cacheKey).Return(&cache.Entry{
+					Value:     []byte(`{ "user_id": "barbar" }`),
+					ExpiresAt: time.Now().Add(auth.ttl),
+				})
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -916,7 +935,7 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 
 				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
 				cch.On("Get", cacheKey).Return(nil)
-				cch.On("Set", cacheKey, []byte(`{ "user_id": "barbar" }`), auth.ttl)
+				cch.On("Set", cacheKey, cachedValue([]byte(`{ "user_id": "barbar" }`)), auth.ttl)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -1002,6 +1021,149 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 				assert.Equal(t, "auth3", identifier.HandlerID())
 			},
 		},
+		{
+			uc: "execution with not active session and negative caching",
+			authenticator: &genericAuthenticator{
+				id: "auth3",
+				e: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+					Headers: map[string]string{
+						"Accept": "application/json",
+					},
+				},
+				sf:                  &SubjectInfo{IDFrom: "user_id"},
+				ttl:                 5 * time.Second,
+				cachePolicy:         cache.Policy{NegativeTTL: 10 * time.Second},
+				sessionLifespanConf: &SessionLifespanConfig{ActiveField: "active"},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.MockContext,
+				cch *mocks.MockCache,
+				ads *mockAuthDataGetter,
+				auth *genericAuthenticator,
+			) {
+				t.Helper()
+
+				cacheKey := auth.calculateCacheKey("session_token")
+
+				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
+				cch.On("Get", cacheKey).Return(nil)
+				cch.On("Set", cacheKey, mock.MatchedBy(func(entry *cache.Entry) bool {
+					return entry.Value == nil && strings.Contains(entry.Failure, "not active")
+				}), 10*time.Second)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusOK
+				responseContent = []byte(`{ "user_id": "barbar", "active": false }`)
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "not active")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "execution with endpoint rejecting the authentication data and negative caching",
+			authenticator: &genericAuthenticator{
+				id: "auth3",
+				e: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				sf:          &SubjectInfo{IDFrom: "user_id"},
+				ttl:         5 * time.Second,
+				cachePolicy: cache.Policy{NegativeTTL: 10 * time.Second},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.MockContext,
+				cch *mocks.MockCache,
+				ads *mockAuthDataGetter,
+				auth *genericAuthenticator,
+			) {
+				t.Helper()
+
+				cacheKey := auth.calculateCacheKey("session_token")
+
+				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
+				cch.On("Get", cacheKey).Return(nil)
+				cch.On("Set", cacheKey, mock.MatchedBy(func(entry *cache.Entry) bool {
+					return entry.Value == nil && strings.Contains(entry.Failure, "rejected")
+				}), 10*time.Second)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusUnauthorized
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "rejected")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "execution with cached authentication failure",
+			authenticator: &genericAuthenticator{
+				id: "auth3",
+				e: endpoint.Endpoint{
+					URL:    srv.URL,
+					Method: http.MethodGet,
+				},
+				sf:          &SubjectInfo{IDFrom: "user_id"},
+				ttl:         5 * time.Second,
+				cachePolicy: cache.Policy{NegativeTTL: 10 * time.Second},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.MockContext,
+				cch *mocks.MockCache,
+				ads *mockAuthDataGetter,
+				auth *genericAuthenticator,
+			) {
+				t.Helper()
+
+				cacheKey := auth.calculateCacheKey("session_token")
+
+				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
+				cch.On("Get", cacheKey).Return(&cache.Entry{
+					Failure:   "endpoint rejected the authentication data",
+					ExpiresAt: time.Now().Add(10 * time.Second),
+				})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, cache.ErrCachedFailure)
+				assert.Contains(t, err.Error(), "rejected")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
 		{
 			uc: "execution with error while parsing session lifespan",
 			authenticator: &genericAuthenticator{
@@ -1086,7 +1248,7 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 
 				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
 				cch.On("Get", cacheKey).Return(nil)
-				cch.On("Set", cacheKey, []byte(`{ "user_id": "barbar", "exp": `+exp+` }`), 5*time.Second)
+				cch.On("Set", cacheKey, cachedValue([]byte(`{ "user_id": "barbar", "exp": `+exp+` }`)), 5*time.Second)
 			},
 			instructServer: func(t *testing.T) {
 				t.Helper()
@@ -1166,6 +1328,46 @@ func TestGenericAuthenticatorExecute(t *testing.T) {
 	}
 }
 
+func TestGenericAuthenticatorExecuteWithCachingAndCancelledRequestContext(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "session_token", r.Header.Get("Dummy"))
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err := w.Write([]byte(`{ "user_id": "barbar" }`))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	ads := &mockAuthDataGetter{}
+	auth := &genericAuthenticator{
+		id:  "auth",
+		e:   endpoint.Endpoint{URL: srv.URL, Method: http.MethodGet},
+		sf:  &SubjectInfo{IDFrom: "user_id"},
+		ttl: 30 * time.Second,
+		ads: ads,
+	}
+
+	// e.g. the client of the request, which triggered the load, disconnected. Other requests
+	// waiting for the same load must not be affected by that.
+	appCtx, cancel := context.WithCancel(cache.WithContext(context.Background(), memory.New()))
+	cancel()
+
+	ctx := &heimdallmocks.MockContext{}
+	ctx.On("AppContext").Return(appCtx)
+
+	ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "session_token"}, nil)
+
+	// WHEN
+	sub, err := auth.Execute(ctx)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, "barbar", sub.ID)
+}
+
 func TestGenericAuthenticatorGetCacheTTL(t *testing.T) {
 	t.Parallel()
 
@@ -1245,3 +1447,9 @@ func TestGenericAuthenticatorGetCacheTTL(t *testing.T) {
 		})
 	}
 }
+
+func cachedValue(value any) any {
+	return mock.MatchedBy(func(entry *cache.Entry) bool {
+		return len(entry.Failure) == 0 && assert.ObjectsAreEqual(value, entry.Value)
+	})
+}
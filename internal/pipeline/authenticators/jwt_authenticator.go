@@ -318,7 +318,7 @@ func (a *jwtAuthenticator) verifyTokenWithoutKID(ctx heimdall.Context, token *jw
 }
 
 func (a *jwtAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSONWebKey, error) {
-	cch := cache.Ctx(ctx.AppContext())
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceAuthenticator)
 	logger := zerolog.Ctx(ctx.AppContext())
 
 	var (
@@ -371,6 +371,7 @@ func (a *jwtAuthenticator) getKey(ctx heimdall.Context, keyID string) (*jose.JSO
 
 	if cacheTTL := a.getCacheTTL(jwk); cacheTTL > 0 {
 		cch.Set(cacheKey, jwk, cacheTTL)
+		cache.Index(cch, cacheKey, cacheTTL, cache.HandlerTag(a.id))
 	}
 
 	return jwk, nil
@@ -0,0 +1,212 @@
+package authenticators
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/ldap"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const defaultLDAPUserFilter = "(uid={username})"
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authenticator, error) {
+			if typ != config.POTLDAP {
+				return false, nil, nil
+			}
+
+			auth, err := newLDAPAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type ldapUserSearch struct {
+	ldap.Search `mapstructure:",squash"`
+	Filter      string `mapstructure:"filter"`
+}
+
+type ldapAuthenticator struct {
+	id                   string
+	dir                  *ldap.Directory
+	userSearch           ldapUserSearch
+	attributes           ldap.AttributeMapping
+	groups               *ldap.GroupSearch
+	allowFallbackOnError bool
+}
+
+func newLDAPAuthenticator(id string, rawConfig map[string]any) (*ldapAuthenticator, error) {
+	type Config struct {
+		Connection           ldap.Connection       `mapstructure:"connection"`
+		UserSearch           ldapUserSearch        `mapstructure:"user_search"`
+		Attributes           ldap.AttributeMapping `mapstructure:"attributes"`
+		Groups               *ldap.GroupSearch     `mapstructure:"groups"`
+		AllowFallbackOnError bool                  `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode ldap authenticator config").
+			CausedBy(err)
+	}
+
+	if err := conf.UserSearch.Validate(); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to validate ldap user_search configuration").
+			CausedBy(err)
+	}
+
+	if err := conf.Attributes.Validate(); err != nil {
+		return nil, err
+	}
+
+	if conf.Groups != nil {
+		if err := conf.Groups.Validate(); err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrConfiguration, "failed to validate ldap groups configuration").
+				CausedBy(err)
+		}
+	}
+
+	dir, err := ldap.NewDirectory(conf.Connection)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to validate ldap connection configuration").
+			CausedBy(err)
+	}
+
+	if len(conf.UserSearch.Filter) == 0 {
+		conf.UserSearch.Filter = defaultLDAPUserFilter
+	}
+
+	return &ldapAuthenticator{
+		id:                   id,
+		dir:                  dir,
+		userSearch:           conf.UserSearch,
+		attributes:           conf.Attributes,
+		groups:               conf.Groups,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *ldapAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authenticating using ldap authenticator")
+
+	strategy := extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "Basic"}
+
+	authData, err := strategy.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "expected header not present in request").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	res, err := base64.StdEncoding.DecodeString(authData.Value())
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to decode received credentials value").
+			WithErrorContext(a)
+	}
+
+	// passwords may contain colons, user ids may not (RFC 7617)
+	userID, password, found := strings.Cut(string(res), ":")
+	if !found || len(userID) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "malformed user-id - password scheme").
+			WithErrorContext(a)
+	}
+
+	entry, err := a.dir.FindEntry(a.userSearch.Search,
+		ldap.ReplacePlaceholder(a.userSearch.Filter, "username", userID),
+		a.attributes.LDAPAttributes())
+	if err != nil {
+		return nil, a.wrapError(err)
+	}
+
+	if err = a.dir.Authenticate(entry.DN, password); err != nil {
+		return nil, a.wrapError(err)
+	}
+
+	attributes := a.attributes.Apply(entry)
+
+	if a.groups != nil {
+		groups, err := a.dir.Groups(*a.groups, entry.DN)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunication, "failed to look up the groups of the user").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		attributes[ldap.AttributeGroups] = groups
+	}
+
+	return &subject.Subject{ID: userID, Attributes: attributes}, nil
+}
+
+// wrapError does not disclose whether the user is unknown or the password is wrong.
+func (a *ldapAuthenticator) wrapError(err error) error {
+	if errors.Is(err, heimdall.ErrCommunication) {
+		return errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "failed to verify user credentials").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return errorchain.
+		NewWithMessage(heimdall.ErrAuthentication, "invalid user credentials").
+		WithErrorContext(a).
+		CausedBy(err)
+}
+
+func (a *ldapAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback behavior to be redefined on the rule level
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode ldap authenticator config").
+			CausedBy(err)
+	}
+
+	return &ldapAuthenticator{
+		id:         a.id,
+		dir:        a.dir,
+		userSearch: a.userSearch,
+		attributes: a.attributes,
+		groups:     a.groups,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *ldapAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *ldapAuthenticator) HandlerID() string {
+	return a.id
+}
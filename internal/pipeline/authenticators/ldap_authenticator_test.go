@@ -0,0 +1,404 @@
+package authenticators
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestCreateLDAPAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, auth *ldapAuthenticator)
+	}{
+		{
+			uc: "with unsupported fields",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+user_search: { base_dn: "dc=example,dc=org" }
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "without user search",
+			config: []byte(`connection: { url: "ldap://localhost" }`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no base_dn configured")
+			},
+		},
+		{
+			uc: "with reserved attribute name",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+user_search: { base_dn: "dc=example,dc=org" }
+attributes: { dn: distinguishedName }
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'dn' is reserved")
+			},
+		},
+		{
+			uc: "with unsupported groups scope",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+user_search: { base_dn: "dc=example,dc=org" }
+groups: { base_dn: "dc=example,dc=org", scope: foo }
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported ldap search scope")
+			},
+		},
+		{
+			uc: "with starttls over ldaps",
+			config: []byte(`
+connection: { url: "ldaps://localhost", start_tls: true }
+user_search: { base_dn: "dc=example,dc=org" }
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "start_tls cannot be used together with ldaps")
+			},
+		},
+		{
+			uc: "with minimal configuration",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+user_search: { base_dn: "dc=example,dc=org" }
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.NotNil(t, auth.dir)
+				assert.Equal(t, "dc=example,dc=org", auth.userSearch.BaseDN)
+				assert.Equal(t, defaultLDAPUserFilter, auth.userSearch.Filter)
+				assert.Empty(t, auth.attributes)
+				assert.Nil(t, auth.groups)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			config: []byte(`
+connection:
+  url: ldap://localhost
+  start_tls: true
+  bind_dn: cn=heimdall,dc=example,dc=org
+  bind_password: secret
+user_search:
+  base_dn: ou=users,dc=example,dc=org
+  scope: one
+  filter: "(sAMAccountName={username})"
+attributes:
+  email: mail
+groups:
+  base_dn: ou=groups,dc=example,dc=org
+  nested: true
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *ldapAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Equal(t, "ou=users,dc=example,dc=org", auth.userSearch.BaseDN)
+				assert.Equal(t, "one", auth.userSearch.Scope)
+				assert.Equal(t, "(sAMAccountName={username})", auth.userSearch.Filter)
+				assert.Equal(t, map[string]string{"email": "mail"}, map[string]string(auth.attributes))
+				require.NotNil(t, auth.groups)
+				assert.True(t, auth.groups.Nested)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newLDAPAuthenticator("auth1", conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateLDAPAuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	pc, err := testsupport.DecodeTestConfig([]byte(`
+connection: { url: "ldap://localhost" }
+user_search: { base_dn: "dc=example,dc=org" }
+`))
+	require.NoError(t, err)
+
+	prototype, err := newLDAPAuthenticator("auth2", pc)
+	require.NoError(t, err)
+
+	// empty config results in the prototype
+	auth, err := prototype.WithConfig(nil)
+	require.NoError(t, err)
+	assert.Equal(t, prototype, auth)
+
+	// only the fallback behavior can be redefined
+	auth, err = prototype.WithConfig(map[string]any{"allow_fallback_on_error": true})
+	require.NoError(t, err)
+
+	configured, ok := auth.(*ldapAuthenticator)
+	require.True(t, ok)
+	assert.Equal(t, "auth2", configured.HandlerID())
+	assert.Equal(t, prototype.dir, configured.dir)
+	assert.Equal(t, prototype.userSearch, configured.userSearch)
+	assert.True(t, configured.IsFallbackOnErrorAllowed())
+
+	_, err = prototype.WithConfig(map[string]any{"user_search": map[string]any{"base_dn": "dc=foo"}})
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+}
+
+func TestLDAPAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	srv := testsupport.NewLDAPServer(t, nil, false,
+		testsupport.LDAPEntry{DN: "dc=example,dc=org"},
+		testsupport.LDAPEntry{DN: "cn=heimdall,dc=example,dc=org", Password: "service"},
+		testsupport.LDAPEntry{
+			DN:       "uid=alice,ou=users,dc=example,dc=org",
+			Password: "pass:word",
+			Attributes: map[string][]string{
+				"uid":  {"alice"},
+				"mail": {"alice@example.org"},
+				"cn":   {"Alice"},
+			},
+		},
+		testsupport.LDAPEntry{
+			DN:       "uid=bob,ou=users,dc=example,dc=org",
+			Password: "bob",
+			Attributes: map[string][]string{
+				"uid": {"bob"},
+				"cn":  {"Alice"},
+			},
+		},
+		testsupport.LDAPEntry{
+			DN: "cn=devs,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":     {"devs"},
+				"member": {"uid=alice,ou=users,dc=example,dc=org"},
+			},
+		},
+	)
+	srv.RequireBind = true
+
+	auth, err := newLDAPAuthenticator("auth3", map[string]any{
+		"connection": map[string]any{
+			"url":           srv.URL,
+			"bind_dn":       "cn=heimdall,dc=example,dc=org",
+			"bind_password": "service",
+		},
+		"user_search": map[string]any{"base_dn": "dc=example,dc=org"},
+		"attributes":  map[string]any{"email": "mail", "name": "cn"},
+		"groups":      map[string]any{"base_dn": "ou=groups,dc=example,dc=org"},
+	})
+	require.NoError(t, err)
+
+	basic := func(value string) string { return "Basic " + base64.StdEncoding.EncodeToString([]byte(value)) }
+
+	for _, tc := range []struct {
+		uc     string
+		header string
+		assert func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "no required header present",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "expected header not present")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "base64 decoding error",
+			header: "Basic bar",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to decode")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "malformed encoding",
+			header: basic("alice"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "malformed user-id - password")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "unknown user",
+			header: basic("carol:secret"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+
+				var identifier interface{ HandlerID() string }
+				require.ErrorAs(t, err, &identifier)
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc:     "filter injection attempt",
+			header: basic("*:pass:word"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "wrong password",
+			header: basic("alice:password"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "empty password",
+			header: basic("alice:"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "invalid user credentials")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "valid credentials",
+			header: basic("alice:pass:word"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "alice", sub.ID)
+				assert.Equal(t, map[string]any{
+					"dn":     "uid=alice,ou=users,dc=example,dc=org",
+					"email":  "alice@example.org",
+					"name":   "Alice",
+					"groups": []string{"devs"},
+				}, sub.Attributes)
+			},
+		},
+		{
+			uc:     "valid credentials of a user without groups",
+			header: basic("bob:bob"),
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "bob", sub.ID)
+				assert.Equal(t, map[string]any{
+					"dn":     "uid=bob,ou=users,dc=example,dc=org",
+					"name":   "Alice",
+					"groups": []string{},
+				}, sub.Attributes)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			ctx.On("RequestHeader", "Authorization").Return(tc.header)
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+		})
+	}
+}
+
+func TestLDAPAuthenticatorExecuteWithUnreachableServer(t *testing.T) {
+	t.Parallel()
+
+	auth, err := newLDAPAuthenticator("auth4", map[string]any{
+		"connection":  map[string]any{"url": "ldap://127.0.0.1:1"},
+		"user_search": map[string]any{"base_dn": "dc=example,dc=org"},
+	})
+	require.NoError(t, err)
+
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+	ctx.On("RequestHeader", "Authorization").
+		Return("Basic " + base64.StdEncoding.EncodeToString([]byte("alice:secret")))
+
+	// WHEN
+	sub, err := auth.Execute(ctx)
+
+	// THEN
+	require.Error(t, err)
+	assert.ErrorIs(t, err, heimdall.ErrCommunication)
+	assert.Contains(t, err.Error(), "failed to verify user credentials")
+	assert.Nil(t, sub)
+}
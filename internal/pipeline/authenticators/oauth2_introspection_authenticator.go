@@ -1,6 +1,7 @@
 package authenticators
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"errors"
@@ -22,6 +23,7 @@ import (
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
 // by intention. Used only during application bootstrap
@@ -46,6 +48,7 @@ type oauth2IntrospectionAuthenticator struct {
 	sf                   SubjectFactory
 	ads                  extractors.AuthDataExtractStrategy
 	ttl                  *time.Duration
+	cachePolicy          cache.Policy
 	allowFallbackOnError bool
 }
 
@@ -59,6 +62,7 @@ func newOAuth2IntrospectionAuthenticator(id string, rawConfig map[string]any) (
 		Assertions           oauth2.Expectation                  `mapstructure:"assertions"`
 		SubjectInfo          SubjectInfo                         `mapstructure:"subject"`
 		CacheTTL             *time.Duration                      `mapstructure:"cache_ttl"`
+		CachePolicy          cache.Policy                        `mapstructure:"cache_policy"`
 		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -127,6 +131,7 @@ func newOAuth2IntrospectionAuthenticator(id string, rawConfig map[string]any) (
 		a:                    conf.Assertions,
 		sf:                   &conf.SubjectInfo,
 		ttl:                  conf.CacheTTL,
+		cachePolicy:          conf.CachePolicy,
 		allowFallbackOnError: conf.AllowFallbackOnError,
 	}, nil
 }
@@ -161,7 +166,7 @@ func (a *oauth2IntrospectionAuthenticator) Execute(ctx heimdall.Context) (*subje
 }
 
 func (a *oauth2IntrospectionAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
-	// this authenticator allows assertions and ttl to be redefined on the rule level
+	// this authenticator allows assertions, ttl and cache policy to be redefined on the rule level
 	if len(rawConfig) == 0 {
 		return a, nil
 	}
@@ -169,6 +174,7 @@ func (a *oauth2IntrospectionAuthenticator) WithConfig(rawConfig map[string]any)
 	type Config struct {
 		Assertions           *oauth2.Expectation `mapstructure:"assertions"`
 		CacheTTL             *time.Duration      `mapstructure:"cache_ttl"`
+		CachePolicy          *cache.Policy       `mapstructure:"cache_policy"`
 		AllowFallbackOnError *bool               `mapstructure:"allow_fallback_on_error"`
 	}
 
@@ -186,6 +192,9 @@ func (a *oauth2IntrospectionAuthenticator) WithConfig(rawConfig map[string]any)
 		sf:  a.sf,
 		ads: a.ads,
 		ttl: x.IfThenElse(conf.CacheTTL != nil, conf.CacheTTL, a.ttl),
+		cachePolicy: x.IfThenElseExec(conf.CachePolicy != nil,
+			func() cache.Policy { return *conf.CachePolicy },
+			func() cache.Policy { return a.cachePolicy }),
 		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
 			func() bool { return *conf.AllowFallbackOnError },
 			func() bool { return a.allowFallbackOnError }),
@@ -201,70 +210,84 @@ func (a *oauth2IntrospectionAuthenticator) HandlerID() string {
 }
 
 func (a *oauth2IntrospectionAuthenticator) getSubjectInformation(ctx heimdall.Context, token string) ([]byte, error) {
-	cch := cache.Ctx(ctx.AppContext())
-	logger := zerolog.Ctx(ctx.AppContext())
+	if !a.isCacheEnabled() {
+		load, err := a.prepareIntrospection(ctx, token)
+		if err != nil {
+			return nil, err
+		}
 
-	var (
-		cacheKey       string
-		cacheEntry     any
-		cachedResponse []byte
-		ok             bool
-	)
+		loaded, err := load(ctx.AppContext())
 
-	if a.isCacheEnabled() {
-		cacheKey = a.calculateCacheKey(token)
-		cacheEntry = cch.Get(cacheKey)
+		return loaded.Value, err
 	}
 
-	if cacheEntry != nil {
-		if cachedResponse, ok = cacheEntry.([]byte); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing introspection response from cache")
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceAuthenticator)
 
-			return cachedResponse, nil
-		}
+	rawResp, err := cache.Load(ctx.AppContext(), cch, a.calculateCacheKey(token), a.cachePolicy,
+		func() (cache.LoadFunc[[]byte], error) { return a.prepareIntrospection(ctx, token) })
+	if errors.Is(err, cache.ErrCachedFailure) {
+		return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
 	}
 
-	introspectResp, rawResp, err := a.fetchTokenIntrospectionResponse(ctx, token)
+	return rawResp, err
+}
+
+// prepareIntrospection creates the request to the introspection endpoint. The returned function does not
+// refer to the request to heimdall, so that it can be executed after the latter has been served.
+func (a *oauth2IntrospectionAuthenticator) prepareIntrospection(
+	ctx heimdall.Context, token string,
+) (cache.LoadFunc[[]byte], error) {
+	// the token taken from the request to heimdall refers to memory reused for other requests
+	token = stringx.Clone(token)
+
+	req, err := a.e.CreateRequest(ctx.AppContext(), strings.NewReader(
+		url.Values{
+			"token":           []string{token},
+			"token_type_hint": []string{"access_token"},
+		}.Encode()), nil)
 	if err != nil {
-		return nil, err
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return func(loadCtx context.Context) (cache.Loaded[[]byte], error) {
+		return a.introspect(req.WithContext(loadCtx), token)
+	}, nil
+}
+
+func (a *oauth2IntrospectionAuthenticator) introspect(req *http.Request, token string) (cache.Loaded[[]byte], error) {
+	introspectResp, rawResp, err := a.fetchTokenIntrospectionResponse(req)
+	if err != nil {
+		return cache.Loaded[[]byte]{}, err
 	}
 
 	if err = introspectResp.Validate(a.a); err != nil {
-		return nil, errorchain.
+		return cache.Loaded[[]byte]{}, cache.Negative(errorchain.
 			NewWithMessage(heimdall.ErrAuthentication, "access token does not satisfy assertion conditions").
 			WithErrorContext(a).
-			CausedBy(err)
+			CausedBy(err))
 	}
 
-	if cacheTTL := a.getCacheTTL(introspectResp); cacheTTL > 0 {
-		cch.Set(cacheKey, rawResp, cacheTTL)
-	}
+	cacheTTL := a.getCacheTTL(introspectResp)
 
-	return rawResp, nil
+	return cache.Loaded[[]byte]{
+		Value: rawResp,
+		TTL:   cacheTTL,
+		Tags: x.IfThenElseExec(cacheTTL > 0,
+			func() []string { return cacheTags(a.id, token, a.sf, rawResp) },
+			func() []string { return nil }),
+	}, nil
 }
 
 func (a *oauth2IntrospectionAuthenticator) fetchTokenIntrospectionResponse(
-	ctx heimdall.Context, token string,
+	req *http.Request,
 ) (*oauth2.IntrospectionResponse, []byte, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
+	logger := zerolog.Ctx(req.Context())
 
 	logger.Debug().Msg("Retrieving information about the access token from the introspection endpoint")
 
-	req, err := a.e.CreateRequest(ctx.AppContext(), strings.NewReader(
-		url.Values{
-			"token":           []string{token},
-			"token_type_hint": []string{"access_token"},
-		}.Encode()), nil)
-	if err != nil {
-		return nil, nil, errorchain.
-			NewWithMessage(heimdall.ErrInternal, "failed creating request").
-			WithErrorContext(a).
-			CausedBy(err)
-	}
-
 	resp, err := a.e.CreateClient(req.URL.Hostname()).Do(req)
 	if err != nil {
 		var clientErr *url.Error
@@ -290,6 +313,12 @@ func (a *oauth2IntrospectionAuthenticator) fetchTokenIntrospectionResponse(
 func (a *oauth2IntrospectionAuthenticator) readIntrospectionResponse(
 	resp *http.Response,
 ) (*oauth2.IntrospectionResponse, []byte, error) {
+	if resp.StatusCode == http.StatusUnauthorized {
+		return nil, nil, cache.Negative(errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "introspection endpoint rejected the request").
+			WithErrorContext(a))
+	}
+
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
 		return nil, nil, errorchain.
 			NewWithMessagef(heimdall.ErrCommunication, "unexpected response code: %v", resp.StatusCode).
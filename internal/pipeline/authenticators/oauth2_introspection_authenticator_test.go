@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -191,6 +192,10 @@ assertions:
 subject:
   id: some_claim
 cache_ttl: 5s
+cache_policy:
+  refresh_ahead: 1s
+  stale_ttl: 1m
+  negative_ttl: 10s
 allow_fallback_on_error: true
 `),
 			assert: func(t *testing.T, err error, auth *oauth2IntrospectionAuthenticator) {
@@ -220,6 +225,11 @@ allow_fallback_on_error: true
 
 				// assert ttl
 				assert.Equal(t, 5*time.Second, *auth.ttl)
+				assert.Equal(t, cache.Policy{
+					RefreshAhead: 1 * time.Second,
+					StaleTTL:     1 * time.Minute,
+					NegativeTTL:  10 * time.Second,
+				}, auth.cachePolicy)
 
 				// assert token extractor settings
 				assert.IsType(t, extractors.CompositeExtractStrategy{}, auth.ads)
@@ -391,12 +401,16 @@ assertions:
     - foobar
 subject:
   id: some_template
-cache_ttl: 5s`),
+cache_ttl: 5s
+cache_policy:
+  negative_ttl: 10s`),
 			config: []byte(`
 assertions:
   issuers:
     - barfoo
 cache_ttl: 15s
+cache_policy:
+  stale_ttl: 1m
 `),
 			assert: func(t *testing.T, err error, prototype *oauth2IntrospectionAuthenticator,
 				configured *oauth2IntrospectionAuthenticator,
@@ -413,6 +427,8 @@ cache_ttl: 15s
 
 				assert.Equal(t, 5*time.Second, *prototype.ttl)
 				assert.Equal(t, 15*time.Second, *configured.ttl)
+				assert.Equal(t, cache.Policy{NegativeTTL: 10 * time.Second}, prototype.cachePolicy)
+				assert.Equal(t, cache.Policy{StaleTTL: 1 * time.Minute}, configured.cachePolicy)
 				assert.Equal(t, prototype.IsFallbackOnErrorAllowed(), configured.IsFallbackOnErrorAllowed())
 				assert.Equal(t, "auth2", configured.HandlerID())
 			},
@@ -622,6 +638,83 @@ func TestOauth2IntrospectionAuthenticatorExecute(t *testing.T) {
 				assert.Equal(t, "auth3", identifier.HandlerID())
 			},
 		},
+		{
+			uc: "with default cache, negative caching and endpoint rejecting the request",
+			authenticator: &oauth2IntrospectionAuthenticator{
+				id:          "auth3",
+				e:           endpoint.Endpoint{URL: srv.URL},
+				cachePolicy: cache.Policy{NegativeTTL: 10 * time.Second},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.MockContext,
+				cch *mocks.MockCache,
+				ads *mockAuthDataGetter,
+				auth *oauth2IntrospectionAuthenticator,
+			) {
+				t.Helper()
+
+				cacheKey := auth.calculateCacheKey("test_access_token")
+
+				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "test_access_token"}, nil)
+				cch.On("Get", cacheKey).Return(nil)
+				cch.On("Set", cacheKey, mock.MatchedBy(func(entry *cache.Entry) bool {
+					return entry.Value == nil && strings.Contains(entry.Failure, "rejected")
+				}), 10*time.Second)
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusUnauthorized
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "rejected")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "with default cache and cached authentication failure",
+			authenticator: &oauth2IntrospectionAuthenticator{
+				id:          "auth3",
+				e:           endpoint.Endpoint{URL: srv.URL},
+				cachePolicy: cache.Policy{NegativeTTL: 10 * time.Second},
+			},
+			configureMocks: func(t *testing.T,
+				ctx *heimdallmocks.MockContext,
+				cch *mocks.MockCache,
+				ads *mockAuthDataGetter,
+				auth *oauth2IntrospectionAuthenticator,
+			) {
+				t.Helper()
+
+				ads.On("GetAuthData", ctx).Return(dummyAuthData{Val: "test_access_token"}, nil)
+				cch.On("Get", auth.calculateCacheKey("test_access_token")).Return(&cache.Entry{
+					Failure:   "access token does not satisfy assertion conditions",
+					ExpiresAt: time.Now().Add(10 * time.Second),
+				})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, cache.ErrCachedFailure)
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+			},
+		},
 		{
 			uc: "with disabled cache and failing unmarshalling of the service response",
 			authenticator: &oauth2IntrospectionAuthenticator{
@@ -0,0 +1,137 @@
+package authenticators
+
+import (
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/login"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authenticator, error) {
+			if typ != config.POTOIDCSession {
+				return false, nil, nil
+			}
+
+			auth, err := newOIDCSessionAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type oidcSessionAuthenticator struct {
+	id                   string
+	sf                   SubjectFactory
+	allowFallbackOnError bool
+}
+
+func newOIDCSessionAuthenticator(id string, rawConfig map[string]any) (*oidcSessionAuthenticator, error) {
+	type Config struct {
+		SubjectInfo          SubjectInfo `mapstructure:"subject"`
+		AllowFallbackOnError bool        `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode oidc_session authenticator config").
+			CausedBy(err)
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "sub"
+	}
+
+	return &oidcSessionAuthenticator{
+		id:                   id,
+		sf:                   &conf.SubjectInfo,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *oidcSessionAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authenticating using oidc_session authenticator")
+
+	rp := login.Ctx(ctx.AppContext())
+	if rp == nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "login is not configured").
+			WithErrorContext(a)
+	}
+
+	cookieName := rp.SessionCookie().Name
+
+	value := ctx.RequestCookie(cookieName)
+	if len(value) == 0 {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "no '%s' cookie present", cookieName).
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument)
+	}
+
+	session, err := rp.Session(ctx.AppContext(), value)
+	if err != nil {
+		return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+	}
+
+	rawClaims, err := json.Marshal(session.Claims)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal session claims").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawClaims)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from session").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *oidcSessionAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback to be redefined on the rule level
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode oidc_session authenticator config").
+			CausedBy(err)
+	}
+
+	return &oidcSessionAuthenticator{
+		id: a.id,
+		sf: a.sf,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *oidcSessionAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *oidcSessionAuthenticator) HandlerID() string {
+	return a.id
+}
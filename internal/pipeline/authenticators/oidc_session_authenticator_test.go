@@ -0,0 +1,340 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/login"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func TestCreateOIDCSessionAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *oidcSessionAuthenticator)
+	}{
+		{
+			uc: "without configuration",
+			id: "auth1",
+			assert: func(t *testing.T, err error, auth *oidcSessionAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Equal(t, &SubjectInfo{IDFrom: "sub"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			id: "auth2",
+			config: []byte(`
+subject:
+  id: email
+  attributes: "@this"
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *oidcSessionAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				assert.Equal(t, &SubjectInfo{IDFrom: "email", AttributesFrom: "@this"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "with unsupported attributes",
+			config: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, auth *oidcSessionAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newOIDCSessionAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateOIDCSessionAuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *oidcSessionAuthenticator,
+			configured *oidcSessionAuthenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			id: "auth1",
+			assert: func(t *testing.T, err error, prototype *oidcSessionAuthenticator,
+				configured *oidcSessionAuthenticator,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "fallback on error is redefined",
+			id:     "auth2",
+			config: []byte(`allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype *oidcSessionAuthenticator,
+				configured *oidcSessionAuthenticator,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "not overridable attribute is used",
+			config: []byte(`subject: { id: foo }`),
+			assert: func(t *testing.T, err error, prototype *oidcSessionAuthenticator,
+				configured *oidcSessionAuthenticator,
+			) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newOIDCSessionAuthenticator(tc.id, nil)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				osAuth *oidcSessionAuthenticator
+				ok     bool
+			)
+
+			if err == nil {
+				osAuth, ok = auth.(*oidcSessionAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, osAuth)
+		})
+	}
+}
+
+func TestOIDCSessionAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	const secret = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+	rp, err := login.NewRelyingParty(config.LoginConfig{
+		Issuer:      "https://idp.local",
+		ClientID:    "heimdall",
+		RedirectURL: "https://heimdall.local/_heimdall/callback",
+		Session:     config.LoginSessionConfig{Secret: secret, CookieName: "session"},
+	}, memory.New())
+	require.NoError(t, err)
+
+	// the cookie session store encrypts the session using a key derived from the secret
+	encodeSession := func(t *testing.T, sess *login.Session) string {
+		t.Helper()
+
+		raw, err := json.Marshal(sess)
+		require.NoError(t, err)
+
+		key := sha256.Sum256([]byte(secret))
+		encrypter, err := jose.NewEncrypter(jose.A256GCM,
+			jose.Recipient{Algorithm: jose.DIRECT, Key: key[:]}, nil)
+		require.NoError(t, err)
+
+		obj, err := encrypter.Encrypt(raw)
+		require.NoError(t, err)
+
+		value, err := obj.CompactSerialize()
+		require.NoError(t, err)
+
+		return value
+	}
+
+	for _, tc := range []struct {
+		uc               string
+		id               string
+		withoutLogin     bool
+		configureContext func(t *testing.T, ctx *mocks.MockContext)
+		assert           func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:           "login is not configured",
+			id:           "auth1",
+			withoutLogin: true,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth1", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "no session cookie present",
+			id: "auth2",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return("")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no 'session' cookie")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth2", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "invalid session cookie",
+			id: "auth3",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return("foo.bar.baz")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to load session")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "expired session",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return(encodeSession(t, &login.Session{
+					Claims:    map[string]any{"sub": "foo"},
+					ExpiresAt: time.Now().Add(-time.Minute),
+				}))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "session expired")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "valid session",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return(encodeSession(t, &login.Session{
+					Claims:    map[string]any{"sub": "foo", "email": "foo@bar.baz"},
+					ExpiresAt: time.Now().Add(time.Minute),
+				}))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+				assert.Equal(t, "foo@bar.baz", sub.Attributes["email"])
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			configureContext := x.IfThenElse(tc.configureContext != nil,
+				tc.configureContext,
+				func(t *testing.T, ctx *mocks.MockContext) { t.Helper() })
+
+			appCtx := x.IfThenElse(tc.withoutLogin,
+				context.Background(),
+				login.WithContext(context.Background(), rp))
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(appCtx)
+			configureContext(t, ctx)
+
+			auth, err := newOIDCSessionAuthenticator(tc.id, nil)
+			require.NoError(t, err)
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package authenticators
+
+import (
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"golang.org/x/exp/slices"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	sessionCookieFormatJWT = "jwt"
+	sessionCookieFormatJWE = "jwe"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authenticator, error) {
+			if typ != config.POTSessionCookie {
+				return false, nil, nil
+			}
+
+			auth, err := newSessionCookieAuthenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type sessionCookieAuthenticator struct {
+	id                   string
+	cookieName           string
+	format               string
+	ks                   keystore.KeyStore
+	allowedAlgorithms    []string
+	sf                   SubjectFactory
+	sessionLifespanConf  *SessionLifespanConfig
+	allowFallbackOnError bool
+}
+
+func newSessionCookieAuthenticator(id string, rawConfig map[string]any) (*sessionCookieAuthenticator, error) {
+	type Config struct {
+		CookieName            string                 `mapstructure:"cookie_name"`
+		Format                string                 `mapstructure:"format"`
+		KeyStore              string                 `mapstructure:"key_store"`
+		Password              string                 `mapstructure:"password"`
+		AllowedAlgorithms     []string               `mapstructure:"allowed_algorithms"`
+		SubjectInfo           SubjectInfo            `mapstructure:"subject"`
+		SessionLifespanConfig *SessionLifespanConfig `mapstructure:"session_lifespan"`
+		AllowFallbackOnError  bool                   `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode session_cookie authenticator config").
+			CausedBy(err)
+	}
+
+	if len(conf.CookieName) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "session_cookie authenticator requires cookie_name to be set")
+	}
+
+	if len(conf.Format) == 0 {
+		conf.Format = sessionCookieFormatJWT
+	}
+
+	if conf.Format != sessionCookieFormatJWT && conf.Format != sessionCookieFormatJWE {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrConfiguration,
+				"session_cookie authenticator does not support '%s' format", conf.Format)
+	}
+
+	if len(conf.KeyStore) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "session_cookie authenticator requires key_store to be set")
+	}
+
+	ks, err := keystore.NewKeyStoreFromPEMFile(conf.KeyStore, conf.Password)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed loading key store for session_cookie authenticator").
+			CausedBy(err)
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "sub"
+	}
+
+	if conf.SessionLifespanConfig == nil {
+		conf.SessionLifespanConfig = &SessionLifespanConfig{
+			IssuedAtField:  "iat",
+			NotBeforeField: "nbf",
+			NotAfterField:  "exp",
+		}
+	}
+
+	return &sessionCookieAuthenticator{
+		id:         id,
+		cookieName: conf.CookieName,
+		format:     conf.Format,
+		ks:         ks,
+		allowedAlgorithms: x.IfThenElseExec(len(conf.AllowedAlgorithms) != 0,
+			func() []string { return conf.AllowedAlgorithms },
+			defaultAllowedAlgorithms),
+		sf:                   &conf.SubjectInfo,
+		sessionLifespanConf:  conf.SessionLifespanConfig,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *sessionCookieAuthenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authenticating using session_cookie authenticator")
+
+	value := ctx.RequestCookie(a.cookieName)
+	if len(value) == 0 {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "no '%s' cookie present", a.cookieName).
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument)
+	}
+
+	rawClaims, err := x.IfThenElseExecErr(a.format == sessionCookieFormatJWE,
+		func() ([]byte, error) { return a.decryptSession(value) },
+		func() ([]byte, error) { return a.verifySession(value) })
+	if err != nil {
+		return nil, err
+	}
+
+	session, err := a.sessionLifespanConf.CreateSessionLifespan(rawClaims)
+	if err != nil {
+		return nil, errorchain.New(heimdall.ErrInternal).WithErrorContext(a).CausedBy(err)
+	}
+
+	if err = session.Assert(); err != nil {
+		return nil, errorchain.New(heimdall.ErrAuthentication).WithErrorContext(a).CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawClaims)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from session").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *sessionCookieAuthenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows the session lifespan and the fallback to be redefined on the rule level
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		SessionLifespanConfig *SessionLifespanConfig `mapstructure:"session_lifespan"`
+		AllowFallbackOnError  *bool                  `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to decode session_cookie authenticator config").
+			CausedBy(err)
+	}
+
+	return &sessionCookieAuthenticator{
+		id:                a.id,
+		cookieName:        a.cookieName,
+		format:            a.format,
+		ks:                a.ks,
+		allowedAlgorithms: a.allowedAlgorithms,
+		sf:                a.sf,
+		sessionLifespanConf: x.IfThenElse(conf.SessionLifespanConfig != nil,
+			conf.SessionLifespanConfig, a.sessionLifespanConf),
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *sessionCookieAuthenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *sessionCookieAuthenticator) HandlerID() string {
+	return a.id
+}
+
+func (a *sessionCookieAuthenticator) decryptSession(value string) ([]byte, error) {
+	jwe, err := jose.ParseEncrypted(value)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to parse session cookie").
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument).
+			CausedBy(err)
+	}
+
+	// RSA PKCS v1.5 is not allowed by intention
+	if jwe.Header.Algorithm == string(jose.RSA1_5) {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "%s algorithm is not allowed", jwe.Header.Algorithm).
+			WithErrorContext(a)
+	}
+
+	keys, err := a.candidateKeys(jwe.Header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var plaintext []byte
+
+	for _, entry := range keys {
+		if plaintext, err = jwe.Decrypt(entry.PrivateKey); err == nil {
+			break
+		}
+	}
+
+	if plaintext == nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to decrypt session cookie").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	// only nested JWTs are accepted. The public keys are published via the JWKS endpoint,
+	// so anyone could encrypt a plain session object
+	if contentType, ok := jwe.Header.ExtraHeaders[jose.HeaderContentType].(string); !ok || contentType != "JWT" {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "encrypted session does not contain a signed JWT").
+			WithErrorContext(a)
+	}
+
+	return a.verifySession(string(plaintext))
+}
+
+func (a *sessionCookieAuthenticator) verifySession(value string) ([]byte, error) {
+	token, err := jwt.ParseSigned(value)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to parse session cookie").
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument).
+			CausedBy(err)
+	}
+
+	header := token.Headers[0]
+	if !slices.Contains(a.allowedAlgorithms, header.Algorithm) {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication, "%s algorithm is not allowed", header.Algorithm).
+			WithErrorContext(a)
+	}
+
+	keys, err := a.candidateKeys(header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var claims map[string]any
+
+	for _, entry := range keys {
+		if err = token.Claims(entry.PrivateKey.Public(), &claims); err == nil {
+			break
+		}
+	}
+
+	if claims == nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to verify session cookie signature").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	rawClaims, err := json.Marshal(claims)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal session claims").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return rawClaims, nil
+}
+
+func (a *sessionCookieAuthenticator) candidateKeys(keyID string) ([]*keystore.Entry, error) {
+	if len(keyID) == 0 {
+		return a.ks.Entries(), nil
+	}
+
+	entry, err := a.ks.GetKey(keyID)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrAuthentication,
+				"no key found for the keyID='%s' referenced in the session cookie", keyID).
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return []*keystore.Entry{entry}, nil
+}
@@ -0,0 +1,716 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+	"gopkg.in/square/go-jose.v2/jwt"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func TestCreateSessionCookieAuthenticator(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(
+		testsupport.WithECDSAPrivateKey(privKey, testsupport.WithPEMHeader("X-Key-ID", "key1")))
+	require.NoError(t, err)
+
+	keyStoreFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyStoreFile, pemBytes, 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *sessionCookieAuthenticator)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires cookie_name")
+			},
+		},
+		{
+			uc:     "without key_store",
+			config: []byte(`cookie_name: session`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires key_store")
+			},
+		},
+		{
+			uc: "with not existing key_store",
+			config: []byte(`
+cookie_name: session
+key_store: /does/not/exist.pem
+`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed loading key store")
+			},
+		},
+		{
+			uc: "with unsupported format",
+			config: []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+format: paseto
+`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "paseto")
+			},
+		},
+		{
+			uc: "with unsupported attributes",
+			config: []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc: "with minimal valid configuration",
+			id: "auth1",
+			config: []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Equal(t, "session", auth.cookieName)
+				assert.Equal(t, sessionCookieFormatJWT, auth.format)
+				assert.Len(t, auth.ks.Entries(), 1)
+				assert.ElementsMatch(t, defaultAllowedAlgorithms(), auth.allowedAlgorithms)
+				assert.Equal(t, &SubjectInfo{IDFrom: "sub"}, auth.sf)
+				assert.Equal(t, &SessionLifespanConfig{
+					IssuedAtField:  "iat",
+					NotBeforeField: "nbf",
+					NotAfterField:  "exp",
+				}, auth.sessionLifespanConf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with full configuration",
+			id: "auth2",
+			config: []byte(`
+cookie_name: __Host-session
+format: jwe
+key_store: ` + keyStoreFile + `
+allowed_algorithms:
+  - ES384
+subject:
+  id: identity.id
+  attributes: identity
+session_lifespan:
+  not_after: expires_at
+  time_format: "2006-01-02T15:04:05Z07:00"
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *sessionCookieAuthenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				assert.Equal(t, "__Host-session", auth.cookieName)
+				assert.Equal(t, sessionCookieFormatJWE, auth.format)
+				assert.Equal(t, []string{"ES384"}, auth.allowedAlgorithms)
+				assert.Equal(t, &SubjectInfo{IDFrom: "identity.id", AttributesFrom: "identity"}, auth.sf)
+				assert.Equal(t, &SessionLifespanConfig{
+					NotAfterField: "expires_at",
+					TimeFormat:    "2006-01-02T15:04:05Z07:00",
+				}, auth.sessionLifespanConf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newSessionCookieAuthenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateSessionCookieAuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(testsupport.WithECDSAPrivateKey(privKey))
+	require.NoError(t, err)
+
+	keyStoreFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyStoreFile, pemBytes, 0o600))
+
+	prototypeConfig := []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *sessionCookieAuthenticator,
+			configured *sessionCookieAuthenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			id: "auth1",
+			assert: func(t *testing.T, err error, prototype *sessionCookieAuthenticator,
+				configured *sessionCookieAuthenticator,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc: "session lifespan and fallback on error are redefined",
+			id: "auth2",
+			config: []byte(`
+session_lifespan:
+  not_after: valid_until
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, prototype *sessionCookieAuthenticator,
+				configured *sessionCookieAuthenticator,
+			) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.cookieName, configured.cookieName)
+				assert.Equal(t, prototype.format, configured.format)
+				assert.Equal(t, prototype.ks, configured.ks)
+				assert.Equal(t, prototype.allowedAlgorithms, configured.allowedAlgorithms)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.Equal(t, &SessionLifespanConfig{NotAfterField: "valid_until"}, configured.sessionLifespanConf)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "not overridable attribute is used",
+			config: []byte(`cookie_name: foo`),
+			assert: func(t *testing.T, err error, prototype *sessionCookieAuthenticator,
+				configured *sessionCookieAuthenticator,
+			) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(prototypeConfig)
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newSessionCookieAuthenticator(tc.id, pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				scAuth *sessionCookieAuthenticator
+				ok     bool
+			)
+
+			if err == nil {
+				scAuth, ok = auth.(*sessionCookieAuthenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, scAuth)
+		})
+	}
+}
+
+// nolint: maintidx
+func TestSessionCookieAuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	ecPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rsaPrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	unknownPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(
+		testsupport.WithECDSAPrivateKey(ecPrivKey, testsupport.WithPEMHeader("X-Key-ID", "ec-key")),
+		testsupport.WithRSAPrivateKey(rsaPrivKey, testsupport.WithPEMHeader("X-Key-ID", "rsa-key")),
+	)
+	require.NoError(t, err)
+
+	keyStoreFile := filepath.Join(t.TempDir(), "keys.pem")
+	require.NoError(t, os.WriteFile(keyStoreFile, pemBytes, 0o600))
+
+	now := time.Now()
+	validClaims := map[string]any{
+		"sub":   "foo",
+		"email": "foo@bar.baz",
+		"iat":   now.Unix() - 1,
+		"nbf":   now.Unix() - 1,
+		"exp":   now.Add(time.Minute).Unix(),
+	}
+	expiredClaims := map[string]any{
+		"sub": "foo",
+		"iat": now.Add(-2 * time.Hour).Unix(),
+		"exp": now.Add(-1 * time.Hour).Unix(),
+	}
+
+	newSigner := func(t *testing.T, alg jose.SignatureAlgorithm, key any, kid string) jose.Signer {
+		t.Helper()
+
+		opts := (&jose.SignerOptions{}).WithType("JWT")
+		if len(kid) != 0 {
+			opts = opts.WithHeader("kid", kid)
+		}
+
+		signer, err := jose.NewSigner(jose.SigningKey{Algorithm: alg, Key: key}, opts)
+		require.NoError(t, err)
+
+		return signer
+	}
+
+	newEncrypter := func(t *testing.T, alg jose.KeyAlgorithm, key any, kid string, nested bool) jose.Encrypter {
+		t.Helper()
+
+		opts := &jose.EncrypterOptions{}
+		if nested {
+			opts = opts.WithContentType("JWT")
+		}
+
+		encrypter, err := jose.NewEncrypter(jose.A256GCM, jose.Recipient{Algorithm: alg, Key: key, KeyID: kid}, opts)
+		require.NoError(t, err)
+
+		return encrypter
+	}
+
+	signedSession := func(t *testing.T, signer jose.Signer, claims map[string]any) string {
+		t.Helper()
+
+		value, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+		require.NoError(t, err)
+
+		return value
+	}
+
+	jwtConfig := []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+subject:
+  id: sub
+  attributes: "@this"
+`)
+	jweConfig := []byte(`
+cookie_name: session
+format: jwe
+key_store: ` + keyStoreFile + `
+`)
+
+	for _, tc := range []struct {
+		uc               string
+		id               string
+		config           []byte
+		configureContext func(t *testing.T, ctx *mocks.MockContext)
+		assert           func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:     "no session cookie present",
+			id:     "auth1",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return("")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no 'session' cookie")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth1", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "malformed session cookie",
+			id:     "auth2",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").Return("foo.bar")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to parse")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth2", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "signed session with not allowed algorithm",
+			config: []byte(`
+cookie_name: session
+key_store: ` + keyStoreFile + `
+allowed_algorithms:
+  - PS256
+`),
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, ecPrivKey, "ec-key"), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "ES256 algorithm is not allowed")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "signed session referencing unknown key",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, unknownPrivKey, "foo"), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "no key found")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "signed session with invalid signature",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, unknownPrivKey, ""), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to verify")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "signed session is expired",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, ecPrivKey, "ec-key"), expiredClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "valid signed session referencing key by kid",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.PS256, rsaPrivKey, "rsa-key"), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+				assert.Equal(t, "foo@bar.baz", sub.Attributes["email"])
+			},
+		},
+		{
+			uc:     "valid signed session without kid",
+			config: jwtConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, ecPrivKey, ""), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc:     "signed session used with jwe format",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestCookie", "session").
+					Return(signedSession(t, newSigner(t, jose.ES256, ecPrivKey, "ec-key"), validClaims))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "encrypted session using RSA1_5",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.Encrypted(newEncrypter(t, jose.RSA1_5, &rsaPrivKey.PublicKey, "rsa-key", false)).
+					Claims(validClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "RSA1_5 algorithm is not allowed")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "encrypted session for unknown key",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.Encrypted(newEncrypter(t, jose.ECDH_ES_A256KW, &unknownPrivKey.PublicKey, "", false)).
+					Claims(validClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to decrypt")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "encrypted session with plain claims",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.Encrypted(newEncrypter(t, jose.RSA_OAEP_256, &rsaPrivKey.PublicKey, "rsa-key", false)).
+					Claims(validClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "does not contain a signed JWT")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "encrypted session with plain claims declared as nested JWT",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.Encrypted(newEncrypter(t, jose.RSA_OAEP_256, &rsaPrivKey.PublicKey, "rsa-key", true)).
+					Claims(validClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "failed to parse session cookie")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "valid encrypted session with nested signed claims",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.SignedAndEncrypted(
+					newSigner(t, jose.ES256, ecPrivKey, "ec-key"),
+					newEncrypter(t, jose.ECDH_ES_A256KW, &ecPrivKey.PublicKey, "ec-key", true)).
+					Claims(validClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc:     "encrypted expired session",
+			config: jweConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				value, err := jwt.SignedAndEncrypted(
+					newSigner(t, jose.ES256, ecPrivKey, "ec-key"),
+					newEncrypter(t, jose.ECDH_ES_A256KW, &ecPrivKey.PublicKey, "ec-key", true)).
+					Claims(expiredClaims).CompactSerialize()
+				require.NoError(t, err)
+
+				ctx.On("RequestCookie", "session").Return(value)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Nil(t, sub)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			configureContext := x.IfThenElse(tc.configureContext != nil,
+				tc.configureContext,
+				func(t *testing.T, ctx *mocks.MockContext) { t.Helper() })
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			configureContext(t, ctx)
+
+			auth, err := newSessionCookieAuthenticator(tc.id, conf)
+			require.NoError(t, err)
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
@@ -1,9 +1,22 @@
 package authenticators
 
 import (
+	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 )
 
 type SubjectFactory interface {
 	CreateSubject(rawData []byte) (*subject.Subject, error)
 }
+
+// cacheTags returns the tags to index the cache entry holding the subject information for the
+// given token with.
+func cacheTags(handlerID, token string, sf SubjectFactory, rawData []byte) []string {
+	tags := []string{cache.HandlerTag(handlerID), cache.TokenTag(token)}
+
+	if sub, err := sf.CreateSubject(rawData); err == nil {
+		tags = append(tags, cache.SubjectTag(sub.ID))
+	}
+
+	return tags
+}
@@ -0,0 +1,333 @@
+package authenticators
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	x509pkix "crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"net/url"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthenticatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authenticator, error) {
+			if typ != config.POTX509 {
+				return false, nil, nil
+			}
+
+			auth, err := newX509Authenticator(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type x509Authenticator struct {
+	id                   string
+	ads                  extractors.AuthDataExtractStrategy
+	sf                   SubjectFactory
+	trustStore           truststore.TrustStore
+	allowFallbackOnError bool
+}
+
+func newX509Authenticator(id string, rawConfig map[string]any) (*x509Authenticator, error) {
+	type Config struct {
+		CertSource           extractors.CompositeExtractStrategy `mapstructure:"cert_source"`
+		SubjectInfo          SubjectInfo                         `mapstructure:"subject"`
+		TrustStore           truststore.TrustStore               `mapstructure:"trust_store"`
+		AllowFallbackOnError bool                                `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var (
+		conf Config
+		ads  extractors.AuthDataExtractStrategy
+	)
+
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal x509 authenticator config").
+			CausedBy(err)
+	}
+
+	// certificates received via a header have not been verified by anyone.
+	// so we need the trust anchors to do it
+	if conf.CertSource != nil && len(conf.TrustStore) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration,
+				"x509 authenticator requires a trust_store if cert_source is configured")
+	}
+
+	if len(conf.SubjectInfo.IDFrom) == 0 {
+		conf.SubjectInfo.IDFrom = "subject.dn"
+	}
+
+	if conf.CertSource != nil {
+		ads = conf.CertSource
+	}
+
+	return &x509Authenticator{
+		id:                   id,
+		ads:                  ads,
+		sf:                   &conf.SubjectInfo,
+		trustStore:           conf.TrustStore,
+		allowFallbackOnError: conf.AllowFallbackOnError,
+	}, nil
+}
+
+func (a *x509Authenticator) Execute(ctx heimdall.Context) (*subject.Subject, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authenticating using x509 authenticator")
+
+	chain, err := a.getVerifiedChain(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := json.Marshal(certificateInfo(chain[0]))
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal certificate information").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	sub, err := a.sf.CreateSubject(rawData)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to extract subject information from certificate").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return sub, nil
+}
+
+func (a *x509Authenticator) WithConfig(rawConfig map[string]any) (Authenticator, error) {
+	// this authenticator allows only the fallback to be redefined on the rule level
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		AllowFallbackOnError *bool `mapstructure:"allow_fallback_on_error"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal x509 authenticator config").
+			CausedBy(err)
+	}
+
+	return &x509Authenticator{
+		id:         a.id,
+		ads:        a.ads,
+		sf:         a.sf,
+		trustStore: a.trustStore,
+		allowFallbackOnError: x.IfThenElseExec(conf.AllowFallbackOnError != nil,
+			func() bool { return *conf.AllowFallbackOnError },
+			func() bool { return a.allowFallbackOnError }),
+	}, nil
+}
+
+func (a *x509Authenticator) IsFallbackOnErrorAllowed() bool {
+	return a.allowFallbackOnError
+}
+
+func (a *x509Authenticator) HandlerID() string {
+	return a.id
+}
+
+func (a *x509Authenticator) getVerifiedChain(ctx heimdall.Context) ([]*x509.Certificate, error) {
+	if a.ads != nil {
+		return a.getVerifiedChainFromRequest(ctx)
+	}
+
+	state := ctx.RequestTLSConnectionState()
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no client certificate present").
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument)
+	}
+
+	if len(a.trustStore) == 0 {
+		// we have to rely on the verification done during the TLS handshake
+		if len(state.VerifiedChains) == 0 {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrAuthentication,
+					"client certificate has not been verified and no trust store is configured").
+				WithErrorContext(a)
+		}
+
+		return state.VerifiedChains[0], nil
+	}
+
+	return a.verifyChain(state.PeerCertificates)
+}
+
+func (a *x509Authenticator) getVerifiedChainFromRequest(ctx heimdall.Context) ([]*x509.Certificate, error) {
+	authData, err := a.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "no client certificate present").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	chain, err := parseClientCertificates(authData.Value())
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "failed to parse client certificate").
+			WithErrorContext(a).
+			CausedBy(heimdall.ErrArgument).
+			CausedBy(err)
+	}
+
+	return a.verifyChain(chain)
+}
+
+func (a *x509Authenticator) verifyChain(chain []*x509.Certificate) ([]*x509.Certificate, error) {
+	if err := pkix.ValidateCertificate(chain[0],
+		pkix.WithIntermediateCACertificates(chain[1:]),
+		pkix.WithRootCACertificates(a.trustStore),
+		pkix.WithExtendedKeyUsage(x509.ExtKeyUsageClientAuth),
+	); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrAuthentication, "client certificate is invalid").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	return chain, nil
+}
+
+// parseClientCertificates supports the formats, typically used by reverse proxies to forward client
+// certificates: plain or url encoded PEM (e.g. NGINX), base64 encoded DER without PEM armor, with
+// multiple certificates separated by comma (e.g. Traefik) and the Envoy X-Forwarded-Client-Cert format.
+func parseClientCertificates(value string) ([]*x509.Certificate, error) {
+	const envoyCertKey = `Cert="`
+
+	if idx := strings.Index(value, envoyCertKey); idx != -1 {
+		value = value[idx+len(envoyCertKey):]
+
+		if end := strings.Index(value, `"`); end != -1 {
+			value = value[:end]
+		}
+	}
+
+	if strings.Contains(value, "%") {
+		unescaped, err := url.PathUnescape(value)
+		if err != nil {
+			return nil, err
+		}
+
+		value = unescaped
+	}
+
+	value = strings.TrimSpace(value)
+	if strings.HasPrefix(value, "-----BEGIN") {
+		return parsePEMCertificates([]byte(value))
+	}
+
+	var certs []*x509.Certificate
+
+	for _, entry := range strings.Split(value, ",") {
+		der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(entry))
+		if err != nil {
+			return nil, err
+		}
+
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	return certs, nil
+}
+
+func parsePEMCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+
+		certs = append(certs, cert)
+	}
+
+	if len(certs) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrArgument, "no certificate found")
+	}
+
+	return certs, nil
+}
+
+func certificateInfo(cert *x509.Certificate) map[string]any {
+	nameInfo := func(name x509pkix.Name) map[string]any {
+		return map[string]any{
+			"dn":                  name.String(),
+			"common_name":         name.CommonName,
+			"serial_number":       name.SerialNumber,
+			"organization":        name.Organization,
+			"organizational_unit": name.OrganizationalUnit,
+			"country":             name.Country,
+			"province":            name.Province,
+			"locality":            name.Locality,
+		}
+	}
+
+	ipAddresses := make([]string, len(cert.IPAddresses))
+	for idx, ip := range cert.IPAddresses {
+		ipAddresses[idx] = ip.String()
+	}
+
+	uris := make([]string, len(cert.URIs))
+	for idx, uri := range cert.URIs {
+		uris[idx] = uri.String()
+	}
+
+	fingerprint := sha256.Sum256(cert.Raw)
+
+	return map[string]any{
+		"subject":       nameInfo(cert.Subject),
+		"issuer":        nameInfo(cert.Issuer),
+		"serial_number": cert.SerialNumber.Text(16), // nolint: gomnd
+		"not_before":    cert.NotBefore.Unix(),
+		"not_after":     cert.NotAfter.Unix(),
+		"san": map[string]any{
+			"dns_names":       cert.DNSNames,
+			"email_addresses": cert.EmailAddresses,
+			"ip_addresses":    ipAddresses,
+			"uris":            uris,
+		},
+		"fingerprint": map[string]any{
+			"sha256": hex.EncodeToString(fingerprint[:]),
+		},
+	}
+}
@@ -0,0 +1,525 @@
+package authenticators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func TestCreateX509Authenticator(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(testsupport.WithX509Certificate(rootCA.Certificate))
+	require.NoError(t, err)
+
+	trustStoreFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(trustStoreFile, pemBytes, 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *x509Authenticator)
+	}{
+		{
+			uc: "without configuration",
+			id: "auth1",
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth1", auth.HandlerID())
+				assert.Nil(t, auth.ads)
+				assert.Empty(t, auth.trustStore)
+				assert.Equal(t, &SubjectInfo{IDFrom: "subject.dn"}, auth.sf)
+				assert.False(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc: "with cert_source, but without trust_store",
+			config: []byte(`
+cert_source:
+  - header: X-Forwarded-Client-Cert
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires a trust_store")
+			},
+		},
+		{
+			uc: "with unsupported attributes",
+			config: []byte(`
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc: "with full configuration",
+			id: "auth2",
+			config: []byte(`
+cert_source:
+  - header: X-Forwarded-Client-Cert
+trust_store: ` + trustStoreFile + `
+subject:
+  id: subject.common_name
+  attributes: san
+allow_fallback_on_error: true
+`),
+			assert: func(t *testing.T, err error, auth *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "auth2", auth.HandlerID())
+				assert.NotNil(t, auth.ads)
+				require.Len(t, auth.trustStore, 1)
+				assert.Equal(t, rootCA.Certificate, auth.trustStore[0])
+				assert.Equal(t, &SubjectInfo{IDFrom: "subject.common_name", AttributesFrom: "san"}, auth.sf)
+				assert.True(t, auth.IsFallbackOnErrorAllowed())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newX509Authenticator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateX509AuthenticatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc        string
+		id        string
+		prototype []byte
+		config    []byte
+		assert    func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator)
+	}{
+		{
+			uc: "no new configuration provided",
+			id: "auth1",
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "fallback on error is redefined",
+			id:     "auth2",
+			config: []byte(`allow_fallback_on_error: true`),
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.sf, configured.sf)
+				assert.False(t, prototype.IsFallbackOnErrorAllowed())
+				assert.True(t, configured.IsFallbackOnErrorAllowed())
+			},
+		},
+		{
+			uc:     "not overridable attribute is used",
+			config: []byte(`subject: { id: foo }`),
+			assert: func(t *testing.T, err error, prototype *x509Authenticator, configured *x509Authenticator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(tc.prototype)
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newX509Authenticator(tc.id, pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				x509Auth *x509Authenticator
+				ok       bool
+			)
+
+			if err == nil {
+				x509Auth, ok = auth.(*x509Authenticator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, x509Auth)
+		})
+	}
+}
+
+// nolint: maintidx
+func TestX509AuthenticatorExecute(t *testing.T) {
+	t.Parallel()
+
+	type HandlerIdentifier interface {
+		HandlerID() string
+	}
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	otherCA, err := testsupport.NewRootCA("Other Root CA", time.Hour*24)
+	require.NoError(t, err)
+
+	clientPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	clientCert, err := rootCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{
+			CommonName:   "foo",
+			Organization: []string{"Test"},
+			Country:      []string{"EU"},
+		}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&clientPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	untrustedCert, err := otherCA.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "bar"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&clientPrivKey.PublicKey, x509.ECDSAWithSHA384),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	caPEMBytes, err := testsupport.BuildPEM(testsupport.WithX509Certificate(rootCA.Certificate))
+	require.NoError(t, err)
+
+	clientCertPEMBytes, err := testsupport.BuildPEM(testsupport.WithX509Certificate(clientCert))
+	require.NoError(t, err)
+
+	trustStoreFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(trustStoreFile, caPEMBytes, 0o600))
+
+	headerSourceConfig := []byte(`
+cert_source:
+  - header: X-Forwarded-Client-Cert
+trust_store: ` + trustStoreFile + `
+subject:
+  id: subject.common_name
+`)
+
+	for _, tc := range []struct {
+		uc               string
+		id               string
+		config           []byte
+		configureContext func(t *testing.T, ctx *mocks.MockContext)
+		assert           func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "no TLS connection",
+			id: "auth1",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestTLSConnectionState").Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no client certificate")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth1", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "certificate not verified during TLS handshake and no trust store configured",
+			id: "auth2",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestTLSConnectionState").
+					Return(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.NotErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "has not been verified")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth2", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc: "certificate verified during TLS handshake",
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestTLSConnectionState").
+					Return(&tls.ConnectionState{
+						PeerCertificates: []*x509.Certificate{clientCert},
+						VerifiedChains:   [][]*x509.Certificate{{clientCert, rootCA.Certificate}},
+					})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+
+				assert.Equal(t, "CN=foo,O=Test,C=EU", sub.ID)
+				assert.Equal(t, "CN=Test Root CA,O=Test,C=EU", sub.Attributes["issuer"].(map[string]any)["dn"])
+				assert.Equal(t, "foo", sub.Attributes["subject"].(map[string]any)["common_name"])
+				assert.Equal(t, clientCert.SerialNumber.Text(16), sub.Attributes["serial_number"])
+				assert.Contains(t, sub.Attributes, "san")
+				assert.Contains(t, sub.Attributes, "fingerprint")
+			},
+		},
+		{
+			uc: "certificate not verified during TLS handshake and verified using configured trust store",
+			config: []byte(`
+trust_store: ` + trustStoreFile + `
+subject:
+  id: subject.common_name
+`),
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestTLSConnectionState").
+					Return(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{clientCert}})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc: "certificate from TLS handshake is not trusted by configured trust store",
+			id: "auth3",
+			config: []byte(`
+trust_store: ` + trustStoreFile + `
+`),
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestTLSConnectionState").
+					Return(&tls.ConnectionState{PeerCertificates: []*x509.Certificate{untrustedCert}})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "client certificate is invalid")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth3", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "no certificate in the configured header",
+			id:     "auth4",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").Return("")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "no client certificate")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth4", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "malformed certificate in the configured header",
+			id:     "auth5",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").Return("foo.bar")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to parse")
+
+				var identifier HandlerIdentifier
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "auth5", identifier.HandlerID())
+
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "untrusted certificate in the configured header",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").
+					Return(base64.StdEncoding.EncodeToString(untrustedCert.Raw))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthentication)
+				assert.Contains(t, err.Error(), "client certificate is invalid")
+				assert.Nil(t, sub)
+			},
+		},
+		{
+			uc:     "url encoded PEM certificate in the configured header",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").
+					Return(url.PathEscape(string(clientCertPEMBytes)))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc:     "base64 encoded DER certificate in the configured header",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").
+					Return(base64.StdEncoding.EncodeToString(clientCert.Raw) + "," +
+						base64.StdEncoding.EncodeToString(rootCA.Certificate.Raw))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+		{
+			uc:     "envoy formatted certificate in the configured header",
+			config: headerSourceConfig,
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestHeader", "X-Forwarded-Client-Cert").
+					Return(fmt.Sprintf(`By=spiffe://heimdall;Hash=abcd;Cert="%s";Subject="CN=foo"`,
+						url.PathEscape(string(clientCertPEMBytes))))
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, sub)
+				assert.Equal(t, "foo", sub.ID)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			configureContext := x.IfThenElse(tc.configureContext != nil,
+				tc.configureContext,
+				func(t *testing.T, ctx *mocks.MockContext) { t.Helper() })
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			configureContext(t, ctx)
+
+			auth, err := newX509Authenticator(tc.id, conf)
+			require.NoError(t, err)
+
+			// WHEN
+			sub, err := auth.Execute(ctx)
+
+			// THEN
+			tc.assert(t, err, sub)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
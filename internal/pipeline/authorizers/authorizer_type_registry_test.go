@@ -12,8 +12,8 @@ import (
 func TestCreateAuthorizerPrototypeUsingKnowType(t *testing.T) {
 	t.Parallel()
 
-	// there are 3 authorizers implemented, which should have been registered
-	require.Len(t, authorizerTypeFactories, 4)
+	// there are 8 authorizers implemented, which should have been registered
+	require.Len(t, authorizerTypeFactories, 8)
 
 	for _, tc := range []struct {
 		uc     string
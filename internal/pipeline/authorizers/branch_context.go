@@ -0,0 +1,74 @@
+package authorizers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+)
+
+type upstreamValue struct {
+	name  string
+	value string
+}
+
+// branchContext isolates the execution of an authorizer being part of a composite authorizer.
+// Headers and cookies for the upstream service, as well as the subject attributes set by the
+// authorizer are only applied to the actual request context and subject, if the outcome of the
+// authorizer is used.
+type branchContext struct {
+	heimdall.Context
+
+	appCtx context.Context
+	sub    *subject.Subject
+
+	mut     sync.Mutex
+	headers []upstreamValue
+	cookies []upstreamValue
+}
+
+func newBranchContext(ctx heimdall.Context, sub *subject.Subject) *branchContext {
+	return &branchContext{Context: ctx, appCtx: ctx.AppContext(), sub: sub.Copy()}
+}
+
+func (b *branchContext) AppContext() context.Context { return b.appCtx }
+
+func (b *branchContext) AddHeaderForUpstream(name, value string) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.headers = append(b.headers, upstreamValue{name: name, value: value})
+}
+
+func (b *branchContext) AddCookieForUpstream(name, value string) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	b.cookies = append(b.cookies, upstreamValue{name: name, value: value})
+}
+
+func (b *branchContext) apply(ctx heimdall.Context, sub *subject.Subject) {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	for _, header := range b.headers {
+		ctx.AddHeaderForUpstream(header.name, header.value)
+	}
+
+	for _, cookie := range b.cookies {
+		ctx.AddCookieForUpstream(cookie.name, cookie.value)
+	}
+
+	if sub == nil || b.sub == nil {
+		return
+	}
+
+	if sub.Attributes == nil {
+		sub.Attributes = make(map[string]any, len(b.sub.Attributes))
+	}
+
+	for key, value := range b.sub.Attributes {
+		sub.Attributes[key] = value
+	}
+}
@@ -0,0 +1,36 @@
+package authorizers
+
+import (
+	"net/http"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*authorizationInformation]("authorizers.authorization_information", authorizationInformationCodec{})
+}
+
+type authorizationInformationCodec struct{}
+
+type authorizationInformationDTO struct {
+	Headers http.Header `json:"headers,omitempty"`
+	Payload any         `json:"payload,omitempty"`
+}
+
+func (authorizationInformationCodec) Encode(value *authorizationInformation) ([]byte, error) {
+	return json.Marshal(authorizationInformationDTO{Headers: value.headers, Payload: value.payload})
+}
+
+func (authorizationInformationCodec) Decode(data []byte) (*authorizationInformation, error) {
+	var dto authorizationInformationDTO
+
+	if err := json.Unmarshal(data, &dto); err != nil {
+		return nil, err
+	}
+
+	return &authorizationInformation{headers: dto.Headers, payload: dto.Payload}, nil
+}
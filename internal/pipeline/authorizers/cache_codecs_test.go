@@ -0,0 +1,31 @@
+package authorizers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+)
+
+func TestAuthorizationInformationCodec(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	authInfo := &authorizationInformation{
+		headers: http.Header{"X-Foo": []string{"bar"}},
+		payload: map[string]any{"foo": "bar"},
+	}
+
+	// WHEN
+	data, err := codec.Marshal(authInfo)
+	require.NoError(t, err)
+
+	value, err := codec.Unmarshal(data)
+
+	// THEN
+	require.NoError(t, err)
+	assert.Equal(t, authInfo, value)
+}
@@ -0,0 +1,96 @@
+package authorizers
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/cellib"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthorizerTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authorizer, error) {
+			if typ != config.POTCEL {
+				return false, nil, nil
+			}
+
+			auth, err := newCELAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type celAuthorizer struct {
+	id          string
+	expressions cellib.Expressions
+}
+
+func newCELAuthorizer(id string, rawConfig map[string]any) (*celAuthorizer, error) {
+	type Config struct {
+		Expressions cellib.Expressions `mapstructure:"expressions"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal cel authorizer config").
+			CausedBy(err)
+	}
+
+	if len(conf.Expressions) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "no expressions provided for cel authorizer")
+	}
+
+	return &celAuthorizer{id: id, expressions: conf.Expressions}, nil
+}
+
+func (a *celAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authorizing using cel authorizer")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute cel authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	return verifyExpressions(ctx, a, a.expressions, sub, nil)
+}
+
+func (a *celAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	return newCELAuthorizer(a.id, rawConfig)
+}
+
+func (a *celAuthorizer) HandlerID() string {
+	return a.id
+}
+
+func verifyExpressions(
+	ctx heimdall.Context, errCtx any, exprs cellib.Expressions, sub *subject.Subject, payload any,
+) error {
+	failed, err := exprs.Eval(ctx, sub, payload)
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrAuthorization, "failed to evaluate expressions").
+			WithErrorContext(errCtx).
+			CausedBy(err)
+	}
+
+	if failed != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrAuthorization, failed.Message).
+			WithErrorContext(errCtx)
+	}
+
+	return nil
+}
@@ -0,0 +1,300 @@
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/cellib"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func compileExpressions(t *testing.T, exprs ...map[string]any) cellib.Expressions {
+	t.Helper()
+
+	type Config struct {
+		Expressions cellib.Expressions `mapstructure:"expressions"`
+	}
+
+	values := make([]any, len(exprs))
+	for idx, expr := range exprs {
+		values[idx] = expr
+	}
+
+	var conf Config
+
+	err := decodeConfig(map[string]any{"expressions": values}, &conf)
+	require.NoError(t, err)
+
+	return conf.Expressions
+}
+
+func TestCreateCELAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *celAuthorizer)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no expressions provided")
+			},
+		},
+		{
+			uc:     "without expressions",
+			config: []byte(`expressions: []`),
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no expressions provided")
+			},
+		},
+		{
+			uc:     "with malformed expression",
+			config: []byte(`expressions: [ { expression: "Subject.ID ==" } ]`),
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to compile")
+			},
+		},
+		{
+			uc: "with unsupported attributes",
+			config: []byte(`
+expressions: [ { expression: "true" } ]
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc: "with valid expressions",
+			id: "authz",
+			config: []byte(`
+expressions:
+  - expression: "Subject.ID == 'foo'"
+    message: not foo
+  - expression: "Request.Method == 'GET'"
+`),
+			assert: func(t *testing.T, err error, auth *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, auth.expressions, 2)
+				assert.Equal(t, "not foo", auth.expressions[0].Message)
+				assert.Equal(t, "Request.Method == 'GET'", auth.expressions[1].Value)
+				assert.Equal(t, "authz", auth.HandlerID())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			a, err := newCELAuthorizer(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, a)
+		})
+	}
+}
+
+func TestCreateCELAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc              string
+		id              string
+		prototypeConfig []byte
+		config          []byte
+		assert          func(t *testing.T, err error, prototype *celAuthorizer, configured *celAuthorizer)
+	}{
+		{
+			uc:              "no new configuration provided",
+			prototypeConfig: []byte(`expressions: [ { expression: "true" } ]`),
+			assert: func(t *testing.T, err error, prototype *celAuthorizer, configured *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:              "configuration without expressions provided",
+			prototypeConfig: []byte(`expressions: [ { expression: "true" } ]`),
+			config:          []byte(``),
+			assert: func(t *testing.T, err error, prototype *celAuthorizer, configured *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:              "new expressions provided",
+			id:              "authz",
+			prototypeConfig: []byte(`expressions: [ { expression: "true" } ]`),
+			config:          []byte(`expressions: [ { expression: "false", message: denied } ]`),
+			assert: func(t *testing.T, err error, prototype *celAuthorizer, configured *celAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				require.NotNil(t, configured)
+				require.Len(t, configured.expressions, 1)
+				assert.Equal(t, "false", configured.expressions[0].Value)
+				assert.Equal(t, "denied", configured.expressions[0].Message)
+				assert.Equal(t, "authz", configured.HandlerID())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newCELAuthorizer(tc.id, pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			celAuth, ok := auth.(*celAuthorizer)
+			require.True(t, ok)
+
+			tc.assert(t, err, prototype, celAuth)
+		})
+	}
+}
+
+func TestCELAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc      string
+		id      string
+		config  []byte
+		subject *subject.Subject
+		assert  func(t *testing.T, err error)
+	}{
+		{
+			uc:     "without subject",
+			id:     "authz1",
+			config: []byte(`expressions: [ { expression: "true" } ]`),
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+			},
+		},
+		{
+			uc: "denied by expression",
+			id: "authz2",
+			config: []byte(`
+expressions:
+  - expression: "Subject.ID == 'foo'"
+  - expression: "'admin' in Subject.Attributes.groups"
+    message: user is not an admin
+`),
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"groups": []string{"users"}}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "user is not an admin")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz2", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "expression evaluation fails",
+			id:      "authz3",
+			config:  []byte(`expressions: [ { expression: "Subject.Attributes.foo == 'bar'" } ]`),
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "failed to evaluate")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz3", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "allowed by all expressions",
+			id: "authz4",
+			config: []byte(`
+expressions:
+  - expression: "Subject.ID == 'foo'"
+  - expression: "'admin' in Subject.Attributes.groups"
+  - expression: "Request.Method == 'GET' && Request.URL.Path.startsWith('/admin')"
+  - expression: "Request.Header('X-Foo') == 'bar'"
+`),
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"groups": []string{"admin"}}},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			ctx.On("RequestMethod").Return(http.MethodGet).Maybe()
+			ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/admin/users"}).Maybe()
+			ctx.On("RequestClientIPs").Return([]string{"127.0.0.1"}).Maybe()
+			ctx.On("RequestHeader", "X-Foo").Return("bar").Maybe()
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			auth, err := newCELAuthorizer(tc.id, conf)
+			require.NoError(t, err)
+
+			// WHEN
+			err = auth.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var ErrUnresolvedReference = errors.New("unresolved authorizer reference")
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthorizerTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authorizer, error) {
+			if typ != config.POTAnyOf && typ != config.POTAllOf && typ != config.POTNot {
+				return false, nil, nil
+			}
+
+			auth, err := newCompositeAuthorizer(id, typ, conf)
+
+			return true, auth, err
+		})
+}
+
+type authorizerReference struct {
+	ID     string         `mapstructure:"id"`
+	Config map[string]any `mapstructure:"config"`
+}
+
+// compositeAuthorizer combines other authorizers referenced by their prototype ids. The references
+// are resolved by ResolveAuthorizerReferences after all authorizer prototypes have been created.
+type compositeAuthorizer struct {
+	id          string
+	typ         config.PipelineObjectType
+	refs        []authorizerReference
+	parallel    bool
+	authorizers []Authorizer
+}
+
+func newCompositeAuthorizer(
+	id string, typ config.PipelineObjectType, rawConfig map[string]any,
+) (*compositeAuthorizer, error) {
+	type Config struct {
+		Authorizers []authorizerReference `mapstructure:"authorizers"`
+		Authorizer  *authorizerReference  `mapstructure:"authorizer"`
+		Parallel    bool                  `mapstructure:"parallel"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrConfiguration, "failed to unmarshal %s authorizer config", typ).
+			CausedBy(err)
+	}
+
+	refs := conf.Authorizers
+
+	if typ == config.POTNot {
+		if conf.Authorizer == nil || len(conf.Authorizers) != 0 || conf.Parallel {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"not authorizer requires exactly one authorizer to be configured using the authorizer property")
+		}
+
+		refs = []authorizerReference{*conf.Authorizer}
+	} else if conf.Authorizer != nil || len(conf.Authorizers) == 0 {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"%s authorizer requires at least one authorizer to be configured using the authorizers property", typ)
+	}
+
+	for _, ref := range refs {
+		if len(ref.ID) == 0 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"%s authorizer references an authorizer without id", typ)
+		}
+	}
+
+	return &compositeAuthorizer{id: id, typ: typ, refs: refs, parallel: conf.Parallel}, nil
+}
+
+func (a *compositeAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msgf("Authorizing using %s authorizer", a.typ)
+
+	if len(a.authorizers) != len(a.refs) {
+		return errorchain.NewWithMessage(ErrUnresolvedReference, "referenced authorizers have not been resolved").
+			WithErrorContext(a)
+	}
+
+	switch a.typ {
+	case config.POTAllOf:
+		return a.executeAllOf(ctx, sub)
+	case config.POTAnyOf:
+		return a.executeAnyOf(ctx, sub)
+	default:
+		return a.executeNot(ctx, sub)
+	}
+}
+
+func (a *compositeAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+		"%s authorizer cannot be reconfigured. Reference a differently configured authorizer instead", a.typ)
+}
+
+func (a *compositeAuthorizer) HandlerID() string {
+	return a.id
+}
+
+func (a *compositeAuthorizer) executeAllOf(ctx heimdall.Context, sub *subject.Subject) error {
+	if !a.parallel {
+		for _, auth := range a.authorizers {
+			if err := auth.Execute(ctx, sub); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	branches, errs, failed := a.executeBranches(ctx, sub, func(err error) bool { return err != nil })
+	if failed != -1 {
+		return errs[failed]
+	}
+
+	for _, branch := range branches {
+		branch.apply(ctx, sub)
+	}
+
+	return nil
+}
+
+func (a *compositeAuthorizer) executeAnyOf(ctx heimdall.Context, sub *subject.Subject) error {
+	var errs []error
+
+	if a.parallel {
+		var (
+			branches  []*branchContext
+			succeeded int
+		)
+
+		branches, errs, succeeded = a.executeBranches(ctx, sub, func(err error) bool { return err == nil })
+		if succeeded != -1 {
+			branches[succeeded].apply(ctx, sub)
+
+			return nil
+		}
+	} else {
+		errs = make([]error, len(a.authorizers))
+
+		for idx, auth := range a.authorizers {
+			branch := newBranchContext(ctx, sub)
+
+			if errs[idx] = auth.Execute(branch, branch.sub); errs[idx] == nil {
+				branch.apply(ctx, sub)
+
+				return nil
+			}
+		}
+	}
+
+	chain := errorchain.
+		NewWithMessage(heimdall.ErrAuthorization, "none of the authorizers succeeded").
+		WithErrorContext(a)
+
+	for _, err := range errs {
+		if err != nil {
+			chain.CausedBy(err)
+		}
+	}
+
+	return chain
+}
+
+func (a *compositeAuthorizer) executeNot(ctx heimdall.Context, sub *subject.Subject) error {
+	// the outcome of the negated authorizer must not affect the actual subject or request
+	branch := newBranchContext(ctx, sub)
+
+	err := a.authorizers[0].Execute(branch, branch.sub)
+	if err == nil {
+		return errorchain.
+			NewWithMessagef(heimdall.ErrAuthorization, "negated authorizer %s succeeded", a.refs[0].ID).
+			WithErrorContext(a)
+	}
+
+	// only an authorization failure can be negated. Any other error, like
+	// a communication error, does not allow any statement about the authorization
+	if !errors.Is(err, heimdall.ErrAuthorization) {
+		return err
+	}
+
+	return nil
+}
+
+// executeBranches executes all authorizers in parallel and cancels the pending ones as soon as
+// one of them finishes with a result, for which decisive returns true. The index of that authorizer
+// is returned as well, or -1 if there was no such authorizer.
+func (a *compositeAuthorizer) executeBranches(
+	ctx heimdall.Context, sub *subject.Subject, decisive func(err error) bool,
+) ([]*branchContext, []error, int) {
+	var (
+		wg          sync.WaitGroup
+		mut         sync.Mutex
+		decisiveIdx = -1
+	)
+
+	appCtx, cancel := context.WithCancel(ctx.AppContext())
+	defer cancel()
+
+	// the branches share the request, which is not safe for concurrent access
+	syncCtx := heimdall.NewSynchronizedContext(ctx)
+	branches := make([]*branchContext, len(a.authorizers))
+	errs := make([]error, len(a.authorizers))
+
+	for idx, auth := range a.authorizers {
+		branches[idx] = newBranchContext(syncCtx, sub)
+		branches[idx].appCtx = appCtx
+
+		wg.Add(1)
+
+		go func(idx int, auth Authorizer) {
+			defer wg.Done()
+
+			err := auth.Execute(branches[idx], branches[idx].sub)
+
+			mut.Lock()
+			defer mut.Unlock()
+
+			errs[idx] = err
+
+			if decisiveIdx == -1 && decisive(err) {
+				decisiveIdx = idx
+
+				cancel()
+			}
+		}(idx, auth)
+	}
+
+	wg.Wait()
+
+	return branches, errs, decisiveIdx
+}
+
+func (a *compositeAuthorizer) resolve(prototypes map[string]Authorizer) error {
+	resolved := make([]Authorizer, len(a.refs))
+
+	for idx, ref := range a.refs {
+		prototype, ok := prototypes[ref.ID]
+		if !ok {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"%s authorizer '%s' references unknown authorizer '%s'", a.typ, a.id, ref.ID)
+		}
+
+		auth, err := prototype.WithConfig(ref.Config)
+		if err != nil {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to configure authorizer '%s' referenced by %s authorizer '%s'", ref.ID, a.typ, a.id).
+				CausedBy(err)
+		}
+
+		resolved[idx] = auth
+	}
+
+	a.authorizers = resolved
+
+	return nil
+}
+
+// ResolveAuthorizerReferences resolves the references of all composite authorizers to other authorizer
+// prototypes. It must be called after all authorizer prototypes have been created.
+func ResolveAuthorizerReferences(prototypes map[string]Authorizer) error {
+	for _, prototype := range prototypes {
+		if composite, ok := prototype.(*compositeAuthorizer); ok {
+			if err := composite.resolve(prototypes); err != nil {
+				return err
+			}
+		}
+	}
+
+	visited := make(map[string]bool)
+
+	for id := range prototypes {
+		if err := checkForCycles(id, prototypes, visited, map[string]bool{}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func checkForCycles(id string, prototypes map[string]Authorizer, visited, path map[string]bool) error {
+	if path[id] {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"authorizer '%s' references itself, directly or indirectly", id)
+	}
+
+	if visited[id] {
+		return nil
+	}
+
+	visited[id] = true
+
+	composite, ok := prototypes[id].(*compositeAuthorizer)
+	if !ok {
+		return nil
+	}
+
+	path[id] = true
+	defer delete(path, id)
+
+	for _, ref := range composite.refs {
+		if err := checkForCycles(ref.ID, prototypes, visited, path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,606 @@
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+type testAuthorizer struct {
+	id    string
+	err   error
+	delay time.Duration
+}
+
+func (a *testAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	if a.delay != 0 {
+		select {
+		case <-time.After(a.delay):
+		case <-ctx.AppContext().Done():
+			return ctx.AppContext().Err()
+		}
+	}
+
+	if a.err != nil {
+		return errorchain.New(a.err).WithErrorContext(a)
+	}
+
+	ctx.AddHeaderForUpstream("X-Authorized-By", a.id)
+	sub.Attributes[a.id] = true
+
+	return nil
+}
+
+func (a *testAuthorizer) WithConfig(map[string]any) (Authorizer, error) { return a, nil }
+
+func (a *testAuthorizer) HandlerID() string { return a.id }
+
+func TestCreateCompositeAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		typ    config.PipelineObjectType
+		config []byte
+		assert func(t *testing.T, err error, auth *compositeAuthorizer)
+	}{
+		{
+			uc:  "any_of without authorizers",
+			typ: config.POTAnyOf,
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "any_of authorizer requires at least one authorizer")
+			},
+		},
+		{
+			uc:     "all_of with single authorizer reference",
+			typ:    config.POTAllOf,
+			config: []byte(`authorizer: { id: foo }`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "all_of authorizer requires at least one authorizer")
+			},
+		},
+		{
+			uc:     "not with list of authorizers",
+			typ:    config.POTNot,
+			config: []byte(`authorizers: [ { id: foo } ]`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not authorizer requires exactly one authorizer")
+			},
+		},
+		{
+			uc:  "not with parallel execution",
+			typ: config.POTNot,
+			config: []byte(`
+authorizer: { id: foo }
+parallel: true
+`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not authorizer requires exactly one authorizer")
+			},
+		},
+		{
+			uc:     "reference without id",
+			typ:    config.POTAnyOf,
+			config: []byte(`authorizers: [ { id: foo }, { config: { foo: bar } } ]`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "references an authorizer without id")
+			},
+		},
+		{
+			uc:     "with unsupported properties",
+			typ:    config.POTAnyOf,
+			config: []byte(`authorizers: [ { id: foo, bar: baz } ]`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal any_of authorizer config")
+			},
+		},
+		{
+			uc:  "valid any_of configuration",
+			typ: config.POTAnyOf,
+			config: []byte(`
+authorizers:
+  - id: foo
+  - id: bar
+    config:
+      expressions: [ { expression: "true" } ]
+parallel: true
+`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, config.POTAnyOf, auth.typ)
+				require.Len(t, auth.refs, 2)
+				assert.Equal(t, "foo", auth.refs[0].ID)
+				assert.Nil(t, auth.refs[0].Config)
+				assert.Equal(t, "bar", auth.refs[1].ID)
+				assert.NotEmpty(t, auth.refs[1].Config)
+				assert.True(t, auth.parallel)
+				assert.Empty(t, auth.authorizers)
+				assert.Equal(t, "composite", auth.HandlerID())
+			},
+		},
+		{
+			uc:     "valid not configuration",
+			typ:    config.POTNot,
+			config: []byte(`authorizer: { id: foo }`),
+			assert: func(t *testing.T, err error, auth *compositeAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, config.POTNot, auth.typ)
+				require.Len(t, auth.refs, 1)
+				assert.Equal(t, "foo", auth.refs[0].ID)
+				assert.False(t, auth.parallel)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newCompositeAuthorizer("composite", tc.typ, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCompositeAuthorizerWithConfig(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	auth, err := newCompositeAuthorizer("composite", config.POTAnyOf,
+		map[string]any{"authorizers": []any{map[string]any{"id": "foo"}}})
+	require.NoError(t, err)
+
+	// WHEN
+	same, err1 := auth.WithConfig(nil)
+	_, err2 := auth.WithConfig(map[string]any{"parallel": true})
+
+	// THEN
+	require.NoError(t, err1)
+	assert.Equal(t, auth, same)
+
+	require.Error(t, err2)
+	assert.ErrorIs(t, err2, heimdall.ErrConfiguration)
+	assert.Contains(t, err2.Error(), "cannot be reconfigured")
+}
+
+func TestResolveAuthorizerReferences(t *testing.T) {
+	t.Parallel()
+
+	newComposite := func(t *testing.T, id string, typ config.PipelineObjectType, conf string) Authorizer {
+		t.Helper()
+
+		rawConf, err := testsupport.DecodeTestConfig([]byte(conf))
+		require.NoError(t, err)
+
+		auth, err := newCompositeAuthorizer(id, typ, rawConf)
+		require.NoError(t, err)
+
+		return auth
+	}
+
+	newCEL := func(t *testing.T, id string) Authorizer {
+		t.Helper()
+
+		auth, err := newCELAuthorizer(id, map[string]any{
+			"expressions": []any{map[string]any{"expression": "true"}},
+		})
+		require.NoError(t, err)
+
+		return auth
+	}
+
+	for _, tc := range []struct {
+		uc         string
+		prototypes func(t *testing.T) map[string]Authorizer
+		assert     func(t *testing.T, err error, prototypes map[string]Authorizer)
+	}{
+		{
+			uc: "reference to unknown authorizer",
+			prototypes: func(t *testing.T) map[string]Authorizer {
+				t.Helper()
+
+				return map[string]Authorizer{
+					"composite": newComposite(t, "composite", config.POTAnyOf, `authorizers: [ { id: foo } ]`),
+				}
+			},
+			assert: func(t *testing.T, err error, prototypes map[string]Authorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "references unknown authorizer 'foo'")
+			},
+		},
+		{
+			uc: "reference with invalid config",
+			prototypes: func(t *testing.T) map[string]Authorizer {
+				t.Helper()
+
+				return map[string]Authorizer{
+					"cel": newCEL(t, "cel"),
+					"composite": newComposite(t, "composite", config.POTAllOf,
+						`authorizers: [ { id: cel, config: { foo: bar } } ]`),
+				}
+			},
+			assert: func(t *testing.T, err error, prototypes map[string]Authorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to configure authorizer 'cel'")
+			},
+		},
+		{
+			uc: "indirect cyclic reference",
+			prototypes: func(t *testing.T) map[string]Authorizer {
+				t.Helper()
+
+				return map[string]Authorizer{
+					"cel": newCEL(t, "cel"),
+					"c1":  newComposite(t, "c1", config.POTAllOf, `authorizers: [ { id: cel }, { id: c2 } ]`),
+					"c2":  newComposite(t, "c2", config.POTNot, `authorizer: { id: c1 }`),
+				}
+			},
+			assert: func(t *testing.T, err error, prototypes map[string]Authorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "references itself")
+			},
+		},
+		{
+			uc: "successfully resolved references",
+			prototypes: func(t *testing.T) map[string]Authorizer {
+				t.Helper()
+
+				return map[string]Authorizer{
+					"cel": newCEL(t, "cel"),
+					"c1": newComposite(t, "c1", config.POTAnyOf, `
+authorizers:
+  - id: cel
+  - id: cel
+    config:
+      expressions: [ { expression: "false" } ]
+  - id: c2
+`),
+					"c2": newComposite(t, "c2", config.POTNot, `authorizer: { id: cel }`),
+				}
+			},
+			assert: func(t *testing.T, err error, prototypes map[string]Authorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				c1, ok := prototypes["c1"].(*compositeAuthorizer)
+				require.True(t, ok)
+				require.Len(t, c1.authorizers, 3)
+				assert.Equal(t, prototypes["cel"], c1.authorizers[0])
+				assert.NotEqual(t, prototypes["cel"], c1.authorizers[1])
+				assert.Equal(t, prototypes["c2"], c1.authorizers[2])
+
+				c2, ok := prototypes["c2"].(*compositeAuthorizer)
+				require.True(t, ok)
+				require.Len(t, c2.authorizers, 1)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			prototypes := tc.prototypes(t)
+
+			// WHEN
+			err := ResolveAuthorizerReferences(prototypes)
+
+			// THEN
+			tc.assert(t, err, prototypes)
+		})
+	}
+}
+
+func TestCompositeAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	errDenied := errorchain.NewWithMessage(heimdall.ErrAuthorization, "denied")
+
+	for _, tc := range []struct {
+		uc          string
+		typ         config.PipelineObjectType
+		parallel    bool
+		authorizers []Authorizer
+		configure   func(t *testing.T, ctx *mocks.MockContext)
+		assert      func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:  "all_of succeeds",
+			typ: config.POTAllOf,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1"},
+				&testAuthorizer{id: "a2"},
+			},
+			configure: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a1")
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a2")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"a1": true, "a2": true}, sub.Attributes)
+			},
+		},
+		{
+			uc:  "all_of fails",
+			typ: config.POTAllOf,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1"},
+				&testAuthorizer{id: "a2", err: heimdall.ErrCommunication},
+				&testAuthorizer{id: "a3"},
+			},
+			configure: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a1")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "a2", identifier.HandlerID())
+			},
+		},
+		{
+			uc:       "all_of fails in parallel mode",
+			typ:      config.POTAllOf,
+			parallel: true,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", delay: 5 * time.Second},
+				&testAuthorizer{id: "a2", err: heimdall.ErrAuthorization},
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "a2", identifier.HandlerID())
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:       "all_of succeeds in parallel mode",
+			typ:      config.POTAllOf,
+			parallel: true,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", delay: 10 * time.Millisecond},
+				&testAuthorizer{id: "a2"},
+			},
+			configure: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a1")
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a2")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"a1": true, "a2": true}, sub.Attributes)
+			},
+		},
+		{
+			uc:  "any_of succeeds with the second authorizer",
+			typ: config.POTAnyOf,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", err: heimdall.ErrAuthorization},
+				&testAuthorizer{id: "a2"},
+				&testAuthorizer{id: "a3"},
+			},
+			configure: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a2")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"a2": true}, sub.Attributes)
+			},
+		},
+		{
+			uc:  "any_of fails aggregating errors",
+			typ: config.POTAnyOf,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", err: heimdall.ErrAuthorization},
+				&testAuthorizer{id: "a2", err: heimdall.ErrCommunication},
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Equal(t,
+					"authorization error: none of the authorizers succeeded: authorization error: communication error",
+					err.Error())
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "composite", identifier.HandlerID())
+			},
+		},
+		{
+			uc:       "any_of succeeds in parallel mode without waiting for slow authorizers",
+			typ:      config.POTAnyOf,
+			parallel: true,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", delay: 5 * time.Second},
+				&testAuthorizer{id: "a2", err: heimdall.ErrAuthorization},
+				&testAuthorizer{id: "a3", delay: 10 * time.Millisecond},
+			},
+			configure: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-Authorized-By", "a3")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"a3": true}, sub.Attributes)
+			},
+		},
+		{
+			uc:       "any_of fails in parallel mode",
+			typ:      config.POTAnyOf,
+			parallel: true,
+			authorizers: []Authorizer{
+				&testAuthorizer{id: "a1", err: heimdall.ErrAuthorization},
+				&testAuthorizer{id: "a2", err: heimdall.ErrAuthorization},
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "none of the authorizers succeeded")
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:          "not succeeds if negated authorizer denies",
+			typ:         config.POTNot,
+			authorizers: []Authorizer{&testAuthorizer{id: "a1", err: errDenied}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:          "not fails if negated authorizer allows",
+			typ:         config.POTNot,
+			authorizers: []Authorizer{&testAuthorizer{id: "a1"}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "negated authorizer a1 succeeded")
+				assert.Empty(t, sub.Attributes)
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "composite", identifier.HandlerID())
+			},
+		},
+		{
+			uc:          "not does not negate errors other than authorization errors",
+			typ:         config.POTNot,
+			authorizers: []Authorizer{&testAuthorizer{id: "a1", err: heimdall.ErrCommunication}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+			},
+		},
+		{
+			uc:  "with unresolved references",
+			typ: config.POTAllOf,
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrUnresolvedReference)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			refs := make([]authorizerReference, len(tc.authorizers))
+			for idx := range tc.authorizers {
+				refs[idx] = authorizerReference{ID: tc.authorizers[idx].(*testAuthorizer).id} // nolint: forcetypeassert
+			}
+
+			if len(refs) == 0 {
+				refs = []authorizerReference{{ID: "foo"}}
+			}
+
+			auth := &compositeAuthorizer{
+				id:          "composite",
+				typ:         tc.typ,
+				refs:        refs,
+				parallel:    tc.parallel,
+				authorizers: tc.authorizers,
+			}
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			if tc.configure != nil {
+				tc.configure(t, ctx)
+			}
+
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+			// WHEN
+			start := time.Now()
+			err := auth.Execute(ctx, sub)
+
+			// THEN
+			assert.Less(t, time.Since(start), 5*time.Second)
+			tc.assert(t, err, sub)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
@@ -3,6 +3,8 @@ package authorizers
 import (
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/dadrus/heimdall/internal/pipeline/cellib"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/script"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
 )
@@ -13,6 +15,8 @@ func decodeConfig(input any, output any) error {
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				mapstructure.StringToTimeDurationHookFunc(),
 				script.DecodeScriptHookFunc(),
+				cellib.DecodeExpressionsHookFunc(),
+				graphql.DecodeQueryHookFunc(),
 				template.DecodeTemplateHookFunc(),
 			),
 			Result:      output,
@@ -0,0 +1,223 @@
+package authorizers
+
+import (
+	"fmt"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthorizerTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authorizer, error) {
+			if typ != config.POTOPA {
+				return false, nil, nil
+			}
+
+			auth, err := newOPAAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type opaAuthorizer struct {
+	id     string
+	policy *opaPolicy
+	query  string
+}
+
+type opaDecision struct {
+	allow      bool
+	headers    map[string]string
+	attributes any
+}
+
+func newOPAAuthorizer(id string, rawConfig map[string]any) (*opaAuthorizer, error) {
+	type Config struct {
+		Policies []string `mapstructure:"policies"`
+		Bundles  []string `mapstructure:"bundles"`
+		Query    string   `mapstructure:"query"`
+		Watch    bool     `mapstructure:"watch"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal opa authorizer config").
+			CausedBy(err)
+	}
+
+	if len(conf.Policies) == 0 && len(conf.Bundles) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "opa authorizer requires policies or bundles to be configured")
+	}
+
+	if len(conf.Query) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "no query configured for opa authorizer")
+	}
+
+	policy, err := newOPAPolicy(conf.Policies, conf.Bundles, conf.Watch)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = policy.prepare(conf.Query); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to prepare opa query").
+			CausedBy(err)
+	}
+
+	return &opaAuthorizer{id: id, policy: policy, query: conf.Query}, nil
+}
+
+func (a *opaAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authorizing using opa authorizer")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute opa authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	if err := a.policy.lastReloadError(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to reload opa policies. Using previously loaded ones")
+	}
+
+	results, err := a.policy.eval(ctx.AppContext(), a.query, a.createInput(ctx, sub))
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to evaluate opa query").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if len(results) == 0 || len(results[0].Expressions) == 0 {
+		return errorchain.
+			NewWithMessage(heimdall.ErrAuthorization, "opa query result is undefined").
+			WithErrorContext(a)
+	}
+
+	decision, err := toOPADecision(results[0].Expressions[0].Value)
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to interpret opa query result").
+			WithErrorContext(a).
+			CausedBy(err)
+	}
+
+	if !decision.allow {
+		return errorchain.
+			NewWithMessage(heimdall.ErrAuthorization, "denied by opa policy").
+			WithErrorContext(a)
+	}
+
+	for name, value := range decision.headers {
+		ctx.AddHeaderForUpstream(name, value)
+	}
+
+	if decision.attributes != nil {
+		sub.Attributes[a.id] = decision.attributes
+	}
+
+	return nil
+}
+
+func (a *opaAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		Query string `mapstructure:"query"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal opa authorizer config").
+			CausedBy(err)
+	}
+
+	query := x.IfThenElse(len(conf.Query) != 0, conf.Query, a.query)
+
+	if err := a.policy.prepare(query); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to prepare opa query").
+			CausedBy(err)
+	}
+
+	return &opaAuthorizer{id: a.id, policy: a.policy, query: query}, nil
+}
+
+func (a *opaAuthorizer) HandlerID() string {
+	return a.id
+}
+
+// Close stops watching the policy files. The policy is shared with the authorizers created from the prototype
+// by WithConfig, so only the prototype is closed on shutdown.
+func (a *opaAuthorizer) Close() error {
+	return a.policy.close()
+}
+
+func (a *opaAuthorizer) createInput(ctx heimdall.Context, sub *subject.Subject) map[string]any {
+	reqURL := ctx.RequestURL()
+
+	return map[string]any{
+		"subject": map[string]any{
+			"id":         sub.ID,
+			"attributes": sub.Attributes,
+		},
+		"request": map[string]any{
+			"method":     ctx.RequestMethod(),
+			"url":        reqURL.String(),
+			"scheme":     reqURL.Scheme,
+			"host":       reqURL.Host,
+			"path":       reqURL.Path,
+			"query":      reqURL.Query(),
+			"headers":    ctx.RequestHeaders(),
+			"client_ips": ctx.RequestClientIPs(),
+		},
+	}
+}
+
+func toOPADecision(value any) (*opaDecision, error) {
+	switch result := value.(type) {
+	case bool:
+		return &opaDecision{allow: result}, nil
+	case map[string]any:
+		allow, ok := result["allow"].(bool)
+		if !ok {
+			return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+				"decision object does not contain a boolean allow property")
+		}
+
+		decision := &opaDecision{allow: allow, attributes: result["attributes"]}
+
+		if rawHeaders, present := result["headers"]; present {
+			headers, ok := rawHeaders.(map[string]any)
+			if !ok {
+				return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+					"headers property of the decision object is not an object")
+			}
+
+			decision.headers = make(map[string]string, len(headers))
+			for name, val := range headers {
+				decision.headers[name] = fmt.Sprintf("%v", val)
+			}
+		}
+
+		return decision, nil
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"unexpected result type %T. Expected either a boolean or a decision object", value)
+	}
+}
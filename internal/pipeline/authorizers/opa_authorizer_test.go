@@ -0,0 +1,503 @@
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+const testOPAPolicy = `
+package heimdall.authz
+
+default allow := false
+
+allow {
+	input.subject.id == "foo"
+	input.request.method == "GET"
+	data.heimdall.allowed_paths[_] == input.request.path
+}
+
+decision := {
+	"allow": allow,
+	"headers": {"X-User-Role": data.heimdall.roles[input.subject.id]},
+	"attributes": {"role": data.heimdall.roles[input.subject.id]},
+}
+`
+
+const testOPAData = `
+{
+  "heimdall": {
+    "allowed_paths": ["/foo"],
+    "roles": { "foo": "admin" }
+  }
+}
+`
+
+func writeOPAFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+}
+
+func TestCreateOPAAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	policyDir := t.TempDir()
+	writeOPAFile(t, filepath.Join(policyDir, "policy.rego"), testOPAPolicy)
+	writeOPAFile(t, filepath.Join(policyDir, "data.json"), testOPAData)
+
+	brokenPolicy := filepath.Join(t.TempDir(), "broken.rego")
+	writeOPAFile(t, brokenPolicy, `package foo allow {`)
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *opaAuthorizer)
+	}{
+		{
+			uc:     "without policies and bundles",
+			config: []byte(`query: data.heimdall.authz.allow`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires policies or bundles")
+			},
+		},
+		{
+			uc:     "without query",
+			config: []byte(`policies: [ ` + policyDir + ` ]`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no query configured")
+			},
+		},
+		{
+			uc: "with unsupported attributes",
+			config: []byte(`
+policies: [ ` + policyDir + ` ]
+query: data.heimdall.authz.allow
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc: "with broken policy",
+			config: []byte(`
+policies: [ ` + brokenPolicy + ` ]
+query: data.foo.allow
+`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to prepare opa query")
+			},
+		},
+		{
+			uc: "with not existing bundle",
+			config: []byte(`
+bundles: [ /does/not/exist ]
+query: data.foo.allow
+`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc: "with valid configuration",
+			id: "authz",
+			config: []byte(`
+policies: [ ` + policyDir + ` ]
+query: data.heimdall.authz.allow
+`),
+			assert: func(t *testing.T, err error, auth *opaAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "authz", auth.HandlerID())
+				assert.Equal(t, "data.heimdall.authz.allow", auth.query)
+				require.NotNil(t, auth.policy)
+				assert.Nil(t, auth.policy.w)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newOPAAuthorizer(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateOPAAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	policyDir := t.TempDir()
+	writeOPAFile(t, filepath.Join(policyDir, "policy.rego"), testOPAPolicy)
+	writeOPAFile(t, filepath.Join(policyDir, "data.json"), testOPAData)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *opaAuthorizer, configured *opaAuthorizer)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *opaAuthorizer, configured *opaAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with redefined query",
+			config: []byte(`query: data.heimdall.authz.decision`),
+			assert: func(t *testing.T, err error, prototype *opaAuthorizer, configured *opaAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.policy, configured.policy)
+				assert.Equal(t, "data.heimdall.authz.decision", configured.query)
+			},
+		},
+		{
+			uc:     "with invalid query",
+			config: []byte(`query: "data.heimdall["`),
+			assert: func(t *testing.T, err error, prototype *opaAuthorizer, configured *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to prepare opa query")
+			},
+		},
+		{
+			uc:     "with not overridable attribute",
+			config: []byte(`policies: [ /foo ]`),
+			assert: func(t *testing.T, err error, prototype *opaAuthorizer, configured *opaAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig([]byte(`
+policies: [ ` + policyDir + ` ]
+query: data.heimdall.authz.allow
+`))
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newOPAAuthorizer("authz", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				opaAuth *opaAuthorizer
+				ok      bool
+			)
+
+			if err == nil {
+				opaAuth, ok = auth.(*opaAuthorizer)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, opaAuth)
+		})
+	}
+}
+
+func TestOPAAuthorizerExecute(t *testing.T) {
+	t.Parallel()
+
+	policyDir := t.TempDir()
+	writeOPAFile(t, filepath.Join(policyDir, "policy.rego"), testOPAPolicy+`
+undefined {
+	false
+}
+
+unexpected := "foo"
+`)
+	writeOPAFile(t, filepath.Join(policyDir, "data.json"), testOPAData)
+
+	for _, tc := range []struct {
+		uc               string
+		id               string
+		query            string
+		subject          *subject.Subject
+		configureContext func(t *testing.T, ctx *mocks.MockContext)
+		assert           func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:    "with 'nil' subject",
+			id:    "authz1",
+			query: "data.heimdall.authz.allow",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz1", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "denied by boolean decision",
+			id:      "authz2",
+			query:   "data.heimdall.authz.allow",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return("POST")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "denied by opa policy")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz2", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "allowed by boolean decision",
+			query:   "data.heimdall.authz.allow",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:      "allowed by decision object",
+			id:      "authz3",
+			query:   "data.heimdall.authz.decision",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			configureContext: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("AddHeaderForUpstream", "X-User-Role", "admin")
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"role": "admin"}, sub.Attributes["authz3"])
+			},
+		},
+		{
+			uc:      "with undefined result",
+			query:   "data.heimdall.authz.undefined",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "undefined")
+			},
+		},
+		{
+			uc:      "with unexpected result type",
+			query:   "data.heimdall.authz.unexpected",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "unexpected result type")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+policies: [ ` + policyDir + ` ]
+query: ` + tc.query + `
+`))
+			require.NoError(t, err)
+
+			auth, err := newOPAAuthorizer(tc.id, conf)
+			require.NoError(t, err)
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			if tc.configureContext != nil {
+				tc.configureContext(t, ctx)
+			}
+
+			ctx.On("RequestMethod").Maybe().Return("GET")
+			ctx.On("RequestURL").Maybe().Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/foo"})
+			ctx.On("RequestHeaders").Maybe().Return(map[string]string{"X-Foo": "bar"})
+			ctx.On("RequestClientIPs").Maybe().Return([]string{"127.0.0.1"})
+
+			// WHEN
+			err = auth.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err, tc.subject)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
+
+func TestOPAAuthorizerReloadsPolicies(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	policyDir := t.TempDir()
+	policyFile := filepath.Join(policyDir, "policy.rego")
+	writeOPAFile(t, policyFile, "package heimdall\n\nallow := false\n")
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+policies: [ ` + policyDir + ` ]
+query: data.heimdall.allow
+watch: true
+`))
+	require.NoError(t, err)
+
+	auth, err := newOPAAuthorizer("authz", conf)
+	require.NoError(t, err)
+
+	sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+	ctx.On("RequestMethod").Return("GET")
+	ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/foo"})
+	ctx.On("RequestHeaders").Return(map[string]string{})
+	ctx.On("RequestClientIPs").Return([]string{})
+
+	require.ErrorIs(t, auth.Execute(ctx, sub), heimdall.ErrAuthorization)
+
+	// WHEN
+	writeOPAFile(t, policyFile, "package heimdall\n\nallow := true\n")
+
+	// THEN
+	assert.Eventually(t, func() bool { return auth.Execute(ctx, sub) == nil }, 2*time.Second, 10*time.Millisecond)
+
+	// WHEN
+	writeOPAFile(t, policyFile, "package heimdall allow := {")
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN
+	// a broken policy does not replace the previously loaded one
+	assert.NoError(t, auth.Execute(ctx, sub))
+}
+
+func TestOPAAuthorizerReloadsReplacedPolicyFile(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	policyDir := t.TempDir()
+	policyFile := filepath.Join(policyDir, "policy.rego")
+	writeOPAFile(t, policyFile, "package heimdall\n\nallow := false\n")
+
+	replacePolicy := func(t *testing.T, content string) {
+		t.Helper()
+
+		tmpFile := filepath.Join(policyDir, "policy.rego.tmp")
+		writeOPAFile(t, tmpFile, content)
+		require.NoError(t, os.Rename(tmpFile, policyFile))
+	}
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+policies: [ ` + policyFile + ` ]
+query: data.heimdall.allow
+watch: true
+`))
+	require.NoError(t, err)
+
+	auth, err := newOPAAuthorizer("authz", conf)
+	require.NoError(t, err)
+
+	defer auth.Close() // nolint: errcheck
+
+	sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+	ctx.On("RequestMethod").Return("GET")
+	ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/foo"})
+	ctx.On("RequestHeaders").Return(map[string]string{})
+	ctx.On("RequestClientIPs").Return([]string{})
+
+	require.ErrorIs(t, auth.Execute(ctx, sub), heimdall.ErrAuthorization)
+
+	// WHEN
+	replacePolicy(t, "package heimdall\n\nallow := true\n")
+
+	// THEN
+	assert.Eventually(t, func() bool { return auth.Execute(ctx, sub) == nil }, 2*time.Second, 10*time.Millisecond)
+
+	// WHEN
+	// the watch must survive the replacement of the file
+	replacePolicy(t, "package heimdall\n\nallow := false\n")
+
+	// THEN
+	assert.Eventually(t, func() bool { return errors.Is(auth.Execute(ctx, sub), heimdall.ErrAuthorization) },
+		2*time.Second, 10*time.Millisecond)
+
+	// WHEN
+	require.NoError(t, auth.Close())
+	replacePolicy(t, "package heimdall\n\nallow := true\n")
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN
+	// changes are not picked up after closing
+	assert.ErrorIs(t, auth.Execute(ctx, sub), heimdall.ErrAuthorization)
+}
@@ -0,0 +1,215 @@
+package authorizers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/open-policy-agent/opa/rego"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// opaPolicy holds the rego modules and data documents loaded from the configured sources, as well as the
+// queries prepared for evaluation against them. It is shared by all authorizers created from the same
+// prototype, so a reload affects all of them.
+type opaPolicy struct {
+	policies []string
+	bundles  []string
+
+	mut       sync.RWMutex
+	queries   map[string]rego.PreparedEvalQuery
+	reloadErr error
+
+	w *fsnotify.Watcher
+}
+
+func newOPAPolicy(policies, bundles []string, watch bool) (*opaPolicy, error) {
+	policy := &opaPolicy{
+		policies: policies,
+		bundles:  bundles,
+		queries:  make(map[string]rego.PreparedEvalQuery),
+	}
+
+	if !watch {
+		return policy, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to instantiate file watcher").
+			CausedBy(err)
+	}
+
+	names := make(map[string]bool)
+
+	for _, path := range append(append([]string{}, policies...), bundles...) {
+		path = filepath.Clean(path)
+
+		// directories are watched as they are. For files, the directory is watched, as editors and config
+		// map updates replace the file instead of writing to it, which would end the watch of the file itself.
+		dir := path
+		if fInfo, err := os.Stat(path); err == nil && !fInfo.IsDir() {
+			dir = filepath.Dir(path)
+			names[path] = true
+		} else {
+			names[dir] = false
+		}
+
+		if err = watcher.Add(dir); err != nil {
+			watcher.Close()
+
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to watch %s", path).
+				CausedBy(err)
+		}
+	}
+
+	policy.w = watcher
+
+	go policy.watchFiles(names)
+
+	return policy, nil
+}
+
+func (p *opaPolicy) prepare(query string) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	if _, ok := p.queries[query]; ok {
+		return nil
+	}
+
+	prepared, err := p.prepareQuery(query)
+	if err != nil {
+		return err
+	}
+
+	p.queries[query] = prepared
+
+	return nil
+}
+
+func (p *opaPolicy) prepareQuery(query string) (rego.PreparedEvalQuery, error) {
+	options := []func(r *rego.Rego){rego.Query(query)}
+
+	if len(p.policies) != 0 {
+		options = append(options, rego.Load(p.policies, nil))
+	}
+
+	for _, bundle := range p.bundles {
+		options = append(options, rego.LoadBundle(bundle))
+	}
+
+	return rego.New(options...).PrepareForEval(context.Background())
+}
+
+func (p *opaPolicy) eval(ctx context.Context, query string, input any) (rego.ResultSet, error) {
+	p.mut.RLock()
+	prepared, ok := p.queries[query]
+	p.mut.RUnlock()
+
+	if !ok {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrInternal, "query %s has not been prepared", query)
+	}
+
+	return prepared.Eval(ctx, rego.EvalInput(input))
+}
+
+// lastReloadError returns the error of the last failed reload attempt once. Reloading happens
+// in the background, so the error can only be reported while handling a request.
+func (p *opaPolicy) lastReloadError() error {
+	p.mut.RLock()
+	err := p.reloadErr
+	p.mut.RUnlock()
+
+	if err == nil {
+		return nil
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	// might have been reported by a concurrent request in the meantime
+	err = p.reloadErr
+	p.reloadErr = nil
+
+	return err
+}
+
+func (p *opaPolicy) reload() {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+
+	queries := make(map[string]rego.PreparedEvalQuery, len(p.queries))
+
+	for query := range p.queries {
+		prepared, err := p.prepareQuery(query)
+		if err != nil {
+			// previously loaded policies stay active
+			p.reloadErr = err
+
+			return
+		}
+
+		queries[query] = prepared
+	}
+
+	p.queries = queries
+	p.reloadErr = nil
+}
+
+// close stops watching the files. The watcher channels are closed by that, which ends watchFiles.
+func (p *opaPolicy) close() error {
+	if p.w == nil {
+		return nil
+	}
+
+	return p.w.Close()
+}
+
+// watchFiles reloads the policy on changes. names holds the watched files and directories. Events for
+// watched directories (false) are always relevant, events in directories watched for a file (true) only
+// if they concern that file, or the data of a Kubernetes config map or secret mount.
+func (p *opaPolicy) watchFiles(names map[string]bool) {
+	for {
+		select {
+		case evt, ok := <-p.w.Events:
+			if !ok {
+				return
+			}
+
+			if evt.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Remove|fsnotify.Rename) != 0 && isPolicyChange(evt, names) {
+				p.reload()
+			}
+		case _, ok := <-p.w.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+func isPolicyChange(evt fsnotify.Event, names map[string]bool) bool {
+	name := filepath.Clean(evt.Name)
+	if _, ok := names[name]; ok {
+		return true
+	}
+
+	dir := filepath.Dir(name)
+	if isFile, ok := names[dir]; ok && !isFile {
+		return true
+	}
+
+	if filepath.Base(name) == "..data" {
+		for path, isFile := range names {
+			if isFile && filepath.Dir(path) == dir {
+				return true
+			}
+		}
+	}
+
+	return false
+}
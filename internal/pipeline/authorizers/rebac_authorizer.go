@@ -0,0 +1,378 @@
+package authorizers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	rebacModeAll = "all"
+	rebacModeAny = "any"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerAuthorizerTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Authorizer, error) {
+			if typ != config.POTReBAC {
+				return false, nil, nil
+			}
+
+			auth, err := newReBACAuthorizer(id, conf)
+
+			return true, auth, err
+		})
+}
+
+type relationCheck struct {
+	Object   template.Template `mapstructure:"object"`
+	Relation string            `mapstructure:"relation"`
+	Subject  template.Template `mapstructure:"subject"`
+}
+
+type rebacAuthorizer struct {
+	id               string
+	checker          relationChecker
+	consistencyToken template.Template
+	checks           []relationCheck
+	mode             string
+	ttl              time.Duration
+}
+
+func newReBACAuthorizer(id string, rawConfig map[string]any) (*rebacAuthorizer, error) {
+	type Config struct {
+		Provider             string            `mapstructure:"provider"`
+		Endpoint             endpoint.Endpoint `mapstructure:"endpoint"`
+		StoreID              string            `mapstructure:"store_id"`
+		AuthorizationModelID string            `mapstructure:"authorization_model_id"`
+		Consistency          string            `mapstructure:"consistency"`
+		ConsistencyToken     template.Template `mapstructure:"consistency_token"`
+		Checks               []relationCheck   `mapstructure:"checks"`
+		Mode                 string            `mapstructure:"mode"`
+		CacheTTL             time.Duration     `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal rebac authorizer config").
+			CausedBy(err)
+	}
+
+	checker, err := newRelationChecker(conf.Provider, conf.Endpoint,
+		conf.StoreID, conf.AuthorizationModelID, conf.Consistency)
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.ConsistencyToken != nil && conf.Provider != rebacProviderSpiceDB {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"consistency_token is not supported by the %s provider", conf.Provider)
+	}
+
+	if err = validateRelationChecks(conf.Checks, conf.Mode); err != nil {
+		return nil, err
+	}
+
+	return &rebacAuthorizer{
+		id:               id,
+		checker:          checker,
+		consistencyToken: conf.ConsistencyToken,
+		checks:           conf.Checks,
+		mode:             x.IfThenElse(len(conf.Mode) != 0, conf.Mode, rebacModeAll),
+		ttl:              conf.CacheTTL,
+	}, nil
+}
+
+func validateRelationChecks(checks []relationCheck, mode string) error {
+	if len(checks) == 0 {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"no checks configured for rebac authorizer")
+	}
+
+	for idx, check := range checks {
+		if check.Object == nil || len(check.Relation) == 0 || check.Subject == nil {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"check %d requires object, relation and subject to be configured", idx)
+		}
+	}
+
+	if len(mode) != 0 && mode != rebacModeAll && mode != rebacModeAny {
+		return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported mode %s", mode)
+	}
+
+	return nil
+}
+
+func (a *rebacAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Authorizing using rebac authorizer")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute rebac authorizer due to 'nil' subject").
+			WithErrorContext(a)
+	}
+
+	tuples, consistencyToken, err := a.renderChecks(ctx, sub)
+	if err != nil {
+		return err
+	}
+
+	results, err := a.check(ctx.AppContext(), sub.ID, tuples, consistencyToken)
+	if err != nil {
+		return a.checkError(err)
+	}
+
+	if a.mode == rebacModeAny {
+		return a.decideAny(results)
+	}
+
+	return a.decideAll(tuples, results)
+}
+
+// decideAny grants access if any check succeeded, even if other checks could not be performed.
+func (a *rebacAuthorizer) decideAny(results []relationCheckResult) error {
+	var checkErr error
+
+	for _, result := range results {
+		if result.allowed {
+			return nil
+		}
+
+		if result.err != nil && checkErr == nil {
+			checkErr = result.err
+		}
+	}
+
+	if checkErr != nil {
+		return a.checkError(checkErr)
+	}
+
+	return errorchain.
+		NewWithMessage(heimdall.ErrAuthorization, "none of the relation checks succeeded").
+		WithErrorContext(a)
+}
+
+// decideAll denies access if any check failed, even if other checks could not be performed.
+func (a *rebacAuthorizer) decideAll(tuples []relationTuple, results []relationCheckResult) error {
+	var checkErr error
+
+	for idx, result := range results {
+		if result.err != nil {
+			if checkErr == nil {
+				checkErr = result.err
+			}
+
+			continue
+		}
+
+		if !result.allowed {
+			return errorchain.
+				NewWithMessagef(heimdall.ErrAuthorization, "%s has no %s relation to %s",
+					tuples[idx].Subject, tuples[idx].Relation, tuples[idx].Object).
+				WithErrorContext(a)
+		}
+	}
+
+	if checkErr != nil {
+		return a.checkError(checkErr)
+	}
+
+	return nil
+}
+
+// checkError keeps argument errors, like malformed object references rendered from the request,
+// and reports all other errors as communication errors.
+func (a *rebacAuthorizer) checkError(err error) error {
+	errType := x.IfThenElse(errors.Is(err, heimdall.ErrArgument), heimdall.ErrArgument, heimdall.ErrCommunication)
+
+	return errorchain.
+		NewWithMessage(errType, "relation check failed").
+		WithErrorContext(a).
+		CausedBy(err)
+}
+
+func (a *rebacAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, error) {
+	if len(rawConfig) == 0 {
+		return a, nil
+	}
+
+	type Config struct {
+		Checks   []relationCheck `mapstructure:"checks"`
+		Mode     string          `mapstructure:"mode"`
+		CacheTTL *time.Duration  `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal rebac authorizer config").
+			CausedBy(err)
+	}
+
+	checks := x.IfThenElse(len(conf.Checks) != 0, conf.Checks, a.checks)
+	mode := x.IfThenElse(len(conf.Mode) != 0, conf.Mode, a.mode)
+
+	if err := validateRelationChecks(checks, mode); err != nil {
+		return nil, err
+	}
+
+	return &rebacAuthorizer{
+		id:               a.id,
+		checker:          a.checker,
+		consistencyToken: a.consistencyToken,
+		checks:           checks,
+		mode:             mode,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return a.ttl }),
+	}, nil
+}
+
+func (a *rebacAuthorizer) HandlerID() string {
+	return a.id
+}
+
+func (a *rebacAuthorizer) renderChecks(
+	ctx heimdall.Context, sub *subject.Subject,
+) ([]relationTuple, string, error) {
+	var (
+		consistencyToken string
+		err              error
+	)
+
+	render := func(tpl template.Template, name string) (string, error) {
+		value, err := tpl.Render(ctx, sub)
+		if err != nil {
+			return "", errorchain.
+				NewWithMessagef(heimdall.ErrInternal, "failed to render %s", name).
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return value, nil
+	}
+
+	if a.consistencyToken != nil {
+		if consistencyToken, err = render(a.consistencyToken, "consistency token"); err != nil {
+			return nil, "", err
+		}
+	}
+
+	tuples := make([]relationTuple, len(a.checks))
+
+	for idx, check := range a.checks {
+		tuples[idx].Relation = check.Relation
+
+		if tuples[idx].Object, err = render(check.Object, "object"); err != nil {
+			return nil, "", err
+		}
+
+		if tuples[idx].Subject, err = render(check.Subject, "subject"); err != nil {
+			return nil, "", err
+		}
+	}
+
+	return tuples, consistencyToken, nil
+}
+
+// check performs the checks not answered by the cache using a single batch request. In any mode,
+// a cached successful check makes that request obsolete.
+func (a *rebacAuthorizer) check(
+	ctx context.Context, subjectID string, tuples []relationTuple, consistencyToken string,
+) ([]relationCheckResult, error) {
+	logger := zerolog.Ctx(ctx)
+	cch := cache.NamespacedCtx(ctx, cache.NamespaceAuthorizer)
+	cacheable := a.ttl > 0 && a.checker.cacheable(consistencyToken)
+
+	results := make([]relationCheckResult, len(tuples))
+	cacheKeys := make([]string, len(tuples))
+
+	var (
+		pending       []int
+		pendingTuples []relationTuple
+	)
+
+	for idx, tuple := range tuples {
+		if cacheable {
+			cacheKeys[idx] = a.calculateCacheKey(tuple, consistencyToken)
+
+			if allowed, ok := cachedCheckResult(ctx, cch, cacheKeys[idx]); ok {
+				logger.Debug().Msg("Reusing relation check result from cache")
+
+				results[idx].allowed = allowed
+
+				if allowed && a.mode == rebacModeAny {
+					return results, nil
+				}
+
+				continue
+			}
+		}
+
+		pending = append(pending, idx)
+		pendingTuples = append(pendingTuples, tuple)
+	}
+
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	checked, err := a.checker.check(ctx, pendingTuples, consistencyToken)
+	if err != nil {
+		return nil, err
+	}
+
+	for pos, idx := range pending {
+		results[idx] = checked[pos]
+
+		if cacheable && checked[pos].err == nil {
+			cch.Set(cacheKeys[idx], checked[pos].allowed, a.ttl)
+			cache.Index(cch, cacheKeys[idx], a.ttl, cache.HandlerTag(a.id), cache.SubjectTag(subjectID))
+		}
+	}
+
+	return results, nil
+}
+
+func cachedCheckResult(ctx context.Context, cch cache.Cache, key string) (bool, bool) {
+	entry := cch.Get(key)
+	if entry == nil {
+		return false, false
+	}
+
+	allowed, ok := entry.(bool)
+	if !ok {
+		zerolog.Ctx(ctx).Warn().Msg("Wrong object type from cache")
+		cch.Delete(key)
+	}
+
+	return allowed, ok
+}
+
+func (a *rebacAuthorizer) calculateCacheKey(tuple relationTuple, consistencyToken string) string {
+	hash := sha256.New()
+	hash.Write([]byte(a.checker.hash()))
+	hash.Write([]byte(strings.Join([]string{tuple.Object, tuple.Relation, tuple.Subject}, "|")))
+	hash.Write([]byte(consistencyToken))
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
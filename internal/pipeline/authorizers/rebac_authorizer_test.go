@@ -0,0 +1,974 @@
+package authorizers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestCreateReBACAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, auth *rebacAuthorizer)
+	}{
+		{
+			uc: "without configuration",
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "endpoint requires url")
+			},
+		},
+		{
+			uc: "with unsupported provider",
+			config: []byte(`
+provider: keto
+endpoint:
+  url: http://foo.bar
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported rebac provider keto")
+			},
+		},
+		{
+			uc: "openfga without store id",
+			config: []byte(`
+provider: openfga
+endpoint:
+  url: http://foo.bar
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires store_id")
+			},
+		},
+		{
+			uc: "openfga with consistency token",
+			config: []byte(`
+provider: openfga
+endpoint:
+  url: http://foo.bar
+store_id: foo
+consistency_token: "{{ .RequestHeader \"X-Token\" }}"
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "consistency_token is not supported")
+			},
+		},
+		{
+			uc: "spicedb with store id",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+store_id: foo
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not supported by the spicedb provider")
+			},
+		},
+		{
+			uc: "spicedb with unsupported consistency",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+consistency: foo
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported consistency foo")
+			},
+		},
+		{
+			uc: "without checks",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no checks configured")
+			},
+		},
+		{
+			uc: "with incomplete check",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+checks:
+  - object: "document:1"
+    subject: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "check 0 requires object, relation and subject")
+			},
+		},
+		{
+			uc: "with unsupported mode",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+mode: some
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported mode some")
+			},
+		},
+		{
+			uc: "with unsupported properties",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+foo: bar
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc: "minimal openfga configuration",
+			id: "authz",
+			config: []byte(`
+provider: openfga
+endpoint:
+  url: http://foo.bar/
+store_id: 01GXSA8YR785C4FYS3C0RTG7B1
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				checker, ok := auth.checker.(*openFGAChecker)
+				require.True(t, ok)
+				assert.Equal(t, "http://foo.bar/stores/01GXSA8YR785C4FYS3C0RTG7B1/batch-check", checker.e.URL)
+				assert.Equal(t, http.MethodPost, checker.e.Method)
+				assert.Equal(t, "application/json", checker.e.Headers["Content-Type"])
+				assert.Equal(t, "application/json", checker.e.Headers["Accept"])
+				assert.Empty(t, checker.modelID)
+				assert.Nil(t, auth.consistencyToken)
+				assert.Len(t, auth.checks, 1)
+				assert.Equal(t, rebacModeAll, auth.mode)
+				assert.Zero(t, auth.ttl)
+				assert.Equal(t, "authz", auth.HandlerID())
+			},
+		},
+		{
+			uc: "full spicedb configuration",
+			id: "authz",
+			config: []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+  headers:
+    Authorization: Bearer foo
+consistency: fully_consistent
+consistency_token: "{{ .RequestHeader \"X-Zed-Token\" }}"
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+cache_ttl: 10s
+`),
+			assert: func(t *testing.T, err error, auth *rebacAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				checker, ok := auth.checker.(*spiceDBChecker)
+				require.True(t, ok)
+				assert.Equal(t, "http://foo.bar/v1/permissions/checkbulk", checker.e.URL)
+				assert.Equal(t, "Bearer foo", checker.e.Headers["Authorization"])
+				assert.Equal(t, spiceDBConsistencyFullyConsistent, checker.consistency)
+				assert.NotNil(t, auth.consistencyToken)
+				assert.Len(t, auth.checks, 2)
+				assert.Equal(t, rebacModeAny, auth.mode)
+				assert.Equal(t, 10*time.Second, auth.ttl)
+				assert.Equal(t, "authz", auth.HandlerID())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := newReBACAuthorizer(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, auth)
+		})
+	}
+}
+
+func TestCreateReBACAuthorizerFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	prototypeConfig := []byte(`
+provider: spicedb
+endpoint:
+  url: http://foo.bar
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+cache_ttl: 10s
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *rebacAuthorizer, configured *rebacAuthorizer)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *rebacAuthorizer, configured *rebacAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with not overridable property",
+			config: []byte(`provider: openfga`),
+			assert: func(t *testing.T, err error, prototype *rebacAuthorizer, configured *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc:     "with unsupported mode",
+			config: []byte(`mode: foo`),
+			assert: func(t *testing.T, err error, prototype *rebacAuthorizer, configured *rebacAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported mode foo")
+			},
+		},
+		{
+			uc: "with everything possible reconfigured",
+			config: []byte(`
+checks:
+  - object: "document:2"
+    relation: edit
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:2"
+    relation: edit
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+cache_ttl: 0s
+`),
+			assert: func(t *testing.T, err error, prototype *rebacAuthorizer, configured *rebacAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.checker, configured.checker)
+				assert.Len(t, configured.checks, 2)
+				assert.Equal(t, "edit", configured.checks[0].Relation)
+				assert.Equal(t, rebacModeAny, configured.mode)
+				assert.Zero(t, configured.ttl)
+				assert.Equal(t, 10*time.Second, prototype.ttl)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(prototypeConfig)
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newReBACAuthorizer("authz", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			auth, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				rebacAuth *rebacAuthorizer
+				ok        bool
+			)
+
+			if err == nil {
+				rebacAuth, ok = auth.(*rebacAuthorizer)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, rebacAuth)
+		})
+	}
+}
+
+type relationStub struct {
+	mut       sync.Mutex
+	relations map[string]bool
+	failing   map[string]bool
+	requests  []map[string]any
+	fail      bool
+}
+
+func (s *relationStub) handle(t *testing.T, respond func(req map[string]any) any) http.HandlerFunc {
+	t.Helper()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mut.Lock()
+		defer s.mut.Unlock()
+
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		if s.fail {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		data, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		var req map[string]any
+		assert.NoError(t, json.Unmarshal(data, &req))
+
+		s.requests = append(s.requests, req)
+
+		rawResp, err := json.Marshal(respond(req))
+		assert.NoError(t, err)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(rawResp)
+		assert.NoError(t, err)
+	}
+}
+
+func TestReBACAuthorizerExecuteUsingOpenFGA(t *testing.T) {
+	t.Parallel()
+
+	stub := &relationStub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stores/store1/batch-check", stub.handle(t, func(req map[string]any) any {
+		result := map[string]any{}
+
+		for _, entry := range req["checks"].([]any) { // nolint: forcetypeassert
+			// nolint: forcetypeassert
+			check := entry.(map[string]any)
+			// nolint: forcetypeassert
+			tupleKey := check["tuple_key"].(map[string]any)
+			// nolint: forcetypeassert
+			correlationID := check["correlation_id"].(string)
+
+			key := fmt.Sprintf("%s#%s@%s", tupleKey["object"], tupleKey["relation"], tupleKey["user"])
+
+			if stub.failing[key] {
+				result[correlationID] = map[string]any{
+					"error": map[string]any{"input_error": "validation_error", "message": "invalid tuple"},
+				}
+			} else {
+				result[correlationID] = map[string]any{"allowed": stub.relations[key]}
+			}
+		}
+
+		return map[string]any{"result": result}
+	}))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc        string
+		config    string
+		relations map[string]bool
+		failing   map[string]bool
+		cached    map[string]bool
+		fail      bool
+		assert    func(t *testing.T, err error, requests []map[string]any)
+	}{
+		{
+			uc: "all checks succeed",
+			config: `
+checks:
+  - object: "document:{{ .RequestQueryParameter \"doc\" }}"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+`,
+			relations: map[string]bool{"document:1#reader@user:foo": true, "folder:1#viewer@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				// checks are sent in one batch
+				require.Len(t, requests, 1)
+				assert.Equal(t, "model1", requests[0]["authorization_model_id"])
+				assert.Len(t, requests[0]["checks"], 2)
+			},
+		},
+		{
+			uc: "one of all checks fails",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+`,
+			relations: map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "user:foo has no viewer relation to folder:1")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "one of any checks succeeds",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+`,
+			relations: map[string]bool{"folder:1#viewer@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "none of any checks succeeds",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+`,
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "none of the relation checks succeeded")
+			},
+		},
+		{
+			uc: "one of any checks succeeds while another one cannot be performed",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+`,
+			relations: map[string]bool{"folder:1#viewer@user:foo": true},
+			failing:   map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc: "one of all checks cannot be performed",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+`,
+			relations: map[string]bool{"folder:1#viewer@user:foo": true},
+			failing:   map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "invalid tuple")
+			},
+		},
+		{
+			uc: "one of all checks fails while another one cannot be performed",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+`,
+			failing: map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "user:foo has no viewer relation to folder:1")
+			},
+		},
+		{
+			uc: "cached successful check of any checks",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+cache_ttl: 10s
+`,
+			cached: map[string]bool{"folder:1#viewer@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, requests)
+			},
+		},
+		{
+			uc: "only checks not cached are sent",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: viewer
+    subject: "user:{{ .Subject.ID }}"
+cache_ttl: 10s
+`,
+			relations: map[string]bool{"folder:1#viewer@user:foo": true},
+			cached:    map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, requests, 1)
+
+				checks := requests[0]["checks"].([]any) // nolint: forcetypeassert
+				require.Len(t, checks, 1)
+				// nolint: forcetypeassert
+				assert.Equal(t, "folder:1", checks[0].(map[string]any)["tuple_key"].(map[string]any)["object"])
+			},
+		},
+		{
+			uc: "check endpoint fails",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+`,
+			fail: true,
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "unexpected response code: 500")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "check results are cached",
+			config: `
+checks:
+  - object: "document:1"
+    relation: reader
+    subject: "user:{{ .Subject.ID }}"
+cache_ttl: 10s
+`,
+			relations: map[string]bool{"document:1#reader@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				// the authorizer has been executed twice
+				assert.Len(t, requests, 1)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			stub.mut.Lock()
+			stub.relations = tc.relations
+			stub.failing = tc.failing
+			stub.requests = nil
+			stub.fail = tc.fail
+			stub.mut.Unlock()
+
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+provider: openfga
+endpoint:
+  url: ` + srv.URL + `
+store_id: store1
+authorization_model_id: model1
+` + tc.config))
+			require.NoError(t, err)
+
+			auth, err := newReBACAuthorizer("authz", conf)
+			require.NoError(t, err)
+
+			appCtx := cache.WithContext(context.Background(), memory.New())
+			cch := cache.NamespacedCtx(appCtx, cache.NamespaceAuthorizer)
+
+			for relation, allowed := range tc.cached {
+				object, rest, _ := strings.Cut(relation, "#")
+				rel, sub, _ := strings.Cut(rest, "@")
+
+				cch.Set(auth.calculateCacheKey(relationTuple{Object: object, Relation: rel, Subject: sub}, ""),
+					allowed, 1*time.Minute)
+			}
+
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(appCtx)
+			ctx.On("RequestQueryParameter", "doc").Return("1")
+
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+			// WHEN
+			err = auth.Execute(ctx, sub)
+			if err == nil && auth.ttl > 0 {
+				err = auth.Execute(ctx, sub)
+			}
+
+			// THEN
+			tc.assert(t, err, stub.requests)
+		})
+	}
+}
+
+func TestReBACAuthorizerExecuteUsingSpiceDB(t *testing.T) {
+	t.Parallel()
+
+	stub := &relationStub{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/permissions/checkbulk", stub.handle(t, func(req map[string]any) any {
+		var pairs []any
+
+		for _, entry := range req["items"].([]any) { // nolint: forcetypeassert
+			// nolint: forcetypeassert
+			item := entry.(map[string]any)
+			// nolint: forcetypeassert
+			resource := item["resource"].(map[string]any)
+			// nolint: forcetypeassert
+			sub := item["subject"].(map[string]any)["object"].(map[string]any)
+
+			key := fmt.Sprintf("%s:%s#%s@%s:%s",
+				resource["objectType"], resource["objectId"], item["permission"],
+				sub["objectType"], sub["objectId"])
+
+			if stub.failing[key] {
+				pairs = append(pairs, map[string]any{
+					"request": item,
+					"error":   map[string]any{"code": 3, "message": "object definition not found"},
+				})
+			} else {
+				pairs = append(pairs, map[string]any{
+					"request": item,
+					"item": map[string]any{
+						"permissionship": map[bool]string{
+							true:  "PERMISSIONSHIP_HAS_PERMISSION",
+							false: "PERMISSIONSHIP_NO_PERMISSION",
+						}[stub.relations[key]],
+					},
+				})
+			}
+		}
+
+		return map[string]any{
+			"checkedAt": map[string]any{"token": "GhUKEzE2ODE3NDk4NjU"},
+			"pairs":     pairs,
+		}
+	}))
+
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc        string
+		config    string
+		token     string
+		relations map[string]bool
+		failing   map[string]bool
+		assert    func(t *testing.T, err error, requests []map[string]any)
+	}{
+		{
+			uc: "check succeeds with minimized latency",
+			config: `
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "group:{{ .Subject.ID }}#member"
+`,
+			relations: map[string]bool{"document:1#view@group:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, requests, 1)
+				assert.Equal(t, map[string]any{"minimizeLatency": true}, requests[0]["consistency"])
+
+				items := requests[0]["items"].([]any) // nolint: forcetypeassert
+				require.Len(t, items, 1)
+				// nolint: forcetypeassert
+				assert.Equal(t, "member", items[0].(map[string]any)["subject"].(map[string]any)["optionalRelation"])
+			},
+		},
+		{
+			uc: "check with malformed object reference",
+			config: `
+checks:
+  - object: "document"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "document is not a valid object reference")
+				assert.Empty(t, requests)
+			},
+		},
+		{
+			uc: "one of any checks succeeds while another one has a malformed object reference",
+			config: `
+checks:
+  - object: "document"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+  - object: "folder:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+mode: any
+`,
+			relations: map[string]bool{"folder:1#view@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, requests, 1)
+				assert.Len(t, requests[0]["items"], 1)
+			},
+		},
+		{
+			uc: "check cannot be performed",
+			config: `
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+`,
+			failing: map[string]bool{"document:1#view@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "object definition not found")
+			},
+		},
+		{
+			uc: "check fails",
+			config: `
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "user:foo has no view relation to document:1")
+			},
+		},
+		{
+			uc: "fully consistent checks are not cached",
+			config: `
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+consistency: fully_consistent
+consistency_token: "{{ .RequestHeader \"X-Zed-Token\" }}"
+cache_ttl: 10s
+`,
+			relations: map[string]bool{"document:1#view@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, requests, 2)
+				assert.Equal(t, map[string]any{"fullyConsistent": true}, requests[0]["consistency"])
+			},
+		},
+		{
+			uc: "checks with consistency token are cached per token",
+			config: `
+checks:
+  - object: "document:1"
+    relation: view
+    subject: "user:{{ .Subject.ID }}"
+consistency: fully_consistent
+consistency_token: "{{ .RequestHeader \"X-Zed-Token\" }}"
+cache_ttl: 10s
+`,
+			token:     "GhUKEzE2ODE3NDk4NjU",
+			relations: map[string]bool{"document:1#view@user:foo": true},
+			assert: func(t *testing.T, err error, requests []map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, requests, 1)
+				assert.Equal(t,
+					map[string]any{"atLeastAsFresh": map[string]any{"token": "GhUKEzE2ODE3NDk4NjU"}},
+					requests[0]["consistency"])
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			stub.mut.Lock()
+			stub.relations = tc.relations
+			stub.failing = tc.failing
+			stub.requests = nil
+			stub.mut.Unlock()
+
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+provider: spicedb
+endpoint:
+  url: ` + srv.URL + `
+` + tc.config))
+			require.NoError(t, err)
+
+			auth, err := newReBACAuthorizer("authz", conf)
+			require.NoError(t, err)
+
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+			ctx.On("RequestHeader", "X-Zed-Token").Return(tc.token)
+
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{}}
+
+			// WHEN
+			err = auth.Execute(ctx, sub)
+			if err == nil && auth.ttl > 0 {
+				err = auth.Execute(ctx, sub)
+			}
+
+			// THEN
+			tc.assert(t, err, stub.requests)
+		})
+	}
+}
@@ -0,0 +1,359 @@
+package authorizers
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	rebacProviderOpenFGA = "openfga"
+	rebacProviderSpiceDB = "spicedb"
+
+	spiceDBConsistencyMinimizeLatency = "minimize_latency"
+	spiceDBConsistencyFullyConsistent = "fully_consistent"
+
+	spiceDBHasPermission = "PERMISSIONSHIP_HAS_PERMISSION"
+)
+
+// relationTuple is a rendered relation check. Object and subject are expected to
+// be given in the "type:id" format. The subject may additionally reference a relation
+// of the subject object using the "type:id#relation" format.
+type relationTuple struct {
+	Object   string
+	Relation string
+	Subject  string
+}
+
+// relationCheckResult is the result of a single relation check within a batch. err is set if that
+// check could not be performed.
+type relationCheckResult struct {
+	allowed bool
+	err     error
+}
+
+type relationChecker interface {
+	// check performs the given checks using a single batch request and returns the results in the order
+	// of the tuples. An error is only returned if the batch request failed as a whole. consistencyToken
+	// is only used by checkers, which support it and is ignored otherwise.
+	check(ctx context.Context, tuples []relationTuple, consistencyToken string) ([]relationCheckResult, error)
+	// cacheable returns whether the results can be cached for the given consistency token
+	cacheable(consistencyToken string) bool
+	hash() string
+}
+
+func newRelationChecker(
+	provider string, ep endpoint.Endpoint, storeID, modelID, consistency string,
+) (relationChecker, error) {
+	if err := ep.Validate(); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to validate endpoint configuration").
+			CausedBy(err)
+	}
+
+	ep = prepareRelationCheckEndpoint(ep)
+
+	switch provider {
+	case rebacProviderOpenFGA:
+		if len(storeID) == 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"openfga provider requires store_id to be configured")
+		}
+
+		if len(consistency) != 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"consistency is not supported by the openfga provider")
+		}
+
+		ep.URL = strings.TrimSuffix(ep.URL, "/") + "/stores/" + url.PathEscape(storeID) + "/batch-check"
+
+		return &openFGAChecker{e: ep, modelID: modelID}, nil
+	case rebacProviderSpiceDB:
+		if len(storeID) != 0 || len(modelID) != 0 {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"store_id and authorization_model_id are not supported by the spicedb provider")
+		}
+
+		if len(consistency) == 0 {
+			consistency = spiceDBConsistencyMinimizeLatency
+		}
+
+		if consistency != spiceDBConsistencyMinimizeLatency && consistency != spiceDBConsistencyFullyConsistent {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"unsupported consistency %s", consistency)
+		}
+
+		ep.URL = strings.TrimSuffix(ep.URL, "/") + "/v1/permissions/checkbulk"
+
+		return &spiceDBChecker{e: ep, consistency: consistency}, nil
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported rebac provider %s", provider)
+	}
+}
+
+func prepareRelationCheckEndpoint(ep endpoint.Endpoint) endpoint.Endpoint {
+	headers := make(map[string]string, len(ep.Headers))
+	for k, v := range ep.Headers {
+		headers[k] = v
+	}
+
+	if _, ok := headers["Content-Type"]; !ok {
+		headers["Content-Type"] = "application/json"
+	}
+
+	if _, ok := headers["Accept"]; !ok {
+		headers["Accept"] = "application/json"
+	}
+
+	ep.Headers = headers
+
+	if len(ep.Method) == 0 {
+		ep.Method = http.MethodPost
+	}
+
+	return ep
+}
+
+type openFGAChecker struct {
+	e       endpoint.Endpoint
+	modelID string
+}
+
+func (c *openFGAChecker) check(
+	ctx context.Context, tuples []relationTuple, _ string,
+) ([]relationCheckResult, error) {
+	type TupleKey struct {
+		User     string `json:"user"`
+		Relation string `json:"relation"`
+		Object   string `json:"object"`
+	}
+
+	type Check struct {
+		TupleKey      TupleKey `json:"tuple_key"`
+		CorrelationID string   `json:"correlation_id"`
+	}
+
+	type Request struct {
+		Checks               []Check `json:"checks"`
+		AuthorizationModelID string  `json:"authorization_model_id,omitempty"`
+	}
+
+	type Result struct {
+		Allowed bool `json:"allowed"`
+		Error   *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	type Response struct {
+		Result map[string]Result `json:"result"`
+	}
+
+	req := Request{Checks: make([]Check, len(tuples)), AuthorizationModelID: c.modelID}
+
+	for idx, tuple := range tuples {
+		req.Checks[idx] = Check{
+			TupleKey:      TupleKey{User: tuple.Subject, Relation: tuple.Relation, Object: tuple.Object},
+			CorrelationID: strconv.Itoa(idx),
+		}
+	}
+
+	var resp Response
+
+	if err := sendJSON(ctx, c.e, req, &resp); err != nil {
+		return nil, err
+	}
+
+	results := make([]relationCheckResult, len(tuples))
+
+	for idx := range tuples {
+		result, ok := resp.Result[strconv.Itoa(idx)]
+
+		switch {
+		case !ok:
+			results[idx].err = errorchain.NewWithMessagef(heimdall.ErrCommunication,
+				"no result received for check %d", idx)
+		case result.Error != nil:
+			results[idx].err = errorchain.NewWithMessagef(heimdall.ErrCommunication,
+				"check %d failed: %s", idx, result.Error.Message)
+		default:
+			results[idx].allowed = result.Allowed
+		}
+	}
+
+	return results, nil
+}
+
+func (c *openFGAChecker) cacheable(_ string) bool { return true }
+
+func (c *openFGAChecker) hash() string { return rebacProviderOpenFGA + c.e.Hash() + c.modelID }
+
+type spiceDBChecker struct {
+	e           endpoint.Endpoint
+	consistency string
+}
+
+func (c *spiceDBChecker) check(
+	ctx context.Context, tuples []relationTuple, consistencyToken string,
+) ([]relationCheckResult, error) {
+	type ObjectReference struct {
+		ObjectType string `json:"objectType"`
+		ObjectID   string `json:"objectId"`
+	}
+
+	type SubjectReference struct {
+		Object           ObjectReference `json:"object"`
+		OptionalRelation string          `json:"optionalRelation,omitempty"`
+	}
+
+	type Item struct {
+		Resource   ObjectReference  `json:"resource"`
+		Permission string           `json:"permission"`
+		Subject    SubjectReference `json:"subject"`
+	}
+
+	type Request struct {
+		Consistency map[string]any `json:"consistency"`
+		Items       []Item         `json:"items"`
+	}
+
+	type Pair struct {
+		Item *struct {
+			Permissionship string `json:"permissionship"`
+		} `json:"item"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+
+	type Response struct {
+		Pairs []Pair `json:"pairs"`
+	}
+
+	results := make([]relationCheckResult, len(tuples))
+	req := Request{Consistency: c.consistencyRequirement(consistencyToken)}
+
+	// indices of the tuples sent, as tuples with malformed references are not
+	var sent []int
+
+	for idx, tuple := range tuples {
+		resourceType, resourceID, err := splitObjectReference(tuple.Object)
+		if err != nil {
+			results[idx].err = err
+
+			continue
+		}
+
+		subjectRef, subjectRelation, _ := strings.Cut(tuple.Subject, "#")
+
+		subjectType, subjectID, err := splitObjectReference(subjectRef)
+		if err != nil {
+			results[idx].err = err
+
+			continue
+		}
+
+		sent = append(sent, idx)
+		req.Items = append(req.Items, Item{
+			Resource:   ObjectReference{ObjectType: resourceType, ObjectID: resourceID},
+			Permission: tuple.Relation,
+			Subject: SubjectReference{
+				Object:           ObjectReference{ObjectType: subjectType, ObjectID: subjectID},
+				OptionalRelation: subjectRelation,
+			},
+		})
+	}
+
+	if len(sent) == 0 {
+		return results, nil
+	}
+
+	var resp Response
+
+	if err := sendJSON(ctx, c.e, req, &resp); err != nil {
+		return nil, err
+	}
+
+	// the pairs are returned in the order of the items
+	if len(resp.Pairs) != len(sent) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"received %d check results for %d checks", len(resp.Pairs), len(sent))
+	}
+
+	for pos, idx := range sent {
+		pair := resp.Pairs[pos]
+
+		switch {
+		case pair.Error != nil:
+			results[idx].err = errorchain.NewWithMessagef(heimdall.ErrCommunication,
+				"check %d failed: %s", idx, pair.Error.Message)
+		case pair.Item == nil:
+			results[idx].err = errorchain.NewWithMessagef(heimdall.ErrCommunication,
+				"no result received for check %d", idx)
+		default:
+			results[idx].allowed = pair.Item.Permissionship == spiceDBHasPermission
+		}
+	}
+
+	return results, nil
+}
+
+func (c *spiceDBChecker) consistencyRequirement(consistencyToken string) map[string]any {
+	switch {
+	case len(consistencyToken) != 0:
+		return map[string]any{"atLeastAsFresh": map[string]any{"token": consistencyToken}}
+	case c.consistency == spiceDBConsistencyFullyConsistent:
+		return map[string]any{"fullyConsistent": true}
+	default:
+		return map[string]any{"minimizeLatency": true}
+	}
+}
+
+// cacheable returns false for fully consistent checks without a token, as caching
+// would defeat the purpose of this consistency requirement. With a token, the results
+// are cached per token, so a newer token results in a new check.
+func (c *spiceDBChecker) cacheable(consistencyToken string) bool {
+	return len(consistencyToken) != 0 || c.consistency != spiceDBConsistencyFullyConsistent
+}
+
+func (c *spiceDBChecker) hash() string { return rebacProviderSpiceDB + c.e.Hash() + c.consistency }
+
+func splitObjectReference(value string) (string, string, error) {
+	objectType, objectID, found := strings.Cut(value, ":")
+	if !found || len(objectType) == 0 || len(objectID) == 0 {
+		return "", "", errorchain.NewWithMessagef(heimdall.ErrArgument,
+			"%s is not a valid object reference. Expected type:id", value)
+	}
+
+	return objectType, objectID, nil
+}
+
+func sendJSON(ctx context.Context, ep endpoint.Endpoint, req any, resp any) error {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to marshal check request").
+			CausedBy(err)
+	}
+
+	rawData, err := ep.SendRequest(ctx, bytes.NewReader(body), nil)
+	if err != nil {
+		return err
+	}
+
+	if err = json.Unmarshal(rawData, resp); err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to unmarshal check response").
+			CausedBy(err)
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package authorizers
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -18,7 +19,9 @@ import (
 	"github.com/dadrus/heimdall/internal/config"
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/cellib"
 	"github.com/dadrus/heimdall/internal/pipeline/contenttype"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/script"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
@@ -45,7 +48,9 @@ type remoteAuthorizer struct {
 	id                 string
 	e                  endpoint.Endpoint
 	payload            template.Template
+	graphql            *graphql.Query
 	script             script.Script
+	expressions        cellib.Expressions
 	headersForUpstream []string
 	ttl                time.Duration
 }
@@ -72,11 +77,13 @@ func (ai *authorizationInformation) addAttributesTo(key string, sub *subject.Sub
 
 func newRemoteAuthorizer(id string, rawConfig map[string]any) (*remoteAuthorizer, error) {
 	type Config struct {
-		Endpoint                 endpoint.Endpoint `mapstructure:"endpoint"`
-		Payload                  template.Template `mapstructure:"payload"`
-		Script                   script.Script     `mapstructure:"script"`
-		ResponseHeadersToForward []string          `mapstructure:"forward_response_headers_to_upstream"`
-		CacheTTL                 time.Duration     `mapstructure:"cache_ttl"`
+		Endpoint                 endpoint.Endpoint  `mapstructure:"endpoint"`
+		Payload                  template.Template  `mapstructure:"payload"`
+		GraphQL                  *graphql.Query     `mapstructure:"graphql"`
+		Script                   script.Script      `mapstructure:"script"`
+		Expressions              cellib.Expressions `mapstructure:"expressions"`
+		ResponseHeadersToForward []string           `mapstructure:"forward_response_headers_to_upstream"`
+		CacheTTL                 time.Duration      `mapstructure:"cache_ttl"`
 	}
 
 	var conf Config
@@ -92,17 +99,25 @@ func newRemoteAuthorizer(id string, rawConfig map[string]any) (*remoteAuthorizer
 			CausedBy(err)
 	}
 
-	if len(conf.Endpoint.Headers) == 0 && conf.Payload == nil {
+	if len(conf.Endpoint.Headers) == 0 && conf.Payload == nil && conf.GraphQL == nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrConfiguration,
-				"either a payload or at least one endpoint header must be configured for remote authorizer")
+				"either a payload or at least one endpoint header must be configured for remote authorizer, "+
+					"if no graphql query is used")
+	}
+
+	if conf.Payload != nil && conf.GraphQL != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "payload and graphql cannot be configured together")
 	}
 
 	return &remoteAuthorizer{
 		e:                  conf.Endpoint,
 		id:                 id,
 		payload:            conf.Payload,
+		graphql:            conf.GraphQL,
 		script:             conf.Script,
+		expressions:        conf.Expressions,
 		headersForUpstream: conf.ResponseHeadersToForward,
 		ttl:                conf.CacheTTL,
 	}, nil
@@ -118,7 +133,7 @@ func (a *remoteAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) e
 			WithErrorContext(a)
 	}
 
-	cch := cache.Ctx(ctx.AppContext())
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceAuthorizer)
 
 	var (
 		cacheKey   string
@@ -154,6 +169,7 @@ func (a *remoteAuthorizer) Execute(ctx heimdall.Context, sub *subject.Subject) e
 
 		if a.ttl > 0 && len(cacheKey) != 0 {
 			cch.Set(cacheKey, authInfo, a.ttl)
+			cache.Index(cch, cacheKey, a.ttl, cache.HandlerTag(a.id), cache.SubjectTag(sub.ID))
 		}
 	}
 
@@ -169,10 +185,12 @@ func (a *remoteAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, err
 	}
 
 	type Config struct {
-		Payload                  template.Template `mapstructure:"payload"`
-		Script                   script.Script     `mapstructure:"script"`
-		ResponseHeadersToForward []string          `mapstructure:"forward_response_headers_to_upstream"`
-		CacheTTL                 time.Duration     `mapstructure:"cache_ttl"`
+		Payload                  template.Template  `mapstructure:"payload"`
+		GraphQL                  *graphql.Query     `mapstructure:"graphql"`
+		Script                   script.Script      `mapstructure:"script"`
+		Expressions              cellib.Expressions `mapstructure:"expressions"`
+		ResponseHeadersToForward []string           `mapstructure:"forward_response_headers_to_upstream"`
+		CacheTTL                 time.Duration      `mapstructure:"cache_ttl"`
 	}
 
 	var conf Config
@@ -182,11 +200,25 @@ func (a *remoteAuthorizer) WithConfig(rawConfig map[string]any) (Authorizer, err
 			CausedBy(err)
 	}
 
+	if conf.Payload != nil && conf.GraphQL != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "payload and graphql cannot be configured together")
+	}
+
+	// a payload or a graphql query configured on the rule level replaces whatever was configured
+	// in the prototype
+	payload, gql := a.payload, a.graphql
+	if conf.Payload != nil || conf.GraphQL != nil {
+		payload, gql = conf.Payload, conf.GraphQL
+	}
+
 	return &remoteAuthorizer{
-		id:      a.id,
-		e:       a.e,
-		payload: x.IfThenElse(conf.Payload != nil, conf.Payload, a.payload),
-		script:  x.IfThenElse(conf.Script != nil, conf.Script, a.script),
+		id:          a.id,
+		e:           a.e,
+		payload:     payload,
+		graphql:     gql,
+		script:      x.IfThenElse(conf.Script != nil, conf.Script, a.script),
+		expressions: x.IfThenElse(len(conf.Expressions) != 0, conf.Expressions, a.expressions),
 		headersForUpstream: x.IfThenElse(len(conf.ResponseHeadersToForward) != 0,
 			conf.ResponseHeadersToForward, a.headersForUpstream),
 		ttl: x.IfThenElse(conf.CacheTTL > 0, conf.CacheTTL, a.ttl),
@@ -230,7 +262,7 @@ func (a *remoteAuthorizer) doAuthorize(ctx heimdall.Context, sub *subject.Subjec
 		return nil, err
 	}
 
-	err = a.verify(ctx, data)
+	err = a.verify(ctx, sub, data)
 	if err != nil {
 		return nil, err
 	}
@@ -253,6 +285,19 @@ func (a *remoteAuthorizer) createRequest(ctx heimdall.Context, sub *subject.Subj
 		body = strings.NewReader(bodyContents)
 	}
 
+	if a.graphql != nil {
+		bodyContents, err := a.graphql.Body(ctx, sub)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrInternal,
+					"failed to create graphql request for the authorization endpoint").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		body = bytes.NewReader(bodyContents)
+	}
+
 	req, err := a.e.CreateRequest(ctx.AppContext(), body,
 		endpoint.RenderFunc(func(value string) (string, error) {
 			tpl, err := template.New(value)
@@ -272,6 +317,10 @@ func (a *remoteAuthorizer) createRequest(ctx heimdall.Context, sub *subject.Subj
 			CausedBy(err)
 	}
 
+	if a.graphql != nil && len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
 	return req, nil
 }
 
@@ -299,6 +348,18 @@ func (a *remoteAuthorizer) readResponse(ctx heimdall.Context, resp *http.Respons
 			CausedBy(err)
 	}
 
+	if a.graphql != nil {
+		result, err := a.graphql.Result(rawData)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunication, "graphql request to the authorization endpoint failed").
+				WithErrorContext(a).
+				CausedBy(err)
+		}
+
+		return result, nil
+	}
+
 	contentType := resp.Header.Get("Content-Type")
 
 	decoder, err := contenttype.NewDecoder(contentType)
@@ -341,18 +402,30 @@ func (a *remoteAuthorizer) calculateCacheKey(sub *subject.Subject) (string, erro
 	hash.Write(x.IfThenElseExec(a.payload != nil,
 		func() []byte { return []byte(a.payload.Hash()) },
 		func() []byte { return []byte("nil") }))
+	hash.Write(x.IfThenElseExec(a.graphql != nil,
+		func() []byte { return []byte(a.graphql.Hash()) },
+		func() []byte { return []byte("nil") }))
 	hash.Write(ttlBytes)
 	hash.Write(rawSub)
 
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-func (a *remoteAuthorizer) verify(ctx heimdall.Context, result any) error {
+func (a *remoteAuthorizer) verify(ctx heimdall.Context, sub *subject.Subject, result any) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	if len(a.expressions) != 0 {
+		logger.Debug().Msg("Verifying authorization response using expressions")
+
+		if err := verifyExpressions(ctx, a, a.expressions, sub, result); err != nil {
+			return err
+		}
+	}
+
 	if a.script == nil {
 		return nil
 	}
 
-	logger := zerolog.Ctx(ctx.AppContext())
 	logger.Debug().Msg("Verifying authorization response using script")
 
 	res, err := a.script.ExecuteOnPayload(ctx, result)
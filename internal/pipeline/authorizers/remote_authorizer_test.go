@@ -24,6 +24,7 @@ import (
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/script"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
@@ -85,6 +86,44 @@ endpoint:
 				assert.Contains(t, err.Error(), "either a payload or at least")
 			},
 		},
+		{
+			uc: "configuration with both payload and graphql",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+payload: foo
+graphql:
+  query: "{ me { roles } }"
+`),
+			assert: func(t *testing.T, err error, auth *remoteAuthorizer) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot be configured together")
+			},
+		},
+		{
+			uc: "configuration with endpoint and graphql",
+			id: "authz",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+graphql:
+  query: "query Perms($id: ID!) { permissions(user: $id) }"
+  variables:
+    id: "{{ .Subject.ID }}"
+  result_path: permissions
+`),
+			assert: func(t *testing.T, err error, auth *remoteAuthorizer) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, auth.payload)
+				require.NotNil(t, auth.graphql)
+				assert.Equal(t, "authz", auth.HandlerID())
+			},
+		},
 		{
 			uc: "configuration with endpoint and payload",
 			id: "authz",
@@ -117,6 +156,9 @@ endpoint:
   url: http://foo.bar
 payload: "{{ .Subject.ID }}"
 script: "throw 'foobar'"
+expressions:
+  - expression: "Payload.access_granted == true"
+    message: access not granted
 forward_response_headers_to_upstream:
   - Foo
   - Bar
@@ -138,6 +180,8 @@ cache_ttl: 5s
 				_, err = auth.script.ExecuteOnPayload(ctx, nil)
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), "foobar")
+				require.Len(t, auth.expressions, 1)
+				assert.Equal(t, "access not granted", auth.expressions[0].Message)
 				assert.Equal(t, "bar", val)
 				assert.Len(t, auth.headersForUpstream, 2)
 				assert.Contains(t, auth.headersForUpstream, "Foo")
@@ -268,6 +312,8 @@ forward_response_headers_to_upstream:
   - Bar
   - Foo
 script: "throw 'foobar'"
+expressions:
+  - expression: "Payload.access_granted == true"
 cache_ttl: 15s
 `),
 			assert: func(t *testing.T, err error, prototype *remoteAuthorizer, configured *remoteAuthorizer) {
@@ -290,6 +336,9 @@ cache_ttl: 15s
 				_, err = configured.script.ExecuteOnPayload(ctx, nil)
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), "foobar")
+				assert.Empty(t, prototype.expressions)
+				require.Len(t, configured.expressions, 1)
+				assert.Equal(t, "Payload.access_granted == true", configured.expressions[0].Value)
 				assert.Equal(t, "Baz", val)
 				assert.Len(t, configured.headersForUpstream, 2)
 				assert.Contains(t, configured.headersForUpstream, "Bar")
@@ -1078,6 +1127,213 @@ func TestRemoteAuthorizerExecute(t *testing.T) {
 				assert.Contains(t, authorizerAttrs["groups"], "Foo-Users")
 			},
 		},
+		{
+			uc: "with expressions, one of which returns false",
+			authorizer: &remoteAuthorizer{
+				id: "authz",
+				e: endpoint.Endpoint{
+					URL: srv.URL,
+					Headers: map[string]string{
+						"Content-Type": "application/json",
+						"Accept":       "application/json",
+					},
+				},
+				payload: func() template.Template {
+					tpl, _ := template.New(`{ "user_id": {{ quote .Subject.ID }} }`)
+
+					return tpl
+				}(),
+				expressions: compileExpressions(t,
+					map[string]any{"expression": "Payload.access_granted == true"},
+					map[string]any{
+						"expression": "'write_bar' in Payload.permissions",
+						"message":    "no write_bar permission",
+					},
+				),
+			},
+			subject: &subject.Subject{
+				ID:         "my-id",
+				Attributes: map[string]any{},
+			},
+			configureContext: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodGet)
+				ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"})
+				ctx.On("RequestClientIPs").Return([]string{"127.0.0.1"})
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusOK
+				rawData, err := json.Marshal(map[string]any{
+					"access_granted": true,
+					"permissions":    []string{"read_foo", "write_foo"},
+				})
+				require.NoError(t, err)
+				responseContent = rawData
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, authorizationEndpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrAuthorization)
+				assert.Contains(t, err.Error(), "no write_bar permission")
+				assert.Empty(t, sub.Attributes)
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz", identifier.HandlerID())
+			},
+		},
+		{
+			uc: "with expressions, which succeed",
+			authorizer: &remoteAuthorizer{
+				id: "authz",
+				e: endpoint.Endpoint{
+					URL: srv.URL,
+					Headers: map[string]string{
+						"Content-Type": "application/json",
+						"Accept":       "application/json",
+					},
+				},
+				payload: func() template.Template {
+					tpl, _ := template.New(`{ "user_id": {{ quote .Subject.ID }} }`)
+
+					return tpl
+				}(),
+				expressions: compileExpressions(t,
+					map[string]any{"expression": "Payload.access_granted == true"},
+					map[string]any{"expression": "'write_foo' in Payload.permissions && Subject.ID == 'my-id'"},
+				),
+			},
+			subject: &subject.Subject{
+				ID:         "my-id",
+				Attributes: map[string]any{},
+			},
+			configureContext: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodGet)
+				ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"})
+				ctx.On("RequestClientIPs").Return([]string{"127.0.0.1"})
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusOK
+				rawData, err := json.Marshal(map[string]any{
+					"access_granted": true,
+					"permissions":    []string{"read_foo", "write_foo"},
+				})
+				require.NoError(t, err)
+				responseContent = rawData
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, authorizationEndpointCalled)
+
+				require.NoError(t, err)
+				assert.Len(t, sub.Attributes, 1)
+				assert.NotEmpty(t, sub.Attributes["authz"])
+			},
+		},
+		{
+			uc: "with graphql query and expressions, which succeed",
+			authorizer: &remoteAuthorizer{
+				id: "authz",
+				e:  endpoint.Endpoint{URL: srv.URL},
+				graphql: func() *graphql.Query {
+					query, _ := graphql.NewQuery("query Perms($id: ID!) { permissions(user: $id) }", "",
+						map[string]any{"id": "{{ .Subject.ID }}"}, "permissions")
+
+					return query
+				}(),
+				expressions: compileExpressions(t,
+					map[string]any{"expression": "'write_foo' in Payload"},
+				),
+			},
+			subject: &subject.Subject{
+				ID:         "my-id",
+				Attributes: map[string]any{},
+			},
+			configureContext: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodGet)
+				ctx.On("RequestURL").Return(&url.URL{Scheme: "http", Host: "foo.bar", Path: "/baz"})
+				ctx.On("RequestClientIPs").Return([]string{"127.0.0.1"})
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				checkRequest = func(req *http.Request) {
+					t.Helper()
+
+					assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+					data, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+					assert.JSONEq(t, `{
+"query": "query Perms($id: ID!) { permissions(user: $id) }",
+"variables": {"id": "my-id"}
+}`, string(data))
+				}
+
+				responseCode = http.StatusOK
+				responseContent = []byte(`{"data": {"permissions": ["read_foo", "write_foo"]}}`)
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, authorizationEndpointCalled)
+
+				require.NoError(t, err)
+				assert.Equal(t, []any{"read_foo", "write_foo"}, sub.Attributes["authz"])
+			},
+		},
+		{
+			uc: "with graphql query resulting in errors",
+			authorizer: &remoteAuthorizer{
+				id: "authz",
+				e:  endpoint.Endpoint{URL: srv.URL},
+				graphql: func() *graphql.Query {
+					query, _ := graphql.NewQuery("{ permissions }", "", nil, "permissions")
+
+					return query
+				}(),
+			},
+			subject: &subject.Subject{
+				ID:         "my-id",
+				Attributes: map[string]any{},
+			},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusOK
+				responseContent = []byte(`{"errors": [{"message": "backend unavailable"}]}`)
+				responseContentType = "application/json"
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, authorizationEndpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "backend unavailable")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "authz", identifier.HandlerID())
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -0,0 +1,167 @@
+package cellib
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// costLimit restricts the amount of work a single expression may perform. Exceeding it
+// aborts the evaluation with an error.
+const costLimit = 100000
+
+var ErrExpressionEvaluation = errors.New("expression evaluation error")
+
+// nolint: gochecknoglobals
+var (
+	env    *cel.Env
+	envErr error
+)
+
+// by intention. The environment is immutable and can be shared by all expressions
+// nolint: gochecknoinits
+func init() {
+	requestType := cel.ObjectType("cellib.Request")
+
+	env, envErr = cel.NewEnv(
+		ext.NativeTypes(reflect.TypeOf(&Request{}), reflect.TypeOf(&URL{})),
+		ext.Strings(),
+		cel.Variable("Subject", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("Request", requestType),
+		cel.Variable("Payload", cel.DynType),
+		cel.Function("Header",
+			cel.MemberOverload("request_header_string",
+				[]*cel.Type{requestType, cel.StringType}, cel.StringType,
+				cel.BinaryBinding(requestAccessor((*Request).Header)))),
+		cel.Function("Cookie",
+			cel.MemberOverload("request_cookie_string",
+				[]*cel.Type{requestType, cel.StringType}, cel.StringType,
+				cel.BinaryBinding(requestAccessor((*Request).Cookie)))),
+	)
+}
+
+// Expression is a CEL expression, which must evaluate to a boolean value. If it evaluates
+// to false, Message describes the reason.
+type Expression struct {
+	Value   string
+	Message string
+
+	program cel.Program
+}
+
+type Expressions []*Expression
+
+// NewExpression compiles the given CEL expression and verifies it results in a boolean value.
+// If message is empty, a generic one referencing the expression is used.
+func NewExpression(value, message string) (*Expression, error) {
+	if envErr != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create CEL environment").
+			CausedBy(envErr)
+	}
+
+	ast, iss := env.Compile(value)
+	if iss.Err() != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to compile expression %s", value).
+			CausedBy(iss.Err())
+	}
+
+	if !ast.OutputType().IsAssignableType(cel.BoolType) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"expression %s does not result in a boolean value, but in %s", value, ast.OutputType())
+	}
+
+	program, err := env.Program(ast, cel.CostLimit(costLimit))
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to create program for expression %s", value).
+			CausedBy(err)
+	}
+
+	if len(message) == 0 {
+		message = fmt.Sprintf("expression %s failed", value)
+	}
+
+	return &Expression{Value: value, Message: message, program: program}, nil
+}
+
+// Eval evaluates the expression against the given request, subject and payload. The payload is
+// made available via the Payload variable and is null if not set.
+func (e *Expression) Eval(ctx heimdall.Context, sub *subject.Subject, payload any) (bool, error) {
+	return e.eval(ctx, newVariables(ctx, sub, payload))
+}
+
+func (e *Expression) eval(ctx heimdall.Context, vars map[string]any) (bool, error) {
+	res, _, err := e.program.ContextEval(ctx.AppContext(), vars)
+	if err != nil {
+		return false, errorchain.NewWithMessagef(ErrExpressionEvaluation,
+			"failed to evaluate expression %s", e.Value).
+			CausedBy(err)
+	}
+
+	result, ok := res.Value().(bool)
+	if !ok {
+		return false, errorchain.NewWithMessagef(ErrExpressionEvaluation,
+			"expression %s did not result in a boolean value", e.Value)
+	}
+
+	return result, nil
+}
+
+// Eval evaluates the expressions in the order they have been configured and returns the
+// first one, which evaluated to false. If all expressions evaluated to true, nil is returned.
+// The payload is made available via the Payload variable and is null if not set.
+func (e Expressions) Eval(ctx heimdall.Context, sub *subject.Subject, payload any) (*Expression, error) {
+	vars := newVariables(ctx, sub, payload)
+
+	for _, expr := range e {
+		result, err := expr.eval(ctx, vars)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result {
+			return expr, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func newVariables(ctx heimdall.Context, sub *subject.Subject, payload any) map[string]any {
+	vars := map[string]any{
+		"Subject": map[string]any{},
+		"Request": newRequest(ctx),
+		"Payload": payload,
+	}
+
+	if sub != nil {
+		vars["Subject"] = map[string]any{"ID": sub.ID, "Attributes": sub.Attributes}
+	}
+
+	return vars
+}
+
+func requestAccessor(accessor func(*Request, string) string) func(lhs, rhs ref.Val) ref.Val {
+	return func(lhs, rhs ref.Val) ref.Val {
+		req, ok := lhs.Value().(*Request)
+		if !ok {
+			return types.NewErr("unexpected receiver type %s", lhs.Type().TypeName())
+		}
+
+		name, ok := rhs.(types.String)
+		if !ok {
+			return types.MaybeNoSuchOverloadErr(rhs)
+		}
+
+		return types.String(accessor(req, string(name)))
+	}
+}
@@ -0,0 +1,269 @@
+package cellib
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestDecodeExpressions(t *testing.T) {
+	t.Parallel()
+
+	type Typ struct {
+		Expressions Expressions `mapstructure:"expressions"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, exprs Expressions)
+	}{
+		{
+			uc:     "without expressions",
+			config: []byte(`{}`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, exprs)
+			},
+		},
+		{
+			uc:     "with empty expression",
+			config: []byte(`expressions: [ { message: foo } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "empty expression")
+			},
+		},
+		{
+			uc:     "with unsupported properties",
+			config: []byte(`expressions: [ { expression: "true", foo: bar } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to decode")
+			},
+		},
+		{
+			uc:     "with malformed expression",
+			config: []byte(`expressions: [ { expression: "Subject.ID ==" } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to compile")
+			},
+		},
+		{
+			uc:     "with expression referencing unknown variable",
+			config: []byte(`expressions: [ { expression: "Foo == 'bar'" } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "undeclared reference")
+			},
+		},
+		{
+			uc:     "with expression using a request field in a wrong way",
+			config: []byte(`expressions: [ { expression: "Request.Method == 1" } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to compile")
+			},
+		},
+		{
+			uc:     "with expression not resulting in a boolean value",
+			config: []byte(`expressions: [ { expression: "Request.Method" } ]`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "does not result in a boolean")
+			},
+		},
+		{
+			uc: "with valid expressions",
+			config: []byte(`
+expressions:
+  - expression: "Subject.ID == 'foo'"
+    message: not foo
+  - expression: "Request.Method == 'GET'"
+`),
+			assert: func(t *testing.T, err error, exprs Expressions) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, exprs, 2)
+				assert.Equal(t, "Subject.ID == 'foo'", exprs[0].Value)
+				assert.Equal(t, "not foo", exprs[0].Message)
+				assert.NotNil(t, exprs[0].program)
+				assert.Equal(t, "Request.Method == 'GET'", exprs[1].Value)
+				assert.Equal(t, "expression Request.Method == 'GET' failed", exprs[1].Message)
+				assert.NotNil(t, exprs[1].program)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			var typ Typ
+
+			dec, err := mapstructure.NewDecoder(
+				&mapstructure.DecoderConfig{
+					DecodeHook:  DecodeExpressionsHookFunc(),
+					Result:      &typ,
+					ErrorUnused: true,
+				})
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			err = dec.Decode(conf)
+
+			// THEN
+			tc.assert(t, err, typ.Expressions)
+		})
+	}
+}
+
+func TestExpressionsEval(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc          string
+		expressions []string
+		payload     any
+		assert      func(t *testing.T, err error, failed *Expression)
+	}{
+		{
+			uc: "all expressions are true",
+			expressions: []string{
+				"Subject.ID == 'foo'",
+				"'admin' in Subject.Attributes.groups",
+				"Request.Method == 'PATCH'",
+				"Request.URL.Scheme == 'https' && Request.URL.Host == 'foo.bar' && Request.URL.Path.startsWith('/api')",
+				"Request.URL.Query.bar == ['baz']",
+				"Request.Header('X-My-Header') == 'my-value'",
+				"Request.Cookie('session') == 'session-value'",
+				"'192.168.1.1' in Request.ClientIP",
+			},
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, failed)
+			},
+		},
+		{
+			uc:          "expression on payload is true",
+			expressions: []string{"Payload.allowed == true", "Payload.allowed"},
+			payload:     map[string]any{"allowed": true},
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, failed)
+			},
+		},
+		{
+			uc:          "second expression is false",
+			expressions: []string{"Subject.ID == 'foo'", "Subject.ID == 'bar'", "Subject.Attributes.foo"},
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, failed)
+				assert.Equal(t, "Subject.ID == 'bar'", failed.Value)
+			},
+		},
+		{
+			uc:          "expression evaluation fails",
+			expressions: []string{"Subject.Attributes.foo == 'bar'"},
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrExpressionEvaluation)
+				assert.Contains(t, err.Error(), "no such key")
+				assert.Nil(t, failed)
+			},
+		},
+		{
+			uc:          "dynamic expression does not result in a boolean",
+			expressions: []string{"Payload"},
+			payload:     "foo",
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrExpressionEvaluation)
+				assert.Contains(t, err.Error(), "did not result in a boolean")
+				assert.Nil(t, failed)
+			},
+		},
+		{
+			uc:          "expression exceeds the cost limit",
+			expressions: []string{"Payload.all(x, Payload.all(y, Payload.all(z, x + y + z >= 0)))"},
+			payload: func() []any {
+				values := make([]any, 100)
+				for idx := range values {
+					values[idx] = idx
+				}
+
+				return values
+			}(),
+			assert: func(t *testing.T, err error, failed *Expression) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrExpressionEvaluation)
+				assert.Contains(t, err.Error(), "cost limit exceeded")
+				assert.Nil(t, failed)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			ctx.On("RequestMethod").Return("PATCH")
+			ctx.On("RequestURL").Return(&url.URL{Scheme: "https", Host: "foo.bar", Path: "/api/v1", RawQuery: "bar=baz"})
+			ctx.On("RequestClientIPs").Return([]string{"192.168.1.1"})
+			ctx.On("RequestHeader", "X-My-Header").Return("my-value")
+			ctx.On("RequestCookie", "session").Return("session-value")
+
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{"groups": []string{"admin", "dev"}}}
+
+			var exprs Expressions
+
+			for _, val := range tc.expressions {
+				expr, err := NewExpression(val, "")
+				require.NoError(t, err)
+
+				exprs = append(exprs, expr)
+			}
+
+			// WHEN
+			failed, err := exprs.Eval(ctx, sub, tc.payload)
+
+			// THEN
+			tc.assert(t, err, failed)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package cellib
+
+import (
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+func DecodeExpressionsHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		var expressions Expressions
+
+		if from.Kind() != reflect.Slice {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(expressions) {
+			return data, nil
+		}
+
+		type Config struct {
+			Expression string `mapstructure:"expression"`
+			Message    string `mapstructure:"message"`
+		}
+
+		var conf []Config
+
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{Result: &conf, ErrorUnused: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = dec.Decode(data); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to decode expressions").
+				CausedBy(err)
+		}
+
+		for _, cfg := range conf {
+			if len(cfg.Expression) == 0 {
+				return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "empty expression configured")
+			}
+
+			expr, err := NewExpression(cfg.Expression, cfg.Message)
+			if err != nil {
+				return nil, err
+			}
+
+			expressions = append(expressions, expr)
+		}
+
+		return expressions, nil
+	}
+}
@@ -0,0 +1,42 @@
+package cellib
+
+import (
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+// Request is the representation of the currently handled request, which is made available
+// to the expressions via the Request variable.
+type Request struct {
+	Method   string
+	URL      URL
+	ClientIP []string
+
+	ctx heimdall.Context
+}
+
+type URL struct {
+	Scheme string
+	Host   string
+	Path   string
+	Query  map[string][]string
+}
+
+func newRequest(ctx heimdall.Context) *Request {
+	reqURL := ctx.RequestURL()
+
+	return &Request{
+		Method: ctx.RequestMethod(),
+		URL: URL{
+			Scheme: reqURL.Scheme,
+			Host:   reqURL.Host,
+			Path:   reqURL.Path,
+			Query:  reqURL.Query(),
+		},
+		ClientIP: ctx.RequestClientIPs(),
+		ctx:      ctx,
+	}
+}
+
+func (r *Request) Header(name string) string { return r.ctx.RequestHeader(name) }
+
+func (r *Request) Cookie(name string) string { return r.ctx.RequestCookie(name) }
@@ -0,0 +1,43 @@
+package graphql
+
+import (
+	"reflect"
+
+	"github.com/mitchellh/mapstructure"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+func DecodeQueryHookFunc() mapstructure.DecodeHookFunc {
+	return func(from reflect.Type, to reflect.Type, data any) (any, error) {
+		if from.Kind() != reflect.Map {
+			return data, nil
+		}
+
+		if to != reflect.TypeOf(&Query{}) {
+			return data, nil
+		}
+
+		type Config struct {
+			Query         string         `mapstructure:"query"`
+			OperationName string         `mapstructure:"operation_name"`
+			Variables     map[string]any `mapstructure:"variables"`
+			ResultPath    string         `mapstructure:"result_path"`
+		}
+
+		var conf Config
+
+		dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{Result: &conf, ErrorUnused: true})
+		if err != nil {
+			return nil, err
+		}
+
+		if err = dec.Decode(data); err != nil {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to decode graphql query").
+				CausedBy(err)
+		}
+
+		return NewQuery(conf.Query, conf.OperationName, conf.Variables, conf.ResultPath)
+	}
+}
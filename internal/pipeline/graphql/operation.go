@@ -0,0 +1,356 @@
+package graphql
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+var ErrMalformedDocument = errors.New("malformed graphql document")
+
+const (
+	OperationTypeQuery        = "query"
+	OperationTypeMutation     = "mutation"
+	OperationTypeSubscription = "subscription"
+)
+
+// Operation describes the GraphQL operation of a request.
+type Operation struct {
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Variables map[string]any `json:"variables"`
+
+	// variableTypes holds the types of the variables defined by the operation, like [Int!]!
+	variableTypes map[string]string
+}
+
+// RequestContext provides access to the parts of a request required to parse a GraphQL request.
+type RequestContext interface {
+	RequestMethod() string
+	RequestHeader(key string) string
+	RequestQueryParameter(key string) string
+	RequestBody() []byte
+}
+
+// ParseRequest extracts the operation from a GraphQL request sent either via GET with query
+// parameters, or via POST with an application/json or application/graphql body. If the request
+// does not look like a GraphQL request, nil is returned.
+func ParseRequest(ctx RequestContext) (*Operation, error) {
+	var req struct {
+		Query         string         `json:"query"`
+		OperationName string         `json:"operationName"`
+		Variables     map[string]any `json:"variables"`
+	}
+
+	switch ctx.RequestMethod() {
+	case http.MethodGet:
+		req.Query = ctx.RequestQueryParameter("query")
+		req.OperationName = ctx.RequestQueryParameter("operationName")
+
+		if variables := ctx.RequestQueryParameter("variables"); len(req.Query) != 0 && len(variables) != 0 {
+			if err := json.Unmarshal([]byte(variables), &req.Variables); err != nil {
+				return nil, errorchain.NewWithMessage(ErrMalformedDocument, "failed to decode variables").
+					CausedBy(err)
+			}
+		}
+	case http.MethodPost:
+		contentType := strings.ToLower(ctx.RequestHeader("Content-Type"))
+
+		switch {
+		case strings.HasPrefix(contentType, "application/graphql"):
+			req.Query = string(ctx.RequestBody())
+			req.OperationName = ctx.RequestQueryParameter("operationName")
+		case strings.Contains(contentType, "json"):
+			body := ctx.RequestBody()
+			if len(body) == 0 {
+				return nil, nil
+			}
+
+			if err := json.Unmarshal(body, &req); err != nil {
+				return nil, errorchain.NewWithMessage(ErrMalformedDocument, "failed to decode request body").
+					CausedBy(err)
+			}
+		}
+	}
+
+	if len(req.Query) == 0 {
+		return nil, nil
+	}
+
+	op, err := FindOperation(req.Query, req.OperationName)
+	if err != nil {
+		return nil, err
+	}
+
+	op.Variables = req.Variables
+
+	return op, nil
+}
+
+// FindOperation determines the name and the type of the operation in the given document, which
+// would be executed for the given operation name. The name can be empty if the document
+// contains a single operation only.
+func FindOperation(document, operationName string) (*Operation, error) {
+	operations, err := parseOperations(document)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(operationName) != 0 {
+		for _, op := range operations {
+			if op.Name == operationName {
+				return op, nil
+			}
+		}
+
+		return nil, errorchain.NewWithMessagef(ErrMalformedDocument,
+			"operation %s not present in document", operationName)
+	}
+
+	switch len(operations) {
+	case 0:
+		return nil, errorchain.NewWithMessage(ErrMalformedDocument, "document contains no operation")
+	case 1:
+		return operations[0], nil
+	default:
+		return nil, errorchain.NewWithMessage(ErrMalformedDocument,
+			"document contains multiple operations, but no operation name is given")
+	}
+}
+
+// parseOperations scans the top level definitions of the document. Selection sets are not
+// parsed, only checked for balanced brackets.
+func parseOperations(document string) ([]*Operation, error) {
+	tokens, err := tokenize(document)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		operations     []*Operation
+		current        *Operation
+		inDefinition   bool
+		expectingName  bool
+		depth          int
+		isOperationDef bool
+	)
+
+	for idx, tok := range tokens {
+		if !inDefinition {
+			switch tok {
+			case "{":
+				current, isOperationDef = &Operation{Type: OperationTypeQuery}, true
+			case OperationTypeQuery, OperationTypeMutation, OperationTypeSubscription:
+				current, isOperationDef, expectingName = &Operation{Type: tok}, true, true
+			case "fragment":
+				current, isOperationDef = nil, false
+			default:
+				return nil, errorchain.NewWithMessagef(ErrMalformedDocument, "unexpected token '%s'", tok)
+			}
+
+			inDefinition = true
+
+			if tok != "{" {
+				continue
+			}
+		}
+
+		if expectingName {
+			expectingName = false
+
+			if isName(tok) {
+				current.Name = tok
+
+				continue
+			}
+		}
+
+		// variable definitions directly follow the operation type, or its name. Parentheses of
+		// directives are preceded by the name of the directive
+		if tok == "(" && depth == 0 && isOperationDef && current.variableTypes == nil &&
+			(idx < 2 || tokens[idx-2] != "@") {
+			current.variableTypes = parseVariableTypes(tokens[idx+1:])
+		}
+
+		switch tok {
+		case "{", "(", "[":
+			depth++
+		case ")", "]":
+			depth--
+		case "}":
+			depth--
+
+			if depth == 0 {
+				if isOperationDef {
+					operations = append(operations, current)
+				}
+
+				inDefinition = false
+			}
+		}
+
+		if depth < 0 {
+			return nil, errorchain.NewWithMessagef(ErrMalformedDocument, "unbalanced '%s'", tok)
+		}
+	}
+
+	if inDefinition {
+		return nil, errorchain.NewWithMessage(ErrMalformedDocument, "unexpected end of document")
+	}
+
+	return operations, nil
+}
+
+// parseVariableTypes extracts the types of the variable definitions of an operation from the tokens
+// following its opening parenthesis. Default values and directives are skipped.
+func parseVariableTypes(tokens []string) map[string]string {
+	types := make(map[string]string)
+	depth := 0
+
+	for idx := 0; idx < len(tokens); idx++ {
+		switch tok := tokens[idx]; {
+		case depth == 0 && tok == ")":
+			return types
+		case depth == 0 && tok == "$" && idx+2 < len(tokens) && tokens[idx+2] == ":":
+			typ, next := parseType(tokens, idx+3)
+			types[tokens[idx+1]] = typ
+			idx = next - 1
+		case tok == "(" || tok == "[" || tok == "{":
+			depth++
+		case tok == ")" || tok == "]" || tok == "}":
+			depth--
+		}
+	}
+
+	return types
+}
+
+// parseType reads a type reference starting at the given position and returns it together with
+// the position of the first token following it.
+func parseType(tokens []string, pos int) (string, int) {
+	var typ string
+
+	switch {
+	case pos >= len(tokens):
+		return "", pos
+	case tokens[pos] == "[":
+		var inner string
+
+		inner, pos = parseType(tokens, pos+1)
+		if pos < len(tokens) && tokens[pos] == "]" {
+			pos++
+		}
+
+		typ = "[" + inner + "]"
+	case isName(tokens[pos]):
+		typ = tokens[pos]
+		pos++
+	default:
+		return "", pos
+	}
+
+	if pos < len(tokens) && tokens[pos] == "!" {
+		typ += "!"
+		pos++
+	}
+
+	return typ, pos
+}
+
+// tokenize splits the document into names, numbers and punctuators. Strings and comments are
+// dropped, as these are irrelevant for the detection of operations.
+func tokenize(document string) ([]string, error) { // nolint: cyclop
+	var tokens []string
+
+	for pos := 0; pos < len(document); {
+		chr := document[pos]
+
+		switch {
+		case chr == ' ' || chr == '\t' || chr == '\n' || chr == '\r' || chr == ',':
+			pos++
+		case strings.HasPrefix(document[pos:], "\ufeff"):
+			pos += len("\ufeff")
+		case chr == '#':
+			for pos < len(document) && document[pos] != '\n' && document[pos] != '\r' {
+				pos++
+			}
+		case strings.HasPrefix(document[pos:], `"""`):
+			end := findBlockStringEnd(document, pos+3)
+			if end < 0 {
+				return nil, errorchain.NewWithMessage(ErrMalformedDocument, "unterminated block string")
+			}
+
+			pos = end
+		case chr == '"':
+			end := findStringEnd(document, pos+1)
+			if end < 0 {
+				return nil, errorchain.NewWithMessage(ErrMalformedDocument, "unterminated string")
+			}
+
+			pos = end
+		case strings.HasPrefix(document[pos:], "..."):
+			tokens = append(tokens, "...")
+			pos += 3
+		case strings.ContainsRune("{}()[]:=@!$|&", rune(chr)):
+			tokens = append(tokens, string(chr))
+			pos++
+		case isNameStart(chr) || isDigit(chr) || chr == '-':
+			start := pos
+			pos++
+
+			for pos < len(document) && (isNameStart(document[pos]) || isDigit(document[pos]) ||
+				document[pos] == '.' || document[pos] == '+' || document[pos] == '-') {
+				pos++
+			}
+
+			tokens = append(tokens, document[start:pos])
+		default:
+			return nil, errorchain.NewWithMessagef(ErrMalformedDocument, "unexpected character '%c'", chr)
+		}
+	}
+
+	return tokens, nil
+}
+
+func findStringEnd(document string, pos int) int {
+	for ; pos < len(document); pos++ {
+		switch document[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1
+		case '\n', '\r':
+			return -1
+		}
+	}
+
+	return -1
+}
+
+func findBlockStringEnd(document string, pos int) int {
+	for ; pos < len(document); pos++ {
+		if strings.HasPrefix(document[pos:], `\"""`) {
+			pos += 3
+
+			continue
+		}
+
+		if strings.HasPrefix(document[pos:], `"""`) {
+			return pos + 3
+		}
+	}
+
+	return -1
+}
+
+func isName(tok string) bool { return len(tok) != 0 && isNameStart(tok[0]) }
+
+func isNameStart(chr byte) bool {
+	return chr == '_' || (chr >= 'a' && chr <= 'z') || (chr >= 'A' && chr <= 'Z')
+}
+
+func isDigit(chr byte) bool { return chr >= '0' && chr <= '9' }
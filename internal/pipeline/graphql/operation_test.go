@@ -0,0 +1,295 @@
+package graphql
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+)
+
+func TestFindOperation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc            string
+		document      string
+		operationName string
+		assert        func(t *testing.T, op *Operation, err error)
+	}{
+		{
+			uc:       "anonymous query shorthand",
+			document: `{ user(id: "1") { name } }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "", op.Name)
+				assert.Equal(t, OperationTypeQuery, op.Type)
+			},
+		},
+		{
+			uc:       "named mutation with variables, directives, comments and strings",
+			document: "# comment with { brace\nmutation Update($id: ID! = \"}\", $in: In = {a: [1, 2]}) @log(msg: \"\"\"multi\n}\"\"\") {\n  update(id: $id) { ok }\n}",
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "Update", op.Name)
+				assert.Equal(t, OperationTypeMutation, op.Type)
+				assert.Equal(t, map[string]string{"id": "ID!", "in": "In"}, op.variableTypes)
+			},
+		},
+		{
+			uc:       "anonymous query with variables",
+			document: `query ($id: ID) { user(id: $id) { name } }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "", op.Name)
+				assert.Equal(t, map[string]string{"id": "ID"}, op.variableTypes)
+			},
+		},
+		{
+			uc:       "query with list, non-null and directive annotated variables",
+			document: `query Q($ids: [Int!]!, $flag: Boolean @deprecated(reason: "x"), $m: [[Float]]) { q { id } }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "Q", op.Name)
+				assert.Equal(t,
+					map[string]string{"ids": "[Int!]!", "flag": "Boolean", "m": "[[Float]]"},
+					op.variableTypes)
+			},
+		},
+		{
+			uc: "operation selected by name from a document with fragments",
+			document: `
+query A { ...F }
+subscription B { events { id } }
+fragment F on Query { me { id } }`,
+			operationName: "B",
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "B", op.Name)
+				assert.Equal(t, OperationTypeSubscription, op.Type)
+			},
+		},
+		{
+			uc:       "multiple operations without operation name",
+			document: `query A { a } query B { b }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "no operation name")
+			},
+		},
+		{
+			uc:            "unknown operation name",
+			document:      `query A { a }`,
+			operationName: "B",
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "B not present")
+			},
+		},
+		{
+			uc:       "only fragments",
+			document: `fragment F on Query { me { id } }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "no operation")
+			},
+		},
+		{
+			uc:       "unbalanced selection set",
+			document: `query A { a { b }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "unexpected end")
+			},
+		},
+		{
+			uc:       "unterminated string",
+			document: `query A { a(b: "foo) }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "unterminated string")
+			},
+		},
+		{
+			uc:       "unexpected top level token",
+			document: `type Query { a: String }`,
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+				assert.Contains(t, err.Error(), "unexpected token 'type'")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// WHEN
+			op, err := FindOperation(tc.document, tc.operationName)
+
+			// THEN
+			tc.assert(t, op, err)
+		})
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		configureMocks func(t *testing.T, ctx *mocks.MockContext)
+		assert         func(t *testing.T, op *Operation, err error)
+	}{
+		{
+			uc: "POST request with json body",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodPost)
+				ctx.On("RequestHeader", "Content-Type").Return("application/json; charset=utf-8")
+				ctx.On("RequestBody").Return([]byte(
+					`{"query":"query A { a } mutation B { b }","operationName":"B","variables":{"id":"1"}}`))
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Operation{
+					Name: "B", Type: OperationTypeMutation, Variables: map[string]any{"id": "1"},
+				}, op)
+			},
+		},
+		{
+			uc: "POST request with application/graphql body",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodPost)
+				ctx.On("RequestHeader", "Content-Type").Return("application/graphql")
+				ctx.On("RequestBody").Return([]byte(`query A { a }`))
+				ctx.On("RequestQueryParameter", "operationName").Return("")
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Operation{Name: "A", Type: OperationTypeQuery}, op)
+			},
+		},
+		{
+			uc: "GET request with query parameters",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodGet)
+				ctx.On("RequestQueryParameter", "query").Return(`{ me { id } }`)
+				ctx.On("RequestQueryParameter", "operationName").Return("")
+				ctx.On("RequestQueryParameter", "variables").Return(`{"foo":1}`)
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, &Operation{
+					Type: OperationTypeQuery, Variables: map[string]any{"foo": float64(1)},
+				}, op)
+			},
+		},
+		{
+			uc: "GET request without query",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodGet)
+				ctx.On("RequestQueryParameter", "query").Return("")
+				ctx.On("RequestQueryParameter", "operationName").Return("")
+				ctx.On("RequestQueryParameter", "variables").Return("")
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, op)
+			},
+		},
+		{
+			uc: "POST request with unsupported content type",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodPost)
+				ctx.On("RequestHeader", "Content-Type").Return("application/x-www-form-urlencoded")
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, op)
+			},
+		},
+		{
+			uc: "POST request with malformed json body",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodPost)
+				ctx.On("RequestHeader", "Content-Type").Return("application/json")
+				ctx.On("RequestBody").Return([]byte(`{"query":`))
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+			},
+		},
+		{
+			uc: "POST request with malformed document",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestMethod").Return(http.MethodPost)
+				ctx.On("RequestHeader", "Content-Type").Return("application/json")
+				ctx.On("RequestBody").Return([]byte(`{"query":"query { a "}`))
+			},
+			assert: func(t *testing.T, op *Operation, err error) {
+				t.Helper()
+
+				require.ErrorIs(t, err, ErrMalformedDocument)
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			tc.configureMocks(t, ctx)
+
+			// WHEN
+			op, err := ParseRequest(ctx)
+
+			// THEN
+			tc.assert(t, op, err)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,317 @@
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/tidwall/gjson"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// Query describes a GraphQL operation to be sent to an endpoint. String values of the variables
+// are templates, which are rendered for each request. Rendered top level variables are converted
+// into the type declared for them by the operation, like Int or [String!].
+type Query struct {
+	document      string
+	operationName string
+	variables     map[string]any
+	variableTypes map[string]string
+	resultPath    string
+	hash          string
+}
+
+func NewQuery(document, operationName string, variables map[string]any, resultPath string) (*Query, error) {
+	if len(strings.TrimSpace(document)) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "no graphql query configured")
+	}
+
+	operation, err := FindOperation(document, operationName)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "invalid graphql query").
+			CausedBy(err)
+	}
+
+	variables, _ = normalize(variables).(map[string]any)
+
+	compiled, err := compileVariables(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	rawVariables, err := json.Marshal(variables)
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to marshal graphql variables").
+			CausedBy(err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(document))
+	hash.Write([]byte(operationName))
+	hash.Write(rawVariables)
+	hash.Write([]byte(resultPath))
+
+	return &Query{
+		document:      document,
+		operationName: operationName,
+		variables:     compiled,
+		variableTypes: operation.variableTypes,
+		resultPath:    resultPath,
+		hash:          hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
+
+// Body renders the variables and returns the JSON encoded request body.
+func (q *Query) Body(ctx heimdall.Context, sub *subject.Subject) ([]byte, error) {
+	variables, err := q.renderVariables(ctx, sub)
+	if err != nil {
+		return nil, err
+	}
+
+	type request struct {
+		Query         string `json:"query"`
+		OperationName string `json:"operationName,omitempty"`
+		Variables     any    `json:"variables,omitempty"`
+	}
+
+	return json.Marshal(request{
+		Query:         q.document,
+		OperationName: q.operationName,
+		Variables:     variables,
+	})
+}
+
+// Result extracts the configured result path from the data of a GraphQL response. Errors
+// reported by the server are mapped to ErrCommunication. A missing result results in nil.
+func (q *Query) Result(rawResponse []byte) (any, error) {
+	var resp struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+			Path    []any  `json:"path"`
+		} `json:"errors"`
+	}
+
+	if err := json.Unmarshal(rawResponse, &resp); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrCommunication, "received malformed graphql response").
+			CausedBy(err)
+	}
+
+	if len(resp.Errors) != 0 {
+		messages := make([]string, len(resp.Errors))
+
+		for idx, gqlErr := range resp.Errors {
+			messages[idx] = gqlErr.Message
+			if len(gqlErr.Path) != 0 {
+				messages[idx] = fmt.Sprintf("%s (path: %v)", gqlErr.Message, gqlErr.Path)
+			}
+		}
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrCommunication,
+			"graphql request failed: %s", strings.Join(messages, "; "))
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, nil
+	}
+
+	result := gjson.ParseBytes(resp.Data)
+	if len(q.resultPath) != 0 {
+		result = result.Get(q.resultPath)
+	}
+
+	if !result.Exists() {
+		return nil, nil
+	}
+
+	return result.Value(), nil
+}
+
+func (q *Query) Hash() string { return q.hash }
+
+func (q *Query) renderVariables(ctx heimdall.Context, sub *subject.Subject) (any, error) {
+	if len(q.variables) == 0 {
+		return nil, nil
+	}
+
+	variables := make(map[string]any, len(q.variables))
+
+	for key, value := range q.variables {
+		rendered, err := renderValue(ctx, sub, value)
+		if err == nil {
+			if _, ok := value.(template.Template); ok {
+				rendered, err = coerceValue(rendered.(string), q.variableTypes[key]) // nolint: forcetypeassert
+			}
+		}
+
+		if err != nil {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"failed to render graphql variable %s", key).CausedBy(err)
+		}
+
+		variables[key] = rendered
+	}
+
+	return variables, nil
+}
+
+const noValue = "<no value>"
+
+// coerceValue converts a rendered value into the given GraphQL type. Lists are expected to be
+// rendered as JSON arrays, e.g. by making use of the toJson function. A single value is taken over as
+// is, as GraphQL servers coerce it into a list. Types other than Int, Float, Boolean and lists, like
+// String, ID, enums or custom scalars, are kept as strings. An empty or missing value results in null,
+// if the type is neither a string and nor non-null.
+func coerceValue(value, typ string) (any, error) {
+	nonNull := strings.HasSuffix(typ, "!")
+	typ = strings.TrimSuffix(typ, "!")
+
+	isList := strings.HasPrefix(typ, "[") && strings.HasSuffix(typ, "]")
+	if !isList && typ != "Int" && typ != "Float" && typ != "Boolean" {
+		return value, nil
+	}
+
+	// missing values are rendered as <no value> by the template engine
+	if (len(value) == 0 || value == noValue) && !nonNull {
+		return nil, nil
+	}
+
+	switch typ {
+	case "Int":
+		return strconv.ParseInt(value, 10, 64)
+	case "Float":
+		return strconv.ParseFloat(value, 64)
+	case "Boolean":
+		return strconv.ParseBool(value)
+	}
+
+	var list []any
+	if err := json.Unmarshal([]byte(value), &list); err == nil {
+		return list, nil
+	}
+
+	return coerceValue(value, typ[1:len(typ)-1])
+}
+
+func compileVariables(variables map[string]any) (map[string]any, error) {
+	if len(variables) == 0 {
+		return nil, nil
+	}
+
+	compiled, err := compileValue(variables)
+	if err != nil {
+		return nil, err
+	}
+
+	// nolint: forcetypeassert
+	// a map is always compiled into a map
+	return compiled.(map[string]any), nil
+}
+
+// normalize converts maps with non string keys, as e.g. produced by yaml decoders, into maps
+// with string keys.
+func normalize(value any) any {
+	switch val := value.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(val))
+		for key, entry := range val {
+			res[key] = normalize(entry)
+		}
+
+		return res
+	case map[any]any:
+		res := make(map[string]any, len(val))
+		for key, entry := range val {
+			res[fmt.Sprintf("%v", key)] = normalize(entry)
+		}
+
+		return res
+	case []any:
+		res := make([]any, len(val))
+		for idx, entry := range val {
+			res[idx] = normalize(entry)
+		}
+
+		return res
+	default:
+		return val
+	}
+}
+
+func compileValue(value any) (any, error) {
+	switch val := value.(type) {
+	case string:
+		return template.New(val)
+	case map[string]any:
+		res := make(map[string]any, len(val))
+
+		for key, entry := range val {
+			compiled, err := compileValue(entry)
+			if err != nil {
+				return nil, err
+			}
+
+			res[key] = compiled
+		}
+
+		return res, nil
+	case []any:
+		res := make([]any, len(val))
+
+		for idx, entry := range val {
+			compiled, err := compileValue(entry)
+			if err != nil {
+				return nil, err
+			}
+
+			res[idx] = compiled
+		}
+
+		return res, nil
+	default:
+		return val, nil
+	}
+}
+
+func renderValue(ctx heimdall.Context, sub *subject.Subject, value any) (any, error) {
+	switch val := value.(type) {
+	case template.Template:
+		return val.Render(ctx, sub)
+	case map[string]any:
+		res := make(map[string]any, len(val))
+
+		for key, entry := range val {
+			rendered, err := renderValue(ctx, sub, entry)
+			if err != nil {
+				return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+					"failed to render graphql variable %s", key).CausedBy(err)
+			}
+
+			res[key] = rendered
+		}
+
+		return res, nil
+	case []any:
+		res := make([]any, len(val))
+
+		for idx, entry := range val {
+			rendered, err := renderValue(ctx, sub, entry)
+			if err != nil {
+				return nil, err
+			}
+
+			res[idx] = rendered
+		}
+
+		return res, nil
+	default:
+		return val, nil
+	}
+}
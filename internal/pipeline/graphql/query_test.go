@@ -0,0 +1,301 @@
+package graphql
+
+import (
+	"testing"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tidwall/gjson"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestDecodeQuery(t *testing.T) {
+	t.Parallel()
+
+	type Typ struct {
+		Query *Query `mapstructure:"graphql"`
+	}
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, query *Query)
+	}{
+		{
+			uc: "valid configuration",
+			config: []byte(`
+graphql:
+  query: "query User($id: ID!) { user(id: $id) { roles } }"
+  operation_name: User
+  variables:
+    id: "{{ .Subject.ID }}"
+    filter:
+      active: true
+  result_path: user.roles
+`),
+			assert: func(t *testing.T, err error, query *Query) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, query)
+				assert.Equal(t, "User", query.operationName)
+				assert.Equal(t, "user.roles", query.resultPath)
+				assert.Len(t, query.variables, 2)
+				assert.NotEmpty(t, query.Hash())
+			},
+		},
+		{
+			uc:     "without query",
+			config: []byte(`graphql: { result_path: foo }`),
+			assert: func(t *testing.T, err error, query *Query) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "no graphql query")
+			},
+		},
+		{
+			uc:     "with malformed query",
+			config: []byte(`graphql: { query: "query { foo" }`),
+			assert: func(t *testing.T, err error, query *Query) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "invalid graphql query")
+			},
+		},
+		{
+			uc:     "with malformed variable template",
+			config: []byte(`graphql: { query: "{ foo }", variables: { id: "{{ .Subject.ID" } }`),
+			assert: func(t *testing.T, err error, query *Query) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to parse template")
+			},
+		},
+		{
+			uc:     "with unsupported property",
+			config: []byte(`graphql: { query: "{ foo }", foo: bar }`),
+			assert: func(t *testing.T, err error, query *Query) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "failed to decode graphql query")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			var typ Typ
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+				DecodeHook: DecodeQueryHookFunc(),
+				Result:     &typ,
+			})
+			require.NoError(t, err)
+
+			// WHEN
+			err = dec.Decode(conf)
+
+			// THEN
+			tc.assert(t, err, typ.Query)
+		})
+	}
+}
+
+func TestQueryBody(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := &mocks.MockContext{}
+	ctx.On("RequestHeader", "X-Tenant").Return("acme")
+
+	sub := &subject.Subject{ID: "foo"}
+
+	query, err := NewQuery("query User($id: ID!) { user(id: $id) { roles } }", "User",
+		map[string]any{
+			"id":     "{{ .Subject.ID }}",
+			"filter": map[any]any{"tenant": `{{ .RequestHeader "X-Tenant" }}`, "active": true},
+			"limit":  10,
+		}, "")
+	require.NoError(t, err)
+
+	// WHEN
+	body, err := query.Body(ctx, sub)
+
+	// THEN
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+"query": "query User($id: ID!) { user(id: $id) { roles } }",
+"operationName": "User",
+"variables": {"id": "foo", "filter": {"tenant": "acme", "active": true}, "limit": 10}
+}`, string(body))
+}
+
+func TestQueryBodyWithTypedVariables(t *testing.T) {
+	t.Parallel()
+
+	document := `query User($id: ID!, $age: Int, $score: Float!, $active: Boolean, $groups: [String!], ` +
+		`$ids: [Int], $name: String) { user(id: $id) { roles } }`
+
+	for _, tc := range []struct {
+		uc         string
+		attributes map[string]any
+		assert     func(t *testing.T, err error, body []byte)
+	}{
+		{
+			uc: "all values present",
+			attributes: map[string]any{
+				"age": 42, "score": 1.5, "active": true, "groups": []any{"a", "b"}, "ids": []any{1, 2}, "name": "42",
+			},
+			assert: func(t *testing.T, err error, body []byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.JSONEq(t, `{
+"id": "42", "age": 42, "score": 1.5, "active": true, "groups": ["a", "b"], "ids": [1, 2], "name": "42"
+}`, gjson.GetBytes(body, "variables").Raw)
+			},
+		},
+		{
+			uc:         "single value for list types and missing nullable values",
+			attributes: map[string]any{"score": 2, "ids": 3, "name": "foo"},
+			assert: func(t *testing.T, err error, body []byte) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.JSONEq(t, `{
+"id": "42", "age": null, "score": 2, "active": null, "groups": null, "ids": 3, "name": "foo"
+}`, gjson.GetBytes(body, "variables").Raw)
+			},
+		},
+		{
+			uc:         "value not matching the declared type",
+			attributes: map[string]any{"score": 2, "age": "old"},
+			assert: func(t *testing.T, err error, body []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "graphql variable age")
+			},
+		},
+		{
+			uc:         "missing non-null value",
+			attributes: map[string]any{},
+			assert: func(t *testing.T, err error, body []byte) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "graphql variable score")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			query, err := NewQuery(document, "", map[string]any{
+				"id":     "{{ .Subject.ID }}",
+				"age":    "{{ .Subject.Attributes.age }}",
+				"score":  "{{ .Subject.Attributes.score }}",
+				"active": "{{ .Subject.Attributes.active }}",
+				"groups": "{{ if .Subject.Attributes.groups }}{{ .Subject.Attributes.groups | toJson }}{{ end }}",
+				"ids":    "{{ .Subject.Attributes.ids | toJson }}",
+				"name":   "{{ .Subject.Attributes.name }}",
+			}, "")
+			require.NoError(t, err)
+
+			sub := &subject.Subject{ID: "42", Attributes: tc.attributes}
+
+			// WHEN
+			body, err := query.Body(&mocks.MockContext{}, sub)
+
+			// THEN
+			tc.assert(t, err, body)
+		})
+	}
+}
+
+func TestQueryResult(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc         string
+		resultPath string
+		response   string
+		assert     func(t *testing.T, err error, result any)
+	}{
+		{
+			uc:       "without result path",
+			response: `{"data": {"user": {"roles": ["admin"]}}}`,
+			assert: func(t *testing.T, err error, result any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"user": map[string]any{"roles": []any{"admin"}}}, result)
+			},
+		},
+		{
+			uc:         "with result path",
+			resultPath: "user.roles",
+			response:   `{"data": {"user": {"roles": ["admin", "dev"]}}}`,
+			assert: func(t *testing.T, err error, result any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, []any{"admin", "dev"}, result)
+			},
+		},
+		{
+			uc:         "with result path not present in response",
+			resultPath: "user.roles",
+			response:   `{"data": {"user": null}}`,
+			assert: func(t *testing.T, err error, result any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, result)
+			},
+		},
+		{
+			uc:       "with errors in response",
+			response: `{"data": null, "errors": [{"message": "not allowed", "path": ["user"]}, {"message": "boom"}]}`,
+			assert: func(t *testing.T, err error, result any) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "not allowed (path: [user]); boom")
+			},
+		},
+		{
+			uc:       "with malformed response",
+			response: `foo`,
+			assert: func(t *testing.T, err error, result any) {
+				t.Helper()
+
+				require.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "malformed graphql response")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			query, err := NewQuery("{ user { roles } }", "", nil, tc.resultPath)
+			require.NoError(t, err)
+
+			// WHEN
+			result, err := query.Result([]byte(tc.response))
+
+			// THEN
+			tc.assert(t, err, result)
+		})
+	}
+}
@@ -47,6 +47,11 @@ type handlerFactory struct {
 	r *handlerPrototypeRepository
 }
 
+// Close releases the resources held by the pipeline objects.
+func (hf *handlerFactory) Close() error {
+	return hf.r.close()
+}
+
 func (hf *handlerFactory) CreateAuthenticator(id string, conf map[string]any) (authenticators.Authenticator, error) {
 	prototype, err := hf.r.Authenticator(id)
 	if err != nil {
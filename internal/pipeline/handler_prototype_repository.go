@@ -2,6 +2,7 @@ package pipeline
 
 import (
 	"errors"
+	"io"
 
 	"github.com/rs/zerolog"
 
@@ -40,6 +41,12 @@ func newHandlerPrototypeRepository(
 		return nil, err
 	}
 
+	if err = authorizers.ResolveAuthorizerReferences(authorizerMap); err != nil {
+		logger.Error().Err(err).Msg("Failed resolving authorizer references")
+
+		return nil, err
+	}
+
 	logger.Debug().Msg("Loading definitions for hydrators")
 
 	hydratorMap, err := createPipelineObjects(conf.Pipeline.Hydrators, logger,
@@ -99,6 +106,29 @@ func createPipelineObjects[T any](
 	return objects, nil
 }
 
+// close releases the resources held by the prototypes, like file watchers.
+func (r *handlerPrototypeRepository) close() error {
+	var err error
+
+	closeHandler := func(prototype any) {
+		if closer, ok := prototype.(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+	}
+
+	for _, prototype := range r.authorizers {
+		closeHandler(prototype)
+	}
+
+	for _, prototype := range r.hydrators {
+		closeHandler(prototype)
+	}
+
+	return err
+}
+
 type handlerPrototypeRepository struct {
 	authenticators map[string]authenticators.Authenticator
 	authorizers    map[string]authorizers.Authorizer
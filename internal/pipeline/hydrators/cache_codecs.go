@@ -0,0 +1,46 @@
+package hydrators
+
+import (
+	"github.com/goccy/go-json"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*hydrationData]("hydrators.hydration_data", hydrationDataCodec{})
+	codec.Register[*ldapEntryData]("hydrators.ldap_entry_data", ldapEntryDataCodec{})
+}
+
+type hydrationDataCodec struct{}
+
+func (hydrationDataCodec) Encode(value *hydrationData) ([]byte, error) {
+	return json.Marshal(value.payload)
+}
+
+func (hydrationDataCodec) Decode(data []byte) (*hydrationData, error) {
+	var payload any
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, err
+	}
+
+	return &hydrationData{payload: payload}, nil
+}
+
+type ldapEntryDataCodec struct{}
+
+func (ldapEntryDataCodec) Encode(value *ldapEntryData) ([]byte, error) {
+	return json.Marshal(value.attributes)
+}
+
+func (ldapEntryDataCodec) Decode(data []byte) (*ldapEntryData, error) {
+	var attributes map[string]any
+
+	if err := json.Unmarshal(data, &attributes); err != nil {
+		return nil, err
+	}
+
+	return &ldapEntryData{attributes: attributes}, nil
+}
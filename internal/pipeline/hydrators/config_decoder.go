@@ -4,7 +4,9 @@ import (
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/truststore"
 )
 
 func decodeConfig(input any, output any) error {
@@ -13,6 +15,9 @@ func decodeConfig(input any, output any) error {
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				mapstructure.StringToTimeDurationHookFunc(),
 				endpoint.DecodeAuthenticationStrategyHookFunc(),
+				graphql.DecodeQueryHookFunc(),
+				truststore.DecodeTrustStoreHookFunc(),
+				// must be the last one, as it results in nil for empty templates
 				template.DecodeTemplateHookFunc(),
 			),
 			Result:      output,
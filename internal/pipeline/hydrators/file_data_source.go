@@ -0,0 +1,252 @@
+package hydrators
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/goccy/go-json"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	dataFormatJSON = "json"
+	dataFormatYAML = "yaml"
+	dataFormatCSV  = "csv"
+)
+
+// fileDataSource holds the entries loaded from a file, keyed by the lookup key. If watched, the file
+// is reloaded on changes. A failed reload keeps the previously loaded entries active.
+type fileDataSource struct {
+	path      string
+	format    string
+	keyColumn string
+
+	mut       sync.RWMutex
+	entries   map[string]any
+	reloadErr error
+
+	w *fsnotify.Watcher
+}
+
+func newFileDataSource(path, format, keyColumn string, watch bool) (*fileDataSource, error) {
+	if len(format) == 0 {
+		format = formatFromExtension(path)
+	}
+
+	switch format {
+	case dataFormatJSON, dataFormatYAML, dataFormatCSV:
+	default:
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported data format '%s'. Use json, yaml or csv", format)
+	}
+
+	if len(keyColumn) != 0 && format != dataFormatCSV {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"key_column is supported for csv files only")
+	}
+
+	source := &fileDataSource{path: path, format: format, keyColumn: keyColumn}
+
+	entries, err := source.load()
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to load %s", path).
+			CausedBy(err)
+	}
+
+	source.entries = entries
+
+	if !watch {
+		return source, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "failed to instantiate file watcher").
+			CausedBy(err)
+	}
+
+	// the directory is watched, as editors and config map updates replace the file instead of writing to it
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration, "failed to watch %s", path).
+			CausedBy(err)
+	}
+
+	source.w = watcher
+
+	go source.watchFile()
+
+	return source, nil
+}
+
+func formatFromExtension(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return dataFormatJSON
+	case ".yaml", ".yml":
+		return dataFormatYAML
+	case ".csv":
+		return dataFormatCSV
+	default:
+		return ""
+	}
+}
+
+func (s *fileDataSource) lookup(key string) (any, bool) {
+	s.mut.RLock()
+	defer s.mut.RUnlock()
+
+	entry, found := s.entries[key]
+
+	return entry, found
+}
+
+// lastReloadError returns the error of the last failed reload attempt once. Reloading happens
+// in the background, so the error can only be reported while handling a request.
+func (s *fileDataSource) lastReloadError() error {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	err := s.reloadErr
+	s.reloadErr = nil
+
+	return err
+}
+
+func (s *fileDataSource) reload() {
+	entries, err := s.load()
+
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if err != nil {
+		s.reloadErr = err
+
+		return
+	}
+
+	s.entries = entries
+	s.reloadErr = nil
+}
+
+func (s *fileDataSource) watchFile() {
+	name := filepath.Clean(s.path)
+
+	for {
+		select {
+		case evt, ok := <-s.w.Events:
+			if !ok {
+				return
+			}
+
+			if evt.Op&(fsnotify.Create|fsnotify.Write|fsnotify.Rename) != 0 && isDataFileChange(evt, name) {
+				s.reload()
+			}
+		case _, ok := <-s.w.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// isDataFileChange reports whether the event affects the data file. Kubernetes updates config maps and secrets
+// mounted as volumes by atomically replacing the ..data symlink in the directory of the file, so the file itself
+// does not emit any events.
+func isDataFileChange(evt fsnotify.Event, name string) bool {
+	changed := filepath.Clean(evt.Name)
+
+	return changed == name || (filepath.Base(changed) == "..data" && filepath.Dir(changed) == filepath.Dir(name))
+}
+
+// close stops watching the file. The watcher channels are closed by that, which ends watchFile.
+func (s *fileDataSource) close() error {
+	if s.w == nil {
+		return nil
+	}
+
+	return s.w.Close()
+}
+
+func (s *fileDataSource) load() (map[string]any, error) {
+	raw, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make(map[string]any)
+
+	switch s.format {
+	case dataFormatJSON:
+		err = json.Unmarshal(raw, &entries)
+	case dataFormatYAML:
+		err = yaml.Unmarshal(raw, &entries)
+	default:
+		entries, err = s.loadCSV(raw)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func (s *fileDataSource) loadCSV(raw []byte) (map[string]any, error) {
+	records, err := csv.NewReader(bytes.NewReader(raw)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "csv file has no header")
+	}
+
+	header := records[0]
+	keyIdx := 0
+
+	if len(s.keyColumn) != 0 {
+		keyIdx = -1
+
+		for idx, column := range header {
+			if column == s.keyColumn {
+				keyIdx = idx
+
+				break
+			}
+		}
+
+		if keyIdx == -1 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"csv file has no %s column", s.keyColumn)
+		}
+	}
+
+	entries := make(map[string]any, len(records)-1)
+
+	for line, record := range records[1:] {
+		key := record[keyIdx]
+		if _, present := entries[key]; present {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"duplicate key %s in line %d", key, line+2)
+		}
+
+		entry := make(map[string]any, len(header))
+		for idx, column := range header {
+			entry[column] = record[idx]
+		}
+
+		entries[key] = entry
+	}
+
+	return entries, nil
+}
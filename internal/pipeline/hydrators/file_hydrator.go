@@ -0,0 +1,185 @@
+package hydrators
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	missingKeyIgnore = "ignore"
+	missingKeyError  = "error"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerHydratorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Hydrator, error) {
+			if typ != config.POTFile {
+				return false, nil, nil
+			}
+
+			hydrator, err := newFileHydrator(id, conf)
+
+			return true, hydrator, err
+		})
+}
+
+type fileHydrator struct {
+	id           string
+	source       *fileDataSource
+	key          template.Template
+	defaultValue any
+	onMissingKey string
+}
+
+func newFileHydrator(id string, rawConfig map[string]any) (*fileHydrator, error) {
+	type Config struct {
+		Path         string            `mapstructure:"path"`
+		Format       string            `mapstructure:"format"`
+		KeyColumn    string            `mapstructure:"key_column"`
+		Watch        bool              `mapstructure:"watch"`
+		Key          template.Template `mapstructure:"key"`
+		Default      any               `mapstructure:"default"`
+		OnMissingKey string            `mapstructure:"on_missing_key"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal file hydrator config").
+			CausedBy(err)
+	}
+
+	if len(conf.Path) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "no path configured for file hydrator")
+	}
+
+	if conf.Key == nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "no key template configured for file hydrator")
+	}
+
+	onMissingKey, err := validateMissingKeyBehavior(conf.OnMissingKey, missingKeyIgnore)
+	if err != nil {
+		return nil, err
+	}
+
+	source, err := newFileDataSource(conf.Path, conf.Format, conf.KeyColumn, conf.Watch)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileHydrator{
+		id:           id,
+		source:       source,
+		key:          conf.Key,
+		defaultValue: conf.Default,
+		onMissingKey: onMissingKey,
+	}, nil
+}
+
+func validateMissingKeyBehavior(value, fallback string) (string, error) {
+	value = x.IfThenElse(len(value) != 0, value, fallback)
+
+	if value != missingKeyIgnore && value != missingKeyError {
+		return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported on_missing_key value '%s'. Use ignore or error", value)
+	}
+
+	return value, nil
+}
+
+func (h *fileHydrator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Hydrating using file hydrator")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute file hydrator due to 'nil' subject").
+			WithErrorContext(h)
+	}
+
+	if err := h.source.lastReloadError(); err != nil {
+		logger.Warn().Err(err).Msg("Failed to reload hydration data. Using previously loaded data")
+	}
+
+	key, err := h.key.Render(ctx, sub)
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to render lookup key").
+			WithErrorContext(h).
+			CausedBy(err)
+	}
+
+	entry, found := h.source.lookup(key)
+	if !found {
+		entry = h.defaultValue
+	}
+
+	if entry == nil {
+		if h.onMissingKey == missingKeyError {
+			return errorchain.
+				NewWithMessagef(heimdall.ErrInternal, "no entry found for key '%s'", key).
+				WithErrorContext(h)
+		}
+
+		logger.Debug().Msgf("No entry found for key '%s'", key)
+
+		return nil
+	}
+
+	// the entry is shared by all requests and must not be modified by subsequent pipeline handlers
+	sub.Attributes[h.id] = subject.DeepCopy(entry)
+
+	return nil
+}
+
+func (h *fileHydrator) WithConfig(rawConfig map[string]any) (Hydrator, error) {
+	if len(rawConfig) == 0 {
+		return h, nil
+	}
+
+	type Config struct {
+		Key          template.Template `mapstructure:"key"`
+		Default      any               `mapstructure:"default"`
+		OnMissingKey string            `mapstructure:"on_missing_key"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal file hydrator config").
+			CausedBy(err)
+	}
+
+	onMissingKey, err := validateMissingKeyBehavior(conf.OnMissingKey, h.onMissingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileHydrator{
+		id:           h.id,
+		source:       h.source,
+		key:          x.IfThenElse(conf.Key != nil, conf.Key, h.key),
+		defaultValue: x.IfThenElse(conf.Default != nil, conf.Default, h.defaultValue),
+		onMissingKey: onMissingKey,
+	}, nil
+}
+
+func (h *fileHydrator) HandlerID() string {
+	return h.id
+}
+
+// Close stops watching the data file. The data source is shared with the hydrators created from the prototype
+// by WithConfig, so only the prototype is closed on shutdown.
+func (h *fileHydrator) Close() error {
+	return h.source.close()
+}
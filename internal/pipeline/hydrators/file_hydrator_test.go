@@ -0,0 +1,624 @@
+package hydrators
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func writeDataFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+// replaceDataFile atomically replaces the file, like editors do. Writing to the file directly
+// could result in the watcher loading the truncated file.
+func replaceDataFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	tmp := writeDataFile(t, filepath.Dir(path), filepath.Base(path)+".tmp", content)
+	require.NoError(t, os.Rename(tmp, path))
+}
+
+func TestCreateFileHydrator(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	jsonFile := writeDataFile(t, dir, "data.json", `{"alice": {"tenant": "acme"}}`)
+	yamlFile := writeDataFile(t, dir, "data.yml", "alice:\n  tenant: acme\n")
+	csvFile := writeDataFile(t, dir, "data.csv", "tenant,user\nacme,alice\nfoo,bob\n")
+	dataFile := writeDataFile(t, dir, "data", `{"alice": {"tenant": "acme"}}`)
+	duplicatesFile := writeDataFile(t, dir, "duplicates.csv", "user,tenant\nalice,acme\nalice,foo\n")
+	malformedFile := writeDataFile(t, dir, "malformed.yaml", "alice: [")
+
+	for _, tc := range []struct {
+		uc     string
+		config string
+		assert func(t *testing.T, err error, hydrator *fileHydrator)
+	}{
+		{
+			uc: "with unsupported fields",
+			config: `
+path: ` + jsonFile + `
+key: "{{ .Subject.ID }}"
+foo: bar
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc:     "without path",
+			config: `key: "{{ .Subject.ID }}"`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no path configured")
+			},
+		},
+		{
+			uc:     "without key",
+			config: `path: ` + jsonFile,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no key template configured")
+			},
+		},
+		{
+			uc: "with unsupported on_missing_key value",
+			config: `
+path: ` + jsonFile + `
+key: "{{ .Subject.ID }}"
+on_missing_key: foo
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported on_missing_key")
+			},
+		},
+		{
+			uc: "with format not derivable from file extension",
+			config: `
+path: ` + dataFile + `
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported data format")
+			},
+		},
+		{
+			uc: "with key_column for a non csv file",
+			config: `
+path: ` + jsonFile + `
+key_column: user
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "csv files only")
+			},
+		},
+		{
+			uc: "with not existing file",
+			config: `
+path: ` + filepath.Join(dir, "missing.json") + `
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load")
+			},
+		},
+		{
+			uc: "with malformed file",
+			config: `
+path: ` + malformedFile + `
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to load")
+			},
+		},
+		{
+			uc: "with csv file containing duplicate keys",
+			config: `
+path: ` + duplicatesFile + `
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "duplicate key alice in line 3")
+			},
+		},
+		{
+			uc: "with csv file not containing the key column",
+			config: `
+path: ` + csvFile + `
+key_column: name
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no name column")
+			},
+		},
+		{
+			uc: "with json file",
+			config: `
+path: ` + jsonFile + `
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "file", hydrator.HandlerID())
+				assert.Equal(t, dataFormatJSON, hydrator.source.format)
+				assert.Equal(t, missingKeyIgnore, hydrator.onMissingKey)
+				assert.Nil(t, hydrator.defaultValue)
+				assert.Nil(t, hydrator.source.w)
+
+				entry, found := hydrator.source.lookup("alice")
+				assert.True(t, found)
+				assert.Equal(t, map[string]any{"tenant": "acme"}, entry)
+			},
+		},
+		{
+			uc: "with yaml file and all possible options",
+			config: `
+path: ` + yamlFile + `
+key: "{{ .Subject.ID }}"
+default:
+  tenant: default
+on_missing_key: error
+watch: true
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, dataFormatYAML, hydrator.source.format)
+				assert.Equal(t, missingKeyError, hydrator.onMissingKey)
+				assert.Equal(t, map[string]any{"tenant": "default"}, hydrator.defaultValue)
+				assert.NotNil(t, hydrator.source.w)
+
+				entry, found := hydrator.source.lookup("alice")
+				assert.True(t, found)
+				assert.Equal(t, map[string]any{"tenant": "acme"}, entry)
+			},
+		},
+		{
+			uc: "with csv file using a key column",
+			config: `
+path: ` + csvFile + `
+key_column: user
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, dataFormatCSV, hydrator.source.format)
+
+				entry, found := hydrator.source.lookup("bob")
+				assert.True(t, found)
+				assert.Equal(t, map[string]any{"tenant": "foo", "user": "bob"}, entry)
+			},
+		},
+		{
+			uc: "with explicit format",
+			config: `
+path: ` + dataFile + `
+format: json
+key: "{{ .Subject.ID }}"
+`,
+			assert: func(t *testing.T, err error, hydrator *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, dataFormatJSON, hydrator.source.format)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig([]byte(tc.config))
+			require.NoError(t, err)
+
+			// WHEN
+			hydrator, err := newFileHydrator("file", conf)
+
+			// THEN
+			tc.assert(t, err, hydrator)
+		})
+	}
+}
+
+func TestCreateFileHydratorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	path := writeDataFile(t, t.TempDir(), "data.json", `{"alice": {"tenant": "acme"}}`)
+
+	prototype, err := newFileHydrator("file", map[string]any{"path": path, "key": "{{ .Subject.ID }}"})
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		config string
+		assert func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator)
+	}{
+		{
+			uc: "with empty config",
+			assert: func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with not overridable property",
+			config: `path: /foo/bar.json`,
+			assert: func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc:     "with unsupported on_missing_key value",
+			config: `on_missing_key: foo`,
+			assert: func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported on_missing_key")
+			},
+		},
+		{
+			uc: "with all overridable properties",
+			config: `
+key: "{{ .Subject.Attributes.email }}"
+default: foo
+on_missing_key: error
+`,
+			assert: func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.source, configured.source)
+				assert.NotEqual(t, prototype.key, configured.key)
+				assert.Equal(t, "foo", configured.defaultValue)
+				assert.Equal(t, missingKeyError, configured.onMissingKey)
+			},
+		},
+		{
+			uc:     "with default only",
+			config: `default: foo`,
+			assert: func(t *testing.T, err error, prototype *fileHydrator, configured *fileHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype.key, configured.key)
+				assert.Equal(t, "foo", configured.defaultValue)
+				assert.Equal(t, prototype.onMissingKey, configured.onMissingKey)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig([]byte(tc.config))
+			require.NoError(t, err)
+
+			// WHEN
+			hydrator, err := prototype.WithConfig(conf)
+
+			// THEN
+			var (
+				configured *fileHydrator
+				ok         bool
+			)
+
+			if err == nil {
+				configured, ok = hydrator.(*fileHydrator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestFileHydratorExecute(t *testing.T) {
+	t.Parallel()
+
+	path := writeDataFile(t, t.TempDir(), "data.json", `{"alice": {"tenant": "acme"}}`)
+
+	for _, tc := range []struct {
+		uc      string
+		config  map[string]any
+		subject *subject.Subject
+		assert  func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:     "with nil subject",
+			config: map[string]any{},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+			},
+		},
+		{
+			uc:      "with failing key rendering",
+			config:  map[string]any{"key": "{{ .Subject.ID.foo }}"},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to render lookup key")
+			},
+		},
+		{
+			uc:      "with existing entry",
+			config:  map[string]any{},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"tenant": "acme"}, sub.Attributes["file"])
+			},
+		},
+		{
+			uc:      "with missing entry and default value",
+			config:  map[string]any{"default": map[string]any{"tenant": "default"}, "on_missing_key": "error"},
+			subject: &subject.Subject{ID: "bob", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"tenant": "default"}, sub.Attributes["file"])
+			},
+		},
+		{
+			uc:      "with missing entry to be ignored",
+			config:  map[string]any{},
+			subject: &subject.Subject{ID: "bob", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:      "with missing entry resulting in an error",
+			config:  map[string]any{"on_missing_key": "error"},
+			subject: &subject.Subject{ID: "bob", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "no entry found for key 'bob'")
+				assert.Empty(t, sub.Attributes)
+
+				var identifier interface{ HandlerID() string }
+				require.ErrorAs(t, err, &identifier)
+				assert.Equal(t, "file", identifier.HandlerID())
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			prototype, err := newFileHydrator("file", map[string]any{"path": path, "key": "{{ .Subject.ID }}"})
+			require.NoError(t, err)
+
+			hydrator, err := prototype.WithConfig(tc.config)
+			require.NoError(t, err)
+
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			// WHEN
+			err = hydrator.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err, tc.subject)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFileHydratorReloadsWatchedFile(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := writeDataFile(t, t.TempDir(), "data.yaml", "alice: acme\n")
+
+	hydrator, err := newFileHydrator("file",
+		map[string]any{"path": path, "key": "{{ .Subject.ID }}", "watch": true})
+	require.NoError(t, err)
+
+	defer hydrator.Close() // nolint: errcheck
+
+	ctx := &heimdallmocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+
+	execute := func(id string) *subject.Subject {
+		sub := &subject.Subject{ID: id, Attributes: map[string]any{}}
+		require.NoError(t, hydrator.Execute(ctx, sub))
+
+		return sub
+	}
+
+	require.Equal(t, "acme", execute("alice").Attributes["file"])
+
+	// WHEN
+	replaceDataFile(t, path, "alice: foo\nbob: bar\n")
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		_, found := hydrator.source.lookup("bob")
+
+		return found
+	}, 2*time.Second, 10*time.Millisecond)
+
+	assert.Equal(t, "foo", execute("alice").Attributes["file"])
+	assert.Equal(t, "bar", execute("bob").Attributes["file"])
+
+	// WHEN
+	replaceDataFile(t, path, "alice: [")
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		hydrator.source.mut.RLock()
+		defer hydrator.source.mut.RUnlock()
+
+		return hydrator.source.reloadErr != nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// previously loaded data is still used
+	assert.Equal(t, "foo", execute("alice").Attributes["file"])
+}
+
+func TestFileHydratorExecuteReturnsCopyOfEntry(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := writeDataFile(t, t.TempDir(), "data.json", `{"alice": {"tenant": "acme", "roles": ["admin"]}}`)
+
+	hydrator, err := newFileHydrator("file", map[string]any{"path": path, "key": "{{ .Subject.ID }}"})
+	require.NoError(t, err)
+
+	ctx := &heimdallmocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+
+	first := &subject.Subject{ID: "alice", Attributes: map[string]any{}}
+	require.NoError(t, hydrator.Execute(ctx, first))
+
+	// WHEN
+	entry, ok := first.Attributes["file"].(map[string]any)
+	require.True(t, ok)
+
+	entry["tenant"] = "foo"
+	entry["roles"].([]any)[0] = "foo" // nolint: forcetypeassert
+
+	second := &subject.Subject{ID: "alice", Attributes: map[string]any{}}
+	require.NoError(t, hydrator.Execute(ctx, second))
+
+	// THEN
+	assert.Equal(t, map[string]any{"tenant": "acme", "roles": []any{"admin"}}, second.Attributes["file"])
+}
+
+func TestFileHydratorReloadsWatchedFileInKubernetesConfigMapMount(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	// mimics the layout of config maps and secrets mounted as volumes by Kubernetes, which updates
+	// these by atomically replacing the ..data symlink
+	dir := t.TempDir()
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v1"), 0o700))
+	writeDataFile(t, filepath.Join(dir, "..v1"), "data.yaml", "alice: acme\n")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "data.yaml"), filepath.Join(dir, "data.yaml")))
+
+	hydrator, err := newFileHydrator("file",
+		map[string]any{"path": filepath.Join(dir, "data.yaml"), "key": "{{ .Subject.ID }}", "watch": true})
+	require.NoError(t, err)
+
+	defer hydrator.Close() // nolint: errcheck
+
+	entry, found := hydrator.source.lookup("alice")
+	require.True(t, found)
+	require.Equal(t, "acme", entry)
+
+	// WHEN
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "..v2"), 0o700))
+	writeDataFile(t, filepath.Join(dir, "..v2"), "data.yaml", "alice: foo\n")
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	// THEN
+	assert.Eventually(t, func() bool {
+		entry, _ := hydrator.source.lookup("alice")
+
+		return entry == "foo"
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestFileHydratorIsNotReloadedAfterClose(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	path := writeDataFile(t, t.TempDir(), "data.yaml", "alice: acme\n")
+
+	hydrator, err := newFileHydrator("file",
+		map[string]any{"path": path, "key": "{{ .Subject.ID }}", "watch": true})
+	require.NoError(t, err)
+
+	// WHEN
+	require.NoError(t, hydrator.Close())
+	replaceDataFile(t, path, "alice: foo\n")
+	time.Sleep(100 * time.Millisecond)
+
+	// THEN
+	entry, found := hydrator.source.lookup("alice")
+	require.True(t, found)
+	assert.Equal(t, "acme", entry)
+}
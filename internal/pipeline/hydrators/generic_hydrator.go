@@ -1,6 +1,8 @@
 package hydrators
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
@@ -19,10 +21,12 @@ import (
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/pipeline/contenttype"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
 	"github.com/dadrus/heimdall/internal/x"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/stringx"
 )
 
 const (
@@ -49,12 +53,14 @@ type hydrationData struct {
 }
 
 type genericHydrator struct {
-	id         string
-	e          endpoint.Endpoint
-	ttl        time.Duration
-	payload    template.Template
-	fwdHeaders []string
-	fwdCookies []string
+	id          string
+	e           endpoint.Endpoint
+	ttl         time.Duration
+	cachePolicy cache.Policy
+	payload     template.Template
+	graphql     *graphql.Query
+	fwdHeaders  []string
+	fwdCookies  []string
 }
 
 func newGenericHydrator(id string, rawConfig map[string]any) (*genericHydrator, error) {
@@ -63,7 +69,9 @@ func newGenericHydrator(id string, rawConfig map[string]any) (*genericHydrator,
 		ForwardHeaders []string          `mapstructure:"forward_headers"`
 		ForwardCookies []string          `mapstructure:"forward_cookies"`
 		Payload        template.Template `mapstructure:"payload"`
+		GraphQL        *graphql.Query    `mapstructure:"graphql"`
 		CacheTTL       *time.Duration    `mapstructure:"cache_ttl"`
+		CachePolicy    cache.Policy      `mapstructure:"cache_policy"`
 	}
 
 	var conf Config
@@ -73,6 +81,11 @@ func newGenericHydrator(id string, rawConfig map[string]any) (*genericHydrator,
 			CausedBy(err)
 	}
 
+	if conf.Payload != nil && conf.GraphQL != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "payload and graphql cannot be configured together")
+	}
+
 	if err := conf.Endpoint.Validate(); err != nil {
 		return nil, errorchain.
 			NewWithMessage(heimdall.ErrConfiguration, "failed to validate endpoint configuration").
@@ -85,12 +98,14 @@ func newGenericHydrator(id string, rawConfig map[string]any) (*genericHydrator,
 	}
 
 	return &genericHydrator{
-		id:         id,
-		e:          conf.Endpoint,
-		payload:    conf.Payload,
-		fwdHeaders: conf.ForwardHeaders,
-		fwdCookies: conf.ForwardCookies,
-		ttl:        ttl,
+		id:          id,
+		e:           conf.Endpoint,
+		payload:     conf.Payload,
+		graphql:     conf.GraphQL,
+		fwdHeaders:  conf.ForwardHeaders,
+		fwdCookies:  conf.ForwardCookies,
+		ttl:         ttl,
+		cachePolicy: conf.CachePolicy,
 	}, nil
 }
 
@@ -104,43 +119,9 @@ func (h *genericHydrator) Execute(ctx heimdall.Context, sub *subject.Subject) er
 			WithErrorContext(h)
 	}
 
-	cch := cache.Ctx(ctx.AppContext())
-
-	var (
-		cacheKey          string
-		err               error
-		ok                bool
-		cacheEntry        any
-		hydrationResponse *hydrationData
-	)
-
-	if h.ttl > 0 {
-		cacheKey, err = h.calculateCacheKey(sub)
-		if err != nil {
-			logger.Error().Err(err).Msg("Failed to calculate cache key. Will not be able to use cache.")
-		} else {
-			cacheEntry = cch.Get(cacheKey)
-		}
-	}
-
-	if cacheEntry != nil {
-		if hydrationResponse, ok = cacheEntry.(*hydrationData); !ok {
-			logger.Warn().Msg("Wrong object type from cache")
-			cch.Delete(cacheKey)
-		} else {
-			logger.Debug().Msg("Reusing hydration response from cache")
-		}
-	}
-
-	if hydrationResponse == nil {
-		hydrationResponse, err = h.callHydrationEndpoint(ctx, sub)
-		if err != nil {
-			return err
-		}
-
-		if h.ttl > 0 && len(cacheKey) != 0 {
-			cch.Set(cacheKey, hydrationResponse, h.ttl)
-		}
+	hydrationResponse, err := h.hydrationData(ctx, sub)
+	if err != nil {
+		return err
 	}
 
 	if hydrationResponse.payload != nil {
@@ -159,7 +140,9 @@ func (h *genericHydrator) WithConfig(rawConfig map[string]any) (Hydrator, error)
 		ForwardHeaders []string          `mapstructure:"forward_headers"`
 		ForwardCookies []string          `mapstructure:"forward_cookies"`
 		Payload        template.Template `mapstructure:"payload"`
+		GraphQL        *graphql.Query    `mapstructure:"graphql"`
 		CacheTTL       *time.Duration    `mapstructure:"cache_ttl"`
+		CachePolicy    *cache.Policy     `mapstructure:"cache_policy"`
 	}
 
 	var conf Config
@@ -169,15 +152,31 @@ func (h *genericHydrator) WithConfig(rawConfig map[string]any) (Hydrator, error)
 			CausedBy(err)
 	}
 
+	if conf.Payload != nil && conf.GraphQL != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "payload and graphql cannot be configured together")
+	}
+
+	// a payload or a graphql query configured on the rule level replaces whatever was configured
+	// in the prototype
+	payload, gql := h.payload, h.graphql
+	if conf.Payload != nil || conf.GraphQL != nil {
+		payload, gql = conf.Payload, conf.GraphQL
+	}
+
 	return &genericHydrator{
 		id:         h.id,
 		e:          h.e,
-		payload:    x.IfThenElse(conf.Payload != nil, conf.Payload, h.payload),
+		payload:    payload,
+		graphql:    gql,
 		fwdHeaders: x.IfThenElse(len(conf.ForwardHeaders) != 0, conf.ForwardHeaders, h.fwdHeaders),
 		fwdCookies: x.IfThenElse(len(conf.ForwardCookies) != 0, conf.ForwardCookies, h.fwdCookies),
 		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
 			func() time.Duration { return *conf.CacheTTL },
 			func() time.Duration { return h.ttl }),
+		cachePolicy: x.IfThenElseExec(conf.CachePolicy != nil,
+			func() cache.Policy { return *conf.CachePolicy },
+			func() cache.Policy { return h.cachePolicy }),
 	}, nil
 }
 
@@ -185,15 +184,54 @@ func (h *genericHydrator) HandlerID() string {
 	return h.id
 }
 
-func (h *genericHydrator) callHydrationEndpoint(ctx heimdall.Context, sub *subject.Subject) (*hydrationData, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-	logger.Debug().Msg("Calling hydration endpoint")
+func (h *genericHydrator) hydrationData(ctx heimdall.Context, sub *subject.Subject) (*hydrationData, error) {
+	if h.ttl <= 0 {
+		return h.callHydrationEndpoint(ctx, sub)
+	}
+
+	cacheKey, err := h.calculateCacheKey(sub)
+	if err != nil {
+		zerolog.Ctx(ctx.AppContext()).Error().Err(err).
+			Msg("Failed to calculate cache key. Will not be able to use cache.")
+
+		return h.callHydrationEndpoint(ctx, sub)
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceHydrator)
 
+	return cache.Load(ctx.AppContext(), cch, cacheKey, h.cachePolicy,
+		func() (cache.LoadFunc[*hydrationData], error) {
+			req, err := h.createRequest(ctx, sub)
+			if err != nil {
+				return nil, err
+			}
+
+			tags := []string{cache.HandlerTag(h.id), cache.SubjectTag(stringx.Clone(sub.ID))}
+
+			return func(loadCtx context.Context) (cache.Loaded[*hydrationData], error) {
+				data, err := h.sendRequest(req.WithContext(loadCtx))
+				if err != nil {
+					return cache.Loaded[*hydrationData]{}, err
+				}
+
+				return cache.Loaded[*hydrationData]{Value: data, TTL: h.ttl, Tags: tags}, nil
+			}, nil
+		})
+}
+
+func (h *genericHydrator) callHydrationEndpoint(ctx heimdall.Context, sub *subject.Subject) (*hydrationData, error) {
 	req, err := h.createRequest(ctx, sub)
 	if err != nil {
 		return nil, err
 	}
 
+	return h.sendRequest(req)
+}
+
+func (h *genericHydrator) sendRequest(req *http.Request) (*hydrationData, error) {
+	logger := zerolog.Ctx(req.Context())
+	logger.Debug().Msg("Calling hydration endpoint")
+
 	resp, err := h.e.CreateClient(req.URL.Hostname()).Do(req)
 	if err != nil {
 		var clientErr *url.Error
@@ -212,7 +250,7 @@ func (h *genericHydrator) callHydrationEndpoint(ctx heimdall.Context, sub *subje
 
 	defer resp.Body.Close()
 
-	data, err := h.readResponse(ctx, resp)
+	data, err := h.readResponse(logger, resp)
 	if err != nil {
 		return nil, err
 	}
@@ -220,6 +258,8 @@ func (h *genericHydrator) callHydrationEndpoint(ctx heimdall.Context, sub *subje
 	return &hydrationData{payload: data}, nil
 }
 
+// createRequest creates the request to the hydration endpoint. It does not refer to the request to heimdall,
+// so that it can be sent after the latter has been served.
 func (h *genericHydrator) createRequest(ctx heimdall.Context, sub *subject.Subject) (*http.Request, error) {
 	logger := zerolog.Ctx(ctx.AppContext())
 
@@ -237,6 +277,18 @@ func (h *genericHydrator) createRequest(ctx heimdall.Context, sub *subject.Subje
 		body = strings.NewReader(value)
 	}
 
+	if h.graphql != nil {
+		value, err := h.graphql.Body(ctx, sub)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrInternal, "failed to create graphql request for the hydration endpoint").
+				WithErrorContext(h).
+				CausedBy(err)
+		}
+
+		body = bytes.NewReader(value)
+	}
+
 	req, err := h.e.CreateRequest(ctx.AppContext(), body,
 		endpoint.RenderFunc(func(value string) (string, error) {
 			tpl, err := template.New(value)
@@ -256,6 +308,10 @@ func (h *genericHydrator) createRequest(ctx heimdall.Context, sub *subject.Subje
 			CausedBy(err)
 	}
 
+	if h.graphql != nil && len(req.Header.Get("Content-Type")) == 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
 	for _, headerName := range h.fwdHeaders {
 		headerValue := ctx.RequestHeader(headerName)
 		if len(headerValue) == 0 {
@@ -263,7 +319,8 @@ func (h *genericHydrator) createRequest(ctx heimdall.Context, sub *subject.Subje
 				Msg("Header not present in the request but configured to be forwarded")
 		}
 
-		req.Header.Add(headerName, headerValue)
+		// the values taken from the request to heimdall refer to memory reused for other requests
+		req.Header.Add(headerName, stringx.Clone(headerValue))
 	}
 
 	for _, cookieName := range h.fwdCookies {
@@ -279,9 +336,7 @@ func (h *genericHydrator) createRequest(ctx heimdall.Context, sub *subject.Subje
 	return req, nil
 }
 
-func (h *genericHydrator) readResponse(ctx heimdall.Context, resp *http.Response) (any, error) {
-	logger := zerolog.Ctx(ctx.AppContext())
-
+func (h *genericHydrator) readResponse(logger *zerolog.Logger, resp *http.Response) (any, error) {
 	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
 		return nil, errorchain.
 			NewWithMessagef(heimdall.ErrCommunication, "unexpected response code: %v", resp.StatusCode).
@@ -302,6 +357,18 @@ func (h *genericHydrator) readResponse(ctx heimdall.Context, resp *http.Response
 			CausedBy(err)
 	}
 
+	if h.graphql != nil {
+		result, err := h.graphql.Result(rawData)
+		if err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunication, "graphql request to the hydration endpoint failed").
+				WithErrorContext(h).
+				CausedBy(err)
+		}
+
+		return result, nil
+	}
+
 	contentType := resp.Header.Get("Content-Type")
 
 	logger.Debug().Str("_content_type", contentType).Msg("Response received")
@@ -346,6 +413,9 @@ func (h *genericHydrator) calculateCacheKey(sub *subject.Subject) (string, error
 	hash.Write(x.IfThenElseExec(h.payload != nil,
 		func() []byte { return []byte(h.payload.Hash()) },
 		func() []byte { return []byte("nil") }))
+	hash.Write(x.IfThenElseExec(h.graphql != nil,
+		func() []byte { return []byte(h.graphql.Hash()) },
+		func() []byte { return []byte("nil") }))
 	hash.Write([]byte(h.e.Hash()))
 	hash.Write(ttlBytes)
 	hash.Write(rawSub)
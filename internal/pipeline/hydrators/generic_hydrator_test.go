@@ -19,6 +19,7 @@ import (
 	"github.com/dadrus/heimdall/internal/endpoint"
 	"github.com/dadrus/heimdall/internal/heimdall"
 	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
 	"github.com/dadrus/heimdall/internal/testsupport"
@@ -103,6 +104,9 @@ forward_cookies:
   - My-Foo-Session
 payload: "{{ .Subject.ID }}"
 cache_ttl: 5s
+cache_policy:
+  refresh_ahead: 1s
+  stale_ttl: 1m
 `),
 			assert: func(t *testing.T, err error, hydrator *genericHydrator) {
 				t.Helper()
@@ -121,10 +125,68 @@ cache_ttl: 5s
 				assert.Contains(t, hydrator.fwdHeaders, "X-User-ID")
 				assert.Contains(t, hydrator.fwdHeaders, "X-Foo-Bar")
 				assert.Equal(t, 5*time.Second, hydrator.ttl)
+				assert.Equal(t, cache.Policy{RefreshAhead: 1 * time.Second, StaleTTL: 1 * time.Minute},
+					hydrator.cachePolicy)
 
 				assert.Equal(t, "hydrator", hydrator.HandlerID())
 			},
 		},
+		{
+			uc: "with payload and graphql configured",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+payload: bar
+graphql:
+  query: "{ me { roles } }"
+`),
+			assert: func(t *testing.T, err error, hydrator *genericHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot be configured together")
+			},
+		},
+		{
+			uc: "with invalid graphql query",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+graphql:
+  query: "{ me { roles }"
+`),
+			assert: func(t *testing.T, err error, hydrator *genericHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "invalid graphql query")
+			},
+		},
+		{
+			uc: "with graphql configured",
+			id: "hydrator",
+			config: []byte(`
+endpoint:
+  url: http://foo.bar
+graphql:
+  query: "query User($id: ID!) { user(id: $id) { roles } }"
+  variables:
+    id: "{{ .Subject.ID }}"
+  result_path: user
+`),
+			assert: func(t *testing.T, err error, hydrator *genericHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, hydrator)
+
+				assert.Nil(t, hydrator.payload)
+				require.NotNil(t, hydrator.graphql)
+				assert.NotEmpty(t, hydrator.graphql.Hash())
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			conf, err := testsupport.DecodeTestConfig(tc.config)
@@ -323,6 +385,8 @@ forward_headers:
 forward_cookies:
   - Foo-Session
 cache_ttl: 15s
+cache_policy:
+  stale_ttl: 1m
 `),
 			assert: func(t *testing.T, err error, prototype *genericHydrator, configured *genericHydrator) {
 				t.Helper()
@@ -345,9 +409,56 @@ cache_ttl: 15s
 				assert.Contains(t, configured.fwdCookies, "Foo-Session")
 				assert.NotEqual(t, prototype.ttl, configured.ttl)
 				assert.Equal(t, 15*time.Second, configured.ttl)
+				assert.Equal(t, cache.Policy{}, prototype.cachePolicy)
+				assert.Equal(t, cache.Policy{StaleTTL: 1 * time.Minute}, configured.cachePolicy)
 				assert.Equal(t, "hydrator5", configured.HandlerID())
 			},
 		},
+		{
+			uc: "with payload replaced by graphql query",
+			id: "hydrator6",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+payload: bar
+`),
+			config: []byte(`
+graphql:
+  query: "{ me { roles } }"
+`),
+			assert: func(t *testing.T, err error, prototype *genericHydrator, configured *genericHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.NotNil(t, prototype.payload)
+				assert.Nil(t, prototype.graphql)
+				assert.Nil(t, configured.payload)
+				assert.NotNil(t, configured.graphql)
+				assert.Equal(t, prototype.ttl, configured.ttl)
+			},
+		},
+		{
+			uc: "with payload and graphql query configured on rule level",
+			id: "hydrator7",
+			prototypeConfig: []byte(`
+endpoint:
+  url: http://foo.bar
+payload: bar
+`),
+			config: []byte(`
+payload: foo
+graphql:
+  query: "{ me { roles } }"
+`),
+			assert: func(t *testing.T, err error, prototype *genericHydrator, configured *genericHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot be configured together")
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			pc, err := testsupport.DecodeTestConfig(tc.prototypeConfig)
@@ -452,7 +563,10 @@ func TestGenericHydratorExecute(t *testing.T) {
 				key, err := hydrator.calculateCacheKey(sub)
 				require.NoError(t, err)
 
-				cch.On("Get", key).Return(&hydrationData{payload: "Hi Foo"})
+				cch.On("Get", key).Return(&cache.Entry{
+					Value:     &hydrationData{payload: "Hi Foo"},
+					ExpiresAt: time.Now().Add(hydrator.ttl),
+				})
 			},
 			assert: func(t *testing.T, err error, sub *subject.Subject) {
 				t.Helper()
@@ -485,8 +599,10 @@ func TestGenericHydratorExecute(t *testing.T) {
 
 				cch.On("Get", key).Return("Hi Foo")
 				cch.On("Delete", key)
-				cch.On("Set", key, mock.MatchedBy(func(val *hydrationData) bool {
-					return val != nil && val.payload == "Hi from endpoint"
+				cch.On("Set", key, mock.MatchedBy(func(entry *cache.Entry) bool {
+					val, ok := entry.Value.(*hydrationData)
+
+					return ok && val.payload == "Hi from endpoint"
 				}), 5*time.Second)
 			},
 			instructServer: func(t *testing.T) {
@@ -622,8 +738,10 @@ func TestGenericHydratorExecute(t *testing.T) {
 				require.NoError(t, err)
 
 				cch.On("Get", key).Return(nil)
-				cch.On("Set", key, mock.MatchedBy(func(val *hydrationData) bool {
-					return val != nil && val.payload == "Hi from endpoint"
+				cch.On("Set", key, mock.MatchedBy(func(entry *cache.Entry) bool {
+					val, ok := entry.Value.(*hydrationData)
+
+					return ok && val.payload == "Hi from endpoint"
 				}), hydrator.ttl)
 			},
 			instructServer: func(t *testing.T) {
@@ -716,6 +834,84 @@ func TestGenericHydratorExecute(t *testing.T) {
 				assert.Contains(t, entry, "baz")
 			},
 		},
+		{
+			uc: "with graphql query",
+			hydrator: &genericHydrator{
+				id: "test-hydrator",
+				e:  endpoint.Endpoint{URL: srv.URL, Method: http.MethodPost},
+				graphql: func() *graphql.Query {
+					query, _ := graphql.NewQuery("query User($id: ID!) { user(id: $id) { roles } }", "",
+						map[string]any{"id": "{{ .Subject.ID }}"}, "user.roles")
+
+					return query
+				}(),
+			},
+			subject: &subject.Subject{ID: "Foo", Attributes: map[string]any{"bar": "baz"}},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				checkRequest = func(req *http.Request) {
+					t.Helper()
+
+					assert.Equal(t, "application/json", req.Header.Get("Content-Type"))
+
+					content, err := io.ReadAll(req.Body)
+					require.NoError(t, err)
+
+					assert.JSONEq(t, `{
+"query": "query User($id: ID!) { user(id: $id) { roles } }",
+"variables": {"id": "Foo"}
+}`, string(content))
+				}
+
+				responseContentType = "application/json"
+				responseContent = []byte(`{ "data": { "user": { "roles": ["admin"] } } }`)
+				responseCode = http.StatusOK
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, hydrationEndpointCalled)
+
+				require.NoError(t, err)
+
+				assert.Len(t, sub.Attributes, 2)
+				assert.Equal(t, []any{"admin"}, sub.Attributes["test-hydrator"])
+			},
+		},
+		{
+			uc: "with graphql query resulting in errors",
+			hydrator: &genericHydrator{
+				id: "test-hydrator",
+				e:  endpoint.Endpoint{URL: srv.URL, Method: http.MethodPost},
+				graphql: func() *graphql.Query {
+					query, _ := graphql.NewQuery("{ me { roles } }", "", nil, "")
+
+					return query
+				}(),
+			},
+			subject: &subject.Subject{ID: "Foo", Attributes: map[string]any{"bar": "baz"}},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseContentType = "application/json"
+				responseContent = []byte(`{ "data": null, "errors": [{ "message": "not authenticated" }] }`)
+				responseCode = http.StatusOK
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				assert.True(t, hydrationEndpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "not authenticated")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "test-hydrator", identifier.HandlerID())
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
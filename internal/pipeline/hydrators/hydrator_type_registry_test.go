@@ -13,8 +13,8 @@ import (
 func TestCreateHydratorPrototype(t *testing.T) {
 	t.Parallel()
 
-	// there are 3 error handlers implemented, which should have been registered
-	require.Len(t, hydratorTypeFactories, 1)
+	// there are 3 hydrators implemented, which should have been registered
+	require.Len(t, hydratorTypeFactories, 3)
 
 	for _, tc := range []struct {
 		uc     string
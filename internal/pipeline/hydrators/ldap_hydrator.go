@@ -0,0 +1,287 @@
+package hydrators
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/ldap"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerHydratorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Hydrator, error) {
+			if typ != config.POTLDAP {
+				return false, nil, nil
+			}
+
+			hydrator, err := newLDAPHydrator(id, conf)
+
+			return true, hydrator, err
+		})
+}
+
+type ldapSearch struct {
+	ldap.Search `mapstructure:",squash"`
+	Filter      template.Template `mapstructure:"filter"`
+}
+
+func (s *ldapSearch) validate() error {
+	if s == nil {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "no search configured for ldap hydrator")
+	}
+
+	if s.Filter == nil {
+		return errorchain.NewWithMessage(heimdall.ErrConfiguration, "no search filter configured for ldap hydrator")
+	}
+
+	return s.Validate()
+}
+
+type ldapEntryData struct {
+	attributes map[string]any
+}
+
+type ldapHydrator struct {
+	id         string
+	dir        *ldap.Directory
+	search     *ldapSearch
+	attributes ldap.AttributeMapping
+	groups     *ldap.GroupSearch
+	ttl        time.Duration
+}
+
+func newLDAPHydrator(id string, rawConfig map[string]any) (*ldapHydrator, error) {
+	type Config struct {
+		Connection ldap.Connection       `mapstructure:"connection"`
+		Search     *ldapSearch           `mapstructure:"search"`
+		Attributes ldap.AttributeMapping `mapstructure:"attributes"`
+		Groups     *ldap.GroupSearch     `mapstructure:"groups"`
+		CacheTTL   *time.Duration        `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal ldap hydrator config").
+			CausedBy(err)
+	}
+
+	if err := validateLDAPConfig(conf.Search, conf.Attributes, conf.Groups); err != nil {
+		return nil, err
+	}
+
+	dir, err := ldap.NewDirectory(conf.Connection)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to validate ldap connection configuration").
+			CausedBy(err)
+	}
+
+	ttl := defaultTTL
+	if conf.CacheTTL != nil {
+		ttl = *conf.CacheTTL
+	}
+
+	return &ldapHydrator{
+		id:         id,
+		dir:        dir,
+		search:     conf.Search,
+		attributes: conf.Attributes,
+		groups:     conf.Groups,
+		ttl:        ttl,
+	}, nil
+}
+
+func validateLDAPConfig(search *ldapSearch, attributes ldap.AttributeMapping, groups *ldap.GroupSearch) error {
+	if err := search.validate(); err != nil {
+		return err
+	}
+
+	if err := attributes.Validate(); err != nil {
+		return err
+	}
+
+	if groups != nil {
+		return groups.Validate()
+	}
+
+	return nil
+}
+
+func (h *ldapHydrator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Hydrating using ldap hydrator")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute ldap hydrator due to 'nil' subject").
+			WithErrorContext(h)
+	}
+
+	filter, err := h.search.Filter.Render(ctx, sub)
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to render search filter").
+			WithErrorContext(h).
+			CausedBy(err)
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceHydrator)
+
+	var cacheKey string
+
+	if h.ttl > 0 {
+		cacheKey = h.calculateCacheKey(filter)
+
+		if entry := cch.Get(cacheKey); entry != nil {
+			if data, ok := entry.(*ldapEntryData); ok {
+				logger.Debug().Msg("Reusing ldap entry from cache")
+
+				sub.Attributes[h.id] = data.attributes
+
+				return nil
+			}
+
+			logger.Warn().Msg("Wrong object type from cache")
+			cch.Delete(cacheKey)
+		}
+	}
+
+	attributes, err := h.fetchAttributes(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(cacheKey) != 0 {
+		cch.Set(cacheKey, &ldapEntryData{attributes: attributes}, h.ttl)
+		cache.Index(cch, cacheKey, h.ttl, cache.HandlerTag(h.id), cache.SubjectTag(sub.ID))
+	}
+
+	sub.Attributes[h.id] = attributes
+
+	return nil
+}
+
+func (h *ldapHydrator) fetchAttributes(filter string) (map[string]any, error) {
+	entry, err := h.dir.FindEntry(h.search.Search, filter, h.attributes.LDAPAttributes())
+	if err != nil {
+		return nil, h.wrapError(err, "failed to look up the subject entry")
+	}
+
+	attributes := h.attributes.Apply(entry)
+
+	if h.groups != nil {
+		groups, err := h.dir.Groups(*h.groups, entry.DN)
+		if err != nil {
+			return nil, h.wrapError(err, "failed to look up the groups of the subject")
+		}
+
+		attributes[ldap.AttributeGroups] = groups
+	}
+
+	return attributes, nil
+}
+
+func (h *ldapHydrator) wrapError(err error, message string) error {
+	return errorchain.
+		NewWithMessage(x.IfThenElse(errors.Is(err, heimdall.ErrCommunication),
+			heimdall.ErrCommunication, heimdall.ErrInternal), message).
+		WithErrorContext(h).
+		CausedBy(err)
+}
+
+func (h *ldapHydrator) WithConfig(rawConfig map[string]any) (Hydrator, error) {
+	if len(rawConfig) == 0 {
+		return h, nil
+	}
+
+	type Config struct {
+		Search     *ldapSearch           `mapstructure:"search"`
+		Attributes ldap.AttributeMapping `mapstructure:"attributes"`
+		Groups     *ldap.GroupSearch     `mapstructure:"groups"`
+		CacheTTL   *time.Duration        `mapstructure:"cache_ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal ldap hydrator config").
+			CausedBy(err)
+	}
+
+	search := x.IfThenElse(conf.Search != nil, conf.Search, h.search)
+	attributes := x.IfThenElse(conf.Attributes != nil, conf.Attributes, h.attributes)
+	groups := x.IfThenElse(conf.Groups != nil, conf.Groups, h.groups)
+
+	if err := validateLDAPConfig(search, attributes, groups); err != nil {
+		return nil, err
+	}
+
+	return &ldapHydrator{
+		id:         h.id,
+		dir:        h.dir,
+		search:     search,
+		attributes: attributes,
+		groups:     groups,
+		ttl: x.IfThenElseExec(conf.CacheTTL != nil,
+			func() time.Duration { return *conf.CacheTTL },
+			func() time.Duration { return h.ttl }),
+	}, nil
+}
+
+func (h *ldapHydrator) HandlerID() string {
+	return h.id
+}
+
+func (h *ldapHydrator) calculateCacheKey(filter string) string {
+	const int64BytesCount = 8
+
+	ttlBytes := make([]byte, int64BytesCount)
+	binary.LittleEndian.PutUint64(ttlBytes, uint64(h.ttl))
+
+	names := make([]string, 0, len(h.attributes))
+	for name := range h.attributes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	hash := sha256.New()
+	hash.Write([]byte(h.id))
+	hash.Write([]byte(h.search.BaseDN))
+	hash.Write([]byte(h.search.Scope))
+	hash.Write([]byte(filter))
+
+	for _, name := range names {
+		hash.Write([]byte(name))
+		hash.Write([]byte(h.attributes[name]))
+	}
+
+	if h.groups != nil {
+		hash.Write([]byte(h.groups.BaseDN))
+		hash.Write([]byte(h.groups.Scope))
+		hash.Write([]byte(h.groups.Filter))
+		hash.Write([]byte(h.groups.NameAttribute))
+		hash.Write([]byte(strconv.FormatBool(h.groups.Nested)))
+	}
+
+	hash.Write(ttlBytes)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
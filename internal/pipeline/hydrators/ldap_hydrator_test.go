@@ -0,0 +1,523 @@
+package hydrators
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/mocks"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func newTestLDAPServer(t *testing.T) *testsupport.LDAPServer {
+	t.Helper()
+
+	return testsupport.NewLDAPServer(t, nil, false,
+		testsupport.LDAPEntry{DN: "dc=example,dc=org"},
+		testsupport.LDAPEntry{
+			DN: "uid=alice,ou=users,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"uid":  {"alice"},
+				"mail": {"alice@example.org"},
+				"ou":   {"dev", "ops"},
+			},
+		},
+		testsupport.LDAPEntry{
+			DN: "cn=devs,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":     {"devs"},
+				"member": {"uid=alice,ou=users,dc=example,dc=org"},
+			},
+		},
+		testsupport.LDAPEntry{
+			DN: "cn=staff,ou=groups,dc=example,dc=org",
+			Attributes: map[string][]string{
+				"cn":     {"staff"},
+				"member": {"cn=devs,ou=groups,dc=example,dc=org"},
+			},
+		},
+	)
+}
+
+func TestCreateLDAPHydrator(t *testing.T) {
+	t.Parallel()
+
+	rootCA, err := testsupport.NewRootCA("Test Root CA", time.Hour)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(testsupport.WithX509Certificate(rootCA.Certificate))
+	require.NoError(t, err)
+
+	trustStoreFile := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(trustStoreFile, pemBytes, 0o600))
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, hydrator *ldapHydrator)
+	}{
+		{
+			uc: "with unsupported fields",
+			config: []byte(`
+connection:
+  url: ldap://localhost
+search:
+  base_dn: dc=example,dc=org
+  filter: "(uid={{ ldapenc .Subject.ID }})"
+foo: bar
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc:     "without search",
+			config: []byte(`connection: { url: "ldap://localhost" }`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no search configured")
+			},
+		},
+		{
+			uc: "without search filter",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org" }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no search filter configured")
+			},
+		},
+		{
+			uc: "without search base dn",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { filter: "(uid={{ ldapenc .Subject.ID }})" }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no base_dn configured")
+			},
+		},
+		{
+			uc: "with unsupported search scope",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org", scope: foo, filter: "(uid={{ ldapenc .Subject.ID }})" }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported ldap search scope 'foo'")
+			},
+		},
+		{
+			uc: "with reserved attribute name",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org", filter: "(uid={{ ldapenc .Subject.ID }})" }
+attributes: { groups: memberOf }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'groups' is reserved")
+			},
+		},
+		{
+			uc: "with groups without base dn",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org", filter: "(uid={{ ldapenc .Subject.ID }})" }
+groups: { nested: true }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no base_dn configured")
+			},
+		},
+		{
+			uc: "without connection url",
+			config: []byte(`
+search: { base_dn: "dc=example,dc=org", filter: "(uid={{ ldapenc .Subject.ID }})" }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "no ldap url configured")
+			},
+		},
+		{
+			uc: "with minimal configuration",
+			config: []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org", filter: "(uid={{ ldapenc .Subject.ID }})" }
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "ldap", hydrator.HandlerID())
+				assert.NotNil(t, hydrator.dir)
+				assert.Equal(t, "dc=example,dc=org", hydrator.search.BaseDN)
+				assert.Empty(t, hydrator.search.Scope)
+				assert.NotNil(t, hydrator.search.Filter)
+				assert.Empty(t, hydrator.attributes)
+				assert.Nil(t, hydrator.groups)
+				assert.Equal(t, defaultTTL, hydrator.ttl)
+			},
+		},
+		{
+			uc: "with full configuration",
+			config: []byte(`
+connection:
+  url: ldaps://localhost
+  trust_store: ` + trustStoreFile + `
+  bind_dn: cn=heimdall,dc=example,dc=org
+  bind_password: secret
+  pool_size: 10
+  timeout: 2s
+search:
+  base_dn: ou=users,dc=example,dc=org
+  scope: one
+  filter: "(uid={{ ldapenc .Subject.ID }})"
+attributes:
+  email: mail
+groups:
+  base_dn: ou=groups,dc=example,dc=org
+  filter: "(member={dn})"
+  name_attribute: cn
+  nested: true
+cache_ttl: 5m
+`),
+			assert: func(t *testing.T, err error, hydrator *ldapHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "ldap", hydrator.HandlerID())
+				assert.NotNil(t, hydrator.dir)
+				assert.Equal(t, "ou=users,dc=example,dc=org", hydrator.search.BaseDN)
+				assert.Equal(t, "one", hydrator.search.Scope)
+				assert.Equal(t, map[string]string{"email": "mail"}, map[string]string(hydrator.attributes))
+				require.NotNil(t, hydrator.groups)
+				assert.Equal(t, "ou=groups,dc=example,dc=org", hydrator.groups.BaseDN)
+				assert.Equal(t, "(member={dn})", hydrator.groups.Filter)
+				assert.Equal(t, "cn", hydrator.groups.NameAttribute)
+				assert.True(t, hydrator.groups.Nested)
+				assert.Equal(t, 5*time.Minute, hydrator.ttl)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			hydrator, err := newLDAPHydrator("ldap", conf)
+
+			// THEN
+			tc.assert(t, err, hydrator)
+		})
+	}
+}
+
+func TestCreateLDAPHydratorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	prototypeConfig := []byte(`
+connection: { url: "ldap://localhost" }
+search: { base_dn: "dc=example,dc=org", filter: "(uid={{ ldapenc .Subject.ID }})" }
+attributes: { email: mail }
+`)
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator)
+	}{
+		{
+			uc: "with empty target config",
+			assert: func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with connection override",
+			config: []byte(`connection: { url: "ldap://foo" }`),
+			assert: func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc:     "with invalid attributes override",
+			config: []byte(`attributes: { dn: distinguishedName }`),
+			assert: func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "'dn' is reserved")
+			},
+		},
+		{
+			uc: "with search, attributes, groups and cache ttl override",
+			config: []byte(`
+search: { base_dn: "ou=users,dc=example,dc=org", filter: "(mail={{ ldapenc .Subject.ID }})" }
+attributes: { uid: uid }
+groups: { base_dn: "ou=groups,dc=example,dc=org" }
+cache_ttl: 1m
+`),
+			assert: func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.dir, configured.dir)
+				assert.Equal(t, "ou=users,dc=example,dc=org", configured.search.BaseDN)
+				assert.NotEqual(t, prototype.search.Filter, configured.search.Filter)
+				assert.Equal(t, map[string]string{"uid": "uid"}, map[string]string(configured.attributes))
+				require.NotNil(t, configured.groups)
+				assert.Equal(t, "ou=groups,dc=example,dc=org", configured.groups.BaseDN)
+				assert.Equal(t, time.Minute, configured.ttl)
+			},
+		},
+		{
+			uc:     "with cache ttl override only",
+			config: []byte(`cache_ttl: 0s`),
+			assert: func(t *testing.T, err error, prototype *ldapHydrator, configured *ldapHydrator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype.search, configured.search)
+				assert.Equal(t, prototype.attributes, configured.attributes)
+				assert.Nil(t, configured.groups)
+				assert.Equal(t, time.Duration(0), configured.ttl)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig(prototypeConfig)
+			require.NoError(t, err)
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newLDAPHydrator("ldap", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			hydrator, err := prototype.WithConfig(conf)
+
+			// THEN
+			var configured *ldapHydrator
+			if err == nil {
+				configured = hydrator.(*ldapHydrator) // nolint: forcetypeassert
+			}
+
+			tc.assert(t, err, prototype, configured)
+		})
+	}
+}
+
+func TestLDAPHydratorExecute(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestLDAPServer(t)
+
+	for _, tc := range []struct {
+		uc             string
+		url            string
+		config         map[string]any
+		subject        *subject.Subject
+		configureCache func(t *testing.T, cch *mocks.MockCache, hydrator *ldapHydrator)
+		assert         func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc: "with nil subject",
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+			},
+		},
+		{
+			uc: "with failing filter rendering",
+			config: map[string]any{
+				"search": map[string]any{"base_dn": "dc=example,dc=org", "filter": "{{ .Subject.ID.foo }}"},
+			},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to render search filter")
+			},
+		},
+		{
+			uc:      "with successful cache hit",
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			configureCache: func(t *testing.T, cch *mocks.MockCache, hydrator *ldapHydrator) {
+				t.Helper()
+
+				cch.On("Get", hydrator.calculateCacheKey("(uid=alice)")).
+					Return(&ldapEntryData{attributes: map[string]any{"dn": "cached"}})
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"dn": "cached"}, sub.Attributes["ldap"])
+				assert.Empty(t, srv.Searches())
+			},
+		},
+		{
+			uc:      "with wrong object type in cache",
+			config:  map[string]any{"groups": map[string]any{"base_dn": "dc=example,dc=org", "nested": true}},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			configureCache: func(t *testing.T, cch *mocks.MockCache, hydrator *ldapHydrator) {
+				t.Helper()
+
+				key := hydrator.calculateCacheKey("(uid=alice)")
+
+				cch.On("Get", key).Return("foo")
+				cch.On("Delete", key)
+				cch.On("Set", key, mock.Anything, defaultTTL)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{
+					"dn":     "uid=alice,ou=users,dc=example,dc=org",
+					"email":  "alice@example.org",
+					"units":  []string{"dev", "ops"},
+					"groups": []string{"devs", "staff"},
+				}, sub.Attributes["ldap"])
+			},
+		},
+		{
+			uc:      "without cache and without groups",
+			config:  map[string]any{"cache_ttl": "0s"},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{
+					"dn":    "uid=alice,ou=users,dc=example,dc=org",
+					"email": "alice@example.org",
+					"units": []string{"dev", "ops"},
+				}, sub.Attributes["ldap"])
+			},
+		},
+		{
+			uc:      "with unknown subject",
+			config:  map[string]any{"cache_ttl": "0s"},
+			subject: &subject.Subject{ID: "bob", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to look up the subject entry")
+				assert.Empty(t, sub.Attributes)
+
+				var identifier interface{ HandlerID() string }
+				require.ErrorAs(t, err, &identifier)
+				assert.Equal(t, "ldap", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "with unreachable server",
+			url:     "ldap://127.0.0.1:1",
+			config:  map[string]any{"cache_ttl": "0s"},
+			subject: &subject.Subject{ID: "alice", Attributes: map[string]any{}},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "failed to connect")
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			prototype, err := newLDAPHydrator("ldap", map[string]any{
+				"connection": map[string]any{"url": x.IfThenElse(len(tc.url) != 0, tc.url, srv.URL)},
+				"search": map[string]any{
+					"base_dn": "dc=example,dc=org",
+					"filter":  "(uid={{ ldapenc .Subject.ID }})",
+				},
+				"attributes": map[string]any{"email": "mail", "units": "ou"},
+			})
+			require.NoError(t, err)
+
+			hydrator, err := prototype.WithConfig(tc.config)
+			require.NoError(t, err)
+
+			configureCache := x.IfThenElse(tc.configureCache != nil,
+				tc.configureCache,
+				func(t *testing.T, cch *mocks.MockCache, hydrator *ldapHydrator) { t.Helper() })
+
+			cch := &mocks.MockCache{}
+			configureCache(t, cch, hydrator.(*ldapHydrator)) // nolint: forcetypeassert
+
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(cache.WithContext(context.Background(), cch))
+
+			// WHEN
+			err = hydrator.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err, tc.subject)
+			cch.AssertExpectations(t)
+		})
+	}
+}
@@ -1,10 +1,30 @@
 package pipeline
 
 import (
+	"context"
+	"io"
+
+	"github.com/rs/zerolog"
 	"go.uber.org/fx"
 )
 
 // nolint
 var Module = fx.Options(
 	fx.Provide(NewHandlerFactory),
+	fx.Invoke(registerHandlerFactoryCloser),
 )
+
+func registerHandlerFactoryCloser(lifecycle fx.Lifecycle, logger zerolog.Logger, factory HandlerFactory) {
+	closer, ok := factory.(io.Closer)
+	if !ok {
+		return
+	}
+
+	lifecycle.Append(fx.Hook{
+		OnStop: func(ctx context.Context) error {
+			logger.Debug().Msg("Releasing pipeline resources")
+
+			return closer.Close()
+		},
+	})
+}
@@ -0,0 +1,13 @@
+package mutators
+
+import (
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache/codec"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	codec.Register[*jose.JSONWebKey]("jwk", codec.JSON[*jose.JSONWebKey]{})
+}
@@ -3,7 +3,10 @@ package mutators
 import (
 	"github.com/mitchellh/mapstructure"
 
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
 	"github.com/dadrus/heimdall/internal/pipeline/template"
+	"github.com/dadrus/heimdall/internal/truststore"
 )
 
 func decodeConfig(input any, output any) error {
@@ -11,6 +14,9 @@ func decodeConfig(input any, output any) error {
 		&mapstructure.DecoderConfig{
 			DecodeHook: mapstructure.ComposeDecodeHookFunc(
 				mapstructure.StringToTimeDurationHookFunc(),
+				endpoint.DecodeAuthenticationStrategyHookFunc(),
+				extractors.DecodeCompositeExtractStrategyHookFunc(),
+				truststore.DecodeTrustStoreHookFunc(),
 				template.DecodeTemplateHookFunc(),
 			),
 			Result:      output,
@@ -0,0 +1,344 @@
+package mutators
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	componentMethod        = "@method"
+	componentTargetURI     = "@target-uri"
+	componentAuthority     = "@authority"
+	componentScheme        = "@scheme"
+	componentRequestTarget = "@request-target"
+	componentPath          = "@path"
+	componentQuery         = "@query"
+	componentContentDigest = "content-digest"
+
+	defaultSignatureLabel = "sig"
+	defaultSignatureTag   = "heimdall"
+)
+
+var (
+	// see https://www.rfc-editor.org/rfc/rfc8941#section-3.1.2
+	signatureLabelPattern = regexp.MustCompile(`^[a-z*][a-z0-9_\-.*]*$`)
+	// see https://www.rfc-editor.org/rfc/rfc9110#section-5.1
+	fieldNamePattern = regexp.MustCompile("^[a-z0-9!#$%&'*+\\-.^_`|~]+$")
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerMutatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Mutator, error) {
+			if typ != config.POTHTTPMessageSignature {
+				return false, nil, nil
+			}
+
+			mut, err := newHTTPMessageSignatureMutator(id, conf)
+
+			return true, mut, err
+		})
+}
+
+// httpMessageSignatureMutator signs the request according to RFC 9421 (HTTP Message Signatures).
+type httpMessageSignatureMutator struct {
+	id         string
+	keyID      string
+	label      string
+	tag        string
+	components []string
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+func newHTTPMessageSignatureMutator(id string, rawConfig map[string]any) (*httpMessageSignatureMutator, error) {
+	type Config struct {
+		KeyID      string         `mapstructure:"key_id"`
+		Label      string         `mapstructure:"label"`
+		Tag        *string        `mapstructure:"tag"`
+		Components []string       `mapstructure:"components"`
+		TTL        *time.Duration `mapstructure:"ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal http message signature mutator config").
+			CausedBy(err)
+	}
+
+	mut := &httpMessageSignatureMutator{
+		id:    id,
+		keyID: conf.KeyID,
+		label: x.IfThenElse(len(conf.Label) != 0, conf.Label, defaultSignatureLabel),
+		tag: x.IfThenElseExec(conf.Tag != nil,
+			func() string { return *conf.Tag },
+			func() string { return defaultSignatureTag }),
+		components: x.IfThenElse(len(conf.Components) != 0,
+			conf.Components, []string{componentMethod, componentTargetURI}),
+		ttl: x.IfThenElseExec(conf.TTL != nil,
+			func() time.Duration { return *conf.TTL },
+			func() time.Duration { return 0 }),
+		now: time.Now,
+	}
+
+	if !signatureLabelPattern.MatchString(mut.label) {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"'%s' is not a valid signature label", mut.label)
+	}
+
+	if err := validateSignatureComponents(mut.components); err != nil {
+		return nil, err
+	}
+
+	if mut.ttl < 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "configured ttl must not be negative")
+	}
+
+	return mut, nil
+}
+
+func validateSignatureComponents(components []string) error {
+	known := make(map[string]bool, len(components))
+
+	for _, component := range components {
+		if known[component] {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"component '%s' is configured more than once", component)
+		}
+
+		known[component] = true
+
+		switch component {
+		case componentMethod, componentTargetURI, componentAuthority, componentScheme,
+			componentRequestTarget, componentPath, componentQuery:
+		default:
+			if !fieldNamePattern.MatchString(component) {
+				return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+					"unsupported component '%s'; only derived request components and lower case "+
+						"header names are supported", component)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (m *httpMessageSignatureMutator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Mutating using http message signature mutator")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal,
+				"failed to execute http message signature mutator due to 'nil' subject").
+			WithErrorContext(m)
+	}
+
+	var base strings.Builder
+
+	for _, component := range m.components {
+		value, err := m.componentValue(ctx, component)
+		if err != nil {
+			return err
+		}
+
+		base.WriteString(strconv.Quote(component))
+		base.WriteString(": ")
+		base.WriteString(value)
+		base.WriteString("\n")
+	}
+
+	var params string
+
+	// keyid and alg are part of the signature parameters, which are signed as well. Both are only
+	// known to the signer, as the signing key may change during key rotation.
+	sig, err := ctx.Signer().SignData(m.keyID, func(keyID, algorithm string) []byte {
+		params = m.signatureParams(keyID, httpSignatureAlgorithm(algorithm))
+
+		return []byte(base.String() + `"@signature-params": ` + params)
+	})
+	if err != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to sign request").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
+	ctx.AddHeaderForUpstream("Signature-Input", m.label+"="+params)
+	ctx.AddHeaderForUpstream("Signature",
+		m.label+"=:"+base64.StdEncoding.EncodeToString(sig.Value)+":")
+
+	return nil
+}
+
+func (m *httpMessageSignatureMutator) signatureParams(keyID, alg string) string {
+	var params strings.Builder
+
+	params.WriteString("(")
+
+	for idx, component := range m.components {
+		if idx != 0 {
+			params.WriteString(" ")
+		}
+
+		params.WriteString(strconv.Quote(component))
+	}
+
+	created := m.now().Unix()
+
+	params.WriteString(");created=")
+	params.WriteString(strconv.FormatInt(created, 10))
+
+	if m.ttl > 0 {
+		params.WriteString(";expires=")
+		params.WriteString(strconv.FormatInt(created+int64(m.ttl.Seconds()), 10))
+	}
+
+	if len(keyID) != 0 {
+		params.WriteString(";keyid=")
+		params.WriteString(strconv.Quote(keyID))
+	}
+
+	if len(alg) != 0 {
+		params.WriteString(";alg=")
+		params.WriteString(strconv.Quote(alg))
+	}
+
+	if len(m.tag) != 0 {
+		params.WriteString(";tag=")
+		params.WriteString(strconv.Quote(m.tag))
+	}
+
+	return params.String()
+}
+
+func (m *httpMessageSignatureMutator) componentValue(ctx heimdall.Context, component string) (string, error) {
+	reqURL := ctx.RequestURL()
+
+	switch component {
+	case componentMethod:
+		return strings.ToUpper(ctx.RequestMethod()), nil
+	case componentTargetURI:
+		return reqURL.String(), nil
+	case componentAuthority:
+		return authority(reqURL), nil
+	case componentScheme:
+		return strings.ToLower(reqURL.Scheme), nil
+	case componentRequestTarget:
+		return reqURL.RequestURI(), nil
+	case componentPath:
+		return x.IfThenElse(len(reqURL.EscapedPath()) != 0, reqURL.EscapedPath(), "/"), nil
+	case componentQuery:
+		return "?" + reqURL.RawQuery, nil
+	case componentContentDigest:
+		// the digest is always calculated from the body, as a digest sent by the client cannot be trusted.
+		// The forwarded header is overwritten with it. See https://www.rfc-editor.org/rfc/rfc9530
+		digest := sha256.Sum256(ctx.RequestBody())
+		value := "sha-256=:" + base64.StdEncoding.EncodeToString(digest[:]) + ":"
+
+		ctx.AddHeaderForUpstream("Content-Digest", value)
+
+		return value, nil
+	default:
+		value := strings.TrimSpace(ctx.RequestHeader(component))
+		if len(value) == 0 {
+			return "", errorchain.
+				NewWithMessagef(heimdall.ErrArgument, "request does not contain the '%s' header to sign", component).
+				WithErrorContext(m)
+		}
+
+		return value, nil
+	}
+}
+
+func authority(reqURL *url.URL) string {
+	host := strings.ToLower(reqURL.Hostname())
+	port := reqURL.Port()
+
+	if len(port) == 0 || (reqURL.Scheme == "https" && port == "443") || (reqURL.Scheme == "http" && port == "80") {
+		return host
+	}
+
+	return fmt.Sprintf("%s:%s", host, port)
+}
+
+// httpSignatureAlgorithm maps the JOSE algorithm of the signing key to the algorithm name registered
+// for HTTP Message Signatures. An empty string is returned if there is no such algorithm, like for PS256,
+// which is used for RSA keys with 2048 bit by default. In that case the alg parameter is omitted and the
+// verifier has to derive the algorithm from the key. The keys are not known to the mutator at the time
+// it is configured and may change on key rotation. That is why such keys cannot be rejected upfront.
+func httpSignatureAlgorithm(alg string) string {
+	switch jose.SignatureAlgorithm(alg) { // nolint: exhaustive
+	case jose.RS256:
+		return "rsa-v1_5-sha256"
+	case jose.PS512:
+		return "rsa-pss-sha512"
+	case jose.ES256:
+		return "ecdsa-p256-sha256"
+	case jose.ES384:
+		return "ecdsa-p384-sha384"
+	case jose.EdDSA:
+		return "ed25519"
+	default:
+		return ""
+	}
+}
+
+func (m *httpMessageSignatureMutator) WithConfig(rawConfig map[string]any) (Mutator, error) {
+	if len(rawConfig) == 0 {
+		return m, nil
+	}
+
+	type Config struct {
+		Components []string       `mapstructure:"components"`
+		TTL        *time.Duration `mapstructure:"ttl"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal http message signature mutator config").
+			CausedBy(err)
+	}
+
+	if err := validateSignatureComponents(conf.Components); err != nil {
+		return nil, err
+	}
+
+	if conf.TTL != nil && *conf.TTL < 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "configured ttl must not be negative")
+	}
+
+	return &httpMessageSignatureMutator{
+		id:         m.id,
+		keyID:      m.keyID,
+		label:      m.label,
+		tag:        m.tag,
+		components: x.IfThenElse(len(conf.Components) != 0, conf.Components, m.components),
+		ttl: x.IfThenElseExec(conf.TTL != nil,
+			func() time.Duration { return *conf.TTL },
+			func() time.Duration { return m.ttl }),
+		now: m.now,
+	}, nil
+}
+
+func (m *httpMessageSignatureMutator) HandlerID() string {
+	return m.id
+}
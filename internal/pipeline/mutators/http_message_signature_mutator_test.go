@@ -0,0 +1,476 @@
+package mutators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/keystore"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/signer"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/x"
+)
+
+func TestCreateHTTPMessageSignatureMutator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, mut *httpMessageSignatureMutator)
+	}{
+		{
+			uc: "without configuration",
+			id: "msig",
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, mut)
+				assert.Equal(t, "msig", mut.HandlerID())
+				assert.Empty(t, mut.keyID)
+				assert.Equal(t, "sig", mut.label)
+				assert.Equal(t, "heimdall", mut.tag)
+				assert.Equal(t, []string{"@method", "@target-uri"}, mut.components)
+				assert.Equal(t, time.Duration(0), mut.ttl)
+			},
+		},
+		{
+			uc: "with full configuration",
+			config: []byte(`
+key_id: foo
+label: heimdall
+tag: ""
+components: ["@method", "@authority", "@path", "content-digest", "x-request-id"]
+ttl: 1m
+`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, mut)
+				assert.Equal(t, "foo", mut.keyID)
+				assert.Equal(t, "heimdall", mut.label)
+				assert.Empty(t, mut.tag)
+				assert.Equal(t, []string{"@method", "@authority", "@path", "content-digest", "x-request-id"},
+					mut.components)
+				assert.Equal(t, time.Minute, mut.ttl)
+			},
+		},
+		{
+			uc:     "with invalid label",
+			config: []byte(`label: Sig 1`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not a valid signature label")
+			},
+		},
+		{
+			uc:     "with unsupported derived component",
+			config: []byte(`components: ["@status"]`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported component '@status'")
+			},
+		},
+		{
+			uc:     "with upper case header name",
+			config: []byte(`components: ["X-Request-ID"]`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported component")
+			},
+		},
+		{
+			uc:     "with duplicate component",
+			config: []byte(`components: ["@method", "@method"]`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "more than once")
+			},
+		},
+		{
+			uc:     "with unknown entries in configuration",
+			config: []byte(`foo: bar`),
+			assert: func(t *testing.T, err error, mut *httpMessageSignatureMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			mutator, err := newHTTPMessageSignatureMutator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, mutator)
+		})
+	}
+}
+
+func TestCreateHTTPMessageSignatureMutatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *httpMessageSignatureMutator, configured Mutator)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *httpMessageSignatureMutator, configured Mutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc: "with components and ttl",
+			config: []byte(`
+components: ["@method", "x-request-id"]
+ttl: 10s
+`),
+			assert: func(t *testing.T, err error, prototype *httpMessageSignatureMutator, configured Mutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				mut, ok := configured.(*httpMessageSignatureMutator)
+				require.True(t, ok)
+				assert.Equal(t, prototype.HandlerID(), mut.HandlerID())
+				assert.Equal(t, prototype.keyID, mut.keyID)
+				assert.Equal(t, prototype.label, mut.label)
+				assert.Equal(t, prototype.tag, mut.tag)
+				assert.Equal(t, []string{"@method", "x-request-id"}, mut.components)
+				assert.Equal(t, 10*time.Second, mut.ttl)
+			},
+		},
+		{
+			uc:     "with invalid components",
+			config: []byte(`components: ["@foo"]`),
+			assert: func(t *testing.T, err error, prototype *httpMessageSignatureMutator, configured Mutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+		{
+			uc:     "with not overridable properties",
+			config: []byte(`key_id: bar`),
+			assert: func(t *testing.T, err error, prototype *httpMessageSignatureMutator, configured Mutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newHTTPMessageSignatureMutator("msig", nil)
+			require.NoError(t, err)
+
+			// WHEN
+			mut, err := prototype.WithConfig(conf)
+
+			// THEN
+			tc.assert(t, err, prototype, mut)
+		})
+	}
+}
+
+func TestHTTPMessageSignatureMutatorExecute(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ks, err := keystore.NewKeyStoreFromKey(privKey)
+	require.NoError(t, err)
+
+	jwtSigner, err := signer.NewJWTSigner(ks, config.SignerConfig{Name: "heimdall"}, log.Logger)
+	require.NoError(t, err)
+
+	// RSA keys with 2048 bit are used with PS256, which is not registered for HTTP Message Signatures
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	rsaKS, err := keystore.NewKeyStoreFromKey(rsaKey)
+	require.NoError(t, err)
+
+	rsaSigner, err := signer.NewJWTSigner(rsaKS, config.SignerConfig{Name: "heimdall"}, log.Logger)
+	require.NoError(t, err)
+
+	keyID := ks.Entries()[0].KeyID
+	now := time.Unix(1618884473, 0)
+	reqURL, err := url.Parse("https://Example.com:443/foo/bar?baz=zab")
+	require.NoError(t, err)
+
+	body := []byte(`{"hello": "world"}`)
+	digest := sha256.Sum256(body)
+	contentDigest := "sha-256=:" + base64.StdEncoding.EncodeToString(digest[:]) + ":"
+
+	verify := func(t *testing.T, signatureInput, signature, base string) {
+		t.Helper()
+
+		require.True(t, strings.HasPrefix(signature, "sig=:"))
+		require.True(t, strings.HasSuffix(signature, ":"))
+
+		rawSig, err := base64.StdEncoding.DecodeString(
+			strings.TrimSuffix(strings.TrimPrefix(signature, "sig=:"), ":"))
+		require.NoError(t, err)
+		require.Len(t, rawSig, 64)
+
+		hash := sha256.Sum256([]byte(base + `"@signature-params": ` + strings.TrimPrefix(signatureInput, "sig=")))
+		assert.True(t, ecdsa.Verify(&privKey.PublicKey, hash[:],
+			new(big.Int).SetBytes(rawSig[:32]), new(big.Int).SetBytes(rawSig[32:])))
+	}
+
+	for _, tc := range []struct {
+		uc             string
+		config         []byte
+		subject        *subject.Subject
+		signer         heimdall.JWTSigner
+		configureMocks func(t *testing.T, ctx *heimdallmocks.MockContext)
+		assert         func(t *testing.T, err error, headers map[string]string)
+	}{
+		{
+			uc: "with nil subject",
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "msig", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "with missing header to sign",
+			config:  []byte(`components: ["@method", "x-request-id"]`),
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestMethod").Return("post")
+				ctx.On("RequestHeader", "x-request-id").Return("")
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "x-request-id")
+			},
+		},
+		{
+			uc:      "with failing signer",
+			subject: &subject.Subject{ID: "foo"},
+			signer: func() heimdall.JWTSigner {
+				sig := &heimdallmocks.MockJWTSigner{}
+				sig.On("SignData", "", mock.Anything).Return(nil, errors.New("test error"))
+
+				return sig
+			}(),
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestMethod").Return("get")
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to sign request")
+			},
+		},
+		{
+			uc:      "with default configuration",
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestMethod").Return("get")
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, headers, 2)
+
+				assert.Equal(t,
+					`sig=("@method" "@target-uri");created=1618884473;keyid="`+keyID+
+						`";alg="ecdsa-p256-sha256";tag="heimdall"`,
+					headers["Signature-Input"])
+
+				verify(t, headers["Signature-Input"], headers["Signature"],
+					`"@method": GET`+"\n"+
+						`"@target-uri": https://Example.com:443/foo/bar?baz=zab`+"\n")
+			},
+		},
+		{
+			uc:      "with key, which algorithm is not registered for http message signatures",
+			subject: &subject.Subject{ID: "foo"},
+			signer:  rsaSigner,
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestMethod").Return("get")
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, headers, 2)
+
+				assert.Equal(t,
+					`sig=("@method" "@target-uri");created=1618884473;keyid="`+rsaKS.Entries()[0].KeyID+
+						`";tag="heimdall"`,
+					headers["Signature-Input"])
+			},
+		},
+		{
+			uc: "with derived components, headers, content digest and ttl",
+			config: []byte(`
+components: ["@authority", "@scheme", "@request-target", "@path", "@query", "x-request-id", "content-digest"]
+ttl: 1m
+tag: ""
+`),
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestHeader", "x-request-id").Return(" 1234 ")
+				ctx.On("RequestBody").Return(body)
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, headers, 3)
+
+				assert.Equal(t, contentDigest, headers["Content-Digest"])
+				assert.Equal(t,
+					`sig=("@authority" "@scheme" "@request-target" "@path" "@query" "x-request-id" `+
+						`"content-digest");created=1618884473;expires=1618884533;keyid="`+keyID+
+						`";alg="ecdsa-p256-sha256"`,
+					headers["Signature-Input"])
+
+				verify(t, headers["Signature-Input"], headers["Signature"],
+					`"@authority": example.com`+"\n"+
+						`"@scheme": https`+"\n"+
+						`"@request-target": /foo/bar?baz=zab`+"\n"+
+						`"@path": /foo/bar`+"\n"+
+						`"@query": ?baz=zab`+"\n"+
+						`"x-request-id": 1234`+"\n"+
+						`"content-digest": `+contentDigest+"\n")
+			},
+		},
+		{
+			uc:      "with wrong content digest present in the request",
+			config:  []byte(`components: ["content-digest"]`),
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext) {
+				t.Helper()
+
+				ctx.On("RequestURL").Return(reqURL)
+				ctx.On("RequestHeader", "content-digest").Return("sha-256=:foo:").Maybe()
+				ctx.On("RequestBody").Return(body)
+			},
+			assert: func(t *testing.T, err error, headers map[string]string) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.Len(t, headers, 3)
+				assert.Equal(t, contentDigest, headers["Content-Digest"])
+
+				verify(t, headers["Signature-Input"], headers["Signature"],
+					`"content-digest": `+contentDigest+"\n")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			configureMocks := x.IfThenElse(tc.configureMocks != nil,
+				tc.configureMocks,
+				func(t *testing.T, _ *heimdallmocks.MockContext) { t.Helper() })
+
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			mut, err := newHTTPMessageSignatureMutator("msig", conf)
+			require.NoError(t, err)
+
+			mut.now = func() time.Time { return now }
+
+			headers := make(map[string]string)
+
+			mctx := &heimdallmocks.MockContext{}
+			mctx.On("AppContext").Return(context.Background())
+			mctx.On("Signer").Maybe().Return(x.IfThenElse(tc.signer != nil, tc.signer, jwtSigner))
+			mctx.On("AddHeaderForUpstream", mock.Anything, mock.Anything).Maybe().
+				Run(func(args mock.Arguments) { headers[args.String(0)] = args.String(1) })
+
+			configureMocks(t, mctx)
+
+			// WHEN
+			err = mut.Execute(mctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err, headers)
+
+			mctx.AssertExpectations(t)
+		})
+	}
+}
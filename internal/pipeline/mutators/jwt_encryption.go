@@ -0,0 +1,419 @@
+package mutators
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/truststore"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+	"github.com/dadrus/heimdall/internal/x/pkix"
+)
+
+const defaultRecipientKeyTTL = 10 * time.Minute
+
+type jwtEncryptionConfig struct {
+	KeyManagementAlgorithm     string                `mapstructure:"key_management_algorithm"`
+	ContentEncryptionAlgorithm string                `mapstructure:"content_encryption_algorithm"`
+	JWKSEndpoint               *endpoint.Endpoint    `mapstructure:"jwks_endpoint"`
+	KeyFile                    string                `mapstructure:"key_file"`
+	KeyID                      string                `mapstructure:"key_id"`
+	TrustStore                 truststore.TrustStore `mapstructure:"trust_store"`
+}
+
+// jwtEncryption wraps the signed JWT into a JWE (nested JWT) encrypted for the configured recipient.
+type jwtEncryption struct {
+	keyAlg     jose.KeyAlgorithm
+	encAlg     jose.ContentEncryption
+	e          *endpoint.Endpoint
+	keyID      string
+	key        *jose.JSONWebKey
+	trustStore truststore.TrustStore
+}
+
+func newJWTEncryption(conf *jwtEncryptionConfig) (*jwtEncryption, error) {
+	if conf.JWKSEndpoint != nil && len(conf.KeyFile) != 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"JWT encryption can either use a jwks_endpoint or a key_file, not both")
+	}
+
+	keyAlg, err := keyManagementAlgorithm(conf.KeyManagementAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	encAlg, err := contentEncryptionAlgorithm(conf.ContentEncryptionAlgorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	enc := &jwtEncryption{
+		keyAlg:     keyAlg,
+		encAlg:     encAlg,
+		keyID:      conf.KeyID,
+		trustStore: conf.TrustStore,
+	}
+
+	switch {
+	case conf.JWKSEndpoint != nil:
+		if err = conf.JWKSEndpoint.Validate(); err != nil {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrConfiguration, "failed to validate jwks_endpoint configuration").
+				CausedBy(err)
+		}
+
+		if conf.JWKSEndpoint.Headers == nil {
+			conf.JWKSEndpoint.Headers = make(map[string]string)
+		}
+
+		if _, ok := conf.JWKSEndpoint.Headers["Accept-Type"]; !ok {
+			conf.JWKSEndpoint.Headers["Accept-Type"] = "application/json"
+		}
+
+		if len(conf.JWKSEndpoint.Method) == 0 {
+			conf.JWKSEndpoint.Method = http.MethodGet
+		}
+
+		enc.e = conf.JWKSEndpoint
+	case len(conf.KeyFile) != 0:
+		if enc.key, err = readRecipientKey(conf.KeyFile, conf.KeyID); err != nil {
+			return nil, err
+		}
+
+		if _, err = enc.keyAlgorithm(enc.key); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"JWT encryption requires either a jwks_endpoint or a key_file")
+	}
+
+	return enc, nil
+}
+
+func keyManagementAlgorithm(alg string) (jose.KeyAlgorithm, error) {
+	switch jose.KeyAlgorithm(alg) {
+	case "":
+		return "", nil
+	case jose.RSA_OAEP, jose.RSA_OAEP_256, jose.ECDH_ES, jose.ECDH_ES_A128KW, jose.ECDH_ES_A192KW, jose.ECDH_ES_A256KW:
+		return jose.KeyAlgorithm(alg), nil
+	default:
+		return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported key management algorithm: %s", alg)
+	}
+}
+
+func contentEncryptionAlgorithm(alg string) (jose.ContentEncryption, error) {
+	switch jose.ContentEncryption(alg) {
+	case "":
+		return jose.A256GCM, nil
+	case jose.A128GCM, jose.A192GCM, jose.A256GCM,
+		jose.A128CBC_HS256, jose.A192CBC_HS384, jose.A256CBC_HS512:
+		return jose.ContentEncryption(alg), nil
+	default:
+		return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported content encryption algorithm: %s", alg)
+	}
+}
+
+func readRecipientKey(path, keyID string) (*jose.JSONWebKey, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"failed to read %s", path).CausedBy(err)
+	}
+
+	var key *jose.JSONWebKey
+
+	err = pkix.ReadPEM(contents, func(idx int, blockType string, _ map[string]string, content []byte) error {
+		if key != nil {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"%s must contain exactly one public key or certificate", path)
+		}
+
+		var (
+			pubKey any
+			certs  []*x509.Certificate
+			err    error
+		)
+
+		switch blockType {
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+
+			cert, err = x509.ParseCertificate(content)
+			if err == nil {
+				pubKey = cert.PublicKey
+				certs = []*x509.Certificate{cert}
+			}
+		case "PUBLIC KEY", "ECDSA PUBLIC KEY":
+			pubKey, err = x509.ParsePKIXPublicKey(content)
+		case "RSA PUBLIC KEY":
+			pubKey, err = x509.ParsePKCS1PublicKey(content)
+		default:
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"unsupported entry '%s' in %s", blockType, path)
+		}
+
+		if err != nil {
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"failed to parse %d entry in %s", idx, path).CausedBy(err)
+		}
+
+		key = &jose.JSONWebKey{Key: pubKey, KeyID: keyID, Certificates: certs, Use: "enc"}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+// keyAlgorithm returns the key management algorithm to be used with the given recipient key.
+func (e *jwtEncryption) keyAlgorithm(key *jose.JSONWebKey) (jose.KeyAlgorithm, error) {
+	alg := e.keyAlg
+	if len(alg) == 0 && len(key.Algorithm) != 0 {
+		alg = jose.KeyAlgorithm(key.Algorithm)
+	}
+
+	if len(e.keyAlg) != 0 && len(key.Algorithm) != 0 && key.Algorithm != string(e.keyAlg) {
+		return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"key %s is bound to %s algorithm, but %s is configured", key.KeyID, key.Algorithm, e.keyAlg)
+	}
+
+	switch key.Key.(type) {
+	case *rsa.PublicKey:
+		switch alg {
+		case "":
+			return jose.RSA_OAEP_256, nil
+		case jose.RSA_OAEP, jose.RSA_OAEP_256:
+			return alg, nil
+		}
+	case *ecdsa.PublicKey:
+		switch alg {
+		case "":
+			return jose.ECDH_ES, nil
+		case jose.ECDH_ES, jose.ECDH_ES_A128KW, jose.ECDH_ES_A192KW, jose.ECDH_ES_A256KW:
+			return alg, nil
+		}
+	default:
+		return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+			"unsupported recipient key type %T", key.Key)
+	}
+
+	return "", errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+		"algorithm %s cannot be used with a %T recipient key", alg, key.Key)
+}
+
+// Hash returns a value identifying the encryption configuration. It is used as part of the cache key.
+func (e *jwtEncryption) Hash() string {
+	hash := sha256.New()
+	hash.Write([]byte(e.keyAlg))
+	hash.Write([]byte(e.encAlg))
+	hash.Write([]byte(e.keyID))
+
+	if e.e != nil {
+		hash.Write([]byte(e.e.Hash()))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (e *jwtEncryption) recipientKey(ctx heimdall.Context) (*jose.JSONWebKey, error) {
+	if e.key != nil {
+		return e.key, nil
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceMutator)
+	logger := zerolog.Ctx(ctx.AppContext())
+	cacheKey := e.Hash()
+
+	if entry := cch.Get(cacheKey); entry != nil {
+		if jwk, ok := entry.(*jose.JSONWebKey); ok {
+			logger.Debug().Msg("Reusing recipient JWK from cache")
+
+			return jwk, nil
+		}
+
+		logger.Warn().Msg("Wrong object type from cache")
+		cch.Delete(cacheKey)
+	}
+
+	jwks, err := e.fetchJWKS(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	jwk, err := e.selectKey(jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = e.validateJWK(jwk); err != nil {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrInternal, "recipient JWK %s is invalid", jwk.KeyID).
+			CausedBy(err)
+	}
+
+	if ttl := recipientKeyTTL(jwk); ttl > 0 {
+		cch.Set(cacheKey, jwk, ttl)
+	}
+
+	return jwk, nil
+}
+
+func (e *jwtEncryption) selectKey(jwks *jose.JSONWebKeySet) (*jose.JSONWebKey, error) {
+	if len(e.keyID) != 0 {
+		keys := jwks.Key(e.keyID)
+		if len(keys) != 1 {
+			return nil, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"no (unique) recipient key found for the key_id='%s'", e.keyID)
+		}
+
+		if _, err := e.keyAlgorithm(&keys[0]); err != nil {
+			return nil, err
+		}
+
+		return &keys[0], nil
+	}
+
+	for idx := range jwks.Keys {
+		jwk := &jwks.Keys[idx]
+
+		if len(jwk.Use) != 0 && jwk.Use != "enc" {
+			continue
+		}
+
+		if _, err := e.keyAlgorithm(jwk); err == nil {
+			return jwk, nil
+		}
+	}
+
+	return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+		"none of the keys received from the JWKS endpoint can be used for encryption")
+}
+
+func (e *jwtEncryption) fetchJWKS(ctx heimdall.Context) (*jose.JSONWebKeySet, error) {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	logger.Debug().Msg("Retrieving recipient JWKS from configured endpoint")
+
+	req, err := e.e.CreateRequest(ctx.AppContext(), nil, nil)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed creating request").
+			CausedBy(err)
+	}
+
+	resp, err := e.e.CreateClient(req.URL.Hostname()).Do(req)
+	if err != nil {
+		var clientErr *url.Error
+		if errors.As(err, &clientErr) && clientErr.Timeout() {
+			return nil, errorchain.
+				NewWithMessage(heimdall.ErrCommunicationTimeout, "request to JWKS endpoint timed out").
+				CausedBy(err)
+		}
+
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "request to JWKS endpoint failed").
+			CausedBy(err)
+	}
+
+	defer resp.Body.Close()
+
+	if !(resp.StatusCode >= http.StatusOK && resp.StatusCode < http.StatusMultipleChoices) {
+		return nil, errorchain.
+			NewWithMessagef(heimdall.ErrCommunication, "unexpected response. code: %v", resp.StatusCode)
+	}
+
+	rawData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to read response").
+			CausedBy(err)
+	}
+
+	var jwks jose.JSONWebKeySet
+	if err = json.Unmarshal(rawData, &jwks); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to unmarshal received jwks").
+			CausedBy(err)
+	}
+
+	return &jwks, nil
+}
+
+func (e *jwtEncryption) validateJWK(jwk *jose.JSONWebKey) error {
+	if len(jwk.Certificates) == 0 {
+		// with a configured trust store, keys which cannot be verified against it are not trusted
+		if len(e.trustStore) != 0 {
+			return errorchain.NewWithMessage(pkix.ErrCertificateValidation,
+				"no certificate chain present, which is required to verify the key against the trust store")
+		}
+
+		return nil
+	}
+
+	return pkix.ValidateCertificate(jwk.Certificates[0],
+		pkix.WithIntermediateCACertificates(jwk.Certificates[1:]),
+		x.IfThenElseExec(len(e.trustStore) == 0,
+			pkix.WithSystemTrustStore,
+			func() pkix.ValidationOption { return pkix.WithRootCACertificates(e.trustStore) }),
+	)
+}
+
+func recipientKeyTTL(jwk *jose.JSONWebKey) time.Duration {
+	// timeLeeway ensures the certificate of the JWK is still valid when used from cache
+	const timeLeeway = 10 * time.Second
+
+	if len(jwk.Certificates) == 0 {
+		return defaultRecipientKeyTTL
+	}
+
+	expiresIn := time.Until(jwk.Certificates[0].NotAfter) - timeLeeway
+
+	return x.IfThenElse(expiresIn < defaultRecipientKeyTTL, expiresIn, defaultRecipientKeyTTL)
+}
+
+func (e *jwtEncryption) encrypt(key *jose.JSONWebKey, token string) (string, error) {
+	keyAlg, err := e.keyAlgorithm(key)
+	if err != nil {
+		return "", err
+	}
+
+	encrypter, err := jose.NewEncrypter(e.encAlg,
+		jose.Recipient{Algorithm: keyAlg, Key: key.Key, KeyID: key.KeyID},
+		(&jose.EncrypterOptions{}).WithType("JWT").WithContentType("JWT"))
+	if err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to create JWE encrypter").
+			CausedBy(err)
+	}
+
+	jwe, err := encrypter.Encrypt([]byte(token))
+	if err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to encrypt JWT").
+			CausedBy(err)
+	}
+
+	return jwe.CompactSerialize()
+}
@@ -0,0 +1,417 @@
+package mutators
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/memory"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+	"github.com/dadrus/heimdall/internal/truststore"
+)
+
+func writeTempPEM(t *testing.T, contents []byte) string {
+	t.Helper()
+
+	file, err := os.CreateTemp("", "jwe-recipient-*")
+	require.NoError(t, err)
+
+	t.Cleanup(func() { os.Remove(file.Name()) })
+
+	_, err = file.Write(contents)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	return file.Name()
+}
+
+func TestNewJWTEncryption(t *testing.T) {
+	t.Parallel()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	rawRSAPubKey, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	rsaKeyFile := writeTempPEM(t, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rawRSAPubKey}))
+
+	ecCert, err := testsupport.NewCertificateBuilder(
+		testsupport.WithValidity(time.Now(), 10*time.Hour),
+		testsupport.WithSerialNumber(big.NewInt(1)),
+		testsupport.WithSubjectPubKey(&ecKey.PublicKey, x509.ECDSAWithSHA256),
+		testsupport.WithSelfSigned(),
+		testsupport.WithSignaturePrivKey(ecKey),
+	).Build()
+	require.NoError(t, err)
+
+	ecPEM, err := testsupport.BuildPEM(testsupport.WithX509Certificate(ecCert))
+	require.NoError(t, err)
+
+	ecCertFile := writeTempPEM(t, ecPEM)
+
+	twoKeysPEM, err := testsupport.BuildPEM(
+		testsupport.WithX509Certificate(ecCert),
+		testsupport.WithECDSAPublicKey(&ecKey.PublicKey),
+	)
+	require.NoError(t, err)
+
+	twoKeysFile := writeTempPEM(t, twoKeysPEM)
+
+	for _, tc := range []struct {
+		uc     string
+		conf   jwtEncryptionConfig
+		assert func(t *testing.T, err error, enc *jwtEncryption)
+	}{
+		{
+			uc: "without recipient key source",
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires either")
+			},
+		},
+		{
+			uc: "with jwks_endpoint and key_file",
+			conf: jwtEncryptionConfig{
+				JWKSEndpoint: &endpoint.Endpoint{URL: "http://foo.bar"},
+				KeyFile:      rsaKeyFile,
+			},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not both")
+			},
+		},
+		{
+			uc:   "with unsupported key management algorithm",
+			conf: jwtEncryptionConfig{KeyFile: rsaKeyFile, KeyManagementAlgorithm: "RSA1_5"},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported key management algorithm")
+			},
+		},
+		{
+			uc:   "with unsupported content encryption algorithm",
+			conf: jwtEncryptionConfig{KeyFile: rsaKeyFile, ContentEncryptionAlgorithm: "foo"},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "unsupported content encryption algorithm")
+			},
+		},
+		{
+			uc:   "with not existing key file",
+			conf: jwtEncryptionConfig{KeyFile: "/does/not/exist.pem"},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to read")
+			},
+		},
+		{
+			uc:   "with key file containing multiple keys",
+			conf: jwtEncryptionConfig{KeyFile: twoKeysFile},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "exactly one")
+			},
+		},
+		{
+			uc:   "with key management algorithm not matching the key type",
+			conf: jwtEncryptionConfig{KeyFile: rsaKeyFile, KeyManagementAlgorithm: "ECDH-ES"},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot be used")
+			},
+		},
+		{
+			uc:   "with rsa public key file and defaults",
+			conf: jwtEncryptionConfig{KeyFile: rsaKeyFile, KeyID: "foo"},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, enc.key)
+				assert.Equal(t, "foo", enc.key.KeyID)
+				assert.Equal(t, &rsaKey.PublicKey, enc.key.Key)
+				assert.Equal(t, jose.A256GCM, enc.encAlg)
+
+				alg, err := enc.keyAlgorithm(enc.key)
+				require.NoError(t, err)
+				assert.Equal(t, jose.RSA_OAEP_256, alg)
+			},
+		},
+		{
+			uc: "with ec certificate file and configured algorithms",
+			conf: jwtEncryptionConfig{
+				KeyFile:                    ecCertFile,
+				KeyManagementAlgorithm:     "ECDH-ES+A256KW",
+				ContentEncryptionAlgorithm: "A128CBC-HS256",
+			},
+			assert: func(t *testing.T, err error, enc *jwtEncryption) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, enc.key)
+				assert.Equal(t, &ecKey.PublicKey, enc.key.Key)
+				assert.Len(t, enc.key.Certificates, 1)
+				assert.Equal(t, jose.A128CBC_HS256, enc.encAlg)
+
+				alg, err := enc.keyAlgorithm(enc.key)
+				require.NoError(t, err)
+				assert.Equal(t, jose.ECDH_ES_A256KW, alg)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			enc, err := newJWTEncryption(&tc.conf)
+
+			// THEN
+			tc.assert(t, err, enc)
+		})
+	}
+}
+
+func TestJWTEncryptionRecipientKeyFromJWKS(t *testing.T) {
+	t.Parallel()
+
+	sigKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	var calls int
+
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+		calls++
+
+		data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{
+			{Key: &sigKey.PublicKey, KeyID: "sig", Use: "sig", Algorithm: "ES256"},
+			{Key: &encKey.PublicKey, KeyID: "enc", Use: "enc"},
+		}})
+		require.NoError(t, err)
+
+		rw.Header().Set("Content-Type", "application/json")
+		_, err = rw.Write(data)
+		require.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+jwks_endpoint:
+  url: ` + srv.URL + `
+`))
+	require.NoError(t, err)
+
+	var encConf jwtEncryptionConfig
+	require.NoError(t, decodeConfig(conf, &encConf))
+
+	enc, err := newJWTEncryption(&encConf)
+	require.NoError(t, err)
+
+	ctx := &heimdallmocks.MockContext{}
+	ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+
+	// WHEN
+	key1, err1 := enc.recipientKey(ctx)
+	key2, err2 := enc.recipientKey(ctx)
+
+	// THEN
+	require.NoError(t, err1)
+	require.NoError(t, err2)
+	assert.Equal(t, "enc", key1.KeyID)
+	assert.Equal(t, key1, key2)
+	assert.Equal(t, 1, calls)
+}
+
+func TestJWTEncryptionRecipientKeyFromJWKSWithTrustStore(t *testing.T) {
+	t.Parallel()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	encKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caCert, err := testsupport.NewCertificateBuilder(
+		testsupport.WithValidity(time.Now(), 10*time.Hour),
+		testsupport.WithSerialNumber(big.NewInt(1)),
+		testsupport.WithSubject(pkix.Name{CommonName: "Test CA"}),
+		testsupport.WithSubjectPubKey(&caKey.PublicKey, x509.ECDSAWithSHA256),
+		testsupport.WithIsCA(),
+		testsupport.WithSelfSigned(),
+		testsupport.WithSignaturePrivKey(caKey),
+	).Build()
+	require.NoError(t, err)
+
+	encCert, err := testsupport.NewCertificateBuilder(
+		testsupport.WithValidity(time.Now(), 10*time.Hour),
+		testsupport.WithSerialNumber(big.NewInt(2)),
+		testsupport.WithSubject(pkix.Name{CommonName: "Recipient"}),
+		testsupport.WithSubjectPubKey(&encKey.PublicKey, x509.ECDSAWithSHA256),
+		testsupport.WithIssuer(caKey, caCert),
+	).Build()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		jwk    jose.JSONWebKey
+		assert func(t *testing.T, err error, key *jose.JSONWebKey)
+	}{
+		{
+			uc:  "key without certificate chain",
+			jwk: jose.JSONWebKey{Key: &encKey.PublicKey, KeyID: "enc", Use: "enc"},
+			assert: func(t *testing.T, err error, key *jose.JSONWebKey) {
+				t.Helper()
+
+				require.Error(t, err)
+				require.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "no certificate chain")
+			},
+		},
+		{
+			uc: "key with certificate chain leading to the trust store",
+			jwk: jose.JSONWebKey{
+				Key: &encKey.PublicKey, KeyID: "enc", Use: "enc",
+				Certificates: []*x509.Certificate{encCert},
+			},
+			assert: func(t *testing.T, err error, key *jose.JSONWebKey) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "enc", key.KeyID)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, _ *http.Request) {
+				data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{tc.jwk}})
+				require.NoError(t, err)
+
+				rw.Header().Set("Content-Type", "application/json")
+				_, err = rw.Write(data)
+				require.NoError(t, err)
+			}))
+			defer srv.Close()
+
+			enc, err := newJWTEncryption(&jwtEncryptionConfig{
+				JWKSEndpoint: &endpoint.Endpoint{URL: srv.URL},
+				TrustStore:   truststore.TrustStore{caCert},
+			})
+			require.NoError(t, err)
+
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+
+			// WHEN
+			key, err := enc.recipientKey(ctx)
+
+			// THEN
+			tc.assert(t, err, key)
+		})
+	}
+}
+
+func TestJWTMutatorExecuteWithEncryption(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	privKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(testsupport.WithECDSAPublicKey(&privKey.PublicKey))
+	require.NoError(t, err)
+
+	conf, err := testsupport.DecodeTestConfig([]byte(`
+encryption:
+  key_file: ` + writeTempPEM(t, pemBytes) + `
+  key_id: recipient
+  key_management_algorithm: ECDH-ES+A128KW
+`))
+	require.NoError(t, err)
+
+	mut, err := newJWTMutator("jmut", conf)
+	require.NoError(t, err)
+
+	sub := &subject.Subject{ID: "foo"}
+	signer := &heimdallmocks.MockJWTSigner{}
+	signer.On("Hash").Return("foobar")
+	signer.On("Sign", sub.ID, defaultJWTTTL, map[string]any{}, heimdall.JWTOptions{}).
+		Return("header.payload.signature", nil)
+
+	var header string
+
+	ctx := &heimdallmocks.MockContext{}
+	ctx.On("AppContext").Return(cache.WithContext(context.Background(), memory.New()))
+	ctx.On("Signer").Return(signer)
+	ctx.On("AddHeaderForUpstream", "Authorization", mock.Anything).
+		Run(func(args mock.Arguments) { header = args.String(1) })
+
+	// WHEN
+	err = mut.Execute(ctx, sub)
+
+	// THEN
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(header, "Bearer "))
+
+	jwe, err := jose.ParseEncrypted(strings.TrimPrefix(header, "Bearer "))
+	require.NoError(t, err)
+
+	assert.Equal(t, "recipient", jwe.Header.KeyID)
+	assert.Equal(t, string(jose.ECDH_ES_A128KW), jwe.Header.Algorithm)
+	assert.Equal(t, "JWT", jwe.Header.ExtraHeaders[jose.HeaderContentType])
+
+	plaintext, err := jwe.Decrypt(privKey)
+	require.NoError(t, err)
+	assert.Equal(t, "header.payload.signature", string(plaintext))
+
+	ctx.AssertExpectations(t)
+	signer.AssertExpectations(t)
+}
@@ -1,14 +1,15 @@
 package mutators
 
 import (
+	"crypto"
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/hex"
-	"fmt"
 	"time"
 
 	"github.com/goccy/go-json"
 	"github.com/rs/zerolog"
+	"gopkg.in/square/go-jose.v2"
 
 	"github.com/dadrus/heimdall/internal/cache"
 	"github.com/dadrus/heimdall/internal/config"
@@ -40,15 +41,32 @@ func init() {
 }
 
 type jwtMutator struct {
-	id     string
-	claims template.Template
-	ttl    time.Duration
+	id       string
+	claims   template.Template
+	ttl      time.Duration
+	audience []string
+	opts     heimdall.JWTOptions
+	header   tokenHeader
+	cookie   *tokenCookie
+	enc      *jwtEncryption
+}
+
+type tokenCookie struct {
+	Name string `mapstructure:"name"`
 }
 
 func newJWTMutator(id string, rawConfig map[string]any) (*jwtMutator, error) {
 	type Config struct {
-		Claims template.Template `mapstructure:"claims"`
-		TTL    *time.Duration    `mapstructure:"ttl"`
+		Claims           template.Template    `mapstructure:"claims"`
+		TTL              *time.Duration       `mapstructure:"ttl"`
+		Audience         []string             `mapstructure:"audience"`
+		OmitClaims       []string             `mapstructure:"omit_claims"`
+		OverrideClaims   bool                 `mapstructure:"override_claims"`
+		JOSEHeaders      map[string]any       `mapstructure:"jose_headers"`
+		IncludeCertChain bool                 `mapstructure:"include_x5c"`
+		Header           *tokenHeader         `mapstructure:"header"`
+		Cookie           *tokenCookie         `mapstructure:"cookie"`
+		Encryption       *jwtEncryptionConfig `mapstructure:"encryption"`
 	}
 
 	var conf Config
@@ -63,15 +81,85 @@ func newJWTMutator(id string, rawConfig map[string]any) (*jwtMutator, error) {
 			NewWithMessage(heimdall.ErrConfiguration, "configured JWT ttl is less than one second")
 	}
 
+	if err := validateJWTOutput(conf.Header, conf.Cookie); err != nil {
+		return nil, err
+	}
+
+	if err := validateJWTOptions(conf.OmitClaims, conf.JOSEHeaders); err != nil {
+		return nil, err
+	}
+
+	var enc *jwtEncryption
+
+	if conf.Encryption != nil {
+		var err error
+
+		if enc, err = newJWTEncryption(conf.Encryption); err != nil {
+			return nil, err
+		}
+	}
+
 	return &jwtMutator{
-		id:     id,
-		claims: conf.Claims,
+		id:       id,
+		claims:   conf.Claims,
+		audience: conf.Audience,
 		ttl: x.IfThenElseExec(conf.TTL != nil,
 			func() time.Duration { return *conf.TTL },
 			func() time.Duration { return defaultJWTTTL }),
+		opts: heimdall.JWTOptions{
+			Headers:          conf.JOSEHeaders,
+			IncludeCertChain: conf.IncludeCertChain,
+			OmitClaims:       conf.OmitClaims,
+			OverrideClaims:   conf.OverrideClaims,
+		},
+		header: x.IfThenElseExec(conf.Header != nil,
+			func() tokenHeader { return *conf.Header },
+			func() tokenHeader { return tokenHeader{Name: "Authorization", Scheme: "Bearer"} }),
+		cookie: conf.Cookie,
+		enc:    enc,
 	}, nil
 }
 
+func validateJWTOutput(header *tokenHeader, cookie *tokenCookie) error {
+	if header != nil && cookie != nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "JWT mutator can either use a header or a cookie, not both")
+	}
+
+	if header != nil && len(header.Name) == 0 {
+		return errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "JWT mutator header requires a name")
+	}
+
+	if cookie != nil && len(cookie.Name) == 0 {
+		return errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "JWT mutator cookie requires a name")
+	}
+
+	return nil
+}
+
+func validateJWTOptions(omitClaims []string, joseHeaders map[string]any) error {
+	for _, claim := range omitClaims {
+		switch claim {
+		case "iss", "sub", "iat", "nbf", "jti":
+		default:
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"JWT mutator cannot omit %s claim; only iss, sub, iat, nbf and jti can be omitted", claim)
+		}
+	}
+
+	for name := range joseHeaders {
+		switch name {
+		case "alg", "kid", "x5c":
+			return errorchain.NewWithMessagef(heimdall.ErrConfiguration,
+				"JWT mutator cannot set %s JOSE header; it is set by the signer", name)
+		}
+	}
+
+	return nil
+}
+
 func (m *jwtMutator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
 	logger := zerolog.Ctx(ctx.AppContext())
 	logger.Debug().Msg("Mutating using JWT mutator")
@@ -82,15 +170,26 @@ func (m *jwtMutator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
 			WithErrorContext(m)
 	}
 
-	cch := cache.Ctx(ctx.AppContext())
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceMutator)
 
 	var (
-		cacheEntry any
-		jwtToken   string
-		ok         bool
+		cacheEntry   any
+		jwtToken     string
+		recipientKey *jose.JSONWebKey
+		ok           bool
+		err          error
 	)
 
-	cacheKey, err := m.calculateCacheKey(sub, ctx.Signer())
+	if m.enc != nil {
+		if recipientKey, err = m.enc.recipientKey(ctx); err != nil {
+			return errorchain.
+				NewWithMessage(heimdall.ErrInternal, "failed to retrieve recipient key for JWT encryption").
+				WithErrorContext(m).
+				CausedBy(err)
+		}
+	}
+
+	cacheKey, err := m.calculateCacheKey(sub, ctx.Signer(), recipientKey)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to calculate cache key. Will not be able to cache token")
 	} else {
@@ -114,12 +213,27 @@ func (m *jwtMutator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
 			return err
 		}
 
+		if recipientKey != nil {
+			if jwtToken, err = m.enc.encrypt(recipientKey, jwtToken); err != nil {
+				return errorchain.
+					NewWithMessage(heimdall.ErrInternal, "failed to encrypt token").
+					WithErrorContext(m).
+					CausedBy(err)
+			}
+		}
+
 		if len(cacheKey) != 0 && m.ttl > defaultCacheLeeway {
 			cch.Set(cacheKey, jwtToken, m.ttl-defaultCacheLeeway)
+			cache.Index(cch, cacheKey, m.ttl-defaultCacheLeeway, cache.HandlerTag(m.id), cache.SubjectTag(sub.ID))
 		}
 	}
 
-	ctx.AddHeaderForUpstream("Authorization", fmt.Sprintf("Bearer %s", jwtToken))
+	if m.cookie != nil {
+		ctx.AddCookieForUpstream(m.cookie.Name, jwtToken)
+	} else {
+		ctx.AddHeaderForUpstream(m.header.Name, x.IfThenElse(len(m.header.Scheme) != 0,
+			m.header.Scheme+" "+jwtToken, jwtToken))
+	}
 
 	return nil
 }
@@ -130,8 +244,9 @@ func (m *jwtMutator) WithConfig(rawConfig map[string]any) (Mutator, error) {
 	}
 
 	type Config struct {
-		Claims template.Template `mapstructure:"claims"`
-		TTL    *time.Duration    `mapstructure:"ttl"`
+		Claims   template.Template `mapstructure:"claims"`
+		TTL      *time.Duration    `mapstructure:"ttl"`
+		Audience []string          `mapstructure:"audience"`
 	}
 
 	var conf Config
@@ -147,11 +262,16 @@ func (m *jwtMutator) WithConfig(rawConfig map[string]any) (Mutator, error) {
 	}
 
 	return &jwtMutator{
-		id:     m.id,
-		claims: x.IfThenElse(conf.Claims != nil, conf.Claims, m.claims),
+		id:       m.id,
+		claims:   x.IfThenElse(conf.Claims != nil, conf.Claims, m.claims),
+		audience: x.IfThenElse(conf.Audience != nil, conf.Audience, m.audience),
 		ttl: x.IfThenElseExec(conf.TTL != nil,
 			func() time.Duration { return *conf.TTL },
 			func() time.Duration { return m.ttl }),
+		opts:   m.opts,
+		header: m.header,
+		cookie: m.cookie,
+		enc:    m.enc,
 	}, nil
 }
 
@@ -180,7 +300,15 @@ func (m *jwtMutator) generateToken(ctx heimdall.Context, sub *subject.Subject) (
 		}
 	}
 
-	token, err := iss.Sign(sub.ID, m.ttl, claims)
+	switch len(m.audience) {
+	case 0:
+	case 1:
+		claims["aud"] = m.audience[0]
+	default:
+		claims["aud"] = m.audience
+	}
+
+	token, err := iss.Sign(sub.ID, m.ttl, claims, m.opts)
 	if err != nil {
 		return "", errorchain.
 			NewWithMessage(heimdall.ErrInternal, "failed to sign token").
@@ -191,7 +319,9 @@ func (m *jwtMutator) generateToken(ctx heimdall.Context, sub *subject.Subject) (
 	return token, nil
 }
 
-func (m *jwtMutator) calculateCacheKey(sub *subject.Subject, iss heimdall.JWTSigner) (string, error) {
+func (m *jwtMutator) calculateCacheKey(
+	sub *subject.Subject, iss heimdall.JWTSigner, recipientKey *jose.JSONWebKey,
+) (string, error) {
 	const int64BytesCount = 8
 
 	rawSub, err := json.Marshal(sub)
@@ -202,6 +332,15 @@ func (m *jwtMutator) calculateCacheKey(sub *subject.Subject, iss heimdall.JWTSig
 			CausedBy(err)
 	}
 
+	// the token depends on the audience and the options, but not on where it is forwarded to
+	rawOpts, err := json.Marshal(map[string]any{"aud": m.audience, "opts": m.opts})
+	if err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal JWT options").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
 	ttlBytes := make([]byte, int64BytesCount)
 	binary.LittleEndian.PutUint64(ttlBytes, uint64(m.ttl))
 
@@ -211,7 +350,21 @@ func (m *jwtMutator) calculateCacheKey(sub *subject.Subject, iss heimdall.JWTSig
 		func() string { return m.claims.Hash() },
 		func() string { return "null" })))
 	hash.Write(ttlBytes)
+	hash.Write(rawOpts)
 	hash.Write(rawSub)
 
+	if recipientKey != nil {
+		thumbprint, err := recipientKey.Thumbprint(crypto.SHA256)
+		if err != nil {
+			return "", errorchain.
+				NewWithMessage(heimdall.ErrInternal, "failed to calculate recipient key thumbprint").
+				WithErrorContext(m).
+				CausedBy(err)
+		}
+
+		hash.Write([]byte(m.enc.Hash()))
+		hash.Write(thumbprint)
+	}
+
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
@@ -128,6 +128,107 @@ claims:
 				assert.Equal(t, "jmut", mut.HandlerID())
 			},
 		},
+		{
+			uc: "with all options",
+			id: "jmut",
+			config: []byte(`
+audience: [foo, bar]
+omit_claims: [nbf, jti]
+override_claims: true
+include_x5c: true
+jose_headers:
+  typ: at+jwt
+header:
+  name: X-User-Token
+`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, mut)
+				assert.Equal(t, []string{"foo", "bar"}, mut.audience)
+				assert.Equal(t, heimdall.JWTOptions{
+					Headers:          map[string]any{"typ": "at+jwt"},
+					IncludeCertChain: true,
+					OmitClaims:       []string{"nbf", "jti"},
+					OverrideClaims:   true,
+				}, mut.opts)
+				assert.Equal(t, tokenHeader{Name: "X-User-Token"}, mut.header)
+				assert.Nil(t, mut.cookie)
+			},
+		},
+		{
+			uc:     "with cookie",
+			config: []byte(`cookie: { name: user_token }`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				require.NotNil(t, mut)
+				require.NotNil(t, mut.cookie)
+				assert.Equal(t, "user_token", mut.cookie.Name)
+			},
+		},
+		{
+			uc: "with header and cookie",
+			config: []byte(`
+header: { name: X-User-Token }
+cookie: { name: user_token }
+`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not both")
+			},
+		},
+		{
+			uc:     "with header without name",
+			config: []byte(`header: { scheme: Bearer }`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "header requires a name")
+			},
+		},
+		{
+			uc:     "with cookie without name",
+			config: []byte(`cookie: {}`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cookie requires a name")
+			},
+		},
+		{
+			uc:     "with exp claim to omit",
+			config: []byte(`omit_claims: [exp]`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot omit exp")
+			},
+		},
+		{
+			uc:     "with kid JOSE header",
+			config: []byte(`jose_headers: { kid: foo }`),
+			assert: func(t *testing.T, err error, mut *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "cannot set kid")
+			},
+		},
 		{
 			uc: "with unknown entries in configuration",
 			config: []byte(`
@@ -262,6 +363,33 @@ claims:
 				assert.Equal(t, "jmut5", configured.HandlerID())
 			},
 		},
+		{
+			uc:     "configuration with audience provided",
+			id:     "jmut6",
+			config: []byte(`audience: [foo]`),
+			assert: func(t *testing.T, err error, prototype *jwtMutator, configured *jwtMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Nil(t, prototype.audience)
+				assert.Equal(t, []string{"foo"}, configured.audience)
+				assert.Equal(t, prototype.ttl, configured.ttl)
+				assert.Equal(t, prototype.opts, configured.opts)
+				assert.Equal(t, prototype.header, configured.header)
+				assert.Equal(t, "jmut6", configured.HandlerID())
+			},
+		},
+		{
+			uc:     "configuration with not overridable properties",
+			config: []byte(`header: { name: X-User-Token }`),
+			assert: func(t *testing.T, err error, prototype *jwtMutator, configured *jwtMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
 		{
 			uc: "with unknown entries in configuration",
 			config: []byte(`
@@ -350,7 +478,7 @@ func TestJWTMutatorExecute(t *testing.T) {
 
 				mut := jwtMutator{ttl: defaultJWTTTL}
 
-				cacheKey, err := mut.calculateCacheKey(sub, signer)
+				cacheKey, err := mut.calculateCacheKey(sub, signer, nil)
 				require.NoError(t, err)
 
 				cch.On("Get", cacheKey).Return("TestToken")
@@ -371,7 +499,7 @@ func TestJWTMutatorExecute(t *testing.T) {
 				t.Helper()
 
 				signer.On("Hash").Return("foobar")
-				signer.On("Sign", sub.ID, configuredTTL, map[string]any{}).
+				signer.On("Sign", sub.ID, configuredTTL, map[string]any{}, heimdall.JWTOptions{}).
 					Return("barfoo", nil)
 
 				ctx.On("Signer").Return(signer)
@@ -379,7 +507,7 @@ func TestJWTMutatorExecute(t *testing.T) {
 
 				mut := jwtMutator{ttl: configuredTTL}
 
-				cacheKey, err := mut.calculateCacheKey(sub, signer)
+				cacheKey, err := mut.calculateCacheKey(sub, signer, nil)
 				require.NoError(t, err)
 
 				cch.On("Get", cacheKey).Return(time.Second)
@@ -402,7 +530,7 @@ func TestJWTMutatorExecute(t *testing.T) {
 				t.Helper()
 
 				signer.On("Hash").Return("foobar")
-				signer.On("Sign", sub.ID, configuredTTL, map[string]any{}).
+				signer.On("Sign", sub.ID, configuredTTL, map[string]any{}, heimdall.JWTOptions{}).
 					Return("barfoo", nil)
 
 				ctx.On("Signer").Return(signer)
@@ -435,7 +563,7 @@ claims: '{
 				signer.On("Sign", sub.ID, defaultJWTTTL, map[string]any{
 					"sub_id": "foo",
 					"bar":    "baz",
-				}).Return("barfoo", nil)
+				}, heimdall.JWTOptions{}).Return("barfoo", nil)
 
 				ctx.On("Signer").Return(signer)
 				ctx.On("AddHeaderForUpstream", "Authorization", "Bearer barfoo")
@@ -449,6 +577,66 @@ claims: '{
 				assert.NoError(t, err)
 			},
 		},
+		{
+			uc: "with audience, options and custom header",
+			config: []byte(`
+audience: [foo]
+omit_claims: [nbf]
+jose_headers: { typ: at+jwt }
+header: { name: X-User-Token }
+`),
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"baz": "bar"}},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, signer *heimdallmocks.MockJWTSigner,
+				cch *mocks.MockCache, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				signer.On("Hash").Return("foobar")
+				signer.On("Sign", sub.ID, defaultJWTTTL, map[string]any{"aud": "foo"}, heimdall.JWTOptions{
+					Headers:    map[string]any{"typ": "at+jwt"},
+					OmitClaims: []string{"nbf"},
+				}).Return("barfoo", nil)
+
+				ctx.On("Signer").Return(signer)
+				ctx.On("AddHeaderForUpstream", "X-User-Token", "barfoo")
+
+				cch.On("Get", mock.Anything).Return(nil)
+				cch.On("Set", mock.Anything, "barfoo", defaultJWTTTL-defaultCacheLeeway)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.NoError(t, err)
+			},
+		},
+		{
+			uc: "with multiple audiences and cookie",
+			config: []byte(`
+audience: [foo, bar]
+cookie: { name: user_token }
+`),
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"baz": "bar"}},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, signer *heimdallmocks.MockJWTSigner,
+				cch *mocks.MockCache, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				signer.On("Hash").Return("foobar")
+				signer.On("Sign", sub.ID, defaultJWTTTL, map[string]any{"aud": []string{"foo", "bar"}},
+					heimdall.JWTOptions{}).Return("barfoo", nil)
+
+				ctx.On("Signer").Return(signer)
+				ctx.On("AddCookieForUpstream", "user_token", "barfoo")
+
+				cch.On("Get", mock.Anything).Return(nil)
+				cch.On("Set", mock.Anything, "barfoo", defaultJWTTTL-defaultCacheLeeway)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.NoError(t, err)
+			},
+		},
 		{
 			uc:      "with custom claims template, which does not result in a JSON object",
 			id:      "jmut2",
@@ -9,3 +9,9 @@ type Mutator interface {
 	Execute(ctx heimdall.Context, sub *subject.Subject) error
 	WithConfig(config map[string]any) (Mutator, error)
 }
+
+// tokenHeader configures the header a token is forwarded to the upstream service in.
+type tokenHeader struct {
+	Name   string `mapstructure:"name"`
+	Scheme string `mapstructure:"scheme"`
+}
@@ -12,8 +12,8 @@ import (
 func TestCreateMutatorPrototype(t *testing.T) {
 	t.Parallel()
 
-	// there are 4 mutators implemented, which should have been registered
-	require.Len(t, mutatorTypeFactories, 4)
+	// there are 6 mutators implemented, which should have been registered
+	require.Len(t, mutatorTypeFactories, 6)
 
 	for _, tc := range []struct {
 		uc     string
@@ -0,0 +1,285 @@
+package mutators
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/config"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+const (
+	tokenExchangeGrantType = "urn:ietf:params:oauth:grant-type:token-exchange"
+	accessTokenType        = "urn:ietf:params:oauth:token-type:access_token"
+)
+
+// by intention. Used only during application bootstrap
+// nolint
+func init() {
+	registerMutatorTypeFactory(
+		func(id string, typ config.PipelineObjectType, conf map[string]any) (bool, Mutator, error) {
+			if typ != config.POTTokenExchange {
+				return false, nil, nil
+			}
+
+			mut, err := newTokenExchangeMutator(id, conf)
+
+			return true, mut, err
+		})
+}
+
+type tokenExchangeResponse struct {
+	AccessToken     string `json:"access_token"`
+	IssuedTokenType string `json:"issued_token_type"`
+	TokenType       string `json:"token_type"`
+	ExpiresIn       int64  `json:"expires_in"`
+}
+
+type tokenExchangeMutator struct {
+	id                 string
+	e                  endpoint.Endpoint
+	ads                extractors.AuthDataExtractStrategy
+	subjectTokenType   string
+	requestedTokenType string
+	audience           string
+	scopes             []string
+	header             tokenHeader
+}
+
+func newTokenExchangeMutator(id string, rawConfig map[string]any) (*tokenExchangeMutator, error) {
+	type Config struct {
+		Endpoint           endpoint.Endpoint                   `mapstructure:"token_endpoint"`
+		SubjectTokenSource extractors.CompositeExtractStrategy `mapstructure:"subject_token_source"`
+		SubjectTokenType   string                              `mapstructure:"subject_token_type"`
+		RequestedTokenType string                              `mapstructure:"requested_token_type"`
+		Audience           string                              `mapstructure:"audience"`
+		Scopes             []string                            `mapstructure:"scopes"`
+		Header             *tokenHeader                        `mapstructure:"header"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal token_exchange mutator config").
+			CausedBy(err)
+	}
+
+	if err := conf.Endpoint.Validate(); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to validate token_endpoint configuration").
+			CausedBy(err)
+	}
+
+	if conf.Header != nil && len(conf.Header.Name) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "token_exchange mutator header requires a name")
+	}
+
+	if len(conf.Endpoint.Method) == 0 {
+		conf.Endpoint.Method = http.MethodPost
+	}
+
+	if conf.Endpoint.Headers == nil {
+		conf.Endpoint.Headers = make(map[string]string)
+	}
+
+	if _, ok := conf.Endpoint.Headers["Content-Type"]; !ok {
+		conf.Endpoint.Headers["Content-Type"] = "application/x-www-form-urlencoded"
+	}
+
+	if _, ok := conf.Endpoint.Headers["Accept"]; !ok {
+		conf.Endpoint.Headers["Accept"] = "application/json"
+	}
+
+	return &tokenExchangeMutator{
+		id: id,
+		e:  conf.Endpoint,
+		ads: x.IfThenElse[extractors.AuthDataExtractStrategy](conf.SubjectTokenSource != nil,
+			conf.SubjectTokenSource,
+			extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "Bearer"}),
+		subjectTokenType: x.IfThenElse(len(conf.SubjectTokenType) != 0,
+			conf.SubjectTokenType, accessTokenType),
+		requestedTokenType: conf.RequestedTokenType,
+		audience:           conf.Audience,
+		scopes:             conf.Scopes,
+		header: x.IfThenElseExec(conf.Header != nil,
+			func() tokenHeader { return *conf.Header },
+			func() tokenHeader { return tokenHeader{Name: "Authorization", Scheme: "Bearer"} }),
+	}, nil
+}
+
+func (m *tokenExchangeMutator) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+	logger.Debug().Msg("Mutating using token_exchange mutator")
+
+	if sub == nil {
+		return errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to execute token_exchange mutator due to 'nil' subject").
+			WithErrorContext(m)
+	}
+
+	cch := cache.NamespacedCtx(ctx.AppContext(), cache.NamespaceMutator)
+
+	var (
+		cacheEntry any
+		token      string
+		ok         bool
+	)
+
+	cacheKey, err := m.calculateCacheKey(sub)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to calculate cache key. Will not be able to cache token")
+	} else {
+		cacheEntry = cch.Get(cacheKey)
+	}
+
+	if cacheEntry != nil {
+		if token, ok = cacheEntry.(string); !ok {
+			logger.Warn().Msg("Wrong object type from cache")
+			cch.Delete(cacheKey)
+		} else {
+			logger.Debug().Msg("Reusing exchanged token from cache")
+		}
+	}
+
+	if len(token) == 0 {
+		logger.Debug().Msg("Exchanging token")
+
+		resp, err := m.exchangeToken(ctx)
+		if err != nil {
+			return err
+		}
+
+		token = resp.AccessToken
+
+		if ttl := time.Duration(resp.ExpiresIn) * time.Second; len(cacheKey) != 0 && ttl > defaultCacheLeeway {
+			cch.Set(cacheKey, token, ttl-defaultCacheLeeway)
+			cache.Index(cch, cacheKey, ttl-defaultCacheLeeway, cache.HandlerTag(m.id), cache.SubjectTag(sub.ID))
+		}
+	}
+
+	ctx.AddHeaderForUpstream(m.header.Name, x.IfThenElse(len(m.header.Scheme) != 0,
+		m.header.Scheme+" "+token, token))
+
+	return nil
+}
+
+func (m *tokenExchangeMutator) WithConfig(rawConfig map[string]any) (Mutator, error) {
+	if len(rawConfig) == 0 {
+		return m, nil
+	}
+
+	type Config struct {
+		Audience *string  `mapstructure:"audience"`
+		Scopes   []string `mapstructure:"scopes"`
+	}
+
+	var conf Config
+	if err := decodeConfig(rawConfig, &conf); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrConfiguration, "failed to unmarshal token_exchange mutator config").
+			CausedBy(err)
+	}
+
+	return &tokenExchangeMutator{
+		id:                 m.id,
+		e:                  m.e,
+		ads:                m.ads,
+		subjectTokenType:   m.subjectTokenType,
+		requestedTokenType: m.requestedTokenType,
+		audience: x.IfThenElseExec(conf.Audience != nil,
+			func() string { return *conf.Audience },
+			func() string { return m.audience }),
+		scopes: x.IfThenElse(conf.Scopes != nil, conf.Scopes, m.scopes),
+		header: m.header,
+	}, nil
+}
+
+func (m *tokenExchangeMutator) HandlerID() string {
+	return m.id
+}
+
+func (m *tokenExchangeMutator) exchangeToken(ctx heimdall.Context) (*tokenExchangeResponse, error) {
+	authData, err := m.ads.GetAuthData(ctx)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrArgument, "failed to get subject token from request").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
+	data := url.Values{
+		"grant_type":         []string{tokenExchangeGrantType},
+		"subject_token":      []string{authData.Value()},
+		"subject_token_type": []string{m.subjectTokenType},
+	}
+
+	if len(m.requestedTokenType) != 0 {
+		data.Set("requested_token_type", m.requestedTokenType)
+	}
+
+	if len(m.audience) != 0 {
+		data.Set("audience", m.audience)
+	}
+
+	if len(m.scopes) != 0 {
+		data.Set("scope", strings.Join(m.scopes, " "))
+	}
+
+	rawData, err := m.e.SendRequest(ctx.AppContext(), strings.NewReader(data.Encode()), nil)
+	if err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "token exchange request failed").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
+	var resp tokenExchangeResponse
+	if err = json.Unmarshal(rawData, &resp); err != nil {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to unmarshal token exchange response").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
+	if len(resp.AccessToken) == 0 {
+		return nil, errorchain.
+			NewWithMessage(heimdall.ErrCommunication, "token exchange response does not contain a token").
+			WithErrorContext(m)
+	}
+
+	return &resp, nil
+}
+
+func (m *tokenExchangeMutator) calculateCacheKey(sub *subject.Subject) (string, error) {
+	rawSub, err := json.Marshal(sub)
+	if err != nil {
+		return "", errorchain.
+			NewWithMessage(heimdall.ErrInternal, "failed to marshal subject data").
+			WithErrorContext(m).
+			CausedBy(err)
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(m.e.Hash()))
+	hash.Write([]byte(m.subjectTokenType))
+	hash.Write([]byte(m.requestedTokenType))
+	hash.Write([]byte(m.audience))
+	hash.Write([]byte(strings.Join(m.scopes, " ")))
+	hash.Write(rawSub)
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
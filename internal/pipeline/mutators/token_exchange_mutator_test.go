@@ -0,0 +1,472 @@
+package mutators
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/cache"
+	"github.com/dadrus/heimdall/internal/cache/mocks"
+	"github.com/dadrus/heimdall/internal/endpoint"
+	"github.com/dadrus/heimdall/internal/heimdall"
+	heimdallmocks "github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/authenticators/extractors"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestCreateTokenExchangeMutator(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		id     string
+		config []byte
+		assert func(t *testing.T, err error, mut *tokenExchangeMutator)
+	}{
+		{
+			uc: "without config",
+			assert: func(t *testing.T, err error, mut *tokenExchangeMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to validate token_endpoint")
+			},
+		},
+		{
+			uc:     "with unsupported attributes",
+			config: []byte(`{ token_endpoint: { url: http://idp.local/token }, foo: bar }`),
+			assert: func(t *testing.T, err error, mut *tokenExchangeMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "failed to unmarshal")
+			},
+		},
+		{
+			uc: "with header without name",
+			config: []byte(`
+token_endpoint:
+  url: http://idp.local/token
+header:
+  scheme: Bearer
+`),
+			assert: func(t *testing.T, err error, mut *tokenExchangeMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "requires a name")
+			},
+		},
+		{
+			uc:     "with minimal config",
+			id:     "tem",
+			config: []byte(`token_endpoint: { url: http://idp.local/token }`),
+			assert: func(t *testing.T, err error, mut *tokenExchangeMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "tem", mut.HandlerID())
+				assert.Equal(t, "http://idp.local/token", mut.e.URL)
+				assert.Equal(t, http.MethodPost, mut.e.Method)
+				assert.Equal(t, "application/x-www-form-urlencoded", mut.e.Headers["Content-Type"])
+				assert.Equal(t, "application/json", mut.e.Headers["Accept"])
+				assert.Equal(t, extractors.HeaderValueExtractStrategy{Name: "Authorization", Schema: "Bearer"}, mut.ads)
+				assert.Equal(t, accessTokenType, mut.subjectTokenType)
+				assert.Empty(t, mut.requestedTokenType)
+				assert.Empty(t, mut.audience)
+				assert.Empty(t, mut.scopes)
+				assert.Equal(t, tokenHeader{Name: "Authorization", Scheme: "Bearer"}, mut.header)
+			},
+		},
+		{
+			uc: "with full config",
+			id: "tem",
+			config: []byte(`
+token_endpoint:
+  url: http://idp.local/token
+  auth:
+    type: basic_auth
+    config:
+      user: heimdall
+      password: secret
+subject_token_source:
+  - header: X-Access-Token
+subject_token_type: urn:ietf:params:oauth:token-type:jwt
+requested_token_type: urn:ietf:params:oauth:token-type:access_token
+audience: https://api.local
+scopes: [ read, write ]
+header:
+  name: X-Exchanged-Token
+`),
+			assert: func(t *testing.T, err error, mut *tokenExchangeMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "tem", mut.HandlerID())
+				assert.Equal(t, &endpoint.BasicAuthStrategy{User: "heimdall", Password: "secret"}, mut.e.AuthStrategy)
+				assert.Equal(t, extractors.CompositeExtractStrategy{
+					&extractors.HeaderValueExtractStrategy{Name: "X-Access-Token"},
+				}, mut.ads)
+				assert.Equal(t, "urn:ietf:params:oauth:token-type:jwt", mut.subjectTokenType)
+				assert.Equal(t, accessTokenType, mut.requestedTokenType)
+				assert.Equal(t, "https://api.local", mut.audience)
+				assert.Equal(t, []string{"read", "write"}, mut.scopes)
+				assert.Equal(t, tokenHeader{Name: "X-Exchanged-Token"}, mut.header)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			conf, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			// WHEN
+			mut, err := newTokenExchangeMutator(tc.id, conf)
+
+			// THEN
+			tc.assert(t, err, mut)
+		})
+	}
+}
+
+func TestCreateTokenExchangeMutatorFromPrototype(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		config []byte
+		assert func(t *testing.T, err error, prototype *tokenExchangeMutator, configured *tokenExchangeMutator)
+	}{
+		{
+			uc: "without new configuration",
+			assert: func(t *testing.T, err error, prototype *tokenExchangeMutator, configured *tokenExchangeMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, prototype, configured)
+			},
+		},
+		{
+			uc:     "with audience and scopes redefined",
+			config: []byte(`{ audience: https://other.local, scopes: [ read ] }`),
+			assert: func(t *testing.T, err error, prototype *tokenExchangeMutator, configured *tokenExchangeMutator) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotEqual(t, prototype, configured)
+				assert.Equal(t, prototype.id, configured.id)
+				assert.Equal(t, prototype.e, configured.e)
+				assert.Equal(t, prototype.ads, configured.ads)
+				assert.Equal(t, prototype.header, configured.header)
+				assert.Equal(t, "https://api.local", prototype.audience)
+				assert.Equal(t, "https://other.local", configured.audience)
+				assert.Equal(t, []string{"read", "write"}, prototype.scopes)
+				assert.Equal(t, []string{"read"}, configured.scopes)
+			},
+		},
+		{
+			uc:     "with not overridable attribute",
+			config: []byte(`token_endpoint: { url: http://other.local/token }`),
+			assert: func(t *testing.T, err error, prototype *tokenExchangeMutator, configured *tokenExchangeMutator) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			pc, err := testsupport.DecodeTestConfig([]byte(`
+token_endpoint:
+  url: http://idp.local/token
+audience: https://api.local
+scopes: [ read, write ]
+`))
+			require.NoError(t, err)
+
+			rc, err := testsupport.DecodeTestConfig(tc.config)
+			require.NoError(t, err)
+
+			prototype, err := newTokenExchangeMutator("tem", pc)
+			require.NoError(t, err)
+
+			// WHEN
+			mut, err := prototype.WithConfig(rc)
+
+			// THEN
+			var (
+				teMut *tokenExchangeMutator
+				ok    bool
+			)
+
+			if err == nil {
+				teMut, ok = mut.(*tokenExchangeMutator)
+				require.True(t, ok)
+			}
+
+			tc.assert(t, err, prototype, teMut)
+		})
+	}
+}
+
+func TestTokenExchangeMutatorExecute(t *testing.T) {
+	t.Parallel()
+
+	var (
+		endpointCalled bool
+		checkRequest   func(req *http.Request)
+		responseCode   int
+		responseBody   string
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpointCalled = true
+
+		checkRequest(r)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(responseCode)
+
+		_, err := w.Write([]byte(responseBody))
+		assert.NoError(t, err)
+	}))
+	defer srv.Close()
+
+	for _, tc := range []struct {
+		uc             string
+		id             string
+		subject        *subject.Subject
+		instructServer func(t *testing.T)
+		configureMocks func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+			mut *tokenExchangeMutator, sub *subject.Subject)
+		assert func(t *testing.T, err error)
+	}{
+		{
+			uc: "with 'nil' subject",
+			id: "tem1",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "'nil' subject")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "tem1", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "with token from cache",
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+				mut *tokenExchangeMutator, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				cacheKey, err := mut.calculateCacheKey(sub)
+				require.NoError(t, err)
+
+				cch.On("Get", cacheKey).Return("cached-token")
+				ctx.On("AddHeaderForUpstream", "Authorization", "Bearer cached-token")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.False(t, endpointCalled)
+			},
+		},
+		{
+			uc:      "without subject token in the request",
+			id:      "tem2",
+			subject: &subject.Subject{ID: "foo"},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+				mut *tokenExchangeMutator, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				cch.On("Get", mock.Anything).Return(nil)
+				ctx.On("RequestHeader", "Authorization").Return("")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.False(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrArgument)
+				assert.Contains(t, err.Error(), "failed to get subject token")
+
+				var identifier interface{ HandlerID() string }
+				require.True(t, errors.As(err, &identifier))
+				assert.Equal(t, "tem2", identifier.HandlerID())
+			},
+		},
+		{
+			uc:      "with error response from the token endpoint",
+			subject: &subject.Subject{ID: "foo"},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusBadRequest
+				responseBody = `{"error": "invalid_grant"}`
+			},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+				mut *tokenExchangeMutator, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				cch.On("Get", mock.Anything).Return(nil)
+				ctx.On("RequestHeader", "Authorization").Return("Bearer incoming-token")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.True(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "token exchange request failed")
+			},
+		},
+		{
+			uc:      "with response without token",
+			subject: &subject.Subject{ID: "foo"},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				responseCode = http.StatusOK
+				responseBody = `{"token_type": "Bearer"}`
+			},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+				mut *tokenExchangeMutator, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				cch.On("Get", mock.Anything).Return(nil)
+				ctx.On("RequestHeader", "Authorization").Return("Bearer incoming-token")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				assert.True(t, endpointCalled)
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrCommunication)
+				assert.Contains(t, err.Error(), "does not contain a token")
+			},
+		},
+		{
+			uc:      "with successful token exchange",
+			subject: &subject.Subject{ID: "foo"},
+			instructServer: func(t *testing.T) {
+				t.Helper()
+
+				checkRequest = func(req *http.Request) {
+					t.Helper()
+
+					user, password, ok := req.BasicAuth()
+					assert.True(t, ok)
+					assert.Equal(t, "heimdall", user)
+					assert.Equal(t, "secret", password)
+
+					assert.Equal(t, http.MethodPost, req.Method)
+					assert.NoError(t, req.ParseForm())
+					assert.Equal(t, tokenExchangeGrantType, req.PostForm.Get("grant_type"))
+					assert.Equal(t, "incoming-token", req.PostForm.Get("subject_token"))
+					assert.Equal(t, accessTokenType, req.PostForm.Get("subject_token_type"))
+					assert.Equal(t, "https://api.local", req.PostForm.Get("audience"))
+					assert.Equal(t, "read write", req.PostForm.Get("scope"))
+					assert.Empty(t, req.PostForm.Get("requested_token_type"))
+				}
+
+				responseCode = http.StatusOK
+				responseBody = `{
+"access_token": "exchanged-token",
+"issued_token_type": "urn:ietf:params:oauth:token-type:access_token",
+"token_type": "Bearer",
+"expires_in": 60
+}`
+			},
+			configureMocks: func(t *testing.T, ctx *heimdallmocks.MockContext, cch *mocks.MockCache,
+				mut *tokenExchangeMutator, sub *subject.Subject,
+			) {
+				t.Helper()
+
+				cacheKey, err := mut.calculateCacheKey(sub)
+				require.NoError(t, err)
+
+				cch.On("Get", cacheKey).Return(nil)
+				cch.On("Set", cacheKey, "exchanged-token", time.Minute-defaultCacheLeeway)
+				ctx.On("RequestHeader", "Authorization").Return("Bearer incoming-token")
+				ctx.On("AddHeaderForUpstream", "Authorization", "Bearer exchanged-token")
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.True(t, endpointCalled)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			endpointCalled = false
+			checkRequest = func(*http.Request) {}
+			responseCode = http.StatusOK
+			responseBody = ""
+
+			if tc.instructServer != nil {
+				tc.instructServer(t)
+			}
+
+			conf, err := testsupport.DecodeTestConfig([]byte(`
+token_endpoint:
+  url: ` + srv.URL + `
+  auth:
+    type: basic_auth
+    config:
+      user: heimdall
+      password: secret
+audience: https://api.local
+scopes: [ read, write ]
+`))
+			require.NoError(t, err)
+
+			mut, err := newTokenExchangeMutator(tc.id, conf)
+			require.NoError(t, err)
+
+			cch := &mocks.MockCache{}
+			ctx := &heimdallmocks.MockContext{}
+			ctx.On("AppContext").Return(cache.WithContext(context.Background(), cch))
+
+			if tc.configureMocks != nil {
+				tc.configureMocks(t, ctx, cch, mut, tc.subject)
+			}
+
+			// WHEN
+			err = mut.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err)
+
+			ctx.AssertExpectations(t)
+			cch.AssertExpectations(t)
+		})
+	}
+}
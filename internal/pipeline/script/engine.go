@@ -0,0 +1,242 @@
+package script
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/dop251/goja"
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/graphql"
+)
+
+const (
+	// executionTimeout limits the time a single script execution may take, e.g. if a script
+	// calls built-in functions processing huge amounts of data, which are not covered by the
+	// step limit.
+	executionTimeout = 500 * time.Millisecond
+	// maxCallStackSize limits the depth of function calls, e.g. on unbounded recursion.
+	maxCallStackSize = 256
+	// maxSteps limits the number of loop iterations and function calls a single script
+	// execution may perform. As goja neither supports instruction counting, nor limiting
+	// the memory, this also bounds the amount of memory a script can allocate in a loop.
+	maxSteps = 100000
+)
+
+var (
+	errStackOverflow     = errors.New("maximum call stack size exceeded")
+	errStepLimitExceeded = errors.New("maximum number of loop iterations and function calls exceeded")
+)
+
+// lockdown is executed once for each new runtime after all bindings have been registered.
+// It disables the evaluation of code, which is not instrumented, prevents the global bindings
+// from being replaced or deleted and freezes the objects provided by heimdall. It returns a
+// function, which removes the global variables defined by an execution, so these are not
+// visible to the next one. That function returns false if a variable could not be removed.
+// Built-in objects are not frozen, as that would require to materialize all of them, which
+// goja creates lazily, and would make initialisation of a runtime much more expensive.
+// Since each script has its own runtimes, modifications of these are at most visible to
+// later executions of the same script.
+// nolint: gochecknoglobals
+var lockdown = goja.MustCompile("lockdown", `(function () {
+	const denied = function () { throw new TypeError("dynamic code evaluation is not allowed") }
+	denied.prototype = Function.prototype
+	Object.defineProperty(Function.prototype, "constructor", { value: denied })
+	globalThis.Function = denied
+	delete globalThis.eval
+
+	Object.freeze(globalThis.heimdall)
+	Object.freeze(globalThis.console)
+
+	const initial = new Set(Reflect.ownKeys(globalThis))
+	for (const key of initial) {
+		const desc = Object.getOwnPropertyDescriptor(globalThis, key)
+		Object.defineProperty(globalThis, key, "value" in desc
+			? { writable: false, configurable: false }
+			: { configurable: false })
+	}
+
+	return () => {
+		const current = Reflect.ownKeys(globalThis)
+		if (current.length === initial.size) {
+			return true
+		}
+
+		for (const key of current) {
+			if (initial.has(key)) {
+				continue
+			}
+
+			const desc = Object.getOwnPropertyDescriptor(globalThis, key)
+			if (desc.configurable) {
+				delete globalThis[key]
+			} else if (desc.writable) {
+				// variables declared with var cannot be deleted
+				globalThis[key] = undefined
+			} else {
+				return false
+			}
+		}
+
+		return true
+	}
+})()`, true)
+
+// engine is a pre-initialised runtime with all bindings registered. The bindings refer
+// to the context, the subject and the payload of the current execution, which are set
+// while the engine is in use.
+type engine struct {
+	vm           *goja.Runtime
+	ctx          heimdall.Context
+	sub          goja.Value
+	payload      goja.Value
+	steps        int
+	resetGlobals goja.Callable
+}
+
+func newEngine() *engine {
+	eng := &engine{vm: goja.New(), sub: goja.Undefined(), payload: goja.Undefined()}
+
+	// the error checks below are ignored by intention as these cannot happen here
+	// we can also not test the corresponding occurrence and if these would happen
+	// the script execution would result in an error anyway, which basically means
+	// failed execution of the handler using it.
+
+	hmdl := eng.vm.NewObject()
+
+	// nolint: errcheck
+	hmdl.DefineAccessorProperty("Subject",
+		eng.vm.ToValue(func(goja.FunctionCall) goja.Value { return eng.sub }), nil,
+		goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	// nolint: errcheck
+	hmdl.DefineAccessorProperty("Payload",
+		eng.vm.ToValue(func(goja.FunctionCall) goja.Value { return eng.payload }), nil,
+		goja.FLAG_FALSE, goja.FLAG_TRUE)
+
+	// nolint: errcheck
+	hmdl.Set("RequestMethod", func() string { return eng.ctx.RequestMethod() })
+
+	// nolint: errcheck
+	hmdl.Set("RequestURL", func() string { return eng.ctx.RequestURL().String() })
+
+	// nolint: errcheck
+	hmdl.Set("RequestClientIPs", func() []string { return eng.ctx.RequestClientIPs() })
+
+	// nolint: errcheck
+	hmdl.Set("RequestHeader", func(name string) string { return eng.ctx.RequestHeader(name) })
+
+	// nolint: errcheck
+	hmdl.Set("RequestCookie", func(name string) string { return eng.ctx.RequestCookie(name) })
+
+	// nolint: errcheck
+	hmdl.Set("RequestQueryParameter", func(name string) string { return eng.ctx.RequestQueryParameter(name) })
+
+	// nolint: errcheck
+	hmdl.Set("RequestFormParameter", func(name string) string { return eng.ctx.RequestFormParameter(name) })
+
+	// nolint: errcheck
+	hmdl.Set("RequestBody", func() string { return string(eng.ctx.RequestBody()) })
+
+	// returns null if the request is not a graphql request and throws if it is a malformed one
+	// nolint: errcheck
+	hmdl.Set("GraphQLOperation", func() (any, error) {
+		op, err := graphql.ParseRequest(eng.ctx)
+		if err != nil || op == nil {
+			return nil, err
+		}
+
+		return map[string]any{"name": op.Name, "type": op.Type, "variables": op.Variables}, nil
+	})
+
+	registerStdLib(hmdl)
+
+	console := eng.vm.NewObject()
+
+	// nolint: errcheck
+	console.Set("log", func(val string) { zerolog.Ctx(eng.ctx.AppContext()).Debug().Msg(val) })
+
+	// nolint: errcheck
+	eng.vm.Set("heimdall", hmdl)
+
+	// nolint: errcheck
+	eng.vm.Set("console", console)
+
+	// nolint: errcheck
+	eng.vm.GlobalObject().DefineDataProperty(stepFunction,
+		eng.vm.ToValue(func(goja.FunctionCall) goja.Value {
+			eng.steps++
+			if eng.steps > maxSteps {
+				eng.vm.Interrupt(errStepLimitExceeded)
+			}
+
+			return goja.Undefined()
+		}),
+		goja.FLAG_FALSE, goja.FLAG_FALSE, goja.FLAG_FALSE)
+
+	reset, _ := eng.vm.RunProgram(lockdown)
+	eng.resetGlobals, _ = goja.AssertFunction(reset)
+
+	eng.vm.SetMaxCallStackSize(maxCallStackSize)
+
+	return eng
+}
+
+func (e *engine) run(ctx context.Context, prg *goja.Program, sub any, payload any) (goja.Value, error) {
+	e.sub = e.vm.ToValue(sub)
+	e.payload = e.vm.ToValue(payload)
+	e.steps = 0
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		timer := time.NewTimer(executionTimeout)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			e.vm.Interrupt(ErrScriptTimeout)
+		case <-ctx.Done():
+			e.vm.Interrupt(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	val, err := e.vm.RunProgram(prg)
+
+	close(done)
+	<-stopped
+
+	// the interrupt could have happened after the script has finished. Must be
+	// cleared in any case to not affect the next execution
+	e.vm.ClearInterrupt()
+
+	// the error of goja does not have any message
+	var soErr *goja.StackOverflowError
+	if errors.As(err, &soErr) {
+		return nil, errStackOverflow
+	}
+
+	return val, err
+}
+
+// reset prepares the runtime for the next execution. It returns false, if the runtime cannot
+// be reused, as the state left by the last execution could not be removed.
+func (e *engine) reset() bool {
+	e.ctx = nil
+	e.sub = goja.Undefined()
+	e.payload = goja.Undefined()
+
+	if e.resetGlobals == nil {
+		return false
+	}
+
+	ok, err := e.resetGlobals(goja.Undefined())
+
+	return err == nil && ok.ToBoolean()
+}
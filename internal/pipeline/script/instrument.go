@@ -0,0 +1,149 @@
+package script
+
+import (
+	"reflect"
+
+	"github.com/dop251/goja/ast"
+	"github.com/dop251/goja/file"
+)
+
+// stepFunction is the name of the global function, the instrumented scripts call on each loop
+// iteration and on each function call. It counts the steps of the current execution.
+const stepFunction = "__heimdall_step"
+
+// instrument inserts calls to the stepFunction into the body of each loop and of each function
+// defined by the given script, so the number of executed steps can be limited. This is needed
+// as goja does not support limiting the number of executed instructions. Code evaluated
+// dynamically is not instrumented. That is why eval and the Function constructor are not
+// available to scripts.
+//
+// In addition, the statements of the script are enclosed in a block, so that top-level let,
+// const, class and function declarations are scoped to it and do not conflict with the next
+// execution of the script in the same runtime.
+func instrument(prg *ast.Program) {
+	var nodes []ast.Node
+
+	walkAST(reflect.ValueOf(prg), map[uintptr]bool{}, func(node ast.Node) { nodes = append(nodes, node) })
+
+	for _, node := range nodes {
+		switch node := node.(type) {
+		case *ast.WhileStatement:
+			node.Body = withStep(node.Body)
+		case *ast.DoWhileStatement:
+			node.Body = withStep(node.Body)
+		case *ast.ForStatement:
+			node.Body = withStep(node.Body)
+		case *ast.ForInStatement:
+			node.Body = withStep(node.Body)
+		case *ast.ForOfStatement:
+			node.Body = withStep(node.Body)
+		case *ast.FunctionLiteral:
+			if node.Body != nil {
+				node.Body = withStep(node.Body).(*ast.BlockStatement) // nolint: forcetypeassert
+			}
+		case *ast.ArrowFunctionLiteral:
+			switch body := node.Body.(type) {
+			case *ast.BlockStatement:
+				node.Body = withStep(body).(*ast.BlockStatement) // nolint: forcetypeassert
+			case *ast.ExpressionBody:
+				body.Expression = &ast.SequenceExpression{
+					Sequence: []ast.Expression{stepCall(body.Expression.Idx0()), body.Expression},
+				}
+			}
+		}
+	}
+
+	if len(prg.Body) != 0 {
+		prg.Body = []ast.Statement{&ast.BlockStatement{
+			LeftBrace:  prg.Body[0].Idx0(),
+			List:       prg.Body,
+			RightBrace: prg.Body[len(prg.Body)-1].Idx1(),
+		}}
+	}
+}
+
+// withStep returns a block, which performs a step and executes the given statement afterwards.
+func withStep(stmt ast.Statement) ast.Statement {
+	block, ok := stmt.(*ast.BlockStatement)
+	if !ok {
+		return &ast.BlockStatement{
+			LeftBrace:  stmt.Idx0(),
+			List:       []ast.Statement{&ast.ExpressionStatement{Expression: stepCall(stmt.Idx0())}, stmt},
+			RightBrace: stmt.Idx1(),
+		}
+	}
+
+	// the directive prologue, like "use strict", must stay at the beginning of a function body
+	pos := 0
+
+	for pos < len(block.List) && isDirective(block.List[pos]) {
+		pos++
+	}
+
+	list := make([]ast.Statement, 0, len(block.List)+1)
+	list = append(list, block.List[:pos]...)
+	list = append(list, &ast.ExpressionStatement{Expression: stepCall(block.LeftBrace)})
+	list = append(list, block.List[pos:]...)
+
+	block.List = list
+
+	return block
+}
+
+func stepCall(idx file.Idx) *ast.CallExpression {
+	return &ast.CallExpression{
+		Callee:           &ast.Identifier{Name: stepFunction, Idx: idx},
+		LeftParenthesis:  idx,
+		RightParenthesis: idx,
+	}
+}
+
+func isDirective(stmt ast.Statement) bool {
+	expr, ok := stmt.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	_, ok = expr.Expression.(*ast.StringLiteral)
+
+	return ok
+}
+
+// walkAST calls visit for each node of the given syntax tree in pre-order. As nodes can be
+// referenced multiple times, e.g. by the declaration lists, each node is visited only once.
+func walkAST(val reflect.Value, visited map[uintptr]bool, visit func(node ast.Node)) {
+	switch val.Kind() { // nolint: exhaustive
+	case reflect.Interface:
+		if !val.IsNil() {
+			walkAST(val.Elem(), visited, visit)
+		}
+	case reflect.Pointer:
+		if val.IsNil() || !isASTType(val.Type().Elem()) || visited[val.Pointer()] {
+			return
+		}
+
+		visited[val.Pointer()] = true
+
+		if node, ok := val.Interface().(ast.Node); ok {
+			visit(node)
+		}
+
+		walkAST(val.Elem(), visited, visit)
+	case reflect.Slice:
+		for i := 0; i < val.Len(); i++ {
+			walkAST(val.Index(i), visited, visit)
+		}
+	case reflect.Struct:
+		if !isASTType(val.Type()) {
+			return
+		}
+
+		for i := 0; i < val.NumField(); i++ {
+			walkAST(val.Field(i), visited, visit)
+		}
+	}
+}
+
+func isASTType(typ reflect.Type) bool {
+	return typ.Kind() == reflect.Struct && typ.PkgPath() == reflect.TypeOf(ast.Program{}).PkgPath()
+}
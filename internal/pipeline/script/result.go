@@ -0,0 +1,83 @@
+package script
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/dop251/goja"
+	"github.com/goccy/go-json"
+)
+
+// result is detached from the runtime it has been created by, so the runtime
+// can be reused for other executions while the result is still in use.
+type result struct {
+	value   any
+	typ     reflect.Type
+	boolean bool
+}
+
+func newResult(val goja.Value) *result {
+	return &result{value: val.Export(), typ: val.ExportType(), boolean: val.ToBoolean()}
+}
+
+func (r *result) ToInteger() int64 {
+	switch val := r.value.(type) {
+	case int64:
+		return val
+	case float64:
+		if math.IsNaN(val) {
+			return 0
+		}
+
+		return int64(val)
+	default:
+		res := r.ToFloat()
+		if math.IsNaN(res) {
+			return 0
+		}
+
+		return int64(res)
+	}
+}
+
+func (r *result) ToFloat() float64 {
+	switch val := r.value.(type) {
+	case int64:
+		return float64(val)
+	case float64:
+		return val
+	case bool:
+		if val {
+			return 1
+		}
+
+		return 0
+	case string:
+		res, err := strconv.ParseFloat(val, 64)
+		if err != nil {
+			return math.NaN()
+		}
+
+		return res
+	default:
+		return math.NaN()
+	}
+}
+
+func (r *result) String() string {
+	if r.value == nil {
+		return "undefined"
+	}
+
+	return fmt.Sprintf("%v", r.value)
+}
+
+func (r *result) ToBoolean() bool { return r.boolean }
+
+func (r *result) Export() interface{} { return r.value }
+
+func (r *result) ExportType() reflect.Type { return r.typ }
+
+func (r *result) MarshalJSON() ([]byte, error) { return json.Marshal(r.value) }
@@ -3,16 +3,19 @@ package script
 import (
 	"errors"
 	"reflect"
+	"sync"
 
 	"github.com/dop251/goja"
-	"github.com/rs/zerolog"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
-var ErrScriptExecution = errors.New("script error")
+var (
+	ErrScriptExecution = errors.New("script error")
+	ErrScriptTimeout   = errors.New("script execution timed out")
+)
 
 type Script interface {
 	ExecuteOnSubject(ctx heimdall.Context, sub *subject.Subject) (Result, error)
@@ -21,6 +24,9 @@ type Script interface {
 
 type script struct {
 	p *goja.Program
+	// each script has its own pool of runtimes, so state left by the execution
+	// of a script is never observed by any other script
+	engines sync.Pool
 }
 
 type Result interface {
@@ -33,93 +39,49 @@ type Result interface {
 }
 
 func New(val string) (Script, error) {
-	programm, err := goja.Compile("", val, true)
+	prg, err := goja.Parse("", val)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to compile script").
 			CausedBy(err)
 	}
 
-	return &script{p: programm}, nil
-}
-
-func (s *script) ExecuteOnSubject(ctx heimdall.Context, sub *subject.Subject) (Result, error) {
-	vm := goja.New()
-
-	// the error checks below are ignored by intention as these cannot happen here
-	// we can also not test the corresponding occurrence and if these would happen
-	// the script execution would result in an error anyway, which basically means
-	// failed execution of the handler using it.
-
-	hmdl := vm.NewObject()
-
-	// nolint: errcheck
-	hmdl.Set("Subject", sub)
-
-	// nolint: errcheck
-	hmdl.Set("RequestMethod", func() string { return ctx.RequestMethod() })
-
-	// nolint: errcheck
-	hmdl.Set("RequestURL", func() string { return ctx.RequestURL().String() })
-
-	// nolint: errcheck
-	hmdl.Set("RequestClientIPs", func() []string { return ctx.RequestClientIPs() })
-
-	// nolint: errcheck
-	hmdl.Set("RequestHeader", func(name string) string { return ctx.RequestHeader(name) })
-
-	// nolint: errcheck
-	hmdl.Set("RequestCookie", func(name string) string { return ctx.RequestCookie(name) })
-
-	// nolint: errcheck
-	hmdl.Set("RequestQueryParameter", func(name string) string { return ctx.RequestQueryParameter(name) })
-
-	console := vm.NewObject()
-
-	// nolint: errcheck
-	console.Set("log", func(val string) { zerolog.Ctx(ctx.AppContext()).Debug().Msg(val) })
-
-	// nolint: errcheck
-	vm.Set("heimdall", hmdl)
-
-	// nolint: errcheck
-	vm.Set("console", console)
+	instrument(prg)
 
-	val, err := vm.RunProgram(s.p)
+	programm, err := goja.CompileAST(prg, true)
 	if err != nil {
-		return nil, errorchain.New(ErrScriptExecution).CausedBy(err)
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to compile script").
+			CausedBy(err)
 	}
 
-	return val, nil
+	return &script{
+		p:       programm,
+		engines: sync.Pool{New: func() any { return newEngine() }},
+	}, nil
 }
 
-func (s *script) ExecuteOnPayload(ctx heimdall.Context, payload any) (Result, error) {
-	vm := goja.New()
-
-	// the error checks below are ignored by intention as these cannot happen here
-	// we can also not test the corresponding occurrence and if these would happen
-	// the script execution would result in an error anyway, which basically means
-	// failed execution of the handler using it.
-
-	hmdl := vm.NewObject()
-
-	// nolint: errcheck
-	hmdl.Set("Payload", payload)
-
-	console := vm.NewObject()
-
-	// nolint: errcheck
-	console.Set("log", func(val string) { zerolog.Ctx(ctx.AppContext()).Debug().Msg(val) })
+func (s *script) ExecuteOnSubject(ctx heimdall.Context, sub *subject.Subject) (Result, error) {
+	return s.execute(ctx, sub, goja.Undefined())
+}
 
-	// nolint: errcheck
-	vm.Set("heimdall", hmdl)
+func (s *script) ExecuteOnPayload(ctx heimdall.Context, payload any) (Result, error) {
+	return s.execute(ctx, goja.Undefined(), payload)
+}
 
-	// nolint: errcheck
-	vm.Set("console", console)
+func (s *script) execute(ctx heimdall.Context, sub any, payload any) (Result, error) {
+	eng := s.engines.Get().(*engine) // nolint: forcetypeassert
+	eng.ctx = ctx
 
-	val, err := vm.RunProgram(s.p)
+	val, err := eng.run(ctx.AppContext(), s.p, sub, payload)
 	if err != nil {
+		// the runtime is not reused, as the execution could have been interrupted at any point
 		return nil, errorchain.New(ErrScriptExecution).CausedBy(err)
 	}
 
-	return val, nil
+	res := newResult(val)
+
+	if eng.reset() {
+		s.engines.Put(eng)
+	}
+
+	return res, nil
 }
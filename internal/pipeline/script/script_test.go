@@ -2,7 +2,9 @@ package script_test
 
 import (
 	"context"
+	"net/http"
 	"net/url"
+	"sync"
 	"testing"
 
 	"github.com/goccy/go-json"
@@ -12,8 +14,13 @@ import (
 	"github.com/dadrus/heimdall/internal/heimdall/mocks"
 	"github.com/dadrus/heimdall/internal/pipeline/script"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x"
 )
 
+// depending on the speed of the machine, e.g. with the race detector enabled, the execution
+// of a script can time out before reaching the step limit
+const limitExceeded = "maximum number of loop iterations and function calls exceeded|timed out"
+
 func TestScriptExecuteOnSubject(t *testing.T) {
 	t.Parallel()
 
@@ -170,3 +177,415 @@ func TestScriptExecuteOnEmptyStringPayload(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, res.ToBoolean())
 }
+
+func TestScriptExecuteUsingStdLibAndRequestData(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+	ctx.On("RequestBody").Return([]byte(`{"action":"write"}`))
+	ctx.On("RequestFormParameter", "foo").Return("bar")
+	ctx.On("RequestHeader", "Authorization").
+		Return("Bearer eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0.eyJzdWIiOiJmb28iLCJzY3AiOlsicmVhZCJdfQ.")
+
+	ecmaScript, err := script.New(`
+var token = heimdall.RequestHeader("Authorization").split(" ")[1]
+var jwt = heimdall.JWTDecode(token)
+
+var res = {
+	"body_action": JSON.parse(heimdall.RequestBody()).action,
+	"form_param": heimdall.RequestFormParameter("foo"),
+	"jwt_alg": jwt.header.alg,
+	"jwt_sub": jwt.payload.sub,
+	"jwt_scp": jwt.payload.scp,
+	"b64_encoded": heimdall.Base64Encode("foo"),
+	"b64_decoded": heimdall.Base64Decode("YmFy"),
+	"sha256": heimdall.SHA256("foo"),
+	"sha512": heimdall.SHA512("foo").substring(0, 16)
+}
+
+res
+`)
+	require.NoError(t, err)
+
+	// WHEN
+	res, err := ecmaScript.ExecuteOnSubject(ctx, &subject.Subject{ID: "foo"})
+
+	// THEN
+	require.NoError(t, err)
+
+	rawJSON, err := json.Marshal(res)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+"body_action": "write",
+"form_param": "bar",
+"jwt_alg": "none",
+"jwt_sub": "foo",
+"jwt_scp": ["read"],
+"b64_encoded": "Zm9v",
+"b64_decoded": "bar",
+"sha256": "2c26b46b68ffc68ff99b453c1d30413413422d706483bfa0f98a5e886266e7ae",
+"sha512": "f7fbba6e0636f890"
+}`, string(rawJSON))
+}
+
+func TestScriptExecuteUsingGraphQLOperation(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		body   string
+		assert func(t *testing.T, err error, res any)
+	}{
+		{
+			uc:   "graphql request",
+			body: `{"query":"mutation Delete($id: ID!) { delete(id: $id) }","variables":{"id":"1"}}`,
+			assert: func(t *testing.T, err error, res any) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				rawJSON, err := json.Marshal(res)
+				require.NoError(t, err)
+				assert.JSONEq(t, `{"name":"Delete","type":"mutation","id":"1"}`, string(rawJSON))
+			},
+		},
+		{
+			uc:   "not a graphql request",
+			body: `{"foo":"bar"}`,
+			assert: func(t *testing.T, err error, res any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Nil(t, res)
+			},
+		},
+		{
+			uc:   "malformed graphql request",
+			body: `{"query":"mutation { delete"}`,
+			assert: func(t *testing.T, err error, res any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "malformed graphql document")
+			},
+		},
+	} {
+		t.Run(tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			ctx.On("RequestMethod").Return(http.MethodPost)
+			ctx.On("RequestHeader", "Content-Type").Return("application/json")
+			ctx.On("RequestBody").Return([]byte(tc.body))
+
+			ecmaScript, err := script.New(`
+var op = heimdall.GraphQLOperation()
+
+op === null ? null : { "name": op.name, "type": op.type, "id": op.variables.id }
+`)
+			require.NoError(t, err)
+
+			// WHEN
+			res, err := ecmaScript.ExecuteOnSubject(ctx, &subject.Subject{ID: "foo"})
+
+			// THEN
+			var val any
+			if err == nil {
+				val = res.Export()
+			}
+
+			tc.assert(t, err, val)
+		})
+	}
+}
+
+func TestScriptExecuteWithLimitsAndErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		script string
+		ctx    func() context.Context
+		assert func(t *testing.T, err error)
+	}{
+		{
+			uc:     "endless loop is stopped",
+			script: `while (true) {}`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Regexp(t, limitExceeded, err.Error())
+			},
+		},
+		{
+			uc:     "endless loop without a block is stopped",
+			script: `var i = 0; do i++; while (true)`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Regexp(t, limitExceeded, err.Error())
+			},
+		},
+		{
+			uc:     "exponential recursion is stopped",
+			script: `const fib = (n) => n < 2 ? n : fib(n - 1) + fib(n - 2); fib(40)`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Regexp(t, limitExceeded, err.Error())
+			},
+		},
+		{
+			uc:     "long running script is interrupted",
+			script: `for (let i = 0; i < 90000; i++) { "a".repeat(100000).indexOf("b") }`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "timed out")
+			},
+		},
+		{
+			uc:     "eval is not available",
+			script: `eval("while (true) {}")`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "eval is not defined")
+			},
+		},
+		{
+			uc:     "function constructor is not available",
+			script: `(function () {}).constructor("while (true) {}")()`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "dynamic code evaluation is not allowed")
+			},
+		},
+		{
+			uc:     "objects provided by heimdall cannot be modified",
+			script: `heimdall.SHA256 = function () { return "foo" }`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "read only property")
+			},
+		},
+		{
+			uc:     "unbounded recursion is stopped",
+			script: `function f(n) { return f(n + 1) }; f(0)`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "maximum call stack size exceeded")
+			},
+		},
+		{
+			uc:     "cancelled context interrupts execution",
+			script: `while (true) {}`,
+			ctx: func() context.Context {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				return ctx
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), context.Canceled.Error())
+			},
+		},
+		{
+			uc:     "invalid base64 value",
+			script: `heimdall.Base64Decode("!!!")`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "illegal base64")
+			},
+		},
+		{
+			uc:     "malformed jwt",
+			script: `heimdall.JWTDecode("foo.bar")`,
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, script.ErrScriptExecution)
+				assert.Contains(t, err.Error(), "malformed jwt")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			appCtx := x.IfThenElseExec(tc.ctx != nil, tc.ctx, context.Background)
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(appCtx)
+
+			ecmaScript, err := script.New(tc.script)
+			require.NoError(t, err)
+
+			// WHEN
+			_, err = ecmaScript.ExecuteOnPayload(ctx, nil)
+
+			// THEN
+			tc.assert(t, err)
+
+			// the pooled runtime is usable after an interrupted execution
+			res, err := newPayloadCheckScript(t).ExecuteOnPayload(ctx, "foo")
+			require.NoError(t, err)
+			assert.True(t, res.ToBoolean())
+		})
+	}
+}
+
+func TestScriptConcurrentExecutionsDoNotShareState(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ecmaScript, err := script.New(`heimdall.Subject === undefined && heimdall.Payload.id`)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+
+		go func(idx int) {
+			defer wg.Done()
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			// WHEN
+			res, err := ecmaScript.ExecuteOnPayload(ctx, map[string]any{"id": idx})
+
+			// THEN
+			assert.NoError(t, err)
+			assert.Equal(t, int64(idx), res.ToInteger())
+		}(i)
+	}
+
+	wg.Wait()
+}
+
+func TestScriptExecutionsDoNotLeakState(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	counter, err := script.New(`
+if (typeof counter === "undefined") { var counter = 0 }
+counter++
+globalThis.other = (globalThis.other || 0) + 1
+let declared = 1
+function fn() { return declared }
+counter + other + fn()`)
+	require.NoError(t, err)
+
+	tamper, err := script.New(`Array.prototype.includes = function() { return true }; true`)
+	require.NoError(t, err)
+
+	check, err := script.New(`[1, 2].includes(heimdall.Payload)`)
+	require.NoError(t, err)
+
+	ctx := &mocks.MockContext{}
+	ctx.On("AppContext").Return(context.Background())
+
+	// WHEN
+	first, err := counter.ExecuteOnPayload(ctx, nil)
+	require.NoError(t, err)
+
+	second, err := counter.ExecuteOnPayload(ctx, nil)
+	require.NoError(t, err)
+
+	_, err = tamper.ExecuteOnPayload(ctx, nil)
+	require.NoError(t, err)
+
+	res, err := check.ExecuteOnPayload(ctx, 3)
+	require.NoError(t, err)
+
+	// THEN
+	assert.Equal(t, int64(3), first.ToInteger())
+	assert.Equal(t, int64(3), second.ToInteger())
+	assert.False(t, res.ToBoolean())
+}
+
+func TestScriptExecuteInstrumentedScript(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc     string
+		script string
+		result any
+	}{
+		{uc: "while loop without a block", script: `var i = 0; while (i < 10) i++; i`, result: int64(10)},
+		{uc: "do-while loop without a block", script: `var i = 0; do i++; while (i < 10); i`, result: int64(10)},
+		{
+			uc:     "nested labeled loops",
+			script: `var i = 0; outer: for (; i < 3; i++) for (;;) break outer; i`,
+			result: int64(0),
+		},
+		{
+			uc:     "loop in an if statement with an else branch",
+			script: `var i = 0; if (true) while (i < 3) i++; else i = -1; i`,
+			result: int64(3),
+		},
+		{uc: "for-of loop", script: `let sum = 0; for (const v of [1, 2, 3]) sum += v; sum`, result: int64(6)},
+		{uc: "for-in loop", script: `let keys = ""; for (const k in { a: 1, b: 2 }) keys += k; keys`, result: "ab"},
+		{uc: "arrow functions", script: `((x) => (y) => ({ sum: x + y }))(1)(2).sum`, result: int64(3)},
+		{uc: "function with directive", script: `(function () { "use strict"; return 5 })()`, result: int64(5)},
+		{uc: "class", script: `class A { get v() { return 7 } }; new A().v`, result: int64(7)},
+		{uc: "empty script", script: ``, result: nil},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			ecmaScript, err := script.New(tc.script)
+			require.NoError(t, err)
+
+			for i := 0; i < 2; i++ {
+				// WHEN
+				res, err := ecmaScript.ExecuteOnPayload(ctx, nil)
+
+				// THEN
+				require.NoError(t, err)
+				assert.Equal(t, tc.result, res.Export())
+			}
+		})
+	}
+}
+
+func newPayloadCheckScript(t *testing.T) script.Script {
+	t.Helper()
+
+	ecmaScript, err := script.New(`heimdall.Payload === "foo"`)
+	require.NoError(t, err)
+
+	return ecmaScript
+}
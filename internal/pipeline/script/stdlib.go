@@ -0,0 +1,87 @@
+package script
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/dop251/goja"
+	"github.com/goccy/go-json"
+)
+
+var errMalformedJWT = errors.New("malformed jwt")
+
+// registerStdLib registers helper functions, which are available to all scripts in addition
+// to the ECMAScript built-ins, like JSON.
+func registerStdLib(hmdl *goja.Object) {
+	// nolint: errcheck
+	hmdl.Set("Base64Encode", func(val string) string { return base64.StdEncoding.EncodeToString([]byte(val)) })
+
+	// nolint: errcheck
+	hmdl.Set("Base64Decode", base64Decode)
+
+	// nolint: errcheck
+	hmdl.Set("SHA256", func(val string) string {
+		hash := sha256.Sum256([]byte(val))
+
+		return hex.EncodeToString(hash[:])
+	})
+
+	// nolint: errcheck
+	hmdl.Set("SHA512", func(val string) string {
+		hash := sha512.Sum512([]byte(val))
+
+		return hex.EncodeToString(hash[:])
+	})
+
+	// nolint: errcheck
+	hmdl.Set("JWTDecode", jwtDecode)
+}
+
+func base64Decode(val string) (string, error) {
+	res, err := base64.StdEncoding.DecodeString(val)
+	if err != nil {
+		return "", err
+	}
+
+	return string(res), nil
+}
+
+// jwtDecode decodes the header and the payload of the given JWT. The signature is NOT verified.
+func jwtDecode(token string) (map[string]any, error) {
+	const jwtParts = 3
+
+	parts := strings.Split(token, ".")
+	if len(parts) != jwtParts {
+		return nil, errMalformedJWT
+	}
+
+	header, err := decodeJWTPart(parts[0])
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := decodeJWTPart(parts[1])
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"header": header, "payload": payload}, nil
+}
+
+func decodeJWTPart(part string) (map[string]any, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return nil, err
+	}
+
+	var res map[string]any
+	if err = json.Unmarshal(raw, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
@@ -1,6 +1,101 @@
 package subject
 
+import "reflect"
+
 type Subject struct {
 	ID         string         `json:"id"`
 	Attributes map[string]any `json:"attributes"`
 }
+
+// Copy returns a deep copy of the subject. Attributes, including nested maps and slices, can be
+// modified on the copy without affecting the original subject.
+func (s *Subject) Copy() *Subject {
+	if s == nil {
+		return nil
+	}
+
+	attributes := make(map[string]any, len(s.Attributes))
+	for key, value := range s.Attributes {
+		attributes[key] = DeepCopy(value)
+	}
+
+	return &Subject{ID: s.ID, Attributes: attributes}
+}
+
+// DeepCopy returns a deep copy of the given attribute value. Nested maps and slices are copied, so the
+// copy can be modified without affecting the original value.
+func DeepCopy(value any) any {
+	switch typed := value.(type) {
+	case nil, string, bool, float64, int, int64:
+		return value
+	case map[string]any:
+		result := make(map[string]any, len(typed))
+		for key, entry := range typed {
+			result[key] = DeepCopy(entry)
+		}
+
+		return result
+	case []any:
+		result := make([]any, len(typed))
+		for idx, entry := range typed {
+			result[idx] = DeepCopy(entry)
+		}
+
+		return result
+	default:
+		return deepCopyValue(reflect.ValueOf(value)).Interface()
+	}
+}
+
+// deepCopyValue copies maps, slices and arrays of other types than the ones resulting from
+// JSON decoding, like map[string]string, or []string. Other values are immutable, or, like
+// pointers, are not expected in attributes and are taken over as is.
+func deepCopyValue(value reflect.Value) reflect.Value {
+	switch value.Kind() { // nolint: exhaustive
+	case reflect.Map:
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.MakeMapWithSize(value.Type(), value.Len())
+
+		iter := value.MapRange()
+		for iter.Next() {
+			result.SetMapIndex(iter.Key(), copyElement(iter.Value(), value.Type().Elem()))
+		}
+
+		return result
+	case reflect.Slice:
+		if value.IsNil() {
+			return value
+		}
+
+		result := reflect.MakeSlice(value.Type(), value.Len(), value.Len())
+		for idx := 0; idx < value.Len(); idx++ {
+			result.Index(idx).Set(copyElement(value.Index(idx), value.Type().Elem()))
+		}
+
+		return result
+	case reflect.Array:
+		result := reflect.New(value.Type()).Elem()
+		for idx := 0; idx < value.Len(); idx++ {
+			result.Index(idx).Set(copyElement(value.Index(idx), value.Type().Elem()))
+		}
+
+		return result
+	default:
+		return value
+	}
+}
+
+func copyElement(value reflect.Value, typ reflect.Type) reflect.Value {
+	if typ.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Zero(typ)
+		}
+
+		return reflect.ValueOf(DeepCopy(value.Interface()))
+	}
+
+	return deepCopyValue(value)
+}
@@ -0,0 +1,91 @@
+package subject
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubjectCopy(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc       string
+		subject  *Subject
+		modify   func(t *testing.T, sub *Subject)
+		expected map[string]any
+	}{
+		{
+			uc: "nil subject",
+		},
+		{
+			uc:      "subject without attributes",
+			subject: &Subject{ID: "foo"},
+			modify: func(t *testing.T, sub *Subject) {
+				t.Helper()
+
+				sub.Attributes["foo"] = "bar"
+			},
+		},
+		{
+			uc: "subject with nested attributes",
+			subject: &Subject{ID: "foo", Attributes: map[string]any{
+				"name":   "bar",
+				"groups": []any{"a", map[string]any{"b": "c"}},
+				"nested": map[string]any{"level": map[string]any{"value": 1.0}},
+				"roles":  []string{"admin"},
+				"labels": map[string]string{"team": "x"},
+				"empty":  nil,
+			}},
+			modify: func(t *testing.T, sub *Subject) {
+				t.Helper()
+
+				// nolint: forcetypeassert
+				groups := sub.Attributes["groups"].([]any)
+				// nolint: forcetypeassert
+				nested := sub.Attributes["nested"].(map[string]any)["level"].(map[string]any)
+
+				sub.Attributes["name"] = "baz"
+				groups[0] = "x"
+				groups[1].(map[string]any)["b"] = "x" // nolint: forcetypeassert
+				nested["value"] = 2.0
+				sub.Attributes["roles"].([]string)[0] = "x"                // nolint: forcetypeassert
+				sub.Attributes["labels"].(map[string]string)["team"] = "y" // nolint: forcetypeassert
+			},
+			expected: map[string]any{
+				"name":   "bar",
+				"groups": []any{"a", map[string]any{"b": "c"}},
+				"nested": map[string]any{"level": map[string]any{"value": 1.0}},
+				"roles":  []string{"admin"},
+				"labels": map[string]string{"team": "x"},
+				"empty":  nil,
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			sub := tc.subject.Copy()
+
+			// THEN
+			if tc.subject == nil {
+				assert.Nil(t, sub)
+
+				return
+			}
+
+			require.NotNil(t, sub)
+			assert.NotSame(t, tc.subject, sub)
+			assert.Equal(t, tc.subject.ID, sub.ID)
+			assert.Equal(t, len(tc.subject.Attributes), len(sub.Attributes))
+
+			tc.modify(t, sub)
+
+			if len(tc.expected) == 0 {
+				assert.Empty(t, tc.subject.Attributes)
+			} else {
+				assert.Equal(t, tc.expected, tc.subject.Attributes)
+			}
+		})
+	}
+}
@@ -14,8 +14,7 @@ func DecodeTemplateHookFunc() mapstructure.DecodeHookFunc {
 			return data, nil
 		}
 
-		dect := reflect.ValueOf(&tpl).Elem().Type()
-		if !dect.AssignableTo(to) {
+		if to != reflect.ValueOf(&tpl).Elem().Type() {
 			return data, nil
 		}
 
@@ -9,6 +9,7 @@ import (
 	"text/template"
 
 	"github.com/Masterminds/sprig/v3"
+	goldap "github.com/go-ldap/ldap/v3"
 
 	"github.com/dadrus/heimdall/internal/heimdall"
 	"github.com/dadrus/heimdall/internal/pipeline/subject"
@@ -30,7 +31,7 @@ type templateImpl struct {
 func New(val string) (Template, error) {
 	tmpl, err := template.New("Heimdall").
 		Funcs(sprig.TxtFuncMap()).
-		Funcs(template.FuncMap{"urlenc": url.QueryEscape}).
+		Funcs(template.FuncMap{"urlenc": url.QueryEscape, "ldapenc": goldap.EscapeFilter}).
 		Parse(val)
 	if err != nil {
 		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "failed to parse template").
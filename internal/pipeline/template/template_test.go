@@ -49,7 +49,8 @@ func TestTemplateRender(t *testing.T) {
 "my_header": {{ .RequestHeader "X-My-Header" | quote }},
 "my_cookie": {{ .RequestCookie "session_cookie" | quote }},
 "my_query_param": {{ .RequestQueryParameter "my_query_param" | quote }},
-"ips": "{{ range $i, $el := .RequestClientIPs -}}{{ if $i }} {{ end }}{{ $el }}{{ end }}"
+"ips": "{{ range $i, $el := .RequestClientIPs -}}{{ if $i }} {{ end }}{{ $el }}{{ end }}",
+"ldap_filter": {{ printf "(uid=%s)" (ldapenc "foo*)") | quote }}
 }`)
 	require.NoError(t, err)
 
@@ -69,6 +70,7 @@ func TestTemplateRender(t *testing.T) {
 "my_header": "my-value",
 "my_cookie": "session-value",
 "my_query_param": "query_value",
-"ips": "192.168.1.1"
+"ips": "192.168.1.1",
+"ldap_filter": "(uid=foo\\2a\\29)"
 }`, res)
 }
@@ -0,0 +1,55 @@
+package rules
+
+import (
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/cellib"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// conditionalSubjectHandler executes the wrapped handler only if the configured condition
+// evaluates to true. Otherwise, the step is skipped.
+type conditionalSubjectHandler struct {
+	condition *cellib.Expression
+	handler   subjectHandler
+}
+
+func newConditionalSubjectHandler(condition any, handler subjectHandler) (subjectHandler, error) {
+	if condition == nil {
+		return handler, nil
+	}
+
+	value, ok := condition.(string)
+	if !ok || len(value) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"execution condition must be a non empty string")
+	}
+
+	expr, err := cellib.NewExpression(value, "")
+	if err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration, "bad execution condition").
+			CausedBy(err)
+	}
+
+	return &conditionalSubjectHandler{condition: expr, handler: handler}, nil
+}
+
+func (h *conditionalSubjectHandler) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	canExecute, err := h.condition.Eval(ctx, sub, nil)
+	if err != nil {
+		return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to evaluate execution condition").
+			CausedBy(err)
+	}
+
+	if !canExecute {
+		logger.Debug().Msgf("Execution condition '%s' not met. Skipping pipeline step", h.condition.Value)
+
+		return nil
+	}
+
+	return h.handler.Execute(ctx, sub)
+}
@@ -0,0 +1,174 @@
+package rules
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	rulemocks "github.com/dadrus/heimdall/internal/rules/mocks"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestNewConditionalSubjectHandler(t *testing.T) {
+	t.Parallel()
+
+	handler := &rulemocks.MockSubjectHandler{}
+
+	for _, tc := range []struct {
+		uc        string
+		condition any
+		assert    func(t *testing.T, err error, sh subjectHandler)
+	}{
+		{
+			uc: "without condition",
+			assert: func(t *testing.T, err error, sh subjectHandler) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, handler, sh)
+			},
+		},
+		{
+			uc:        "with empty condition",
+			condition: "",
+			assert: func(t *testing.T, err error, sh subjectHandler) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "non empty string")
+			},
+		},
+		{
+			uc:        "with condition not resulting in a boolean value",
+			condition: "Request.Method",
+			assert: func(t *testing.T, err error, sh subjectHandler) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad execution condition")
+			},
+		},
+		{
+			uc:        "with valid condition",
+			condition: "Request.Method == 'GET'",
+			assert: func(t *testing.T, err error, sh subjectHandler) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				csh, ok := sh.(*conditionalSubjectHandler)
+				require.True(t, ok)
+				assert.Equal(t, handler, csh.handler)
+				assert.Equal(t, "Request.Method == 'GET'", csh.condition.Value)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			sh, err := newConditionalSubjectHandler(tc.condition, handler)
+
+			// THEN
+			tc.assert(t, err, sh)
+		})
+	}
+}
+
+func TestConditionalSubjectHandlerExecute(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc             string
+		condition      string
+		configureMocks func(t *testing.T, handler *rulemocks.MockSubjectHandler)
+		assert         func(t *testing.T, err error)
+	}{
+		{
+			uc:        "condition is met",
+			condition: "Subject.Attributes.tenant == 'acme' && Request.URL.Path.startsWith('/api')",
+			configureMocks: func(t *testing.T, handler *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				handler.On("Execute", mock.Anything, mock.Anything).Return(nil)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:        "condition is met, but handler fails",
+			condition: "Request.Method == 'GET'",
+			configureMocks: func(t *testing.T, handler *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				handler.On("Execute", mock.Anything, mock.Anything).Return(testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, testsupport.ErrTestPurpose, err)
+			},
+		},
+		{
+			uc:        "condition is not met",
+			condition: "has(Subject.Attributes.group)",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+		{
+			uc:        "condition evaluation fails",
+			condition: "Subject.Attributes.group == 'admin'",
+			assert: func(t *testing.T, err error) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "failed to evaluate execution condition")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			configureMocks := func(t *testing.T, _ *rulemocks.MockSubjectHandler) { t.Helper() }
+			if tc.configureMocks != nil {
+				configureMocks = tc.configureMocks
+			}
+
+			handler := &rulemocks.MockSubjectHandler{}
+			configureMocks(t, handler)
+
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+			ctx.On("RequestMethod").Return(http.MethodGet).Maybe()
+			ctx.On("RequestURL").Return(&url.URL{Scheme: "https", Host: "foo.bar", Path: "/api/v1"}).Maybe()
+			ctx.On("RequestClientIPs").Return([]string{"127.0.0.1"}).Maybe()
+
+			sub := &subject.Subject{ID: "foo", Attributes: map[string]any{"tenant": "acme"}}
+
+			sh, err := newConditionalSubjectHandler(tc.condition, handler)
+			require.NoError(t, err)
+
+			// WHEN
+			err = sh.Execute(ctx, sub)
+
+			// THEN
+			tc.assert(t, err)
+			handler.AssertExpectations(t)
+		})
+	}
+}
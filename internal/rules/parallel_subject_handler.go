@@ -0,0 +1,69 @@
+package rules
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/rs/zerolog"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+)
+
+// parallelSubjectHandler executes independent handlers concurrently. Each handler works on its own
+// copy of the subject. The attributes set by the handlers are merged into the actual subject in the
+// order of definition of the handlers, so the result does not depend on the order the handlers
+// complete in.
+type parallelSubjectHandler []subjectHandler
+
+func (ph parallelSubjectHandler) Execute(ctx heimdall.Context, sub *subject.Subject) error {
+	logger := zerolog.Ctx(ctx.AppContext())
+
+	var wg sync.WaitGroup
+
+	syncCtx := heimdall.NewSynchronizedContext(ctx)
+	subjects := make([]*subject.Subject, len(ph))
+	errs := make([]error, len(ph))
+
+	for idx, handler := range ph {
+		subjects[idx] = sub.Copy()
+
+		wg.Add(1)
+
+		go func(idx int, handler subjectHandler) {
+			defer wg.Done()
+
+			errs[idx] = handler.Execute(syncCtx, subjects[idx])
+		}(idx, handler)
+	}
+
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			logger.Debug().Err(err).Msg("Parallel pipeline step execution failed")
+
+			return err
+		}
+	}
+
+	if sub == nil {
+		return nil
+	}
+
+	original := sub.Copy()
+
+	if sub.Attributes == nil {
+		sub.Attributes = make(map[string]any)
+	}
+
+	for _, branch := range subjects {
+		for key, value := range branch.Attributes {
+			if current, present := original.Attributes[key]; !present || !reflect.DeepEqual(current, value) {
+				sub.Attributes[key] = value
+			}
+		}
+	}
+
+	return nil
+}
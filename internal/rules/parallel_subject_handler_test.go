@@ -0,0 +1,195 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/heimdall/mocks"
+	"github.com/dadrus/heimdall/internal/pipeline/subject"
+	rulemocks "github.com/dadrus/heimdall/internal/rules/mocks"
+	"github.com/dadrus/heimdall/internal/testsupport"
+)
+
+func TestParallelSubjectHandlerExecute(t *testing.T) {
+	t.Parallel()
+
+	setAttribute := func(key string, value any, delay time.Duration) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			time.Sleep(delay)
+
+			args.Get(1).(*subject.Subject).Attributes[key] = value
+		}
+	}
+
+	for _, tc := range []struct {
+		uc             string
+		subject        *subject.Subject
+		configureMocks func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler)
+		assert         func(t *testing.T, err error, sub *subject.Subject)
+	}{
+		{
+			uc:      "all handlers succeed",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"baz": "zab"}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("first", "1", 20*time.Millisecond)).Return(nil)
+				second.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("second", "2", 0)).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"baz": "zab", "first": "1", "second": "2"}, sub.Attributes)
+			},
+		},
+		{
+			uc:      "handlers setting the same attribute are merged in the order of their definition",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"shared": "initial"}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("shared", "first", 0)).Return(nil)
+				second.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("shared", "second", 20*time.Millisecond)).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"shared": "second"}, sub.Attributes)
+			},
+		},
+		{
+			uc:      "unchanged attributes do not override changes done by other handlers",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{"shared": "initial"}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("shared", "first", 0)).Return(nil)
+				second.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("other", "second", 0)).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, map[string]any{"shared": "first", "other": "second"}, sub.Attributes)
+			},
+		},
+		{
+			uc: "handlers setting nested attributes do not affect each other",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{
+				"profile": map[string]any{"name": "foo"},
+			}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				setNested := func(key string) func(args mock.Arguments) {
+					return func(args mock.Arguments) {
+						// nolint: forcetypeassert
+						profile := args.Get(1).(*subject.Subject).Attributes["profile"].(map[string]any)
+
+						for i := 0; i < 100; i++ {
+							profile[key] = i
+						}
+					}
+				}
+
+				first.On("Execute", mock.Anything, mock.Anything).Run(setNested("first")).Return(nil)
+				second.On("Execute", mock.Anything, mock.Anything).Run(setNested("second")).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t,
+					map[string]any{"profile": map[string]any{"name": "foo", "second": 99}},
+					sub.Attributes)
+			},
+		},
+		{
+			uc:      "first failing handler by definition order decides",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, mock.Anything).
+					Run(func(mock.Arguments) { time.Sleep(20 * time.Millisecond) }).
+					Return(testsupport.ErrTestPurpose)
+				second.On("Execute", mock.Anything, mock.Anything).Return(heimdall.ErrCommunication)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, testsupport.ErrTestPurpose, err)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc:      "failing handler prevents merging of attributes",
+			subject: &subject.Subject{ID: "foo", Attributes: map[string]any{}},
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, mock.Anything).
+					Run(setAttribute("first", "1", 0)).Return(nil)
+				second.On("Execute", mock.Anything, mock.Anything).Return(testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, testsupport.ErrTestPurpose, err)
+				assert.Empty(t, sub.Attributes)
+			},
+		},
+		{
+			uc: "with nil subject",
+			configureMocks: func(t *testing.T, ctx *mocks.MockContext, first, second *rulemocks.MockSubjectHandler) {
+				t.Helper()
+
+				first.On("Execute", mock.Anything, (*subject.Subject)(nil)).Return(nil)
+				second.On("Execute", mock.Anything, (*subject.Subject)(nil)).Return(nil)
+			},
+			assert: func(t *testing.T, err error, sub *subject.Subject) {
+				t.Helper()
+
+				require.NoError(t, err)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			ctx := &mocks.MockContext{}
+			ctx.On("AppContext").Return(context.Background())
+
+			first := &rulemocks.MockSubjectHandler{}
+			second := &rulemocks.MockSubjectHandler{}
+			tc.configureMocks(t, ctx, first, second)
+
+			handler := parallelSubjectHandler{first, second}
+
+			// WHEN
+			err := handler.Execute(ctx, tc.subject)
+
+			// THEN
+			tc.assert(t, err, tc.subject)
+
+			first.AssertExpectations(t)
+			second.AssertExpectations(t)
+			ctx.AssertExpectations(t)
+		})
+	}
+}
@@ -52,6 +52,8 @@ func (f *ruleFactory) createExecutePipeline(
 	)
 
 	for _, pipelineStep := range pipeline {
+		condition := pipelineStep["if"]
+
 		id, found := pipelineStep["authenticator"]
 		if found {
 			if len(subjectHandlers) != 0 || len(mutators) != 0 {
@@ -59,6 +61,11 @@ func (f *ruleFactory) createExecutePipeline(
 					"an authenticator is defined after some other non authenticator type")
 			}
 
+			if condition != nil {
+				return nil, nil, nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+					"execution conditions are not supported for authenticators")
+			}
+
 			authenticator, err := f.hf.CreateAuthenticator(id.(string), f.getConfig(pipelineStep["config"]))
 			if err != nil {
 				return nil, nil, nil, err
@@ -81,7 +88,12 @@ func (f *ruleFactory) createExecutePipeline(
 				return nil, nil, nil, err
 			}
 
-			subjectHandlers = append(subjectHandlers, authorizer)
+			handler, err := newConditionalSubjectHandler(condition, authorizer)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			subjectHandlers = append(subjectHandlers, handler)
 
 			continue
 		}
@@ -93,12 +105,34 @@ func (f *ruleFactory) createExecutePipeline(
 					"at least one mutator is defined before a hydrator")
 			}
 
-			hydrator, err := f.hf.CreateHydrator(id.(string), f.getConfig(pipelineStep["config"]))
+			handler, err := f.createHydrator(id, pipelineStep)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			subjectHandlers = append(subjectHandlers, handler)
+
+			continue
+		}
+
+		steps, found := pipelineStep["parallel"]
+		if found {
+			if len(mutators) != 0 {
+				return nil, nil, nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+					"at least one mutator is defined before a parallel group")
+			}
+
+			group, err := f.createParallelGroup(steps)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			handler, err := newConditionalSubjectHandler(condition, group)
 			if err != nil {
 				return nil, nil, nil, err
 			}
 
-			subjectHandlers = append(subjectHandlers, hydrator)
+			subjectHandlers = append(subjectHandlers, handler)
 
 			continue
 		}
@@ -110,7 +144,12 @@ func (f *ruleFactory) createExecutePipeline(
 				return nil, nil, nil, err
 			}
 
-			mutators = append(mutators, mutator)
+			handler, err := newConditionalSubjectHandler(condition, mutator)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+
+			mutators = append(mutators, handler)
 
 			continue
 		}
@@ -122,6 +161,49 @@ func (f *ruleFactory) createExecutePipeline(
 	return authenticators, subjectHandlers, mutators, nil
 }
 
+func (f *ruleFactory) createHydrator(id any, pipelineStep map[string]any) (subjectHandler, error) {
+	hydrator, err := f.hf.CreateHydrator(id.(string), f.getConfig(pipelineStep["config"]))
+	if err != nil {
+		return nil, err
+	}
+
+	return newConditionalSubjectHandler(pipelineStep["if"], hydrator)
+}
+
+func (f *ruleFactory) createParallelGroup(steps any) (subjectHandler, error) {
+	stepList, ok := steps.([]any)
+	if !ok || len(stepList) == 0 {
+		return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+			"parallel group must be a non empty list of hydrators")
+	}
+
+	group := make(parallelSubjectHandler, len(stepList))
+
+	for idx, step := range stepList {
+		pipelineStep, ok := step.(map[string]any)
+		if !ok {
+			if m, isMap := step.(map[any]any); isMap {
+				pipelineStep = f.getConfig(m)
+			}
+		}
+
+		id, found := pipelineStep["hydrator"]
+		if !found {
+			return nil, errorchain.NewWithMessage(heimdall.ErrConfiguration,
+				"only hydrators can be executed in parallel")
+		}
+
+		handler, err := f.createHydrator(id, pipelineStep)
+		if err != nil {
+			return nil, err
+		}
+
+		group[idx] = handler
+	}
+
+	return group, nil
+}
+
 func (f *ruleFactory) getConfig(conf any) map[string]any {
 	var mapConf map[string]any
 
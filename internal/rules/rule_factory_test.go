@@ -758,6 +758,267 @@ func TestRuleFactoryCreateRule(t *testing.T) {
 				assert.Len(t, rul.eh, 0)
 			},
 		},
+		{
+			uc: "with execution condition for an authenticator",
+			config: config.RuleConfig{
+				ID:      "foobar",
+				URL:     "http://foo.bar",
+				Execute: []map[string]any{{"authenticator": "foo", "if": "true"}},
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "not supported for authenticators")
+			},
+		},
+		{
+			uc: "with malformed execution condition",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"hydrator": "bar", "if": "Subject.ID =="},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateHydrator", "bar", mock.Anything).
+					Return(&mocks2.MockHydrator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "bad execution condition")
+			},
+		},
+		{
+			uc: "with execution condition not being a string",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"mutator": "bar", "if": true},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateMutator", "bar", mock.Anything).
+					Return(&mocks2.MockMutator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "must be a non empty string")
+			},
+		},
+		{
+			uc: "with execution conditions for hydrator, authorizer and mutator",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"hydrator": "bar", "if": "has(Subject.Attributes.tenant)"},
+					{"authorizer": "baz"},
+					{"mutator": "zab", "if": "Request.URL.Path.startsWith('/api')"},
+				},
+				Methods: []string{"FOO"},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateHydrator", "bar", mock.Anything).
+					Return(&mocks2.MockHydrator{}, nil)
+				mhf.On("CreateAuthorizer", "baz", mock.Anything).
+					Return(&mocks2.MockAuthorizer{}, nil)
+				mhf.On("CreateMutator", "zab", mock.Anything).
+					Return(&mocks2.MockMutator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, rul)
+
+				require.Len(t, rul.sh, 2)
+				assert.IsType(t, &conditionalSubjectHandler{}, rul.sh[0])
+				assert.IsType(t, &mocks2.MockAuthorizer{}, rul.sh[1])
+				require.Len(t, rul.m, 1)
+				assert.IsType(t, &conditionalSubjectHandler{}, rul.m[0])
+			},
+		},
+		{
+			uc: "with parallel group not being a list",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"parallel": "bar"},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "non empty list of hydrators")
+			},
+		},
+		{
+			uc: "with parallel group containing an authorizer",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"parallel": []any{
+						map[string]any{"hydrator": "bar"},
+						map[string]any{"authorizer": "baz"},
+					}},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateHydrator", "bar", mock.Anything).
+					Return(&mocks2.MockHydrator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "only hydrators can be executed in parallel")
+			},
+		},
+		{
+			uc: "with parallel group defined after a mutator",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"mutator": "bar"},
+					{"parallel": []any{map[string]any{"hydrator": "baz"}}},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateMutator", "bar", mock.Anything).
+					Return(&mocks2.MockMutator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+				assert.Contains(t, err.Error(), "mutator is defined before a parallel group")
+			},
+		},
+		{
+			uc: "with error while creating a hydrator of a parallel group",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{"parallel": []any{map[any]any{"hydrator": "bar"}}},
+				},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateHydrator", "bar", mock.Anything).
+					Return(nil, testsupport.ErrTestPurpose)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.Equal(t, testsupport.ErrTestPurpose, err)
+			},
+		},
+		{
+			uc: "with parallel group",
+			config: config.RuleConfig{
+				ID:  "foobar",
+				URL: "http://foo.bar",
+				Execute: []map[string]any{
+					{"authenticator": "foo"},
+					{
+						"parallel": []any{
+							map[string]any{"hydrator": "bar", "config": map[string]any{"cache_ttl": "0s"}},
+							map[any]any{"hydrator": "baz", "if": "has(Subject.Attributes.tenant)"},
+						},
+						"if": "Request.Method == 'GET'",
+					},
+					{"authorizer": "zab"},
+					{"mutator": "oof"},
+				},
+				Methods: []string{"FOO"},
+			},
+			configureMocks: func(t *testing.T, mhf *mocks.MockHandlerFactory) {
+				t.Helper()
+
+				mhf.On("CreateAuthenticator", "foo", mock.Anything).
+					Return(&mocks2.MockAuthenticator{}, nil)
+				mhf.On("CreateHydrator", "bar", map[string]any{"cache_ttl": "0s"}).
+					Return(&mocks2.MockHydrator{}, nil)
+				mhf.On("CreateHydrator", "baz", mock.Anything).
+					Return(&mocks2.MockHydrator{}, nil)
+				mhf.On("CreateAuthorizer", "zab", mock.Anything).
+					Return(&mocks2.MockAuthorizer{}, nil)
+				mhf.On("CreateMutator", "oof", mock.Anything).
+					Return(&mocks2.MockMutator{}, nil)
+			},
+			assert: func(t *testing.T, err error, rul *ruleImpl) {
+				t.Helper()
+
+				require.NoError(t, err)
+				require.NotNil(t, rul)
+
+				require.Len(t, rul.sh, 2)
+
+				conditional, ok := rul.sh[0].(*conditionalSubjectHandler)
+				require.True(t, ok)
+
+				group, ok := conditional.handler.(parallelSubjectHandler)
+				require.True(t, ok)
+				require.Len(t, group, 2)
+				assert.IsType(t, &mocks2.MockHydrator{}, group[0])
+				assert.IsType(t, &conditionalSubjectHandler{}, group[1])
+			},
+		},
 		{
 			uc: "with default rule and with id and url only",
 			config: config.RuleConfig{
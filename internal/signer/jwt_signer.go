@@ -3,7 +3,9 @@ package signer
 import (
 	"crypto"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,73 +20,136 @@ import (
 	"github.com/dadrus/heimdall/internal/x/errorchain"
 )
 
+// NewJWTSigner creates a signer using the signing key of the given key store. If the key store is a
+// keystore.KeyRing, the signer follows the key rotations performed by it. Otherwise, a key ring with
+// a fixed signing key is created.
 func NewJWTSigner(ks keystore.KeyStore, conf config.SignerConfig, logger zerolog.Logger) (heimdall.JWTSigner, error) {
-	var (
-		kse *keystore.Entry
-		err error
-	)
+	kr, ok := ks.(keystore.KeyRing)
+	if !ok {
+		var err error
 
-	if len(conf.KeyID) == 0 {
-		logger.Warn().Msg("No key id for signer configured. Taking first entry from the key store")
+		if len(conf.KeyID) == 0 {
+			logger.Warn().Msg("No key id for signer configured. Taking first entry from the key store")
+		}
 
-		kse, err = ks.Entries()[0], nil
-	} else {
-		kse, err = ks.GetKey(conf.KeyID)
+		if kr, err = keystore.NewKeyRing(ks, conf.KeyID, conf.Algorithm); err != nil {
+			return nil, err
+		}
 	}
 
-	if err != nil {
-		return nil, err
-	}
+	kse := kr.SigningKey()
 
 	logger.Info().Str("_key_id", kse.KeyID).Msg("Signer configured")
 
 	return &jwtSigner{
-		iss: conf.Name,
-		jwk: kse.JWK(),
-		key: kse.PrivateKey,
+		iss:   conf.Name,
+		kr:    kr,
+		entry: kse,
+		jwk:   kse.JWK(),
+		key:   kse.PrivateKey,
 	}, nil
 }
 
 type jwtSigner struct {
 	iss string
-	jwk jose.JSONWebKey
-	key crypto.Signer
+	kr  keystore.KeyRing
+
+	mut   sync.Mutex
+	entry *keystore.Entry
+	jwk   jose.JSONWebKey
+	key   crypto.Signer
+}
+
+// signingKey returns the key to sign with. As the signing key can change during key rotation, the
+// key ring is consulted on every use.
+func (s *jwtSigner) signingKey() (jose.JSONWebKey, crypto.Signer) {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	if s.kr != nil {
+		if entry := s.kr.SigningKey(); entry != s.entry {
+			s.entry, s.jwk, s.key = entry, entry.JWK(), entry.PrivateKey
+		}
+	}
+
+	return s.jwk, s.key
 }
 
+// Hash changes with the signing key. So everything signed and cached before a key switch, is
+// not reused afterwards.
 func (s *jwtSigner) Hash() string {
+	jwk, _ := s.signingKey()
+
 	hash := sha256.New()
-	hash.Write([]byte(s.jwk.KeyID))
-	hash.Write([]byte(s.jwk.Algorithm))
+	hash.Write([]byte(jwk.KeyID))
+	hash.Write([]byte(jwk.Algorithm))
 	hash.Write([]byte(s.iss))
 
 	return hex.EncodeToString(hash.Sum(nil))
 }
 
-func (s *jwtSigner) Sign(sub string, ttl time.Duration, custClaims map[string]any) (string, error) {
+func (s *jwtSigner) Sign(
+	sub string, ttl time.Duration, custClaims map[string]any, opts heimdall.JWTOptions,
+) (string, error) {
+	jwk, key := s.signingKey()
+
 	signerOpts := jose.SignerOptions{}
+	signerOpts.WithType("JWT")
+
+	for name, value := range opts.Headers {
+		signerOpts.WithHeader(jose.HeaderKey(name), value)
+	}
+
+	if opts.IncludeCertChain {
+		if len(jwk.Certificates) == 0 {
+			return "", errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"no certificate chain available for key %s to be used in x5c header", jwk.KeyID)
+		}
+
+		chain := make([]string, len(jwk.Certificates))
+		for idx, cert := range jwk.Certificates {
+			chain[idx] = base64.StdEncoding.EncodeToString(cert.Raw)
+		}
+
+		signerOpts.WithHeader("x5c", chain)
+	}
+
+	// set last, so these cannot be overridden by the configured headers
 	signerOpts.
-		WithType("JWT").
-		WithHeader("kid", s.jwk.KeyID).
-		WithHeader("alg", s.jwk.Algorithm)
+		WithHeader("kid", jwk.KeyID).
+		WithHeader("alg", jwk.Algorithm)
 
 	signer, err := jose.NewSigner(
-		jose.SigningKey{Algorithm: jose.SignatureAlgorithm(s.jwk.Algorithm), Key: s.key},
+		jose.SigningKey{Algorithm: jose.SignatureAlgorithm(jwk.Algorithm), Key: signingKey(jwk, key)},
 		&signerOpts)
 	if err != nil {
 		return "", errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create JWT signer").CausedBy(err)
 	}
 
-	claims := make(map[string]any)
-	maps.Merge(custClaims, claims)
-
 	now := time.Now().UTC()
-	exp := now.Add(ttl)
-	claims["exp"] = exp.Unix()
-	claims["jti"] = uuid.New()
-	claims["iat"] = now.Unix()
-	claims["iss"] = s.iss
-	claims["nbf"] = now.Unix()
-	claims["sub"] = sub
+	claims := map[string]any{
+		"jti": uuid.New(),
+		"iat": now.Unix(),
+		"iss": s.iss,
+		"nbf": now.Unix(),
+		"sub": sub,
+	}
+
+	for _, claim := range opts.OmitClaims {
+		delete(claims, claim)
+	}
+
+	if opts.OverrideClaims {
+		maps.Merge(custClaims, claims)
+	} else {
+		standardClaims := claims
+		claims = make(map[string]any)
+
+		maps.Merge(custClaims, claims)
+		maps.Merge(standardClaims, claims)
+	}
+
+	claims["exp"] = now.Add(ttl).Unix()
 
 	builder := jwt.Signed(signer).Claims(claims)
 
@@ -95,3 +160,29 @@ func (s *jwtSigner) Sign(sub string, ttl time.Duration, custClaims map[string]an
 
 	return rawJwt, nil
 }
+
+func (s *jwtSigner) SignData(
+	keyID string, data func(keyID, algorithm string) []byte,
+) (heimdall.DataSignature, error) {
+	jwk, key := s.signingKey()
+
+	if len(keyID) != 0 && keyID != jwk.KeyID {
+		entry, err := s.kr.GetKey(keyID)
+		if err != nil {
+			return heimdall.DataSignature{}, errorchain.NewWithMessagef(heimdall.ErrInternal,
+				"no key with id %s available for signing", keyID).CausedBy(err)
+		}
+
+		jwk, key = entry.JWK(), entry.PrivateKey
+	}
+
+	// the opaque signer works with every crypto.Signer and produces signatures encoded as required by JWS
+	signature, err := (&opaqueSigner{jwk: jwk, key: key}).
+		SignPayload(data(jwk.KeyID, jwk.Algorithm), jose.SignatureAlgorithm(jwk.Algorithm))
+	if err != nil {
+		return heimdall.DataSignature{}, errorchain.NewWithMessagef(heimdall.ErrInternal,
+			"failed to sign data with key %s", jwk.KeyID).CausedBy(err)
+	}
+
+	return heimdall.DataSignature{KeyID: jwk.KeyID, Algorithm: jwk.Algorithm, Value: signature}, nil
+}
@@ -2,9 +2,16 @@ package signer
 
 import (
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
 	"strings"
 	"testing"
 	"time"
@@ -46,6 +53,9 @@ func TestNewJWTSigner(t *testing.T) {
 	ecdsaPrivKey3, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
 	require.NoError(t, err)
 
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
 	for _, tc := range []struct {
 		uc             string
 		config         config.SignerConfig
@@ -261,6 +271,73 @@ func TestNewJWTSigner(t *testing.T) {
 				assert.Equal(t, string(jose.ES512), signer.jwk.Algorithm)
 			},
 		},
+		{
+			uc:     "with ed25519 key",
+			config: config.SignerConfig{Name: "foo", KeyID: "baz"},
+			configureMocks: func(t *testing.T, mkf *mocks.MockKeyStore) {
+				t.Helper()
+
+				mkf.On("GetKey", "baz").Return(&keystore.Entry{
+					KeyID:      "baz",
+					PrivateKey: ed25519PrivKey,
+					Alg:        keystore.AlgEdDSA,
+					KeySize:    256,
+				}, nil)
+			},
+			assert: func(t *testing.T, err error, signer *jwtSigner) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, "foo", signer.iss)
+				assert.Equal(t, ed25519PrivKey, signer.key)
+				assert.Equal(t, "baz", signer.jwk.KeyID)
+				assert.Equal(t, string(jose.EdDSA), signer.jwk.Algorithm)
+			},
+		},
+		{
+			uc:     "with rsa 2048 key and configured algorithm",
+			config: config.SignerConfig{Name: "foo", KeyID: "baz", Algorithm: "RS256"},
+			configureMocks: func(t *testing.T, mkf *mocks.MockKeyStore) {
+				t.Helper()
+
+				mkf.On("GetKey", "baz").Return(&keystore.Entry{
+					KeyID:      "baz",
+					PrivateKey: rsaPrivKey1,
+					Alg:        keystore.AlgRSA,
+					KeySize:    rsaPrivKey1.Size() * bitsInByte,
+				}, nil)
+			},
+			assert: func(t *testing.T, err error, signer *jwtSigner) {
+				t.Helper()
+
+				require.NoError(t, err)
+
+				assert.Equal(t, rsaPrivKey1, signer.key)
+				assert.Equal(t, "baz", signer.jwk.KeyID)
+				assert.Equal(t, string(jose.RS256), signer.jwk.Algorithm)
+			},
+		},
+		{
+			uc:     "with ecdsa key and not matching algorithm configured",
+			config: config.SignerConfig{Name: "foo", KeyID: "baz", Algorithm: "RS256"},
+			configureMocks: func(t *testing.T, mkf *mocks.MockKeyStore) {
+				t.Helper()
+
+				mkf.On("GetKey", "baz").Return(&keystore.Entry{
+					KeyID:      "baz",
+					PrivateKey: ecdsaPrivKey1,
+					Alg:        keystore.AlgECDSA,
+					KeySize:    ecdsaPrivKey1.Params().BitSize,
+				}, nil)
+			},
+			assert: func(t *testing.T, err error, signer *jwtSigner) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrConfiguration)
+			},
+		},
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// GIVEN
@@ -295,6 +372,9 @@ func TestJWTSignerSign(t *testing.T) {
 	ecdsaPrivKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
 	require.NoError(t, err)
 
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
 	subjectID := "foobar"
 	ttl := 10 * time.Minute
 
@@ -334,6 +414,21 @@ func TestJWTSignerSign(t *testing.T) {
 				validateTestJWT(t, rawJWT, signer, subjectID, ttl, claims)
 			},
 		},
+		{
+			uc: "sign with ed25519",
+			signer: &jwtSigner{
+				iss: "foo",
+				key: ed25519PrivKey,
+				jwk: jose.JSONWebKey{KeyID: "bar", Algorithm: string(jose.EdDSA)},
+			},
+			claims: map[string]any{"baz": "zab", "bla": "foo"},
+			assert: func(t *testing.T, err error, rawJWT string, signer *jwtSigner, claims map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				validateTestJWT(t, rawJWT, signer, subjectID, ttl, claims)
+			},
+		},
 		{
 			uc: "sign claims, which contain JWT specific claims",
 			signer: &jwtSigner{
@@ -369,7 +464,7 @@ func TestJWTSignerSign(t *testing.T) {
 	} {
 		t.Run("case="+tc.uc, func(t *testing.T) {
 			// WHEN
-			jwt, err := tc.signer.Sign(subjectID, ttl, tc.claims)
+			jwt, err := tc.signer.Sign(subjectID, ttl, tc.claims, heimdall.JWTOptions{})
 
 			// THEN
 			tc.assert(t, err, jwt, tc.signer, tc.claims)
@@ -436,3 +531,265 @@ func TestJWTSignerHash(t *testing.T) {
 	assert.NotEmpty(t, hash1)
 	assert.Equal(t, hash1, hash2)
 }
+
+type testKeyRing struct {
+	keystore.KeyStore
+
+	entry *keystore.Entry
+}
+
+func (r *testKeyRing) SigningKey() *keystore.Entry { return r.entry }
+
+func TestJWTSignerFollowsKeyRotation(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ecdsaPrivKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaPrivKey2, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	ring := &testKeyRing{
+		entry: &keystore.Entry{KeyID: "foo", PrivateKey: ecdsaPrivKey1, Alg: keystore.AlgECDSA, KeySize: 256},
+	}
+
+	signer, err := NewJWTSigner(ring, config.SignerConfig{Name: "heimdall", KeyID: "ignored"}, log.Logger)
+	require.NoError(t, err)
+
+	hash1 := signer.Hash()
+
+	// WHEN
+	ring.entry = &keystore.Entry{KeyID: "bar", PrivateKey: ecdsaPrivKey2, Alg: keystore.AlgECDSA, KeySize: 384}
+
+	rawJWT, err := signer.Sign("foo", time.Minute, nil, heimdall.JWTOptions{})
+
+	// THEN
+	require.NoError(t, err)
+	assert.NotEqual(t, hash1, signer.Hash())
+
+	token, err := jwt.ParseSigned(rawJWT)
+	require.NoError(t, err)
+	require.Len(t, token.Headers, 1)
+	assert.Equal(t, "bar", token.Headers[0].KeyID)
+	assert.Equal(t, string(jose.ES384), token.Headers[0].Algorithm)
+
+	var claims map[string]any
+	require.NoError(t, token.Claims(ecdsaPrivKey2.Public(), &claims))
+}
+
+func TestJWTSignerSignWithOptions(t *testing.T) {
+	t.Parallel()
+
+	privKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ca, err := testsupport.NewRootCA("Test CA", time.Hour)
+	require.NoError(t, err)
+
+	cert, err := ca.IssueCertificate(
+		testsupport.WithSubject(pkix.Name{CommonName: "Test EE"}),
+		testsupport.WithValidity(time.Now(), time.Hour),
+		testsupport.WithSubjectPubKey(&privKey.PublicKey, x509.ECDSAWithSHA256),
+		testsupport.WithKeyUsage(x509.KeyUsageDigitalSignature))
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		uc     string
+		certs  []*x509.Certificate
+		claims map[string]any
+		opts   heimdall.JWTOptions
+		assert func(t *testing.T, err error, header map[string]any, claims map[string]any)
+	}{
+		{
+			uc: "with additional JOSE headers",
+			opts: heimdall.JWTOptions{
+				Headers: map[string]any{"typ": "at+jwt", "foo": "bar", "kid": "baz", "alg": "none"},
+			},
+			assert: func(t *testing.T, err error, header map[string]any, claims map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "at+jwt", header["typ"])
+				assert.Equal(t, "bar", header["foo"])
+				assert.Equal(t, "key", header["kid"])
+				assert.Equal(t, string(jose.ES256), header["alg"])
+				assert.NotContains(t, header, "x5c")
+			},
+		},
+		{
+			uc:    "with certificate chain",
+			certs: []*x509.Certificate{cert, ca.Certificate},
+			opts:  heimdall.JWTOptions{IncludeCertChain: true},
+			assert: func(t *testing.T, err error, header map[string]any, claims map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "JWT", header["typ"])
+				assert.Equal(t, []any{
+					base64.StdEncoding.EncodeToString(cert.Raw),
+					base64.StdEncoding.EncodeToString(ca.Certificate.Raw),
+				}, header["x5c"])
+			},
+		},
+		{
+			uc:   "with certificate chain requested, but not available",
+			opts: heimdall.JWTOptions{IncludeCertChain: true},
+			assert: func(t *testing.T, err error, header map[string]any, claims map[string]any) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "x5c")
+			},
+		},
+		{
+			uc:     "with omitted claims",
+			claims: map[string]any{"nbf": 1},
+			opts:   heimdall.JWTOptions{OmitClaims: []string{"nbf", "jti", "iss"}},
+			assert: func(t *testing.T, err error, header map[string]any, claims map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.NotContains(t, claims, "jti")
+				assert.NotContains(t, claims, "iss")
+				assert.Equal(t, float64(1), claims["nbf"])
+				assert.Equal(t, "foo", claims["sub"])
+				assert.Contains(t, claims, "iat")
+				assert.Contains(t, claims, "exp")
+			},
+		},
+		{
+			uc:     "with overridden claims",
+			claims: map[string]any{"iss": "bar", "sub": "baz", "exp": 1},
+			opts:   heimdall.JWTOptions{OverrideClaims: true},
+			assert: func(t *testing.T, err error, header map[string]any, claims map[string]any) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "bar", claims["iss"])
+				assert.Equal(t, "baz", claims["sub"])
+				assert.NotEqual(t, float64(1), claims["exp"])
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			signer := &jwtSigner{
+				iss: "heimdall",
+				key: privKey,
+				jwk: jose.JSONWebKey{KeyID: "key", Algorithm: string(jose.ES256), Certificates: tc.certs},
+			}
+
+			// WHEN
+			rawJWT, err := signer.Sign("foo", time.Minute, tc.claims, tc.opts)
+
+			// THEN
+			var header, claims map[string]any
+
+			if err == nil {
+				parts := strings.Split(rawJWT, ".")
+				require.Len(t, parts, 3)
+
+				rawHeader, err := base64.RawURLEncoding.DecodeString(parts[0])
+				require.NoError(t, err)
+				require.NoError(t, json.Unmarshal(rawHeader, &header))
+
+				token, err := jwt.ParseSigned(rawJWT)
+				require.NoError(t, err)
+				require.NoError(t, token.Claims(privKey.Public(), &claims))
+			}
+
+			tc.assert(t, err, header, claims)
+		})
+	}
+}
+
+func TestJWTSignerSignData(t *testing.T) {
+	t.Parallel()
+
+	// GIVEN
+	ecdsaPrivKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pemBytes, err := testsupport.BuildPEM(
+		testsupport.WithECDSAPrivateKey(ecdsaPrivKey, testsupport.WithPEMHeader("X-Key-ID", "ec")),
+		testsupport.WithEd25519PrivateKey(ed25519PrivKey, testsupport.WithPEMHeader("X-Key-ID", "ed")),
+	)
+	require.NoError(t, err)
+
+	ks, err := keystore.NewKeyStoreFromPEMBytes(pemBytes, "")
+	require.NoError(t, err)
+
+	signer, err := NewJWTSigner(ks, config.SignerConfig{Name: "heimdall", KeyID: "ec"}, log.Logger)
+	require.NoError(t, err)
+
+	data := []byte("foo bar")
+
+	for _, tc := range []struct {
+		uc     string
+		keyID  string
+		assert func(t *testing.T, err error, sig heimdall.DataSignature)
+	}{
+		{
+			uc: "with signing key",
+			assert: func(t *testing.T, err error, sig heimdall.DataSignature) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "ec", sig.KeyID)
+				assert.Equal(t, string(jose.ES256), sig.Algorithm)
+				require.Len(t, sig.Value, 64)
+
+				digest := sha256.Sum256(data)
+				assert.True(t, ecdsa.Verify(&ecdsaPrivKey.PublicKey, digest[:],
+					new(big.Int).SetBytes(sig.Value[:32]), new(big.Int).SetBytes(sig.Value[32:])))
+			},
+		},
+		{
+			uc:    "with other key from the key store",
+			keyID: "ed",
+			assert: func(t *testing.T, err error, sig heimdall.DataSignature) {
+				t.Helper()
+
+				require.NoError(t, err)
+				assert.Equal(t, "ed", sig.KeyID)
+				assert.Equal(t, string(jose.EdDSA), sig.Algorithm)
+				assert.True(t, ed25519.Verify(ed25519PrivKey.Public().(ed25519.PublicKey), data, sig.Value))
+			},
+		},
+		{
+			uc:    "with unknown key",
+			keyID: "foo",
+			assert: func(t *testing.T, err error, sig heimdall.DataSignature) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+				assert.Contains(t, err.Error(), "no key with id foo")
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// WHEN
+			var usedKeyID, usedAlg string
+
+			sig, err := signer.SignData(tc.keyID, func(keyID, alg string) []byte {
+				usedKeyID, usedAlg = keyID, alg
+
+				return data
+			})
+
+			// THEN
+			if err == nil {
+				assert.Equal(t, sig.KeyID, usedKeyID)
+				assert.Equal(t, sig.Algorithm, usedAlg)
+			}
+
+			tc.assert(t, err, sig)
+		})
+	}
+}
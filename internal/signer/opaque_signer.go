@@ -0,0 +1,117 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/asn1"
+	"math/big"
+
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+	"github.com/dadrus/heimdall/internal/x/errorchain"
+)
+
+// signingKey returns the key in a form usable by go-jose. Keys, which are only available as
+// crypto.Signer, like these residing in an HSM or a remote signing service, are wrapped into an
+// opaque signer.
+func signingKey(jwk jose.JSONWebKey, key crypto.Signer) any {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return key
+	default:
+		return &opaqueSigner{jwk: jwk, key: key}
+	}
+}
+
+type opaqueSigner struct {
+	jwk jose.JSONWebKey
+	key crypto.Signer
+}
+
+func (s *opaqueSigner) Public() *jose.JSONWebKey { return &s.jwk }
+
+func (s *opaqueSigner) Algs() []jose.SignatureAlgorithm {
+	return []jose.SignatureAlgorithm{jose.SignatureAlgorithm(s.jwk.Algorithm)}
+}
+
+func (s *opaqueSigner) SignPayload(payload []byte, alg jose.SignatureAlgorithm) ([]byte, error) {
+	var (
+		hash crypto.Hash
+		opts crypto.SignerOpts
+	)
+
+	switch alg { // nolint: exhaustive
+	case jose.EdDSA:
+		// ed25519 signs the message itself
+		signature, err := s.key.Sign(rand.Reader, payload, crypto.Hash(0))
+
+		return signature, wrapSignError(err)
+	case jose.RS256, jose.ES256, jose.PS256:
+		hash = crypto.SHA256
+	case jose.RS384, jose.ES384, jose.PS384:
+		hash = crypto.SHA384
+	case jose.RS512, jose.ES512, jose.PS512:
+		hash = crypto.SHA512
+	default:
+		return nil, jose.ErrUnsupportedAlgorithm
+	}
+
+	opts = hash
+
+	switch alg { // nolint: exhaustive
+	case jose.PS256, jose.PS384, jose.PS512:
+		opts = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: hash}
+	}
+
+	hasher := hash.New()
+	hasher.Write(payload)
+
+	signature, err := s.key.Sign(rand.Reader, hasher.Sum(nil), opts)
+	if err != nil {
+		return nil, wrapSignError(err)
+	}
+
+	if pubKey, ok := s.key.Public().(*ecdsa.PublicKey); ok {
+		// crypto.Signer returns ASN.1 DER encoded ECDSA signatures, JWS requires R || S
+		return toJWSSignature(signature, pubKey)
+	}
+
+	return signature, nil
+}
+
+func toJWSSignature(derSig []byte, pubKey *ecdsa.PublicKey) ([]byte, error) {
+	const bitsInByte = 8
+
+	var sig struct {
+		R, S *big.Int
+	}
+
+	if _, err := asn1.Unmarshal(derSig, &sig); err != nil {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal,
+			"failed to decode ECDSA signature").CausedBy(err)
+	}
+
+	keyBytes := (pubKey.Params().BitSize + bitsInByte - 1) / bitsInByte
+	if sig.R == nil || sig.S == nil || sig.R.BitLen() > keyBytes*bitsInByte || sig.S.BitLen() > keyBytes*bitsInByte {
+		return nil, errorchain.NewWithMessage(heimdall.ErrInternal, "malformed ECDSA signature")
+	}
+
+	out := make([]byte, 2*keyBytes) // nolint: gomnd
+
+	sig.R.FillBytes(out[:keyBytes])
+	sig.S.FillBytes(out[keyBytes:])
+
+	return out, nil
+}
+
+func wrapSignError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return errorchain.NewWithMessage(heimdall.ErrInternal, "failed to create signature").CausedBy(err)
+}
@@ -0,0 +1,101 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/square/go-jose.v2"
+
+	"github.com/dadrus/heimdall/internal/heimdall"
+)
+
+// externalKey hides the type of the wrapped key, like it is the case for keys residing in an HSM.
+type externalKey struct {
+	signer crypto.Signer
+	sig    []byte
+}
+
+func (k externalKey) Public() crypto.PublicKey { return k.signer.Public() }
+
+func (k externalKey) Sign(rnd io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if k.sig != nil {
+		return k.sig, nil
+	}
+
+	return k.signer.Sign(rnd, digest, opts)
+}
+
+func TestJWTSignerSignWithExternalKey(t *testing.T) {
+	t.Parallel()
+
+	rsaPrivKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	ecdsaPrivKey1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	ecdsaPrivKey2, err := ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+	require.NoError(t, err)
+
+	_, ed25519PrivKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	subjectID := "foobar"
+	ttl := 10 * time.Minute
+
+	for _, tc := range []struct {
+		uc     string
+		key    crypto.Signer
+		alg    jose.SignatureAlgorithm
+		assert func(t *testing.T, err error, rawJWT string, signer *jwtSigner)
+	}{
+		{uc: "rsa key with RS256", key: externalKey{signer: rsaPrivKey}, alg: jose.RS256},
+		{uc: "rsa key with PS384", key: externalKey{signer: rsaPrivKey}, alg: jose.PS384},
+		{uc: "ecdsa P256 key", key: externalKey{signer: ecdsaPrivKey1}, alg: jose.ES256},
+		{uc: "ecdsa P521 key", key: externalKey{signer: ecdsaPrivKey2}, alg: jose.ES512},
+		{uc: "ed25519 key", key: externalKey{signer: ed25519PrivKey}, alg: jose.EdDSA},
+		{
+			uc:  "ecdsa key returning malformed signature",
+			key: externalKey{signer: ecdsaPrivKey1, sig: []byte("foo")},
+			alg: jose.ES256,
+			assert: func(t *testing.T, err error, rawJWT string, signer *jwtSigner) {
+				t.Helper()
+
+				require.Error(t, err)
+				assert.ErrorIs(t, err, heimdall.ErrInternal)
+			},
+		},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			signer := &jwtSigner{
+				iss: "foo",
+				key: tc.key,
+				jwk: jose.JSONWebKey{KeyID: "bar", Algorithm: string(tc.alg), Key: tc.key.Public()},
+			}
+			claims := map[string]any{"baz": "zab"}
+
+			// WHEN
+			rawJWT, err := signer.Sign(subjectID, ttl, claims, heimdall.JWTOptions{})
+
+			// THEN
+			if tc.assert != nil {
+				tc.assert(t, err, rawJWT, signer)
+
+				return
+			}
+
+			require.NoError(t, err)
+			validateTestJWT(t, rawJWT, signer, subjectID, ttl, claims)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"math/big"
+	"net"
 	"time"
 
 	"github.com/dadrus/heimdall/internal/x"
@@ -98,6 +99,12 @@ func WithSubjectKeyID(skid []byte) CertificateBuilderOption {
 	}
 }
 
+func WithIPAddresses(ips ...net.IP) CertificateBuilderOption {
+	return func(builder *CertificateBuilder) {
+		builder.tmpl.IPAddresses = ips
+	}
+}
+
 func WithGeneratedSubjectKeyID() CertificateBuilderOption {
 	return func(builder *CertificateBuilder) {
 		builder.generateKeyIdentifier = true
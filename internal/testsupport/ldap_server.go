@@ -0,0 +1,462 @@
+package testsupport
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+)
+
+const (
+	ldapOpBindRequest      = 0
+	ldapOpBindResponse     = 1
+	ldapOpUnbindRequest    = 2
+	ldapOpSearchRequest    = 3
+	ldapOpSearchEntry      = 4
+	ldapOpSearchDone       = 5
+	ldapOpExtendedRequest  = 23
+	ldapOpExtendedResponse = 24
+
+	ldapResultSuccess             = 0
+	ldapResultProtocolError       = 2
+	ldapResultNoSuchObject        = 32
+	ldapResultInvalidCredentials  = 49
+	ldapResultInsufficientAccess  = 50
+	ldapResultUnwillingToPerform  = 53
+	ldapStartTLSOID               = "1.3.6.1.4.1.1466.20037"
+	ldapFilterAnd                 = 0
+	ldapFilterOr                  = 1
+	ldapFilterNot                 = 2
+	ldapFilterEqualityMatch       = 3
+	ldapFilterPresent             = 7
+	ldapScopeBaseObject           = 0
+	ldapScopeSingleLevel          = 1
+	ldapScopeWholeSubtree         = 2
+	ldapMaxPacketsPerConnection   = 1000
+	ldapDefaultDiagnosticsMessage = ""
+)
+
+// LDAPEntry is an entry served by the LDAPServer. If Password is set, the entry can be used to bind.
+type LDAPEntry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// LDAPServer is a minimal in-process LDAP server implementing simple binds, searches with and, or,
+// not, equality and presence filters, as well as StartTLS. It is intended for tests only.
+type LDAPServer struct {
+	URL string
+
+	// RequireBind lets searches fail for anonymous connections.
+	RequireBind bool
+
+	listener  net.Listener
+	tlsConfig *tls.Config
+	entries   []LDAPEntry
+
+	mut         sync.Mutex
+	connections int
+	binds       []string
+	searches    []string
+	wg          sync.WaitGroup
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewLDAPServer starts a server listening on a random local port. If tlsConfig is set and startTLS
+// is false, the server speaks LDAPS. If startTLS is true, tlsConfig is used for the StartTLS extended
+// operation. The server is stopped when the test finishes.
+func NewLDAPServer(t *testing.T, tlsConfig *tls.Config, startTLS bool, entries ...LDAPEntry) *LDAPServer {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to start ldap server: %v", err)
+	}
+
+	scheme := "ldap"
+
+	if tlsConfig != nil && !startTLS {
+		listener = tls.NewListener(listener, tlsConfig)
+		scheme = "ldaps"
+	}
+
+	srv := &LDAPServer{
+		URL:       scheme + "://" + listener.Addr().String(),
+		listener:  listener,
+		tlsConfig: tlsConfig,
+		entries:   entries,
+		done:      make(chan struct{}),
+	}
+
+	if !startTLS {
+		srv.tlsConfig = nil
+	}
+
+	srv.wg.Add(1)
+
+	go srv.serve()
+
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Close stops the server and waits for all connections to be closed.
+func (s *LDAPServer) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.listener.Close()
+	})
+
+	s.wg.Wait()
+}
+
+// Connections returns the number of connections accepted so far.
+func (s *LDAPServer) Connections() int {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return s.connections
+}
+
+// Binds returns the DNs of all bind requests received so far.
+func (s *LDAPServer) Binds() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return append([]string{}, s.binds...)
+}
+
+// Searches returns the base DNs of all search requests received so far.
+func (s *LDAPServer) Searches() []string {
+	s.mut.Lock()
+	defer s.mut.Unlock()
+
+	return append([]string{}, s.searches...)
+}
+
+func (s *LDAPServer) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mut.Lock()
+		s.connections++
+		s.mut.Unlock()
+
+		s.wg.Add(1)
+
+		go func() {
+			defer s.wg.Done()
+
+			s.handle(conn)
+		}()
+	}
+}
+
+type ldapSession struct {
+	conn   net.Conn
+	reader io.Reader
+	bound  bool
+}
+
+func (s *LDAPServer) handle(conn net.Conn) {
+	session := &ldapSession{conn: conn, reader: conn}
+
+	// closing the listener does not close accepted connections
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		select {
+		case <-done:
+		case <-s.done:
+		}
+
+		conn.Close()
+	}()
+
+	for i := 0; i < ldapMaxPacketsPerConnection; i++ {
+		packet, err := ber.ReadPacket(session.reader)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		msgID, _ := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case ldapOpBindRequest:
+			s.handleBind(session, msgID, request)
+		case ldapOpSearchRequest:
+			s.handleSearch(session, msgID, request)
+		case ldapOpExtendedRequest:
+			if !s.handleExtended(session, msgID, request) {
+				return
+			}
+		case ldapOpUnbindRequest:
+			return
+		default:
+			return
+		}
+	}
+}
+
+func (s *LDAPServer) handleBind(session *ldapSession, msgID int64, request *ber.Packet) {
+	if len(request.Children) < 3 {
+		s.respond(session, msgID, ldapOpBindResponse, ldapResultProtocolError, "malformed bind request")
+
+		return
+	}
+
+	name, _ := request.Children[1].Value.(string)
+	password := request.Children[2].Data.String()
+
+	s.mut.Lock()
+	s.binds = append(s.binds, name)
+	s.mut.Unlock()
+
+	if len(name) == 0 && len(password) == 0 {
+		session.bound = false
+		s.respond(session, msgID, ldapOpBindResponse, ldapResultSuccess, ldapDefaultDiagnosticsMessage)
+
+		return
+	}
+
+	entry := s.find(name)
+	if entry == nil || len(entry.Password) == 0 || entry.Password != password {
+		session.bound = false
+		s.respond(session, msgID, ldapOpBindResponse, ldapResultInvalidCredentials, "invalid credentials")
+
+		return
+	}
+
+	session.bound = true
+	s.respond(session, msgID, ldapOpBindResponse, ldapResultSuccess, ldapDefaultDiagnosticsMessage)
+}
+
+func (s *LDAPServer) handleSearch(session *ldapSession, msgID int64, request *ber.Packet) {
+	if len(request.Children) < 8 {
+		s.respond(session, msgID, ldapOpSearchDone, ldapResultProtocolError, "malformed search request")
+
+		return
+	}
+
+	baseDN, _ := request.Children[0].Value.(string)
+	scope, _ := request.Children[1].Value.(int64)
+	filter := request.Children[6]
+
+	var attributes []string
+
+	for _, attr := range request.Children[7].Children {
+		if name, ok := attr.Value.(string); ok {
+			attributes = append(attributes, name)
+		}
+	}
+
+	s.mut.Lock()
+	s.searches = append(s.searches, baseDN)
+	s.mut.Unlock()
+
+	if s.RequireBind && !session.bound {
+		s.respond(session, msgID, ldapOpSearchDone, ldapResultInsufficientAccess, "bind required")
+
+		return
+	}
+
+	if s.find(baseDN) == nil && !s.hasChildren(baseDN) {
+		s.respond(session, msgID, ldapOpSearchDone, ldapResultNoSuchObject, "no such object")
+
+		return
+	}
+
+	for idx := range s.entries {
+		entry := &s.entries[idx]
+
+		if !inScope(entry.DN, baseDN, scope) || !matches(entry, filter) {
+			continue
+		}
+
+		s.write(session, msgID, searchEntry(entry, attributes))
+	}
+
+	s.respond(session, msgID, ldapOpSearchDone, ldapResultSuccess, ldapDefaultDiagnosticsMessage)
+}
+
+func (s *LDAPServer) handleExtended(session *ldapSession, msgID int64, request *ber.Packet) bool {
+	if len(request.Children) == 0 || request.Children[0].Data.String() != ldapStartTLSOID || s.tlsConfig == nil {
+		s.respond(session, msgID, ldapOpExtendedResponse, ldapResultUnwillingToPerform, "unsupported operation")
+
+		return true
+	}
+
+	s.respond(session, msgID, ldapOpExtendedResponse, ldapResultSuccess, ldapDefaultDiagnosticsMessage)
+
+	tlsConn := tls.Server(session.conn, s.tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return false
+	}
+
+	session.conn = tlsConn
+	session.reader = tlsConn
+
+	return true
+}
+
+func (s *LDAPServer) respond(session *ldapSession, msgID int64, op ber.Tag, code int64, message string) {
+	response := ber.Encode(ber.ClassApplication, ber.TypeConstructed, op, nil, "Response")
+	response.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, code, "Result Code"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "Matched DN"))
+	response.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, message,
+		"Diagnostic Message"))
+
+	s.write(session, msgID, response)
+}
+
+func (s *LDAPServer) write(session *ldapSession, msgID int64, op *ber.Packet) {
+	envelope := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	envelope.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, msgID, "Message ID"))
+	envelope.AppendChild(op)
+
+	_, _ = session.conn.Write(envelope.Bytes())
+}
+
+func (s *LDAPServer) find(dn string) *LDAPEntry {
+	for idx := range s.entries {
+		if strings.EqualFold(s.entries[idx].DN, dn) {
+			return &s.entries[idx]
+		}
+	}
+
+	return nil
+}
+
+func (s *LDAPServer) hasChildren(dn string) bool {
+	for idx := range s.entries {
+		if inScope(s.entries[idx].DN, dn, ldapScopeWholeSubtree) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func inScope(dn, baseDN string, scope int64) bool {
+	dn = strings.ToLower(dn)
+	baseDN = strings.ToLower(baseDN)
+
+	switch scope {
+	case ldapScopeBaseObject:
+		return dn == baseDN
+	case ldapScopeSingleLevel:
+		_, parent, found := strings.Cut(dn, ",")
+
+		return found && parent == baseDN
+	default:
+		return dn == baseDN || strings.HasSuffix(dn, ","+baseDN)
+	}
+}
+
+func matches(entry *LDAPEntry, filter *ber.Packet) bool {
+	switch filter.Tag {
+	case ldapFilterAnd:
+		for _, child := range filter.Children {
+			if !matches(entry, child) {
+				return false
+			}
+		}
+
+		return true
+	case ldapFilterOr:
+		for _, child := range filter.Children {
+			if matches(entry, child) {
+				return true
+			}
+		}
+
+		return false
+	case ldapFilterNot:
+		return len(filter.Children) == 1 && !matches(entry, filter.Children[0])
+	case ldapFilterEqualityMatch:
+		if len(filter.Children) != 2 {
+			return false
+		}
+
+		name, _ := filter.Children[0].Value.(string)
+		value, _ := filter.Children[1].Value.(string)
+
+		for _, candidate := range attributeValues(entry, name) {
+			if strings.EqualFold(candidate, value) {
+				return true
+			}
+		}
+
+		return false
+	case ldapFilterPresent:
+		return len(attributeValues(entry, filter.Data.String())) != 0
+	default:
+		return false
+	}
+}
+
+func attributeValues(entry *LDAPEntry, name string) []string {
+	for key, values := range entry.Attributes {
+		if strings.EqualFold(key, name) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func searchEntry(entry *LDAPEntry, attributes []string) *ber.Packet {
+	result := ber.Encode(ber.ClassApplication, ber.TypeConstructed, ldapOpSearchEntry, nil, "Search Result Entry")
+	result.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "DN"))
+
+	attrs := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attributes")
+
+	for name, values := range entry.Attributes {
+		if !requested(name, attributes) {
+			continue
+		}
+
+		attr := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "Attribute")
+		attr.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "Type"))
+
+		vals := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "Values")
+		for _, value := range values {
+			vals.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "Value"))
+		}
+
+		attr.AppendChild(vals)
+		attrs.AppendChild(attr)
+	}
+
+	result.AppendChild(attrs)
+
+	return result
+}
+
+func requested(name string, attributes []string) bool {
+	if len(attributes) == 0 {
+		return true
+	}
+
+	for _, attr := range attributes {
+		if attr == "*" || strings.EqualFold(attr, name) {
+			return true
+		}
+	}
+
+	return false
+}
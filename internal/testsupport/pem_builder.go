@@ -3,6 +3,7 @@ package testsupport
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"crypto/ed25519"
 	"crypto/rsa"
 	"crypto/x509"
 	"encoding/pem"
@@ -78,6 +79,24 @@ func WithRSAPrivateKey(key *rsa.PrivateKey, opts ...PEMBlockOption) PEMEntryOpti
 	}
 }
 
+func WithEd25519PrivateKey(key ed25519.PrivateKey, opts ...PEMBlockOption) PEMEntryOption {
+	return func(block *pem.Block) error {
+		raw, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return err
+		}
+
+		block.Type = "PRIVATE KEY"
+		block.Bytes = raw
+
+		for _, opt := range opts {
+			opt(block)
+		}
+
+		return nil
+	}
+}
+
 type PEMEntryOption func(*pem.Block) error
 
 func BuildPEM(opts ...PEMEntryOption) ([]byte, error) {
@@ -15,7 +15,7 @@ func DecodeTrustStoreHookFunc() mapstructure.DecodeHookFunc {
 		}
 
 		dect := reflect.ValueOf(&trustStore).Elem().Type()
-		if !dect.AssignableTo(to) {
+		if to != dect {
 			return data, nil
 		}
 
@@ -0,0 +1,17 @@
+package stringx
+
+import "unsafe"
+
+// Clone returns a copy of the given string, like strings.Clone available with go 1.20 does. Required for
+// strings referring to memory, which is reused later on, like the values taken from a request to heimdall,
+// which are reused after the request has been served.
+func Clone(value string) string {
+	if len(value) == 0 {
+		return ""
+	}
+
+	buf := make([]byte, len(value))
+	copy(buf, value)
+
+	return *(*string)(unsafe.Pointer(&buf)) // nolint: gosec
+}
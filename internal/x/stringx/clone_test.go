@@ -0,0 +1,37 @@
+package stringx
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClone(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		uc    string
+		value []byte
+	}{
+		{uc: "empty string", value: []byte{}},
+		{uc: "non empty string", value: []byte("foo")},
+	} {
+		t.Run("case="+tc.uc, func(t *testing.T) {
+			// GIVEN
+			// like the strings taken from a request, it refers to memory reused later on
+			value := *(*string)(unsafe.Pointer(&tc.value)) // nolint: gosec
+			expected := string(tc.value)
+
+			// WHEN
+			cloned := Clone(value)
+
+			for idx := range tc.value {
+				tc.value[idx] = 'x'
+			}
+
+			// THEN
+			assert.Equal(t, expected, cloned)
+		})
+	}
+}